@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"nogochain/core/blockchain"
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+)
+
+// dbUsage 数据库子命令用法
+const dbUsage = `Usage: nogochain db <command> [options]
+
+Commands:
+  verify   walk the canonical chain and report corruption
+  repair   rewind the head to the last consistent block
+  version  show the schema version and pending migrations
+  migrate  upgrade the database to the current schema version
+  backup <dir>   write a consistent copy of the chain data into <dir>
+  restore <dir>  replace the chain data with a backup (node must be stopped)
+
+Options:
+  --datadir <dir>   node data directory (default "data")
+  --rpc <url>       backup: ask the running node's admin endpoint at <url>
+                    (e.g. http://127.0.0.1:8545/admin) to write the backup,
+                    <dir> is relative to the node's admin backup root
+  --jwt <file>      backup: JWT token file for the admin endpoint
+  --force           restore: overwrite existing chain data`
+
+// openChainDatabase 打开数据目录下的链数据库，冻结库目录存在时一并打开
+// 节点运行时持有数据目录锁，此时返回 storage.ErrDataDirLocked
+func openChainDatabase(dataDir string) (*storage.OptimizedStorage, *freezer.Freezer, error) {
+	chainDir := filepath.Join(dataDir, storage.ChainDataDir)
+	if _, err := os.Stat(chainDir); err != nil {
+		return nil, nil, fmt.Errorf("chain database not found: %v", err)
+	}
+
+	db, err := storage.OpenOptimizedStorage(chainDir, storage.ChainDatabaseConfig())
+	if err == storage.ErrDataDirLocked {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open chain database: %v", err)
+	}
+
+	ancientDir := filepath.Join(chainDir, storage.AncientDir)
+	if _, err := os.Stat(ancientDir); err != nil {
+		return db, nil, nil
+	}
+	ancients, err := freezer.New(ancientDir, freezer.Options{Compress: true})
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to open freezer: %v", err)
+	}
+	return db, ancients, nil
+}
+
+// printVerifyReport 打印校验结果
+func printVerifyReport(report *blockchain.VerifyReport) {
+	fmt.Printf("Head: #%d %s\n", report.Head, report.HeadHash.Hex())
+	fmt.Printf("Checked: %d blocks (%d frozen)\n", report.Checked, report.Frozen)
+	for _, issue := range report.Issues {
+		fmt.Println("  " + issue.String())
+	}
+	if report.HasGood {
+		fmt.Printf("Last consistent block: #%d %s\n", report.LastGood, report.LastGoodHash.Hex())
+	} else {
+		fmt.Println("Last consistent block: none")
+	}
+	if report.Consistent() {
+		fmt.Println("Database is consistent")
+	} else {
+		fmt.Printf("Database is corrupted: %d errors, %d issues\n", len(report.Errors()), len(report.Issues))
+	}
+}
+
+// printSchemaVersion 打印数据库结构版本和待执行的迁移
+func printSchemaVersion(db storage.Storage, pending []storage.Migration) {
+	version, exists := storage.ReadSchemaVersion(db)
+	if !exists {
+		fmt.Println("Database is empty")
+		return
+	}
+	fmt.Printf("Schema version: %d (supported: %d)\n", version, storage.SchemaVersion)
+	for _, migration := range pending {
+		fmt.Printf("  pending migration %d: %s\n", migration.Version, migration.Name)
+	}
+}
+
+// runMigrate 执行待执行的迁移，打印进度
+func runMigrate(db *storage.OptimizedStorage, pending []storage.Migration) int {
+	if len(pending) == 0 {
+		fmt.Println("Database is up to date")
+		return 0
+	}
+
+	err := storage.UpgradeSchema(db, func(progress storage.MigrationProgress) {
+		percent := 100.0
+		if progress.Total > 0 {
+			percent = float64(progress.Done) * 100 / float64(progress.Total)
+		}
+		fmt.Printf("\rMigration %d (%s): %d/%d keys (%.1f%%)", progress.Version, progress.Name, progress.Done, progress.Total, percent)
+		if progress.Done == progress.Total {
+			fmt.Println()
+		}
+	})
+	if err != nil {
+		fmt.Printf("\nMigration failed: %v\n", err)
+		fmt.Println("Run the command again to resume from the last completed batch")
+		return 1
+	}
+	fmt.Printf("Database upgraded to schema version %d\n", storage.SchemaVersion)
+	return 0
+}
+
+// printBackupInfo 打印备份信息
+func printBackupInfo(dir string, info *blockchain.BackupInfo) {
+	fmt.Printf("Backup written to %s\n", dir)
+	fmt.Printf("  head: #%d %s\n", info.Head, info.HeadHash.Hex())
+	fmt.Printf("  schema version: %d\n", info.SchemaVersion)
+	fmt.Printf("  keys: %d (%d bytes), ancients: %d\n", info.Keys, info.Bytes, info.Ancients)
+	fmt.Printf("  duration: %s\n", info.Duration)
+}
+
+// runRemoteBackup 通过管理 RPC 让运行中的节点写入备份，目录位于节点所在的主机上
+func runRemoteBackup(url, jwtFile, dir string) int {
+	var options []rpc.ClientOption
+	if jwtFile != "" {
+		token, err := os.ReadFile(jwtFile)
+		if err != nil {
+			fmt.Printf("Failed to read JWT token: %v\n", err)
+			return 1
+		}
+		options = append(options, rpc.WithHeader("Authorization", "Bearer "+strings.TrimSpace(string(token))))
+	}
+
+	client, err := rpc.DialOptions(context.Background(), url, options...)
+	if err != nil {
+		fmt.Printf("Failed to connect to %s: %v\n", url, err)
+		return 1
+	}
+	defer client.Close()
+
+	info := new(blockchain.BackupInfo)
+	if err := client.Call(info, "admin_backup", dir); err != nil {
+		fmt.Printf("Backup failed: %v\n", err)
+		return 1
+	}
+	printBackupInfo(dir, info)
+	return 0
+}
+
+// runRestore 恢复备份并校验恢复后的数据库
+func runRestore(backupDir, dataDir string, force bool) int {
+	info, err := blockchain.RestoreBackup(backupDir, dataDir, force)
+	if err == blockchain.ErrDataDirExists {
+		fmt.Printf("Chain data already exists in %s, use --force to overwrite it\n", dataDir)
+		return 1
+	}
+	if err == storage.ErrDataDirLocked {
+		fmt.Println("node is running, stop it first")
+		return 1
+	}
+	if err != nil {
+		fmt.Printf("Restore failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Restored backup of #%d %s created %s\n", info.Head, info.HeadHash.Hex(), info.Created.Format(time.RFC3339))
+
+	db, ancients, err := openChainDatabase(dataDir)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer func() {
+		if ancients != nil {
+			ancients.Close()
+		}
+		db.Close()
+	}()
+
+	report, err := blockchain.VerifyDatabase(db, ancients)
+	if err != nil {
+		fmt.Printf("Verification failed: %v\n", err)
+		return 1
+	}
+	printVerifyReport(report)
+	if !report.Consistent() {
+		return 1
+	}
+	return 0
+}
+
+// runDBCommand 执行数据库子命令，返回进程退出码
+func runDBCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Println(dbUsage)
+		return 2
+	}
+	command := args[0]
+	args = args[1:]
+
+	// backup 和 restore 的目录参数可以写在选项之前
+	var target string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		target, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("db "+command, flag.ContinueOnError)
+	dataDir := fs.String("datadir", "data", "Node data directory")
+	rpcURL := fs.String("rpc", "", "RPC endpoint of a running node")
+	jwtFile := fs.String("jwt", "", "JWT token file for the RPC endpoint")
+	force := fs.Bool("force", false, "Overwrite existing chain data on restore")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if target == "" {
+		target = fs.Arg(0)
+	}
+
+	switch command {
+	case "verify", "repair", "version", "migrate":
+	case "backup", "restore":
+		if target == "" {
+			fmt.Printf("Missing backup directory\n\n%s\n", dbUsage)
+			return 2
+		}
+	default:
+		fmt.Printf("Unknown db command: %s\n\n%s\n", command, dbUsage)
+		return 2
+	}
+
+	// 在线备份由运行中的节点执行，恢复时不能打开数据库
+	if command == "backup" && *rpcURL != "" {
+		return runRemoteBackup(*rpcURL, *jwtFile, target)
+	}
+	if command == "restore" {
+		return runRestore(target, *dataDir, *force)
+	}
+
+	db, ancients, err := openChainDatabase(*dataDir)
+	if err == storage.ErrDataDirLocked {
+		// 直接打开会与运行中的节点同时重放和写入预写日志
+		if command == "backup" {
+			fmt.Println("node is running, use --rpc")
+		} else {
+			fmt.Println("node is running, stop it first")
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer func() {
+		if ancients != nil {
+			ancients.Close()
+		}
+		db.Close()
+	}()
+
+	pending, err := storage.PendingMigrations(db)
+	if err != nil {
+		version, _ := storage.ReadSchemaVersion(db)
+		fmt.Printf("Database schema version %d is newer than supported version %d\n", version, storage.SchemaVersion)
+		return 1
+	}
+
+	switch command {
+	case "version":
+		printSchemaVersion(db, pending)
+		return 0
+	case "migrate":
+		return runMigrate(db, pending)
+	case "backup":
+		info, err := blockchain.BackupDatabase(db, ancients, target)
+		if err != nil {
+			fmt.Printf("Backup failed: %v\n", err)
+			return 1
+		}
+		printBackupInfo(target, info)
+		return 0
+	}
+	if len(pending) > 0 {
+		fmt.Printf("Database needs %d migrations, run \"nogochain db migrate\" first\n", len(pending))
+		return 1
+	}
+
+	report, err := blockchain.VerifyDatabase(db, ancients)
+	if err != nil {
+		fmt.Printf("Verification failed: %v\n", err)
+		return 1
+	}
+	printVerifyReport(report)
+
+	if command == "verify" {
+		if !report.Consistent() {
+			return 1
+		}
+		return 0
+	}
+
+	if report.Consistent() && report.LastGood == report.Head {
+		fmt.Println("Nothing to repair")
+		return 0
+	}
+	if err := blockchain.RepairDatabase(db, ancients, report); err != nil {
+		fmt.Printf("Repair failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Head rewound from #%d to #%d %s\n", report.Head, report.LastGood, report.LastGoodHash.Hex())
+	return 0
+}
@@ -7,8 +7,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -19,6 +22,7 @@ import (
 	"nogochain/metrics"
 	"nogochain/network"
 	"nogochain/network/config"
+	"nogochain/params"
 )
 
 // initLogger 初始化日志系统
@@ -74,13 +78,121 @@ func initMetrics(cfg *config.MetricsConfig) {
 	}()
 }
 
+// overrideChainConfig 用命令行中显式设置的链数据参数覆盖配置文件
+func overrideChainConfig(cfg, flags *config.ChainConfig) {
+	if cfg.Engine == nil {
+		cfg.Engine = config.DefaultEngineConfig()
+	}
+	if cfg.Engine.Clique == nil {
+		cfg.Engine.Clique = &config.CliqueConfig{}
+	}
+	if cfg.Difficulty == nil {
+		cfg.Difficulty = &params.DifficultyConfig{Algorithm: params.DifficultyLegacy}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "datadir":
+			cfg.DataDir = flags.DataDir
+		case "freezer.threshold":
+			cfg.FreezerThreshold = flags.FreezerThreshold
+		case "prune.blocks":
+			cfg.BlockRetention = flags.BlockRetention
+		case "prune.states":
+			cfg.StateRetention = flags.StateRetention
+		case "txlookuplimit":
+			cfg.TxLookupLimit = flags.TxLookupLimit
+		case "finality.depth":
+			cfg.FinalityDepth = flags.FinalityDepth
+		case "checkpoint":
+			if cfg.Checkpoints == nil {
+				cfg.Checkpoints = make(map[uint64]common.Hash)
+			}
+			for number, hash := range flags.Checkpoints {
+				cfg.Checkpoints[number] = hash
+			}
+		case "engine":
+			cfg.Engine.Type = flags.Engine.Type
+		case "clique.period":
+			cfg.Engine.Clique.Period = flags.Engine.Clique.Period
+		case "clique.epoch":
+			cfg.Engine.Clique.Epoch = flags.Engine.Clique.Epoch
+		case "clique.signers":
+			cfg.Engine.Clique.Signers = flags.Engine.Clique.Signers
+		case "clique.signerkey":
+			cfg.Engine.Clique.SignerKey = flags.Engine.Clique.SignerKey
+		case "difficulty.algorithm":
+			cfg.Difficulty.Algorithm = flags.Difficulty.Algorithm
+		case "difficulty.fork":
+			cfg.Difficulty.ForkBlock = flags.Difficulty.ForkBlock
+		case "difficulty.lwmawindow":
+			cfg.Difficulty.LWMAWindow = flags.Difficulty.LWMAWindow
+		case "difficulty.aserthalflife":
+			cfg.Difficulty.ASERTHalfLife = flags.Difficulty.ASERTHalfLife
+		}
+	})
+}
+
+// overrideAdminConfig 用命令行中显式设置的管理接口参数覆盖配置文件
+func overrideAdminConfig(cfg *config.RPCConfig, flags *config.AdminConfig) {
+	if cfg.Admin == nil {
+		cfg.Admin = &config.AdminConfig{}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "rpc.admin":
+			cfg.Admin.Enabled = flags.Enabled
+		case "rpc.admin.backuproot":
+			cfg.Admin.BackupRoot = flags.BackupRoot
+		}
+	})
+}
+
+// openBlockchain 打开数据目录下的区块链，启动冻结、裁剪和交易索引任务
+func openBlockchain(cfg *config.ChainConfig) (*blockchain.Blockchain, error) {
+	dbConfig := blockchain.DefaultDatabaseConfig()
+	dbConfig.FreezerThreshold = cfg.FreezerThreshold
+	dbConfig.Pruning.BlockRetention = cfg.BlockRetention
+	dbConfig.Pruning.StateRetention = cfg.StateRetention
+	dbConfig.TxIndex.Limit = cfg.TxLookupLimit
+	genesis, err := cfg.Engine.Genesis()
+	if err != nil {
+		return nil, err
+	}
+	return blockchain.OpenBlockchain(cfg.DataDir, genesis, dbConfig)
+}
+
 func main() {
+	// 数据库维护子命令不启动节点
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		os.Exit(runDBCommand(os.Args[2:]))
+	}
+
 	fmt.Println("NogoChain (EVM+NogoPow)")
 	fmt.Println("ChainID: 318, Symbol: NOGO, Decimals: 18")
 	fmt.Println("Starting NogoChain node...")
 
 	// 解析命令行参数
 	configFile := flag.String("config", "", "Path to config file")
+	chainFlags := config.DefaultChainConfig()
+	flag.StringVar(&chainFlags.DataDir, "datadir", chainFlags.DataDir, "Node data directory")
+	flag.Uint64Var(&chainFlags.FreezerThreshold, "freezer.threshold", chainFlags.FreezerThreshold, "Depth after which blocks move to the freezer, 0 disables the freezer")
+	flag.Uint64Var(&chainFlags.BlockRetention, "prune.blocks", chainFlags.BlockRetention, "Keep bodies and receipts of the latest N blocks only, 0 keeps all")
+	flag.Uint64Var(&chainFlags.StateRetention, "prune.states", chainFlags.StateRetention, "Keep states of the latest N blocks only, 0 keeps all")
+	flag.Uint64Var(&chainFlags.TxLookupLimit, "txlookuplimit", chainFlags.TxLookupLimit, "Index transactions of the latest N blocks only, 0 indexes the whole chain")
+	flag.Uint64Var(&chainFlags.FinalityDepth, "finality.depth", chainFlags.FinalityDepth, "Reject reorganizations rolling back more than N blocks, 0 disables the limit")
+	flag.Var(config.CheckpointFlag(chainFlags.Checkpoints), "checkpoint", "Checkpoint as number=hash added to the hard-coded checkpoints, may be repeated")
+	flag.StringVar(&chainFlags.Engine.Type, "engine", chainFlags.Engine.Type, "Consensus engine: nogopow, clique or dev")
+	flag.Uint64Var(&chainFlags.Engine.Clique.Period, "clique.period", chainFlags.Engine.Clique.Period, "Seconds between clique blocks")
+	flag.Uint64Var(&chainFlags.Engine.Clique.Epoch, "clique.epoch", chainFlags.Engine.Clique.Epoch, "Clique epoch length resetting votes, 0 uses the default")
+	flag.Var(config.SignersFlag{Signers: &chainFlags.Engine.Clique.Signers}, "clique.signers", "Comma separated genesis signers of the clique network")
+	flag.StringVar(&chainFlags.Engine.Clique.SignerKey, "clique.signerkey", chainFlags.Engine.Clique.SignerKey, "Hex private key file of the local clique signer")
+	flag.StringVar(&chainFlags.Difficulty.Algorithm, "difficulty.algorithm", chainFlags.Difficulty.Algorithm, "Difficulty algorithm from the fork block on: legacy, lwma or asert")
+	flag.Uint64Var(&chainFlags.Difficulty.ForkBlock, "difficulty.fork", chainFlags.Difficulty.ForkBlock, "First block using the configured difficulty algorithm")
+	flag.Uint64Var(&chainFlags.Difficulty.LWMAWindow, "difficulty.lwmawindow", chainFlags.Difficulty.LWMAWindow, "LWMA window in blocks, 0 uses the default")
+	flag.Uint64Var(&chainFlags.Difficulty.ASERTHalfLife, "difficulty.aserthalflife", chainFlags.Difficulty.ASERTHalfLife, "ASERT half-life in seconds, 0 uses the default")
+	adminFlags := &config.AdminConfig{}
+	flag.BoolVar(&adminFlags.Enabled, "rpc.admin", false, "Serve the admin namespace on /admin, requires JWT and a backup root")
+	flag.StringVar(&adminFlags.BackupRoot, "rpc.admin.backuproot", "", "Directory admin backups are written below")
 	flag.Parse()
 
 	// 初始化网络配置
@@ -93,8 +205,8 @@ func main() {
 			os.Exit(1)
 		}
 
-		// 解析JSON配置
-		netConfig = &config.Config{}
+		// 解析JSON配置，链数据配置中缺省的字段保留默认值
+		netConfig = &config.Config{Chain: config.DefaultChainConfig()}
 		if err := json.Unmarshal(data, netConfig); err != nil {
 			fmt.Printf("Failed to parse config file: %v\n", err)
 			os.Exit(1)
@@ -145,10 +257,15 @@ func main() {
 				Host:    "127.0.0.1",
 			}
 		}
+		if netConfig.Chain == nil {
+			netConfig.Chain = config.DefaultChainConfig()
+		}
 	} else {
 		// 使用默认配置
 		netConfig = config.DefaultConfig()
 	}
+	overrideChainConfig(netConfig.Chain, chainFlags)
+	overrideAdminConfig(netConfig.RPC, adminFlags)
 
 	// 初始化日志系统
 	initLogger(netConfig.Log)
@@ -156,7 +273,22 @@ func main() {
 	log.Info().Msg("NogoChain node starting...")
 
 	// 初始化区块链
-	bc := blockchain.NewBlockchain(nil)
+	bc, err := openBlockchain(netConfig.Chain)
+	if err != nil {
+		log.Fatal().Err(err).Str("datadir", netConfig.Chain.DataDir).Msg("Failed to open blockchain")
+	}
+	engine, err := netConfig.Chain.Engine.NewEngine(netConfig.Chain.Params())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create consensus engine")
+	}
+	bc.SetEngine(engine)
+	difficulty := netConfig.Chain.Params().Difficulty
+	log.Info().Str("engine", netConfig.Chain.Engine.Type).Str("difficulty", difficulty.Algorithm).Uint64("forkBlock", difficulty.ForkBlock).Msg("Consensus engine configured")
+	if err := bc.SetFinality(blockchain.NewFinalityConfig(netConfig.Chain.Params())); err != nil {
+		log.Fatal().Err(err).Msg("Chain conflicts with checkpoints")
+	}
+	log.Info().Uint64("finalityDepth", bc.Finality().Depth).Int("checkpoints", len(bc.Finality().Checkpoints)).Msg("Reorg protection configured")
+	bc.StartFutureBlocks()
 	log.Info().Str("genesisBlock", bc.Genesis().Hash().String()).Msg("Blockchain initialized")
 	log.Info().Str("currentHead", bc.CurrentHead().Hash().String()).Uint64("height", bc.CurrentHead().NumberU64()).Msg("Current blockchain status")
 
@@ -207,6 +339,16 @@ func main() {
 	log.Info().Msg("Node started successfully!")
 	log.Info().Msg("NogoChain is ready for transactions and block processing")
 
-	// 保持程序运行
-	select {}
+	// 等待退出信号，关闭区块链以落盘数据
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Info().Msg("Shutting down node...")
+	if err := net.Stop(); err != nil {
+		log.Error().Err(err).Msg("Failed to stop network")
+	}
+	if err := bc.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close blockchain")
+	}
 }
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/natefinch/lumberjack.v2"
 
+	"nogochain/consensus/nogopow"
 	"nogochain/core/blockchain"
 	"nogochain/core/synchronizer"
 	"nogochain/network"
@@ -64,6 +68,20 @@ func initMetrics(cfg *config.MetricsConfig) {
 	}()
 }
 
+// openBlockchain 打开数据目录下的区块链，启动冻结、裁剪和交易索引任务
+func openBlockchain(cfg *config.ChainConfig) (*blockchain.Blockchain, error) {
+	dbConfig := blockchain.DefaultDatabaseConfig()
+	dbConfig.FreezerThreshold = cfg.FreezerThreshold
+	dbConfig.Pruning.BlockRetention = cfg.BlockRetention
+	dbConfig.Pruning.StateRetention = cfg.StateRetention
+	dbConfig.TxIndex.Limit = cfg.TxLookupLimit
+	genesis, err := cfg.Engine.Genesis()
+	if err != nil {
+		return nil, err
+	}
+	return blockchain.OpenBlockchain(cfg.DataDir, genesis, dbConfig)
+}
+
 func main() {
 	fmt.Println("NogoChain (EVM+NogoPow) - Node Daemon")
 	fmt.Println("ChainID: 318, Symbol: NOGO, Decimals: 18")
@@ -72,13 +90,67 @@ func main() {
 	// 初始化网络配置
 	netConfig := config.DefaultConfig()
 
+	// 解析命令行参数
+	chain := netConfig.Chain
+	flag.StringVar(&chain.DataDir, "datadir", chain.DataDir, "Node data directory")
+	flag.Uint64Var(&chain.FreezerThreshold, "freezer.threshold", chain.FreezerThreshold, "Depth after which blocks move to the freezer, 0 disables the freezer")
+	flag.Uint64Var(&chain.BlockRetention, "prune.blocks", chain.BlockRetention, "Keep bodies and receipts of the latest N blocks only, 0 keeps all")
+	flag.Uint64Var(&chain.StateRetention, "prune.states", chain.StateRetention, "Keep states of the latest N blocks only, 0 keeps all")
+	flag.Uint64Var(&chain.TxLookupLimit, "txlookuplimit", chain.TxLookupLimit, "Index transactions of the latest N blocks only, 0 indexes the whole chain")
+	flag.Uint64Var(&chain.FinalityDepth, "finality.depth", chain.FinalityDepth, "Reject reorganizations rolling back more than N blocks, 0 disables the limit")
+	flag.Var(config.CheckpointFlag(chain.Checkpoints), "checkpoint", "Checkpoint as number=hash added to the hard-coded checkpoints, may be repeated")
+	flag.StringVar(&chain.Engine.Type, "engine", chain.Engine.Type, "Consensus engine: nogopow, clique or dev")
+	flag.Uint64Var(&chain.Engine.Clique.Period, "clique.period", chain.Engine.Clique.Period, "Seconds between clique blocks")
+	flag.Uint64Var(&chain.Engine.Clique.Epoch, "clique.epoch", chain.Engine.Clique.Epoch, "Clique epoch length resetting votes, 0 uses the default")
+	flag.Var(config.SignersFlag{Signers: &chain.Engine.Clique.Signers}, "clique.signers", "Comma separated genesis signers of the clique network")
+	flag.StringVar(&chain.Engine.Clique.SignerKey, "clique.signerkey", chain.Engine.Clique.SignerKey, "Hex private key file of the local clique signer")
+	flag.StringVar(&chain.Difficulty.Algorithm, "difficulty.algorithm", chain.Difficulty.Algorithm, "Difficulty algorithm from the fork block on: legacy, lwma or asert")
+	flag.Uint64Var(&chain.Difficulty.ForkBlock, "difficulty.fork", chain.Difficulty.ForkBlock, "First block using the configured difficulty algorithm")
+	flag.Uint64Var(&chain.Difficulty.LWMAWindow, "difficulty.lwmawindow", chain.Difficulty.LWMAWindow, "LWMA window in blocks, 0 uses the default")
+	flag.Uint64Var(&chain.Difficulty.ASERTHalfLife, "difficulty.aserthalflife", chain.Difficulty.ASERTHalfLife, "ASERT half-life in seconds, 0 uses the default")
+	flag.BoolVar(&netConfig.RPC.Admin.Enabled, "rpc.admin", netConfig.RPC.Admin.Enabled, "Serve the admin namespace on /admin, requires JWT and a backup root")
+	flag.StringVar(&netConfig.RPC.Admin.BackupRoot, "rpc.admin.backuproot", netConfig.RPC.Admin.BackupRoot, "Directory admin backups are written below")
+	dev := flag.Bool("dev", false, "Run a local development chain sealing blocks as soon as they have transactions")
+	flag.Parse()
+
+	// 开发模式使用开发引擎，未指定数据目录时与其他网络的数据分开存放
+	if *dev {
+		chain.Engine.Type = config.EngineDev
+		datadirSet := false
+		flag.Visit(func(f *flag.Flag) {
+			datadirSet = datadirSet || f.Name == "datadir"
+		})
+		if !datadirSet {
+			chain.DataDir = filepath.Join(chain.DataDir, "dev")
+		}
+	}
+
 	// 初始化日志系统
 	initLogger(netConfig.Log)
 
 	log.Info().Msg("NogoChain node daemon starting...")
 
+	// NogoPow 缓存和数据集文件与同一主机上的矿工和矿池共享
+	nogopow.SetDatasetDir(nogopow.DefaultDatasetDir())
+	log.Info().Str("dir", nogopow.DatasetDir()).Msg("NogoPow dataset directory")
+
 	// 初始化区块链
-	bc := blockchain.NewBlockchain(nil)
+	bc, err := openBlockchain(netConfig.Chain)
+	if err != nil {
+		log.Fatal().Err(err).Str("datadir", netConfig.Chain.DataDir).Msg("Failed to open blockchain")
+	}
+	engine, err := netConfig.Chain.Engine.NewEngine(netConfig.Chain.Params())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create consensus engine")
+	}
+	bc.SetEngine(engine)
+	difficulty := netConfig.Chain.Params().Difficulty
+	log.Info().Str("engine", netConfig.Chain.Engine.Type).Str("difficulty", difficulty.Algorithm).Uint64("forkBlock", difficulty.ForkBlock).Msg("Consensus engine configured")
+	if err := bc.SetFinality(blockchain.NewFinalityConfig(netConfig.Chain.Params())); err != nil {
+		log.Fatal().Err(err).Msg("Chain conflicts with checkpoints")
+	}
+	log.Info().Uint64("finalityDepth", bc.Finality().Depth).Int("checkpoints", len(bc.Finality().Checkpoints)).Msg("Reorg protection configured")
+	bc.StartFutureBlocks()
 	log.Info().Str("genesisBlock", bc.Genesis().Hash().String()).Msg("Blockchain initialized")
 	log.Info().Str("currentHead", bc.CurrentHead().Hash().String()).Uint64("height", bc.CurrentHead().NumberU64()).Msg("Current blockchain status")
 
@@ -129,6 +201,16 @@ func main() {
 	log.Info().Msg("Node daemon started successfully!")
 	log.Info().Msg("NogoChain is ready for transactions and block processing")
 
-	// 保持程序运行
-	select {}
+	// 等待退出信号，关闭区块链以落盘数据
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Info().Msg("Shutting down node daemon...")
+	if err := net.Stop(); err != nil {
+		log.Error().Err(err).Msg("Failed to stop network")
+	}
+	if err := bc.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close blockchain")
+	}
 }
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/urfave/cli/v2"
+
+	"nogochain/consensus/nogopow"
 )
 
 func main() {
@@ -39,6 +41,12 @@ func main() {
 				Aliases: []string{"b"},
 				Usage:   "Run benchmark mode",
 			},
+			&cli.StringFlag{
+				Name:    "dataset-dir",
+				Usage:   "Directory for NogoPow cache and dataset files, shared with the node and pool",
+				Value:   nogopow.DefaultDatasetDir(),
+				EnvVars: []string{"NOGOPOW_DIR"},
+			},
 			&cli.StringFlag{
 				Name:    "log-level",
 				Aliases: []string{"l"},
@@ -57,6 +65,7 @@ func main() {
 			threads := c.Int("threads")
 			benchmark := c.Bool("benchmark")
 			logLevel := c.String("log-level")
+			nogopow.SetDatasetDir(c.String("dataset-dir"))
 
 			fmt.Println("NogoChain Miner (NogoPow)")
 			fmt.Println("===========================")
@@ -71,6 +80,7 @@ func main() {
 			}
 			fmt.Printf("RPC URL: %s\n", url)
 			fmt.Printf("Threads: %d\n", threads)
+			fmt.Printf("Dataset Dir: %s\n", nogopow.DatasetDir())
 			fmt.Printf("Log Level: %s\n", logLevel)
 			fmt.Println("===========================")
 
@@ -17,6 +17,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"gopkg.in/natefinch/lumberjack.v2"
 
+	"nogochain/consensus/nogopow"
 	"nogochain/core/blockchain"
 	"nogochain/core/types"
 	"nogochain/metrics"
@@ -84,6 +85,7 @@ type PoolConfig struct {
 // 全局变量
 var (
 	configFile string
+	datasetDir string
 	config     PoolConfig
 	bc         *blockchain.Blockchain
 	stratumSrv *stratum.Server
@@ -201,8 +203,13 @@ func main() {
 
 	// 解析命令行参数
 	flag.StringVar(&configFile, "config", "testnet/config/mining_pool_config.json", "Path to pool config file")
+	flag.StringVar(&datasetDir, "dataset-dir", nogopow.DefaultDatasetDir(), "Directory for NogoPow cache and dataset files, shared with the node and miners")
 	flag.Parse()
 
+	// NogoPow 缓存和数据集文件与同一主机上的节点和矿工共享
+	nogopow.SetDatasetDir(datasetDir)
+	fmt.Printf("Using NogoPow dataset directory: %s\n", datasetDir)
+
 	// 打印配置文件路径
 	fmt.Printf("Using config file: %s\n", configFile)
 
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/storage/codec"
+	"nogochain/core/storage/compression"
+	"nogochain/core/types"
+)
+
+// generateChainSamples 生成与存储格式相同的样本链数据：区块头、区块体、收据和状态导出
+func generateChainSamples(blocks int) ([][]byte, error) {
+	rng := rand.New(rand.NewSource(1))
+	randomHash := func() common.Hash {
+		var h common.Hash
+		rng.Read(h[:])
+		return h
+	}
+	randomAddress := func() common.Address {
+		var a common.Address
+		rng.Read(a[:])
+		return a
+	}
+
+	// 少量活跃地址，接近真实链上的地址分布
+	addresses := make([]common.Address, 64)
+	for i := range addresses {
+		addresses[i] = randomAddress()
+	}
+
+	var samples [][]byte
+	appendSample := func(v interface{}) error {
+		data, err := codec.Encode(v)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, data)
+		return nil
+	}
+
+	parent := common.Hash{}
+	dump := &state.Dump{Accounts: make(map[common.Address]state.DumpAccount)}
+	for n := 0; n < blocks; n++ {
+		txs := make([]*types.Transaction, rng.Intn(20))
+		receipts := make(types.Receipts, len(txs))
+		for i := range txs {
+			to := addresses[rng.Intn(len(addresses))]
+			txs[i] = types.NewTransaction(uint64(rng.Intn(1000)), to, big.NewInt(rng.Int63n(1e18)), 21000, big.NewInt(1e9), nil)
+			txs[i].R = new(big.Int).SetBytes(randomHash().Bytes())
+			txs[i].S = new(big.Int).SetBytes(randomHash().Bytes())
+			receipts[i] = &types.Receipt{
+				Status:            types.ReceiptStatusSuccessful,
+				CumulativeGasUsed: uint64(21000 * (i + 1)),
+				Bloom:             make([]byte, 256),
+				TxHash:            txs[i].Hash(),
+				GasUsed:           21000,
+				BlockNumber:       big.NewInt(int64(n)),
+				TransactionIndex:  uint(i),
+			}
+			dump.Accounts[to] = state.DumpAccount{Nonce: uint64(n), Balance: big.NewInt(rng.Int63())}
+		}
+
+		block := types.NewBlock(parent, addresses[n%len(addresses)], randomHash(), randomHash(), randomHash(),
+			big.NewInt(1000000+rng.Int63n(1000)), big.NewInt(int64(n)), 8000000, uint64(21000*len(txs)),
+			uint64(time.Now().Unix())+uint64(n*17), nil, randomHash(), rng.Uint64(), txs, nil)
+		parent = block.Hash()
+
+		for _, v := range []interface{}{block.Header, block.Body(), receipts} {
+			if err := appendSample(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := appendSample(dump); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// runCompressionBenchmark 在样本链数据上测试所有压缩器，并推荐满足速度要求的压缩器
+func runCompressionBenchmark(blocks int, minSpeed float64, duration time.Duration) {
+	fmt.Println("=== NogoChain 压缩算法基准测试 ===")
+
+	samples, err := generateChainSamples(blocks)
+	if err != nil {
+		fmt.Println("生成样本数据失败:", err)
+		return
+	}
+
+	results, err := compression.Benchmark(samples, nil, duration)
+	if err != nil {
+		fmt.Println("基准测试失败:", err)
+		return
+	}
+
+	fmt.Printf("样本: %d 个区块, %d 项, %d 字节\n", blocks, len(samples), results[0].OriginalSize)
+	fmt.Println()
+	fmt.Printf("%-12s %12s %8s %14s %14s\n", "压缩器", "压缩后大小", "比率", "压缩(MB/s)", "解压(MB/s)")
+	for _, result := range results {
+		fmt.Printf("%-12s %12d %8.3f %14.1f %14.1f\n",
+			result.Name, result.CompressedSize, result.Ratio, result.CompressSpeed, result.DecompressSpeed)
+	}
+
+	best, _ := compression.PickBest(results, minSpeed)
+	fmt.Println()
+	fmt.Printf("推荐压缩器 (速度不低于 %.0f MB/s): %s\n", minSpeed, best.Name)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// difficultySimConfig 难度模拟参数
+type difficultySimConfig struct {
+	Chain    *params.ChainConfig // 难度算法配置
+	Scenario string              // 算力场景: step, onoff, timewarp
+	Blocks   int                 // 模拟区块数
+	Factor   float64             // step 和 onoff 场景中算力变化倍数
+	Period   int                 // onoff 场景中矿工开关的区块间隔
+	Share    float64             // timewarp 场景中操纵时间戳的算力占比
+	Drift    uint64              // timewarp 场景中时间戳超前的秒数，节点只接受超前 params.MaxFutureBlockTime 以内的区块
+	Seed     int64               // 随机种子
+}
+
+// simChain 按区块号索引的模拟链，实现 consensus.ChainHeaderReader
+type simChain []*types.BlockHeader
+
+func (c simChain) CurrentHeader() *types.BlockHeader { return c[len(c)-1] }
+
+func (c simChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	return c.GetHeaderByNumber(number)
+}
+
+func (c simChain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+
+func (c simChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader { return nil }
+
+// simBlock 模拟区块的输出行
+type simBlock struct {
+	number      uint64
+	timestamp   uint64  // 区块头时间戳
+	realTime    float64 // 实际出块时间
+	blockTime   int64   // 与父区块的时间戳差
+	solveTime   float64 // 实际求解时间
+	difficulty  *big.Int
+	hashrate    float64
+	manipulated bool // 时间戳是否被操纵
+}
+
+// loadChainConfig 从 JSON 文件读取链配置
+func loadChainConfig(path string) (*params.ChainConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid chain config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// hashrateAt 获取场景在第 n 个区块时的全网算力，基础算力使初始难度下的出块时间等于目标时间
+func (c *difficultySimConfig) hashrateAt(n int) (float64, error) {
+	base := float64(nogopow.InitialDifficulty) / float64(c.Chain.Difficulty.BlockTime())
+	switch c.Scenario {
+	case "step":
+		// 三分之一处算力跃升，三分之二处恢复
+		if n >= c.Blocks/3 && n < 2*c.Blocks/3 {
+			return base * c.Factor, nil
+		}
+		return base, nil
+	case "onoff":
+		// 大矿工每隔 Period 个区块加入或离开
+		if (n/c.Period)%2 == 1 {
+			return base * c.Factor, nil
+		}
+		return base, nil
+	case "timewarp":
+		return base, nil
+	default:
+		return 0, fmt.Errorf("unknown scenario %q", c.Scenario)
+	}
+}
+
+// simulateDifficulty 按场景模拟出块，难度由 nogopow.CalculateDifficulty 计算
+func simulateDifficulty(c *difficultySimConfig) ([]simBlock, error) {
+	if c.Period <= 0 {
+		c.Period = 1
+	}
+	rng := rand.New(rand.NewSource(c.Seed))
+	genesis := &types.BlockHeader{
+		Number:     big.NewInt(0),
+		Difficulty: big.NewInt(nogopow.InitialDifficulty),
+		Time:       1700000000,
+	}
+	chain := simChain{genesis}
+	now := float64(genesis.Time)
+
+	blocks := make([]simBlock, 0, c.Blocks)
+	for n := 1; n <= c.Blocks; n++ {
+		parent := chain.CurrentHeader()
+		hashrate, err := c.hashrateAt(n)
+		if err != nil {
+			return nil, err
+		}
+
+		// 按开始挖矿时的难度求解区块
+		difficulty := nogopow.CalculateDifficulty(c.Chain, chain, uint64(now), parent)
+		if difficulty == nil {
+			return nil, fmt.Errorf("block %d: missing ancestors", n)
+		}
+		diff, _ := new(big.Float).SetInt(difficulty).Float64()
+		solveTime := rng.ExpFloat64() * diff / hashrate
+		now += solveTime
+
+		// 时间戳必须大于过去中位时间，操纵者把时间戳设在最大允许的超前位置
+		timestamp := uint64(now)
+		manipulated := c.Scenario == "timewarp" && rng.Float64() < c.Share
+		if manipulated {
+			timestamp += c.Drift
+		}
+		median, err := consensus.MedianTimePast(chain, parent)
+		if err != nil {
+			return nil, err
+		}
+		if timestamp <= median {
+			timestamp = median + 1
+		}
+		// 原有算法使用新区块时间戳，重新计算使难度与最终时间戳一致
+		difficulty = nogopow.CalculateDifficulty(c.Chain, chain, timestamp, parent)
+
+		header := &types.BlockHeader{
+			Number:     big.NewInt(int64(n)),
+			Difficulty: difficulty,
+			Time:       timestamp,
+		}
+		chain = append(chain, header)
+		blocks = append(blocks, simBlock{
+			number:      uint64(n),
+			timestamp:   timestamp,
+			realTime:    now,
+			blockTime:   int64(timestamp) - int64(parent.Time),
+			solveTime:   solveTime,
+			difficulty:  difficulty,
+			hashrate:    hashrate,
+			manipulated: manipulated,
+		})
+	}
+	return blocks, nil
+}
+
+// writeDifficultyCSV 以 CSV 输出出块时间和难度序列
+func writeDifficultyCSV(w io.Writer, blocks []simBlock) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"block", "timestamp", "real_time", "block_time", "solve_time", "difficulty", "hashrate", "manipulated"})
+	for _, b := range blocks {
+		out.Write([]string{
+			strconv.FormatUint(b.number, 10),
+			strconv.FormatUint(b.timestamp, 10),
+			strconv.FormatFloat(b.realTime, 'f', 1, 64),
+			strconv.FormatInt(b.blockTime, 10),
+			strconv.FormatFloat(b.solveTime, 'f', 1, 64),
+			b.difficulty.String(),
+			strconv.FormatFloat(b.hashrate, 'f', 0, 64),
+			strconv.FormatBool(b.manipulated),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// runDifficultySimulation 运行难度模拟，CSV 写入 output（为空时写到标准输出），统计信息写到标准错误
+func runDifficultySimulation(c *difficultySimConfig, output string) {
+	blocks, err := simulateDifficulty(c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "难度模拟失败:", err)
+		os.Exit(1)
+	}
+
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "创建输出文件失败:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeDifficultyCSV(w, blocks); err != nil {
+		fmt.Fprintln(os.Stderr, "写入CSV失败:", err)
+		os.Exit(1)
+	}
+
+	if len(blocks) == 0 {
+		return
+	}
+
+	// 统计实际求解时间，避免操纵的时间戳影响结果
+	var sum, sumSq float64
+	for _, b := range blocks {
+		sum += b.solveTime
+		sumSq += b.solveTime * b.solveTime
+	}
+	mean := sum / float64(len(blocks))
+	stddev := math.Sqrt(sumSq/float64(len(blocks)) - mean*mean)
+	fmt.Fprintf(os.Stderr, "算法: %s, 场景: %s, 区块数: %d\n", c.Chain.DifficultyAlgorithm(uint64(c.Blocks)), c.Scenario, len(blocks))
+	fmt.Fprintf(os.Stderr, "平均出块时间: %.1fs (目标 %ds), 标准差: %.1fs\n", mean, c.Chain.Difficulty.BlockTime(), stddev)
+}
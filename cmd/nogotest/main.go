@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"os"
 	"sync"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"nogochain/core/types"
 	"nogochain/network"
 	"nogochain/network/config"
+	"nogochain/params"
 )
 
 // TxGenerator 交易生成器
@@ -1076,11 +1078,73 @@ func main() {
 	// 解析命令行参数
 	testInterval := flag.Duration("interval", 1*time.Hour, "测试间隔时间")
 	txRate := flag.Int("tx-rate", 100, "每秒交易数")
-	testType := flag.String("type", "all", "测试类型: all, tx, sync, network, performance")
+	testType := flag.String("type", "all", "测试类型: all, tx, sync, network, performance, compression, difficulty-sim, consensus-vectors")
+	benchBlocks := flag.Int("bench-blocks", 500, "压缩基准测试的样本区块数")
+	benchMinSpeed := flag.Float64("bench-min-speed", 50, "推荐压缩器的最低压缩和解压速度（MB/s）")
+	benchDuration := flag.Duration("bench-duration", time.Second, "每个压缩器的最短测试时间")
+	simConfig := flag.String("sim-config", "", "难度模拟使用的链配置JSON文件，为空时使用 sim-algorithm 等参数")
+	simAlgorithm := flag.String("sim-algorithm", params.DifficultyLWMA, "难度模拟的算法: legacy, lwma, asert")
+	simFork := flag.Uint64("sim-fork", 1, "难度模拟中新算法的分叉区块")
+	simWindow := flag.Uint64("sim-window", params.DefaultLWMAWindow, "LWMA窗口区块数")
+	simHalfLife := flag.Uint64("sim-halflife", params.DefaultASERTHalfLife, "ASERT半衰期（秒）")
+	simScenario := flag.String("sim-scenario", "step", "难度模拟的算力场景: step, onoff, timewarp")
+	simBlocks := flag.Int("sim-blocks", 3000, "难度模拟的区块数")
+	simFactor := flag.Float64("sim-factor", 10, "step 和 onoff 场景中算力变化倍数")
+	simPeriod := flag.Int("sim-period", 100, "onoff 场景中矿工开关的区块间隔")
+	simShare := flag.Float64("sim-share", 0.3, "timewarp 场景中操纵时间戳的算力占比")
+	simDrift := flag.Uint64("sim-drift", params.MaxFutureBlockTime, "timewarp 场景中时间戳超前的秒数")
+	simSeed := flag.Int64("sim-seed", 1, "难度模拟的随机种子")
+	simOut := flag.String("sim-out", "", "难度模拟CSV输出文件，为空时写到标准输出")
+	vectorsOut := flag.String("vectors-out", "", "共识测试向量JSON输出文件，为空时写到标准输出")
+	vectorsCheck := flag.String("vectors-check", "", "用参考实现校验的共识测试向量JSON文件")
 	flag.Parse()
 
+	// 共识测试向量默认写到标准输出，在打印其他信息之前运行
+	if *testType == "consensus-vectors" {
+		runConsensusVectors(*vectorsOut, *vectorsCheck)
+		return
+	}
+
+	// 难度模拟的CSV默认写到标准输出，在打印其他信息之前运行
+	if *testType == "difficulty-sim" {
+		chainConfig := &params.ChainConfig{
+			ChainID: params.ChainID,
+			Difficulty: &params.DifficultyConfig{
+				Algorithm:     *simAlgorithm,
+				ForkBlock:     *simFork,
+				LWMAWindow:    *simWindow,
+				ASERTHalfLife: *simHalfLife,
+			},
+		}
+		if *simConfig != "" {
+			config, err := loadChainConfig(*simConfig)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "读取链配置失败:", err)
+				os.Exit(1)
+			}
+			chainConfig = config
+		}
+		runDifficultySimulation(&difficultySimConfig{
+			Chain:    chainConfig,
+			Scenario: *simScenario,
+			Blocks:   *simBlocks,
+			Factor:   *simFactor,
+			Period:   *simPeriod,
+			Share:    *simShare,
+			Drift:    *simDrift,
+			Seed:     *simSeed,
+		}, *simOut)
+		return
+	}
+
 	fmt.Println("NogoChain 测试工具启动中...")
 
+	// 压缩基准测试不需要启动网络
+	if *testType == "compression" {
+		runCompressionBenchmark(*benchBlocks, *benchMinSpeed, *benchDuration)
+		return
+	}
+
 	// 检查是否运行性能测试
 	if *testType == "performance" {
 		// 运行综合性能测试 (Task 7 相同测试)
@@ -1182,6 +1246,6 @@ func main() {
 		networkTester.PrintStats()
 
 	default:
-		fmt.Println("未知测试类型，请使用: all, tx, sync, network, performance")
+		fmt.Println("未知测试类型，请使用: all, tx, sync, network, performance, compression, difficulty-sim, consensus-vectors")
 	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"nogochain/consensus/nogopow"
+)
+
+// runConsensusVectors 生成共识测试向量并写入 output（为空时写到标准输出）
+// check 不为空时改为用参考实现校验该向量文件
+func runConsensusVectors(output, check string) {
+	if check != "" {
+		if err := checkConsensusVectors(check); err != nil {
+			fmt.Fprintln(os.Stderr, "测试向量校验失败:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "测试向量校验通过:", check)
+		return
+	}
+
+	vectors, err := nogopow.GenerateTestVectors()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "生成测试向量失败:", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "编码测试向量失败:", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "写入测试向量失败:", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "已生成 %d 个封装向量、%d 个难度向量、%d 个奖励向量: %s\n",
+		len(vectors.Seal), len(vectors.Difficulty), len(vectors.Reward), output)
+}
+
+// checkConsensusVectors 读取向量文件并用参考实现校验
+func checkConsensusVectors(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	vectors := new(nogopow.TestVectors)
+	if err := json.Unmarshal(data, vectors); err != nil {
+		return fmt.Errorf("invalid test vectors %s: %v", path, err)
+	}
+	return nogopow.CheckTestVectors(vectors)
+}
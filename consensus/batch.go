@@ -0,0 +1,50 @@
+package consensus
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+)
+
+// BatchHeaderReader is a chain reader also returning the headers of a batch being verified,
+// so rules looking at several ancestors work for headers whose parents are not imported yet
+// BatchHeaderReader 同时返回正在验证的一批区块头的链读取器，
+// 使需要多个祖先区块的规则可以验证父区块尚未导入的区块头
+type BatchHeaderReader struct {
+	ChainHeaderReader
+	headers map[common.Hash]*types.BlockHeader
+}
+
+// NewBatchHeaderReader creates a reader serving headers from the batch before chain
+// NewBatchHeaderReader 创建先从批次、再从 chain 读取区块头的读取器
+func NewBatchHeaderReader(chain ChainHeaderReader, headers []*types.BlockHeader) *BatchHeaderReader {
+	batch := make(map[common.Hash]*types.BlockHeader, len(headers))
+	for _, header := range headers {
+		batch[header.Hash()] = header
+	}
+	return &BatchHeaderReader{ChainHeaderReader: chain, headers: batch}
+}
+
+// GetHeader returns the header with the given hash and number from the batch or the chain
+// GetHeader 从批次或链中获取指定哈希和区块号的区块头
+func (r *BatchHeaderReader) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	if header := r.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	if r.ChainHeaderReader == nil {
+		return nil
+	}
+	return r.ChainHeaderReader.GetHeader(hash, number)
+}
+
+// GetHeaderByHash returns the header with the given hash from the batch or the chain
+// GetHeaderByHash 从批次或链中获取指定哈希的区块头
+func (r *BatchHeaderReader) GetHeaderByHash(hash common.Hash) *types.BlockHeader {
+	if header := r.headers[hash]; header != nil {
+		return header
+	}
+	if r.ChainHeaderReader == nil {
+		return nil
+	}
+	return r.ChainHeaderReader.GetHeaderByHash(hash)
+}
@@ -0,0 +1,161 @@
+package clique
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+)
+
+// API exposes the signer voting of a proof-of-authority chain
+// API 提供权威证明链的签名者投票接口
+type API struct {
+	chain  consensus.ChainHeaderReader
+	clique *Clique
+}
+
+// NewAPI creates the signer voting API of an engine running on chain
+// NewAPI 创建运行在 chain 上的引擎的签名者投票接口
+func NewAPI(chain consensus.ChainHeaderReader, clique *Clique) *API {
+	return &API{chain: chain, clique: clique}
+}
+
+// Status is the sealing activity of the signers over the recent blocks
+// Status 最近区块中签名者的出块情况
+type Status struct {
+	InturnPercent float64                `json:"inturnPercent"`
+	SigningStatus map[common.Address]int `json:"sealerActivity"`
+	NumBlocks     uint64                 `json:"numBlocks"`
+}
+
+// header returns the header with the given number, or the current header if number is nil
+// header 获取指定区块号的区块头，number 为 nil 时获取当前区块头
+func (api *API) header(number *uint64) (*types.BlockHeader, error) {
+	var header *types.BlockHeader
+	if number == nil {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(*number)
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+// GetSnapshot returns the voting snapshot at the given block, or at the head if number is nil
+// GetSnapshot 获取指定区块的投票快照，number 为 nil 时获取头部区块的快照
+func (api *API) GetSnapshot(number *uint64) (*Snapshot, error) {
+	header, err := api.header(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSnapshotAtHash returns the voting snapshot at the given block hash
+// GetSnapshotAtHash 获取指定区块哈希的投票快照
+func (api *API) GetSnapshotAtHash(hash common.Hash) (*Snapshot, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSigners returns the authorized signers at the given block, or at the head if number is nil
+// GetSigners 获取指定区块的授权签名者，number 为 nil 时获取头部区块的签名者
+func (api *API) GetSigners(number *uint64) ([]common.Address, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// GetSignersAtHash returns the authorized signers at the given block hash
+// GetSignersAtHash 获取指定区块哈希的授权签名者
+func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
+	snap, err := api.GetSnapshotAtHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// Proposals returns the current proposals of the local signer
+// Proposals 获取本地签名者当前的提案
+func (api *API) Proposals() map[common.Address]bool {
+	api.clique.lock.RLock()
+	defer api.clique.lock.RUnlock()
+
+	proposals := make(map[common.Address]bool, len(api.clique.proposals))
+	for address, auth := range api.clique.proposals {
+		proposals[address] = auth
+	}
+	return proposals
+}
+
+// Propose makes the local signer vote in its blocks to authorize or remove an address
+// Propose 使本地签名者在其区块中投票授权或移除某个地址
+func (api *API) Propose(address common.Address, auth bool) {
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	api.clique.proposals[address] = auth
+}
+
+// Discard drops a proposal of the local signer
+// Discard 丢弃本地签名者的提案
+func (api *API) Discard(address common.Address) {
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	delete(api.clique.proposals, address)
+}
+
+// Status returns the sealing activity of the signers over the last 64 blocks
+// Status 获取最近64个区块中签名者的出块情况
+func (api *API) Status() (*Status, error) {
+	var (
+		numBlocks = uint64(64)
+		header    = api.chain.CurrentHeader()
+		optimals  = 0
+	)
+	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	end := header.Number.Uint64()
+	if numBlocks > end {
+		numBlocks = end
+	}
+	start := end - numBlocks + 1
+
+	signStatus := make(map[common.Address]int)
+	for _, signer := range snap.signers() {
+		signStatus[signer] = 0
+	}
+	for n := start; n <= end; n++ {
+		h := api.chain.GetHeaderByNumber(n)
+		if h == nil {
+			return nil, fmt.Errorf("missing block %d", n)
+		}
+		if h.Difficulty.Cmp(diffInTurn) == 0 {
+			optimals++
+		}
+		sealer, err := api.clique.Author(h)
+		if err != nil {
+			return nil, err
+		}
+		signStatus[sealer]++
+	}
+
+	status := &Status{SigningStatus: signStatus, NumBlocks: numBlocks}
+	if numBlocks > 0 {
+		status.InturnPercent = float64(100*optimals) / float64(numBlocks)
+	}
+	return status, nil
+}
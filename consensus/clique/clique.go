@@ -0,0 +1,608 @@
+package clique
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"nogochain/consensus"
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+const (
+	// epochLength Default number of blocks after which votes are reset and the signers checkpointed
+	// epochLength 默认的投票重置和签名者检查点间隔区块数
+	epochLength = uint64(30000)
+
+	// inmemorySnapshots Number of recent vote snapshots kept in memory
+	// inmemorySnapshots 内存中保留的最近投票快照数量
+	inmemorySnapshots = 128
+	// inmemorySignatures Number of recent block signers kept in memory
+	// inmemorySignatures 内存中保留的最近区块签名者数量
+	inmemorySignatures = 4096
+
+	// wiggleTime Random delay per signer to allow concurrent out-of-turn signers
+	// wiggleTime 每个签名者的随机延迟，允许多个非轮值签名者并发出块
+	wiggleTime = 500 * time.Millisecond
+
+	// extraVanity Fixed number of extra-data prefix bytes reserved for signer vanity
+	// extraVanity 额外数据中为签名者保留的固定前缀字节数
+	extraVanity = 32
+	// extraSeal Fixed number of extra-data suffix bytes reserved for the signer seal
+	// extraSeal 额外数据中为签名保留的固定后缀字节数
+	extraSeal = crypto.SignatureLength
+
+	// nonceAuthVote Nonce of a vote to add a new signer
+	// nonceAuthVote 投票添加新签名者的 nonce
+	nonceAuthVote = uint64(0xffffffffffffffff)
+	// nonceDropVote Nonce of a vote to remove a signer
+	// nonceDropVote 投票移除签名者的 nonce
+	nonceDropVote = uint64(0)
+)
+
+var (
+	// diffInTurn Block difficulty of in-turn signatures
+	// diffInTurn 轮值签名的区块难度
+	diffInTurn = big.NewInt(2)
+	// diffNoTurn Block difficulty of out-of-turn signatures
+	// diffNoTurn 非轮值签名的区块难度
+	diffNoTurn = big.NewInt(1)
+)
+
+var (
+	// ErrUnauthorizedSigner is returned if a header is signed by a non-authorized entity
+	// ErrUnauthorizedSigner 区块头由未授权的账户签名
+	ErrUnauthorizedSigner = errors.New("unauthorized signer")
+
+	errUnknownBlock                 = errors.New("unknown block")
+	errInvalidCheckpointBeneficiary = errors.New("beneficiary in checkpoint block non-zero")
+	errInvalidVote                  = errors.New("vote nonce not 0x00..0 or 0xff..f")
+	errInvalidCheckpointVote        = errors.New("vote nonce in checkpoint block non-zero")
+	errMissingVanity                = errors.New("extra-data 32 byte vanity prefix missing")
+	errMissingSignature             = errors.New("extra-data 65 byte signature suffix missing")
+	errExtraSigners                 = errors.New("non-checkpoint block contains extra signer list")
+	errInvalidCheckpointSigners     = errors.New("invalid signer list on checkpoint block")
+	errMismatchingCheckpointSigners = errors.New("mismatching signer list on checkpoint block")
+	errInvalidMixDigest             = errors.New("non-zero mix digest")
+	errInvalidUncleHash             = errors.New("non empty uncle hash")
+	errInvalidDifficulty            = errors.New("invalid difficulty")
+	errWrongDifficulty              = errors.New("wrong difficulty")
+	errInvalidTimestamp             = errors.New("invalid timestamp")
+	errInvalidGasLimit              = errors.New("invalid gas limit")
+	errInvalidGasUsed               = errors.New("gas used exceeds gas limit")
+	errInvalidVotingChain           = errors.New("invalid voting chain")
+	errRecentlySigned               = errors.New("recently signed")
+	errUnclesNotAllowed             = errors.New("uncles not allowed")
+	errNoSigner                     = errors.New("no signer authorized")
+	errWaitTransactions             = errors.New("sealing paused while waiting for transactions")
+)
+
+// Config is the configuration of the proof-of-authority engine
+// Config 权威证明引擎配置
+type Config struct {
+	Period uint64 `json:"period"` // Number of seconds between blocks / 出块间隔秒数
+	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint / 重置投票和检查点的纪元长度
+}
+
+// SignerFn signs the given hash with the account of signer
+// SignerFn 使用 signer 账户对哈希签名
+type SignerFn func(signer common.Address, hash []byte) ([]byte, error)
+
+// Clique is the proof-of-authority consensus engine for private networks: authorized signers take turns sealing blocks
+// and vote through block headers to add or remove signers
+// Clique 私有网络的权威证明共识引擎：授权签名者轮流封装区块，
+// 并通过区块头投票添加或移除签名者
+type Clique struct {
+	config *Config
+
+	recents    *lru.Cache[common.Hash, *Snapshot] // Recent snapshots / 最近的快照
+	signatures *sigLRU                            // Recent block signers / 最近的区块签名者
+
+	proposals map[common.Address]bool // Current proposals of the local signer / 本地签名者当前的提案
+
+	signer common.Address // Address of the local signing key / 本地签名账户地址
+	signFn SignerFn       // Signer function / 签名函数
+	lock   sync.RWMutex
+}
+
+var _ consensus.Engine = (*Clique)(nil)
+
+// NewClique creates a proof-of-authority engine with the initial signers taken from the genesis extra data
+// NewClique 创建权威证明引擎，初始签名者取自创世区块额外数据
+func NewClique(config *Config) *Clique {
+	conf := *config
+	if conf.Epoch == 0 {
+		conf.Epoch = epochLength
+	}
+	return &Clique{
+		config:     &conf,
+		recents:    lru.NewCache[common.Hash, *Snapshot](inmemorySnapshots),
+		signatures: lru.NewCache[common.Hash, common.Address](inmemorySignatures),
+		proposals:  make(map[common.Address]bool),
+	}
+}
+
+// GenesisExtra returns the genesis extra data listing the initial signers
+// GenesisExtra 获取列出初始签名者的创世区块额外数据
+func GenesisExtra(signers []common.Address) []byte {
+	extra := make([]byte, extraVanity, extraVanity+len(signers)*common.AddressLength+extraSeal)
+	for _, signer := range signers {
+		extra = append(extra, signer.Bytes()...)
+	}
+	return append(extra, make([]byte, extraSeal)...)
+}
+
+// KeySigner returns the address of a private key and a signer function signing with it
+// KeySigner 获取私钥对应的地址和使用该私钥的签名函数
+func KeySigner(key *ecdsa.PrivateKey) (common.Address, SignerFn) {
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	return address, func(signer common.Address, hash []byte) ([]byte, error) {
+		if signer != address {
+			return nil, ErrUnauthorizedSigner
+		}
+		return crypto.Sign(hash, key)
+	}
+}
+
+// SealHash returns the hash of a header without its signature, the hash signed by the signer
+// SealHash 获取不含签名的区块头哈希，即签名者签名的哈希
+func SealHash(header *types.BlockHeader) common.Hash {
+	unsigned := *header
+	unsigned.Extra = header.Extra[:len(header.Extra)-extraSeal]
+	data, _ := json.Marshal(&unsigned)
+	return crypto.Keccak256Hash(data)
+}
+
+// ecrecover extracts the signer address from a signed header
+// ecrecover 从已签名的区块头中恢复签名者地址
+func ecrecover(header *types.BlockHeader, sigcache *sigLRU) (common.Address, error) {
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address, nil
+	}
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	pubkey, err := crypto.Ecrecover(SealHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// Authorize sets the local signing account used to seal new blocks
+// Authorize 设置封装新区块使用的本地签名账户
+func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.signer = signer
+	c.signFn = signFn
+}
+
+// Author returns the account that signed the header
+// Author 获取签名区块头的账户
+func (c *Clique) Author(header *types.BlockHeader) (common.Address, error) {
+	return ecrecover(header, c.signatures)
+}
+
+// VerifyHeader checks a header against the consensus rules
+// VerifyHeader 按共识规则验证区块头
+func (c *Clique) VerifyHeader(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	return c.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders verifies a batch of headers in order, each header may use the previous ones as its ancestors
+// VerifyHeaders 按顺序验证一批区块头，每个区块头可以以之前的区块头为祖先
+func (c *Clique) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.BlockHeader) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := c.verifyHeader(chain, header, headers[:i])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks the standalone fields of a header, then the fields depending on its ancestors
+// parents is an optional batch of ancestors not yet in the chain
+// verifyHeader 检查区块头的独立字段，然后检查依赖祖先的字段
+// parents 为可选的尚未加入区块链的祖先区块头
+func (c *Clique) verifyHeader(chain consensus.ChainHeaderReader, header *types.BlockHeader, parents []*types.BlockHeader) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	if header.Time > uint64(time.Now().Unix()) {
+		return consensus.ErrFutureBlock
+	}
+	// Checkpoint blocks carry the signer list and no vote
+	// 检查点区块携带签名者列表，不包含投票
+	checkpoint := number%c.config.Epoch == 0
+	if checkpoint && header.Coinbase != (common.Address{}) {
+		return errInvalidCheckpointBeneficiary
+	}
+	if header.Nonce != nonceAuthVote && header.Nonce != nonceDropVote {
+		return errInvalidVote
+	}
+	if checkpoint && header.Nonce != nonceDropVote {
+		return errInvalidCheckpointVote
+	}
+
+	if len(header.Extra) < extraVanity {
+		return errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	signersBytes := len(header.Extra) - extraVanity - extraSeal
+	if !checkpoint && signersBytes != 0 {
+		return errExtraSigners
+	}
+	if checkpoint && signersBytes%common.AddressLength != 0 {
+		return errInvalidCheckpointSigners
+	}
+
+	if header.MixDigest != (common.Hash{}) {
+		return errInvalidMixDigest
+	}
+	if header.UncleHash != types.CalcUncleHash(nil) {
+		return errInvalidUncleHash
+	}
+	if number > 0 {
+		if header.Difficulty == nil || (header.Difficulty.Cmp(diffInTurn) != 0 && header.Difficulty.Cmp(diffNoTurn) != 0) {
+			return errInvalidDifficulty
+		}
+	}
+	return c.verifyCascadingFields(chain, header, parents)
+}
+
+// verifyCascadingFields checks the fields depending on the parent and the voting snapshot, then the seal
+// verifyCascadingFields 检查依赖父区块和投票快照的字段，然后验证签名
+func (c *Clique) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.BlockHeader, parents []*types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+
+	var parent *types.BlockHeader
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time+c.config.Period > header.Time {
+		return errInvalidTimestamp
+	}
+	if header.GasLimit > parent.GasLimit*105/100 || header.GasLimit < parent.GasLimit*95/100 {
+		return errInvalidGasLimit
+	}
+	if header.GasUsed > header.GasLimit {
+		return errInvalidGasUsed
+	}
+
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return err
+	}
+	// Checkpoint blocks must list the current signers
+	// 检查点区块必须列出当前签名者
+	if number%c.config.Epoch == 0 {
+		signers := make([]byte, 0, len(snap.Signers)*common.AddressLength)
+		for _, signer := range snap.signers() {
+			signers = append(signers, signer[:]...)
+		}
+		extraSuffix := len(header.Extra) - extraSeal
+		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], signers) {
+			return errMismatchingCheckpointSigners
+		}
+	}
+	return c.verifySeal(snap, header)
+}
+
+// snapshot returns the voting snapshot at the given block, rebuilding it from the closest known snapshot or checkpoint
+// snapshot 获取指定区块的投票快照，从最近的已知快照或检查点重建
+func (c *Clique) snapshot(chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.BlockHeader) (*Snapshot, error) {
+	var (
+		headers []*types.BlockHeader
+		snap    *Snapshot
+	)
+	for snap == nil {
+		if s, ok := c.recents.Get(hash); ok {
+			snap = s
+			break
+		}
+		// The genesis block, or a checkpoint whose ancestors are not available, is trusted
+		// 创世区块或祖先不可用的检查点区块视为可信
+		if number == 0 || (number%c.config.Epoch == 0 && chain.GetHeaderByNumber(number-1) == nil) {
+			if checkpoint := chain.GetHeaderByNumber(number); checkpoint != nil && checkpoint.Hash() == hash {
+				signers := make([]common.Address, (len(checkpoint.Extra)-extraVanity-extraSeal)/common.AddressLength)
+				for i := 0; i < len(signers); i++ {
+					copy(signers[i][:], checkpoint.Extra[extraVanity+i*common.AddressLength:])
+				}
+				snap = newSnapshot(c.config, c.signatures, number, hash, signers)
+				break
+			}
+		}
+
+		var header *types.BlockHeader
+		if len(parents) > 0 {
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		if number == 0 {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+
+	// Apply the gathered headers oldest first
+	// 从最早的区块头开始应用
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+	return snap, nil
+}
+
+// VerifyUncles rejects any uncles, proof-of-authority blocks have none
+// VerifyUncles 拒绝任何叔块，权威证明区块没有叔块
+func (c *Clique) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles) > 0 {
+		return errUnclesNotAllowed
+	}
+	return nil
+}
+
+// VerifySeal checks that the header is signed by an authorized signer with the right difficulty
+// VerifySeal 检查区块头由授权签名者以正确难度签名
+func (c *Clique) VerifySeal(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	if chain == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	return c.verifySeal(snap, header)
+}
+
+// verifySeal checks the signer of a header against the snapshot of its parent
+// verifySeal 根据父区块快照检查区块头签名者
+func (c *Clique) verifySeal(snap *Snapshot, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	signer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return ErrUnauthorizedSigner
+	}
+	for seen, recent := range snap.Recents {
+		if recent == signer {
+			if limit := uint64(len(snap.Signers)/2 + 1); number < limit || seen > number-limit {
+				return errRecentlySigned
+			}
+		}
+	}
+	if snap.inturn(number, signer) {
+		if header.Difficulty.Cmp(diffInTurn) != 0 {
+			return errWrongDifficulty
+		}
+	} else if header.Difficulty.Cmp(diffNoTurn) != 0 {
+		return errWrongDifficulty
+	}
+	return nil
+}
+
+// Prepare fills in the vote, difficulty, extra data and timestamp of a header for the local signer
+// Prepare 为本地签名者填写区块头的投票、难度、额外数据和时间戳
+func (c *Clique) Prepare(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consensus.ErrInvalidNumber
+	}
+	header.Coinbase = common.Address{}
+	header.Nonce = nonceDropVote
+
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+
+	// Cast a random valid proposal of the local signer outside checkpoints
+	// 在非检查点区块中随机投出本地签名者的一个有效提案
+	c.lock.RLock()
+	if number%c.config.Epoch != 0 {
+		addresses := make([]common.Address, 0, len(c.proposals))
+		for address, authorize := range c.proposals {
+			if snap.validVote(address, authorize) {
+				addresses = append(addresses, address)
+			}
+		}
+		if len(addresses) > 0 {
+			header.Coinbase = addresses[rand.Intn(len(addresses))]
+			if c.proposals[header.Coinbase] {
+				header.Nonce = nonceAuthVote
+			}
+		}
+	}
+	signer := c.signer
+	c.lock.RUnlock()
+
+	header.Difficulty = calcDifficulty(snap, signer)
+
+	extra := make([]byte, extraVanity)
+	copy(extra, header.Extra)
+	if number%c.config.Epoch == 0 {
+		for _, signer := range snap.signers() {
+			extra = append(extra, signer[:]...)
+		}
+	}
+	header.Extra = append(extra, make([]byte, extraSeal)...)
+	header.MixDigest = common.Hash{}
+
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = parent.Time + c.config.Period
+	if now := uint64(time.Now().Unix()); header.Time < now {
+		header.Time = now
+	}
+	return nil
+}
+
+// Finalize sets the header roots and assembles the block, there are no block rewards in proof-of-authority
+// Finalize 设置区块头根哈希并组装区块，权威证明没有区块奖励
+func (c *Clique) Finalize(chain consensus.ChainHeaderReader, header *types.BlockHeader, stateDB state.StateDB, txs []*types.Transaction, uncles []*types.BlockHeader) (*types.Block, error) {
+	if len(uncles) > 0 {
+		return nil, errUnclesNotAllowed
+	}
+	if memState, ok := stateDB.(*state.MemoryStateDB); ok {
+		header.Root = memState.CalculateStateRoot()
+	}
+	header.TxHash = types.CalcTxHash(txs)
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	return types.NewBlockWithHeader(header, &types.Body{Transactions: txs}), nil
+}
+
+// Seal signs the block with the local signer and delivers it once its timestamp is reached,
+// out-of-turn signers wait an extra random delay so the in-turn signer is preferred
+// Seal 使用本地签名者签名区块，并在到达区块时间戳后发送，
+// 非轮值签名者额外等待随机延迟，优先由轮值签名者出块
+func (c *Clique) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	header := block.Header
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	// Without a period only blocks with transactions are sealed
+	// 出块间隔为0时只封装包含交易的区块
+	if c.config.Period == 0 && len(block.Transactions) == 0 {
+		return errWaitTransactions
+	}
+
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+	if signFn == nil {
+		return errNoSigner
+	}
+
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	if _, authorized := snap.Signers[signer]; !authorized {
+		return ErrUnauthorizedSigner
+	}
+	for seen, recent := range snap.Recents {
+		if recent == signer {
+			if limit := uint64(len(snap.Signers)/2 + 1); number < limit || seen > number-limit {
+				return errRecentlySigned
+			}
+		}
+	}
+
+	delay := time.Until(time.Unix(int64(header.Time), 0))
+	if header.Difficulty.Cmp(diffNoTurn) == 0 {
+		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
+		delay += time.Duration(rand.Int63n(int64(wiggle)))
+	}
+
+	sealed := *header
+	sealed.Extra = append([]byte(nil), header.Extra...)
+	if len(sealed.Extra) < extraSeal {
+		return errMissingSignature
+	}
+	sighash, err := signFn(signer, SealHash(&sealed).Bytes())
+	if err != nil {
+		return err
+	}
+	copy(sealed.Extra[len(sealed.Extra)-extraSeal:], sighash)
+
+	go func() {
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		select {
+		case results <- types.NewBlockWithHeader(&sealed, block.Body()):
+		case <-stop:
+		}
+	}()
+	return nil
+}
+
+// CalcDifficulty returns the difficulty of the local signer on top of parent: 2 in-turn, 1 out-of-turn
+// CalcDifficulty 获取本地签名者在 parent 之上的难度：轮值为2，非轮值为1
+func (c *Clique) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.BlockHeader) *big.Int {
+	snap, err := c.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil
+	}
+	c.lock.RLock()
+	signer := c.signer
+	c.lock.RUnlock()
+	return calcDifficulty(snap, signer)
+}
+
+// calcDifficulty returns the difficulty of a signer for the block after the snapshot
+// calcDifficulty 获取签名者在快照之后区块的难度
+func calcDifficulty(snap *Snapshot, signer common.Address) *big.Int {
+	if snap.inturn(snap.Number+1, signer) {
+		return new(big.Int).Set(diffInTurn)
+	}
+	return new(big.Int).Set(diffNoTurn)
+}
@@ -0,0 +1,317 @@
+package clique
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"nogochain/core/blockchain"
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+// testerAccountPool 按名称管理测试签名账户
+type testerAccountPool struct {
+	accounts map[string]*ecdsa.PrivateKey
+}
+
+func newTesterAccountPool() *testerAccountPool {
+	return &testerAccountPool{accounts: make(map[string]*ecdsa.PrivateKey)}
+}
+
+func (ap *testerAccountPool) address(name string) common.Address {
+	if name == "" {
+		return common.Address{}
+	}
+	if ap.accounts[name] == nil {
+		ap.accounts[name], _ = crypto.GenerateKey()
+	}
+	return crypto.PubkeyToAddress(ap.accounts[name].PublicKey)
+}
+
+// sign 使用账户签名区块头
+func (ap *testerAccountPool) sign(header *types.BlockHeader, name string) {
+	ap.address(name)
+	sig, _ := crypto.Sign(SealHash(header).Bytes(), ap.accounts[name])
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// newTestChain 创建以给定签名者为初始签名者的权威证明链
+func newTestChain(ap *testerAccountPool, engine *Clique, signers ...string) *blockchain.Blockchain {
+	addresses := make([]common.Address, len(signers))
+	for i, signer := range signers {
+		addresses[i] = ap.address(signer)
+	}
+	slices.SortFunc(addresses, common.Address.Cmp)
+
+	genesis := types.NewBlockWithHeader(&types.BlockHeader{
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(0),
+		GasLimit:   10000000,
+		Time:       1700000000,
+		Extra:      GenesisExtra(addresses),
+	}, nil)
+	bc := blockchain.NewBlockchain(genesis)
+	bc.SetEngine(engine)
+	return bc
+}
+
+// newVoteBlock 创建由 signer 签名、对 voted 投票的区块
+func newVoteBlock(t *testing.T, ap *testerAccountPool, engine *Clique, bc *blockchain.Blockchain, signer, voted string, auth bool) *types.Block {
+	parent := bc.CurrentHeader()
+	header := &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Coinbase:   ap.address(voted),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+		Extra:      make([]byte, extraVanity+extraSeal),
+		Difficulty: new(big.Int).Set(diffNoTurn),
+	}
+	if auth {
+		header.Nonce = nonceAuthVote
+	}
+	snap, err := engine.snapshot(bc, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if snap.inturn(header.Number.Uint64(), ap.address(signer)) {
+		header.Difficulty = new(big.Int).Set(diffInTurn)
+	}
+	ap.sign(header, signer)
+	return types.NewBlockWithHeader(header, nil)
+}
+
+func TestVoting(t *testing.T) {
+	type vote struct {
+		signer string
+		voted  string
+		auth   bool
+	}
+	tests := []struct {
+		name    string
+		signers []string
+		votes   []vote
+		results []string
+	}{
+		{
+			name:    "single signer, no votes",
+			signers: []string{"A"},
+			votes:   []vote{{signer: "A"}},
+			results: []string{"A"},
+		},
+		{
+			name:    "single signer, voting to add",
+			signers: []string{"A"},
+			votes:   []vote{{signer: "A", voted: "B", auth: true}},
+			results: []string{"A", "B"},
+		},
+		{
+			name:    "two signers, adding needs both votes",
+			signers: []string{"A", "B"},
+			votes: []vote{
+				{signer: "A", voted: "C", auth: true},
+				{signer: "B"},
+				{signer: "A"},
+			},
+			results: []string{"A", "B"},
+		},
+		{
+			name:    "two signers, both voting to add",
+			signers: []string{"A", "B"},
+			votes: []vote{
+				{signer: "A", voted: "C", auth: true},
+				{signer: "B", voted: "C", auth: true},
+			},
+			results: []string{"A", "B", "C"},
+		},
+		{
+			name:    "two signers, a signer voting itself out with the other",
+			signers: []string{"A", "B"},
+			votes: []vote{
+				{signer: "A", voted: "B"},
+				{signer: "B", voted: "B"},
+			},
+			results: []string{"A"},
+		},
+		{
+			name:    "three signers, dropping needs a majority",
+			signers: []string{"A", "B", "C"},
+			votes: []vote{
+				{signer: "A", voted: "C"},
+				{signer: "B"},
+				{signer: "C"},
+				{signer: "A"},
+				{signer: "B", voted: "C"},
+			},
+			results: []string{"A", "B"},
+		},
+		{
+			name:    "a new vote replaces the previous vote of the signer",
+			signers: []string{"A", "B", "C"},
+			votes: []vote{
+				{signer: "A", voted: "D", auth: true},
+				{signer: "B"},
+				{signer: "C"},
+				{signer: "A", voted: "D", auth: true},
+				{signer: "B"},
+				{signer: "C"},
+			},
+			results: []string{"A", "B", "C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := newTesterAccountPool()
+			engine := NewClique(&Config{Period: 1, Epoch: 30000})
+			bc := newTestChain(ap, engine, tt.signers...)
+
+			for i, v := range tt.votes {
+				block := newVoteBlock(t, ap, engine, bc, v.signer, v.voted, v.auth)
+				if err := bc.AddBlock(block); err != nil {
+					t.Fatalf("vote %d: AddBlock failed: %v", i, err)
+				}
+			}
+
+			signers, err := NewAPI(bc, engine).GetSigners(nil)
+			if err != nil {
+				t.Fatalf("GetSigners failed: %v", err)
+			}
+			want := make([]common.Address, len(tt.results))
+			for i, name := range tt.results {
+				want[i] = ap.address(name)
+			}
+			slices.SortFunc(want, common.Address.Cmp)
+			if !slices.Equal(signers, want) {
+				t.Errorf("signers = %x, want %x", signers, want)
+			}
+		})
+	}
+}
+
+func TestVerifySealRules(t *testing.T) {
+	ap := newTesterAccountPool()
+	engine := NewClique(&Config{Period: 1})
+	bc := newTestChain(ap, engine, "A", "B")
+
+	if err := bc.AddBlock(newVoteBlock(t, ap, engine, bc, "C", "", false)); err != ErrUnauthorizedSigner {
+		t.Errorf("unauthorized signer: got %v, want %v", err, ErrUnauthorizedSigner)
+	}
+
+	if err := bc.AddBlock(newVoteBlock(t, ap, engine, bc, "A", "", false)); err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if err := bc.AddBlock(newVoteBlock(t, ap, engine, bc, "A", "", false)); err != errRecentlySigned {
+		t.Errorf("recent signer: got %v, want %v", err, errRecentlySigned)
+	}
+
+	block := newVoteBlock(t, ap, engine, bc, "B", "", false)
+	if block.Header.Difficulty.Cmp(diffInTurn) == 0 {
+		block.Header.Difficulty = new(big.Int).Set(diffNoTurn)
+	} else {
+		block.Header.Difficulty = new(big.Int).Set(diffInTurn)
+	}
+	ap.sign(block.Header, "B")
+	if err := bc.AddBlock(block); err != errWrongDifficulty {
+		t.Errorf("wrong difficulty: got %v, want %v", err, errWrongDifficulty)
+	}
+
+	block = newVoteBlock(t, ap, engine, bc, "B", "", false)
+	block.Header.Nonce = 1
+	ap.sign(block.Header, "B")
+	if err := bc.AddBlock(block); err != errInvalidVote {
+		t.Errorf("invalid vote: got %v, want %v", err, errInvalidVote)
+	}
+
+	block = newVoteBlock(t, ap, engine, bc, "B", "", false)
+	block.Header.Time = bc.CurrentHeader().Time
+	ap.sign(block.Header, "B")
+	if err := bc.AddBlock(block); err != errInvalidTimestamp {
+		t.Errorf("early block: got %v, want %v", err, errInvalidTimestamp)
+	}
+}
+
+func TestSealAndPropose(t *testing.T) {
+	ap := newTesterAccountPool()
+	engine := NewClique(&Config{Period: 0})
+	bc := newTestChain(ap, engine, "A")
+	api := NewAPI(bc, engine)
+
+	signer, signFn := KeySigner(ap.accounts["A"])
+	engine.Authorize(signer, signFn)
+	api.Propose(ap.address("B"), true)
+	if proposals := api.Proposals(); len(proposals) != 1 || !proposals[ap.address("B")] {
+		t.Fatalf("unexpected proposals: %v", proposals)
+	}
+
+	header := &types.BlockHeader{
+		ParentHash: bc.CurrentHeader().Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   bc.CurrentHeader().GasLimit,
+	}
+	if err := engine.Prepare(bc, header); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if header.Coinbase != ap.address("B") || header.Nonce != nonceAuthVote || header.Difficulty.Cmp(diffInTurn) != 0 {
+		t.Fatalf("Prepare did not cast the proposal: coinbase %x nonce %x difficulty %v", header.Coinbase, header.Nonce, header.Difficulty)
+	}
+
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	empty, err := engine.Finalize(bc, header, state.NewMemoryStateDB(), nil, nil)
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if err := engine.Seal(bc, empty, results, stop); err != errWaitTransactions {
+		t.Errorf("empty block: got %v, want %v", err, errWaitTransactions)
+	}
+
+	tx := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	block, err := engine.Finalize(bc, header, state.NewMemoryStateDB(), []*types.Transaction{tx}, nil)
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if err := engine.Seal(bc, block, results, stop); err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	sealed := <-results
+	if author, err := engine.Author(sealed.Header); err != nil || author != signer {
+		t.Errorf("Author = %x, %v, want %x", author, err, signer)
+	}
+	if err := bc.AddBlock(sealed); err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+
+	signers, err := api.GetSigners(nil)
+	if err != nil || len(signers) != 2 {
+		t.Errorf("signers after vote = %x, %v", signers, err)
+	}
+	genesis := uint64(0)
+	if signers, _ := api.GetSigners(&genesis); len(signers) != 1 || signers[0] != signer {
+		t.Errorf("genesis signers = %x", signers)
+	}
+	status, err := api.Status()
+	if err != nil || status.NumBlocks != 1 || status.SigningStatus[signer] != 1 || status.InturnPercent != 100 {
+		t.Errorf("unexpected status %+v, %v", status, err)
+	}
+
+	// 提案已生效，不再投票
+	next := &types.BlockHeader{ParentHash: sealed.Hash(), Number: big.NewInt(2), GasLimit: sealed.Header.GasLimit}
+	if err := engine.Prepare(bc, next); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if next.Coinbase != (common.Address{}) {
+		t.Errorf("passed proposal should not be voted again")
+	}
+	api.Discard(ap.address("B"))
+	if len(api.Proposals()) != 0 {
+		t.Errorf("proposal not discarded")
+	}
+}
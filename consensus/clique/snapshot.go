@@ -0,0 +1,247 @@
+package clique
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+
+	"nogochain/core/types"
+)
+
+// Vote is a single vote that an authorized signer made to modify the list of authorizations
+// Vote 授权签名者为修改授权列表投出的一票
+type Vote struct {
+	Signer    common.Address `json:"signer"`    // Authorized signer that cast this vote / 投票的授权签名者
+	Block     uint64         `json:"block"`     // Block number the vote was cast in / 投票所在区块号
+	Address   common.Address `json:"address"`   // Account being voted on / 被投票的账户
+	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the account / 授权或取消授权
+}
+
+// Tally is a simple vote tally to keep the current score of votes
+// Tally 当前投票计数
+type Tally struct {
+	Authorize bool `json:"authorize"` // Whether the vote is about authorizing or kicking someone / 投票是授权还是踢出
+	Votes     int  `json:"votes"`     // Number of votes wanting to pass the proposal / 支持提案的票数
+}
+
+// sigLRU Cache of recent block signers keyed by block hash
+// sigLRU 按区块哈希索引的最近区块签名者缓存
+type sigLRU = lru.Cache[common.Hash, common.Address]
+
+// Snapshot is the state of the authorization voting at a given point in time
+// Snapshot 某一时刻的授权投票状态
+type Snapshot struct {
+	config   *Config
+	sigcache *sigLRU
+
+	Number  uint64                      `json:"number"`  // Block number where the snapshot was created / 快照所在区块号
+	Hash    common.Hash                 `json:"hash"`    // Block hash where the snapshot was created / 快照所在区块哈希
+	Signers map[common.Address]struct{} `json:"signers"` // Set of authorized signers / 授权签名者集合
+	Recents map[uint64]common.Address   `json:"recents"` // Recent signers for spam protection / 最近的签名者，防止连续出块
+	Votes   []*Vote                     `json:"votes"`   // Votes cast in chronological order / 按时间顺序的投票
+	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally / 当前计票
+}
+
+// newSnapshot creates a snapshot with the given startup signers, used for the genesis and checkpoint blocks
+// newSnapshot 使用初始签名者创建快照，用于创世区块和检查点区块
+func newSnapshot(config *Config, sigcache *sigLRU, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
+	snap := &Snapshot{
+		config:   config,
+		sigcache: sigcache,
+		Number:   number,
+		Hash:     hash,
+		Signers:  make(map[common.Address]struct{}),
+		Recents:  make(map[uint64]common.Address),
+		Tally:    make(map[common.Address]Tally),
+	}
+	for _, signer := range signers {
+		snap.Signers[signer] = struct{}{}
+	}
+	return snap
+}
+
+// copy creates a deep copy of the snapshot
+// copy 深拷贝快照
+func (s *Snapshot) copy() *Snapshot {
+	return &Snapshot{
+		config:   s.config,
+		sigcache: s.sigcache,
+		Number:   s.Number,
+		Hash:     s.Hash,
+		Signers:  maps.Clone(s.Signers),
+		Recents:  maps.Clone(s.Recents),
+		Votes:    slices.Clone(s.Votes),
+		Tally:    maps.Clone(s.Tally),
+	}
+}
+
+// validVote reports whether a vote makes sense: authorizing a non-signer or kicking a signer
+// validVote 判断投票是否有意义：授权非签名者或踢出签名者
+func (s *Snapshot) validVote(address common.Address, authorize bool) bool {
+	_, signer := s.Signers[address]
+	return (signer && !authorize) || (!signer && authorize)
+}
+
+// cast adds a new vote into the tally
+// cast 将新投票计入计票
+func (s *Snapshot) cast(address common.Address, authorize bool) bool {
+	if !s.validVote(address, authorize) {
+		return false
+	}
+	if old, ok := s.Tally[address]; ok {
+		old.Votes++
+		s.Tally[address] = old
+	} else {
+		s.Tally[address] = Tally{Authorize: authorize, Votes: 1}
+	}
+	return true
+}
+
+// uncast removes a previously cast vote from the tally
+// uncast 从计票中撤销之前的投票
+func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
+	tally, ok := s.Tally[address]
+	if !ok || tally.Authorize != authorize {
+		return false
+	}
+	if tally.Votes > 1 {
+		tally.Votes--
+		s.Tally[address] = tally
+	} else {
+		delete(s.Tally, address)
+	}
+	return true
+}
+
+// apply creates a new snapshot by applying the given consecutive headers on top of this one
+// apply 在当前快照上应用一组连续区块头，生成新快照
+func (s *Snapshot) apply(headers []*types.BlockHeader) (*Snapshot, error) {
+	if len(headers) == 0 {
+		return s, nil
+	}
+	for i := 0; i < len(headers)-1; i++ {
+		if headers[i+1].Number.Uint64() != headers[i].Number.Uint64()+1 {
+			return nil, errInvalidVotingChain
+		}
+	}
+	if headers[0].Number.Uint64() != s.Number+1 {
+		return nil, errInvalidVotingChain
+	}
+	snap := s.copy()
+
+	for _, header := range headers {
+		// Votes are reset at every checkpoint
+		// 每个检查点重置投票
+		number := header.Number.Uint64()
+		if number%s.config.Epoch == 0 {
+			snap.Votes = nil
+			snap.Tally = make(map[common.Address]Tally)
+		}
+		// The oldest recent signer may sign again
+		// 最早的最近签名者可以再次签名
+		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+			delete(snap.Recents, number-limit)
+		}
+
+		signer, err := ecrecover(header, s.sigcache)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := snap.Signers[signer]; !ok {
+			return nil, ErrUnauthorizedSigner
+		}
+		for _, recent := range snap.Recents {
+			if recent == signer {
+				return nil, errRecentlySigned
+			}
+		}
+		snap.Recents[number] = signer
+
+		// A signer has one vote per account, a new vote replaces the previous one
+		// 每个签名者对同一账户只有一票，新投票替换旧投票
+		for i, vote := range snap.Votes {
+			if vote.Signer == signer && vote.Address == header.Coinbase {
+				snap.uncast(vote.Address, vote.Authorize)
+				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+				break
+			}
+		}
+
+		var authorize bool
+		switch header.Nonce {
+		case nonceAuthVote:
+			authorize = true
+		case nonceDropVote:
+			authorize = false
+		default:
+			return nil, errInvalidVote
+		}
+		if snap.cast(header.Coinbase, authorize) {
+			snap.Votes = append(snap.Votes, &Vote{
+				Signer:    signer,
+				Block:     number,
+				Address:   header.Coinbase,
+				Authorize: authorize,
+			})
+		}
+
+		// A majority of the signers passes the vote
+		// 签名者过半数时投票通过
+		if tally := snap.Tally[header.Coinbase]; tally.Votes > len(snap.Signers)/2 {
+			if tally.Authorize {
+				snap.Signers[header.Coinbase] = struct{}{}
+			} else {
+				delete(snap.Signers, header.Coinbase)
+
+				// The signer list shrunk, drop leftover recents and the votes of the kicked signer
+				// 签名者列表缩小，删除多余的最近签名记录和被踢出签名者的投票
+				if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+					delete(snap.Recents, number-limit)
+				}
+				for i := 0; i < len(snap.Votes); i++ {
+					if snap.Votes[i].Signer == header.Coinbase {
+						snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
+						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+						i--
+					}
+				}
+			}
+			// Discard the remaining votes about the changed account
+			// 丢弃关于已变更账户的剩余投票
+			for i := 0; i < len(snap.Votes); i++ {
+				if snap.Votes[i].Address == header.Coinbase {
+					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+					i--
+				}
+			}
+			delete(snap.Tally, header.Coinbase)
+		}
+	}
+	snap.Number += uint64(len(headers))
+	snap.Hash = headers[len(headers)-1].Hash()
+
+	return snap, nil
+}
+
+// signers returns the authorized signers in ascending order
+// signers 按升序返回授权签名者
+func (s *Snapshot) signers() []common.Address {
+	sigs := make([]common.Address, 0, len(s.Signers))
+	for sig := range s.Signers {
+		sigs = append(sigs, sig)
+	}
+	slices.SortFunc(sigs, common.Address.Cmp)
+	return sigs
+}
+
+// inturn reports whether a signer is in-turn at the given block number
+// inturn 判断签名者在指定区块号是否轮到出块
+func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
+	signers, offset := s.signers(), 0
+	for offset < len(signers) && signers[offset] != signer {
+		offset++
+	}
+	return (number % uint64(len(signers))) == uint64(offset)
+}
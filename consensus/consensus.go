@@ -0,0 +1,100 @@
+package consensus
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+var (
+	// ErrUnknownAncestor is returned when the parent of a header is not known
+	// ErrUnknownAncestor 父区块未知
+	ErrUnknownAncestor = errors.New("unknown ancestor")
+
+	// ErrInvalidNumber is returned when a header number is not its parent number plus one
+	// ErrInvalidNumber 区块号不等于父区块号加一
+	ErrInvalidNumber = errors.New("invalid block number")
+
+	// ErrFutureBlock is returned when a header timestamp is ahead of the local clock
+	// ErrFutureBlock 区块头时间戳超前于本地时钟
+	ErrFutureBlock = errors.New("block in the future")
+)
+
+// ChainHeaderReader is the read access to the chain needed by engines to verify and prepare headers
+// ChainHeaderReader 共识引擎验证和准备区块头时需要的区块链读取接口
+type ChainHeaderReader interface {
+	// CurrentHeader returns the header of the current head block
+	// CurrentHeader 获取当前头部区块的区块头
+	CurrentHeader() *types.BlockHeader
+
+	// GetHeader returns the header with the given hash and number
+	// GetHeader 通过哈希和区块号获取区块头
+	GetHeader(hash common.Hash, number uint64) *types.BlockHeader
+
+	// GetHeaderByNumber returns the canonical header with the given number
+	// GetHeaderByNumber 通过区块号获取规范链区块头
+	GetHeaderByNumber(number uint64) *types.BlockHeader
+
+	// GetHeaderByHash returns the header with the given hash
+	// GetHeaderByHash 通过哈希获取区块头
+	GetHeaderByHash(hash common.Hash) *types.BlockHeader
+}
+
+// ChainReader is the read access to full blocks needed by engines to verify uncles
+// ChainReader 共识引擎验证叔块时需要的完整区块读取接口
+type ChainReader interface {
+	ChainHeaderReader
+
+	// GetBlock returns the block with the given hash
+	// GetBlock 通过哈希获取区块
+	GetBlock(hash common.Hash) *types.Block
+}
+
+// Engine is a consensus engine: it decides who may create blocks, verifies headers and seals new blocks
+// Engine 共识引擎：决定出块权、验证区块头并封装新区块
+type Engine interface {
+	// Author returns the address of the account that created the block
+	// Author 获取创建区块的账户地址
+	Author(header *types.BlockHeader) (common.Address, error)
+
+	// VerifyHeader checks a header against the consensus rules, including its seal
+	// VerifyHeader 按共识规则验证区块头，包括封装
+	VerifyHeader(chain ChainHeaderReader, header *types.BlockHeader) error
+
+	// VerifyHeaders verifies a batch of consecutive headers concurrently
+	// Each header may use the previous one in the batch as its parent; closing the returned abort channel stops verification
+	// and the results channel yields one error per header in order
+	// VerifyHeaders 并发验证一批连续的区块头
+	// 每个区块头可以以批次中的前一个区块头为父区块；关闭返回的 abort 通道停止验证，结果通道按顺序为每个区块头返回一个错误
+	VerifyHeaders(chain ChainHeaderReader, headers []*types.BlockHeader) (chan<- struct{}, <-chan error)
+
+	// VerifySeal checks only the seal of a header, without access to its ancestors
+	// VerifySeal 只验证区块头的封装，不需要祖先区块
+	VerifySeal(chain ChainHeaderReader, header *types.BlockHeader) error
+
+	// VerifyUncles checks the uncles of a block against the consensus rules
+	// VerifyUncles 按共识规则验证区块的叔块
+	VerifyUncles(chain ChainReader, block *types.Block) error
+
+	// Prepare initializes the consensus fields of a header before transactions are applied
+	// Prepare 在执行交易前初始化区块头的共识字段
+	Prepare(chain ChainHeaderReader, header *types.BlockHeader) error
+
+	// Finalize applies the block rewards to the state, sets the final header roots and assembles the block
+	// Finalize 将区块奖励写入状态，设置最终的区块头根哈希并组装区块
+	Finalize(chain ChainHeaderReader, header *types.BlockHeader, state state.StateDB, txs []*types.Transaction, uncles []*types.BlockHeader) (*types.Block, error)
+
+	// Seal starts sealing a block in the background and sends the sealed block to results
+	// Sealing is abandoned when stop is closed, results may receive nothing in that case
+	// Seal 在后台开始封装区块，并将封装后的区块发送到 results
+	// 关闭 stop 时放弃封装，此时 results 可能不会收到区块
+	Seal(chain ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error
+
+	// CalcDifficulty returns the difficulty of a new block created at time on top of parent
+	// CalcDifficulty 计算在 parent 之上、时间为 time 的新区块难度
+	CalcDifficulty(chain ChainHeaderReader, time uint64, parent *types.BlockHeader) *big.Int
+}
@@ -0,0 +1,38 @@
+package fake
+
+import (
+	"errors"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+)
+
+// ErrWaitTransactions is returned by the dev engine when asked to seal a block without transactions
+// ErrWaitTransactions 开发引擎被要求封装没有交易的区块时返回
+var ErrWaitTransactions = errors.New("sealing paused while waiting for transactions")
+
+// DevEngine is the engine of local development chains: a block is sealed instantly as soon as it has transactions,
+// empty blocks are never sealed so the chain only grows when transactions arrive
+// DevEngine 本地开发链的共识引擎：区块一有交易就立即封装，
+// 空区块不会被封装，只有收到交易时链才会增长
+type DevEngine struct {
+	*Engine
+}
+
+var _ consensus.Engine = (*DevEngine)(nil)
+
+// NewDevEngine creates a dev engine, headers are verified like NewFaker
+// NewDevEngine 创建开发引擎，区块头验证与 NewFaker 相同
+func NewDevEngine() *DevEngine {
+	return &DevEngine{Engine: NewFaker()}
+}
+
+// Seal returns the block as sealed without delay, or ErrWaitTransactions if it has no transactions
+// Seal 无延迟地返回已封装区块，区块没有交易时返回 ErrWaitTransactions
+func (e *DevEngine) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	if len(block.Transactions) == 0 {
+		return ErrWaitTransactions
+	}
+	go e.deliver(block, results, stop)
+	return nil
+}
@@ -0,0 +1,297 @@
+package fake
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/state"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+var (
+	// ErrFakeSeal is returned by a fake failer for its failing block number
+	// ErrFakeSeal 模拟失败的引擎在指定区块号上返回的错误
+	ErrFakeSeal = errors.New("fake seal failure")
+
+	errOlderBlockTime    = errors.New("timestamp not after median time past")
+	errInvalidDifficulty = errors.New("invalid difficulty")
+	errInvalidGasLimit   = errors.New("invalid gas limit")
+	errInvalidGasUsed    = errors.New("gas used exceeds gas limit")
+)
+
+// Engine is a consensus engine accepting any seal, for tests that need valid chains without building the NogoPow dataset
+// Header fields are checked like NogoPow unless the engine is a full faker
+// Engine 接受任意封装的共识引擎，用于需要有效链但不想生成 NogoPow 数据集的测试
+// 除完全模拟的引擎外，区块头字段按 NogoPow 规则检查
+type Engine struct {
+	failNumber uint64        // Block number failing seal verification, 0 for none / 封装验证失败的区块号，0 表示不失败
+	delay      time.Duration // Delay before verifying or sealing / 验证和封装前的延迟
+	fullFake   bool          // Accept every header without checks / 不做检查接受所有区块头
+
+	config *params.ChainConfig // Difficulty algorithm, nil for the NogoPow default / 难度算法，nil 表示 NogoPow 默认配置
+}
+
+var _ consensus.Engine = (*Engine)(nil)
+
+// NewFaker creates an engine accepting any seal
+// NewFaker 创建接受任意封装的引擎
+func NewFaker() *Engine {
+	return &Engine{}
+}
+
+// NewFakeFailer creates an engine accepting any seal except for the block with the given number
+// NewFakeFailer 创建除指定区块号外接受任意封装的引擎
+func NewFakeFailer(number uint64) *Engine {
+	return &Engine{failNumber: number}
+}
+
+// NewFakeDelayer creates an engine accepting any seal after waiting delay for each verification and seal
+// NewFakeDelayer 创建每次验证和封装前等待 delay 后接受任意封装的引擎
+func NewFakeDelayer(delay time.Duration) *Engine {
+	return &Engine{delay: delay}
+}
+
+// NewFullFaker creates an engine accepting every header without any checks
+// NewFullFaker 创建不做任何检查接受所有区块头的引擎
+func NewFullFaker() *Engine {
+	return &Engine{fullFake: true}
+}
+
+// Author returns the coinbase of the header
+// Author 获取区块头的 coinbase
+func (e *Engine) Author(header *types.BlockHeader) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+// VerifyHeader checks a header against its parent, the seal check only fails for the failing block number
+// VerifyHeader 根据父区块验证区块头，封装验证只在指定失败区块号上失败
+func (e *Engine) VerifyHeader(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	if e.fullFake {
+		return nil
+	}
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consensus.ErrInvalidNumber
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return e.verifyHeader(chain, header, parent)
+}
+
+// VerifyHeaders verifies a batch of headers in order, each header may use the previous one as its parent
+// VerifyHeaders 按顺序验证一批区块头，每个区块头可以以前一个区块头为父区块
+func (e *Engine) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.BlockHeader) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		batch := consensus.NewBatchHeaderReader(chain, headers)
+		for i, header := range headers {
+			var err error
+			switch {
+			case e.fullFake:
+			case i > 0 && headers[i-1].Hash() == header.ParentHash:
+				err = e.verifyHeader(batch, header, headers[i-1])
+			default:
+				err = e.VerifyHeader(batch, header)
+			}
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks the header fields against the parent, then the fake seal
+// verifyHeader 根据父区块检查区块头字段，然后验证模拟封装
+func (e *Engine) verifyHeader(chain consensus.ChainHeaderReader, header, parent *types.BlockHeader) error {
+	if header.Number.Cmp(new(big.Int).Add(parent.Number, big.NewInt(1))) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+	if consensus.IsFutureBlock(header, time.Now()) {
+		return consensus.ErrFutureBlock
+	}
+	median, err := consensus.MedianTimePast(chain, parent)
+	if err != nil {
+		return err
+	}
+	if header.Time <= median {
+		return errOlderBlockTime
+	}
+	if header.GasLimit > parent.GasLimit*105/100 || header.GasLimit < parent.GasLimit*95/100 {
+		return errInvalidGasLimit
+	}
+	if header.GasUsed > header.GasLimit {
+		return errInvalidGasUsed
+	}
+	expected := e.CalcDifficulty(chain, header.Time, parent)
+	if expected == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Difficulty == nil || expected.Cmp(header.Difficulty) != 0 {
+		return errInvalidDifficulty
+	}
+	return e.VerifySeal(chain, header)
+}
+
+// VerifySeal accepts any seal, except for the failing block number
+// VerifySeal 接受任意封装，指定失败区块号除外
+func (e *Engine) VerifySeal(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	if e.fullFake {
+		return nil
+	}
+	time.Sleep(e.delay)
+	if e.failNumber != 0 && header.Number.Uint64() == e.failNumber {
+		return ErrFakeSeal
+	}
+	return nil
+}
+
+// VerifyUncles checks the uncles of a block like NogoPow, the uncle seals only fail for the failing block number
+// VerifyUncles 按 NogoPow 规则检查区块的叔块，叔块封装只在指定失败区块号上失败
+func (e *Engine) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if e.fullFake {
+		return nil
+	}
+	if block.Header.UncleHash != types.CalcUncleHash(block.Uncles) {
+		return consensus.ErrInvalidUncleHash
+	}
+	parents, err := consensus.VerifyUncleAncestry(chain, block.Header, block.Uncles)
+	if err != nil {
+		return err
+	}
+	for i, uncle := range block.Uncles {
+		if err := e.verifyHeader(chain, uncle, parents[i]); err != nil {
+			// A future uncle must not queue the including block as a future block
+			// 超前的叔块不能使包含它的区块进入未来区块队列
+			if err == consensus.ErrFutureBlock {
+				return consensus.ErrFutureUncle
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Prepare sets the difficulty of a header from its parent
+// Prepare 根据父区块设置区块头难度
+func (e *Engine) Prepare(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consensus.ErrInvalidNumber
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Difficulty = e.CalcDifficulty(chain, header.Time, parent); header.Difficulty == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return nil
+}
+
+// Finalize credits the NogoPow block, uncle and nephew rewards, sets the header roots and assembles the block
+// Finalize 计入 NogoPow 区块、叔块和侄块奖励，设置区块头根哈希并组装区块
+func (e *Engine) Finalize(chain consensus.ChainHeaderReader, header *types.BlockHeader, stateDB state.StateDB, txs []*types.Transaction, uncles []*types.BlockHeader) (*types.Block, error) {
+	nogopow.AccumulateRewards(stateDB, header, uncles)
+
+	if memState, ok := stateDB.(*state.MemoryStateDB); ok {
+		header.Root = memState.CalculateStateRoot()
+	}
+	header.TxHash = types.CalcTxHash(txs)
+	header.UncleHash = types.CalcUncleHash(uncles)
+
+	return types.NewBlockWithHeader(header, &types.Body{Transactions: txs, Uncles: uncles}), nil
+}
+
+// Seal returns the block unchanged as sealed, after the configured delay
+// Seal 在配置的延迟后将原区块作为已封装区块返回
+func (e *Engine) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	go e.deliver(block, results, stop)
+	return nil
+}
+
+// deliver sends a copy of the block to results after the delay, unless stop is closed first
+// deliver 延迟后将区块副本发送到 results，除非 stop 先被关闭
+func (e *Engine) deliver(block *types.Block, results chan<- *types.Block, stop <-chan struct{}) {
+	if e.delay > 0 {
+		select {
+		case <-time.After(e.delay):
+		case <-stop:
+			return
+		}
+	}
+
+	sealed := *block.Header
+	select {
+	case results <- types.NewBlockWithHeader(&sealed, block.Body()):
+	case <-stop:
+	}
+}
+
+// SetChainConfig sets the chain config selecting the difficulty algorithm
+// SetChainConfig 设置选择难度算法的链配置
+func (e *Engine) SetChainConfig(config *params.ChainConfig) {
+	e.config = config
+}
+
+// CalcDifficulty returns the NogoPow difficulty, so fake chains follow the same difficulty schedule
+// CalcDifficulty 返回 NogoPow 难度，模拟链遵循相同的难度调整
+func (e *Engine) CalcDifficulty(chain consensus.ChainHeaderReader, timestamp uint64, parent *types.BlockHeader) *big.Int {
+	config := e.config
+	if config == nil {
+		config = params.DefaultChainConfig
+	}
+	return nogopow.CalculateDifficulty(config, chain, timestamp, parent)
+}
+
+// GenerateBlock builds a block on top of parent with the given transactions and seals it with the engine
+// The parent must be readable from chain, the block reward is credited to stateDB
+// GenerateBlock 在 parent 之上用给定交易构建区块并使用引擎封装
+// parent 必须能从 chain 读取，区块奖励计入 stateDB
+func GenerateBlock(engine consensus.Engine, chain consensus.ChainHeaderReader, parent *types.BlockHeader, coinbase common.Address, stateDB state.StateDB, txs []*types.Transaction) (*types.Block, error) {
+	return GenerateBlockWithUncles(engine, chain, parent, coinbase, stateDB, txs, nil)
+}
+
+// GenerateBlockWithUncles is GenerateBlock including the given uncles, their rewards are credited to stateDB
+// GenerateBlockWithUncles 与 GenerateBlock 相同但包含给定的叔块，叔块奖励计入 stateDB
+func GenerateBlockWithUncles(engine consensus.Engine, chain consensus.ChainHeaderReader, parent *types.BlockHeader, coinbase common.Address, stateDB state.StateDB, txs []*types.Transaction, uncles []*types.BlockHeader) (*types.Block, error) {
+	header := &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Coinbase:   coinbase,
+		Bloom:      make([]byte, 256),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + nogopow.TargetBlockTime,
+	}
+	for _, tx := range txs {
+		header.GasUsed += tx.Gas
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	block, err := engine.Finalize(chain, header, stateDB, txs, uncles)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := engine.Seal(chain, block, results, stop); err != nil {
+		return nil, err
+	}
+	return <-results, nil
+}
@@ -0,0 +1,127 @@
+package fake
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/blockchain"
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+// 测试模拟引擎可以快速生成并插入有效链
+func TestFakerChain(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	bc.SetEngine(NewFaker())
+	stateDB := state.NewMemoryStateDB()
+
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		block, err := GenerateBlock(bc.Engine(), bc, bc.CurrentHeader(), common.Address{0x01}, stateDB, nil)
+		if err != nil {
+			t.Fatalf("GenerateBlock %d failed: %v", i+1, err)
+		}
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock %d failed: %v", i+1, err)
+		}
+	}
+	if head := bc.CurrentHeader().Number.Uint64(); head != 50 {
+		t.Errorf("head = %d, want 50", head)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("generating 50 blocks took %v", elapsed)
+	}
+	if stateDB.GetBalance(common.Address{0x01}).Sign() <= 0 {
+		t.Errorf("block rewards not credited")
+	}
+
+	// 难度不正确的区块被拒绝
+	block, err := GenerateBlock(NewFaker(), bc, bc.CurrentHeader(), common.Address{0x01}, stateDB, nil)
+	if err != nil {
+		t.Fatalf("GenerateBlock failed: %v", err)
+	}
+	block.Header.Difficulty = new(big.Int).Add(block.Header.Difficulty, big.NewInt(1))
+	if err := bc.AddBlock(block); err != errInvalidDifficulty {
+		t.Errorf("got %v, want %v", err, errInvalidDifficulty)
+	}
+	if err := NewFullFaker().VerifyHeader(bc, block.Header); err != nil {
+		t.Errorf("full faker should accept any header: %v", err)
+	}
+}
+
+func TestFakeFailer(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	bc.SetEngine(NewFakeFailer(3))
+	stateDB := state.NewMemoryStateDB()
+
+	for i := 1; i <= 3; i++ {
+		block, err := GenerateBlock(bc.Engine(), bc, bc.CurrentHeader(), common.Address{0x01}, stateDB, nil)
+		if err != nil {
+			t.Fatalf("GenerateBlock %d failed: %v", i, err)
+		}
+		err = bc.AddBlock(block)
+		if i < 3 && err != nil {
+			t.Fatalf("AddBlock %d failed: %v", i, err)
+		}
+		if i == 3 && err != ErrFakeSeal {
+			t.Errorf("block 3: got %v, want %v", err, ErrFakeSeal)
+		}
+	}
+	if head := bc.CurrentHeader().Number.Uint64(); head != 2 {
+		t.Errorf("head = %d, want 2", head)
+	}
+}
+
+func TestFakeDelayer(t *testing.T) {
+	delay := 50 * time.Millisecond
+	engine := NewFakeDelayer(delay)
+	header := &types.BlockHeader{Number: big.NewInt(1)}
+
+	start := time.Now()
+	if err := engine.VerifySeal(nil, header); err != nil {
+		t.Fatalf("VerifySeal failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("verification returned after %v, want at least %v", elapsed, delay)
+	}
+
+	// 延迟期间关闭 stop 时不返回区块
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	engine.Seal(nil, types.NewBlockWithHeader(header, nil), results, stop)
+	close(stop)
+	select {
+	case <-results:
+		t.Errorf("stopped seal should not deliver a block")
+	case <-time.After(2 * delay):
+	}
+}
+
+func TestDevEngine(t *testing.T) {
+	engine := NewDevEngine()
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	empty := types.NewBlockWithHeader(&types.BlockHeader{Number: big.NewInt(1)}, nil)
+	if err := engine.Seal(nil, empty, results, stop); err != ErrWaitTransactions {
+		t.Errorf("empty block: got %v, want %v", err, ErrWaitTransactions)
+	}
+
+	tx := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	block := types.NewBlockWithHeader(&types.BlockHeader{Number: big.NewInt(1)}, &types.Body{Transactions: []*types.Transaction{tx}})
+	if err := engine.Seal(nil, block, results, stop); err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	select {
+	case sealed := <-results:
+		if sealed.Hash() != block.Hash() || len(sealed.Transactions) != 1 {
+			t.Errorf("sealed block mismatch")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("dev engine did not seal instantly")
+	}
+}
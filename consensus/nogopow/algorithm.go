@@ -6,6 +6,8 @@ import (
 	"math/big"
 	"runtime"
 	"sync"
+
+	"nogochain/params"
 )
 
 const (
@@ -33,16 +35,17 @@ type NogoPow struct {
 	dataset     []uint64
 	cacheOnce   sync.Once
 	datasetOnce sync.Once
-}
 
-// Global cache pool for reusing NogoPow instances
-// 全局缓存池，用于重用NogoPow实例
-var (
-	powPool     = make(map[string]*NogoPow)
-	powPoolMu   sync.RWMutex
-	seedCache   = make(map[string][]uint64)
-	seedCacheMu sync.RWMutex
-)
+	// cacheOwner Instance owning the shared cache, kept alive while the dataset uses its file mapping
+	// cacheOwner 共享缓存的所属实例，数据集使用期间保持其文件映射有效
+	cacheOwner *NogoPow
+	mappings   [][]byte
+	mappingMu  sync.Mutex
+
+	// config Chain config selecting the difficulty algorithm, nil uses params.DefaultChainConfig
+	// config 选择难度算法的链配置，为 nil 时使用 params.DefaultChainConfig
+	config *params.ChainConfig
+}
 
 // NewNogoPow Create a new NogoPow instance
 // NewNogoPow 创建新的NogoPow实例
@@ -50,22 +53,19 @@ func NewNogoPow() *NogoPow {
 	return &NogoPow{}
 }
 
-// GetCachedNogoPow Get NogoPow instance from cache
-// GetCachedNogoPow 从缓存获取NogoPow实例
-func GetCachedNogoPow(seedHash string) *NogoPow {
-	powPoolMu.RLock()
-	if pow, exists := powPool[seedHash]; exists {
-		powPoolMu.RUnlock()
-		return pow
-	}
-	powPoolMu.RUnlock()
-
-	pow := NewNogoPow()
-	powPoolMu.Lock()
-	powPool[seedHash] = pow
-	powPoolMu.Unlock()
+// NewNogoPowWithConfig Create a new NogoPow instance using the difficulty algorithm of config
+// NewNogoPowWithConfig 创建使用 config 中难度算法的NogoPow实例
+func NewNogoPowWithConfig(config *params.ChainConfig) *NogoPow {
+	return &NogoPow{config: config}
+}
 
-	return pow
+// ChainConfig Return the chain config of the engine
+// ChainConfig 获取引擎的链配置
+func (n *NogoPow) ChainConfig() *params.ChainConfig {
+	if n.config == nil {
+		return params.DefaultChainConfig
+	}
+	return n.config
 }
 
 // Initialize Initialize the algorithm
@@ -83,17 +83,6 @@ func (n *NogoPow) Initialize(seed []byte) {
 // buildCache 构建缓存
 func (n *NogoPow) buildCache(seed []byte) {
 	seedHash := sha256.Sum256(seed)
-	seedHashStr := string(seedHash[:])
-
-	// Check if already in cache
-	// 检查缓存中是否已有
-	seedCacheMu.RLock()
-	if cachedCache, exists := seedCache[seedHashStr]; exists {
-		n.cache = cachedCache
-		seedCacheMu.RUnlock()
-		return
-	}
-	seedCacheMu.RUnlock()
 
 	n.cache = make([]uint64, CacheItems)
 	h := seedHash
@@ -111,12 +100,6 @@ func (n *NogoPow) buildCache(seed []byte) {
 		}
 		n.cache[i] = val
 	}
-
-	// Cache result
-	// 缓存结果
-	seedCacheMu.Lock()
-	seedCache[seedHashStr] = n.cache
-	seedCacheMu.Unlock()
 }
 
 // buildDataset Build dataset
@@ -170,7 +153,24 @@ func (n *NogoPow) calculateDatasetItem(index int) uint64 {
 // Hashimoto Hashimoto algorithm
 // Hashimoto 哈希imoto算法
 func (n *NogoPow) Hashimoto(header []byte, nonce uint64) ([]byte, []byte) {
-	data := append(header, make([]byte, 8)...)
+	return hashimoto(header, nonce, func(index int) uint64 {
+		return n.dataset[index]
+	})
+}
+
+// HashimotoLight Hashimoto computing the needed dataset items on demand from the cache only
+// Produces the same result as Hashimoto without the full dataset, for verifiers that do not mine
+// HashimotoLight 只使用缓存按需计算所需数据集项的Hashimoto算法
+// 结果与Hashimoto相同但不需要完整数据集，用于不挖矿的验证者
+func (n *NogoPow) HashimotoLight(header []byte, nonce uint64) ([]byte, []byte) {
+	return hashimoto(header, nonce, n.calculateDatasetItem)
+}
+
+// hashimoto Mix the header and nonce with dataset items returned by lookup
+// hashimoto 将区块头和nonce与lookup返回的数据集项混合
+func hashimoto(header []byte, nonce uint64, lookup func(index int) uint64) ([]byte, []byte) {
+	data := make([]byte, len(header)+8)
+	copy(data, header)
 	binary.LittleEndian.PutUint64(data[len(header):], nonce)
 
 	mix := make([]uint64, 8)
@@ -187,7 +187,9 @@ func (n *NogoPow) Hashimoto(header []byte, nonce uint64) ([]byte, []byte) {
 	for i := 0; i < 32; i++ { // Optimization: reduce iteration count
 		pos := int(mix[i%8] % uint64(NumItems))
 		for j := 0; j < 8; j++ {
-			mix[j] ^= n.dataset[pos+j]
+			// Wrap around at the end of the dataset
+			// 超出数据集末尾时回绕
+			mix[j] ^= lookup((pos + j) % NumItems)
 		}
 	}
 
@@ -196,10 +198,21 @@ func (n *NogoPow) Hashimoto(header []byte, nonce uint64) ([]byte, []byte) {
 		binary.LittleEndian.PutUint64(result[i*8:], mix[i])
 	}
 
-	hash := sha256.Sum256(result)
-	mixDigest := sha256.Sum256(hash[:])
+	mixDigest := sha256.Sum256(result)
+	return powHash(header, nonce, mixDigest[:]), mixDigest[:]
+}
 
-	return hash[:], mixDigest[:]
+// powHash Return the final proof-of-work hash of the header, nonce and mix digest
+// Only the mix digest needs the dataset, so a claimed mix digest can be checked against the target cheaply
+// powHash 计算区块头、nonce 和混合摘要的最终工作量证明哈希
+// 只有混合摘要需要数据集，因此可以低成本地用声明的混合摘要检查目标
+func powHash(header []byte, nonce uint64, mixDigest []byte) []byte {
+	data := make([]byte, 0, len(header)+8+len(mixDigest))
+	data = append(data, header...)
+	data = binary.LittleEndian.AppendUint64(data, nonce)
+	data = append(data, mixDigest...)
+	hash := sha256.Sum256(data)
+	return hash[:]
 }
 
 // Verify Verify hash
@@ -210,6 +223,14 @@ func (n *NogoPow) Verify(header []byte, nonce uint64, target *big.Int) bool {
 	return hashInt.Cmp(target) <= 0
 }
 
+// VerifyLight Verify hash using the cache only
+// VerifyLight 只使用缓存验证哈希
+func (n *NogoPow) VerifyLight(header []byte, nonce uint64, target *big.Int) bool {
+	hash, _ := n.HashimotoLight(header, nonce)
+	hashInt := new(big.Int).SetBytes(hash)
+	return hashInt.Cmp(target) <= 0
+}
+
 // Mine Mine
 // Mine 挖矿
 func (n *NogoPow) Mine(header []byte, target *big.Int, startNonce uint64, iterations uint64) (uint64, []byte, []byte, bool) {
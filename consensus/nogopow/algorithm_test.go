@@ -1,9 +1,12 @@
 package nogopow
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 	"time"
+
+	"nogochain/core/types"
 )
 
 func TestNogoPow_Initialize(t *testing.T) {
@@ -52,6 +55,37 @@ func TestNogoPow_Hashimoto(t *testing.T) {
 	}
 }
 
+func TestNogoPow_HashimotoLight(t *testing.T) {
+	pow := NewNogoPow()
+	pow.Initialize([]byte("test seed"))
+
+	light := NewNogoPow()
+	light.buildCache([]byte("test seed"))
+
+	header := []byte("test header")
+	for nonce := uint64(0); nonce < 64; nonce++ {
+		hash, mixDigest := pow.Hashimoto(header, nonce)
+		lightHash, lightMixDigest := light.HashimotoLight(header, nonce)
+		if !bytes.Equal(hash, lightHash) || !bytes.Equal(mixDigest, lightMixDigest) {
+			t.Fatalf("HashimotoLight mismatch for nonce %d", nonce)
+		}
+	}
+}
+
+func TestVerifyNonceLight(t *testing.T) {
+	number := uint64(7 * EpochLength)
+	header := []byte("test header")
+	target := big.NewInt(1000000)
+	target.Lsh(target, 240) // 增大目标值
+
+	if !VerifyNonce(header, number, 12345, target) {
+		t.Errorf("VerifyNonce failed unexpectedly")
+	}
+	if GetCache(number).dataset != nil {
+		t.Errorf("VerifyNonce should not build the dataset")
+	}
+}
+
 func TestNogoPow_Verify(t *testing.T) {
 	pow := NewNogoPow()
 	seed := []byte("test seed")
@@ -96,12 +130,13 @@ func TestNogoPow_MineParallel(t *testing.T) {
 }
 
 func TestCalculateDifficulty(t *testing.T) {
-	parentTime := time.Now().Add(-20 * time.Second)
-	currentTime := time.Now()
-	parentDiff := big.NewInt(1000000)
-	height := uint64(10)
+	parent := &types.BlockHeader{
+		Number:     big.NewInt(9),
+		Difficulty: big.NewInt(1000000),
+		Time:       uint64(time.Now().Add(-20 * time.Second).Unix()),
+	}
 
-	difficulty := CalculateDifficulty(parentTime, currentTime, parentDiff, height)
+	difficulty := CalculateDifficulty(nil, nil, uint64(time.Now().Unix()), parent)
 	if difficulty.Cmp(big.NewInt(0)) <= 0 {
 		t.Errorf("Invalid difficulty: %v", difficulty)
 	}
@@ -0,0 +1,281 @@
+package nogopow
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+const (
+	// sealRound Number of nonces each sealing thread tries between stop checks
+	// sealRound 每个封装线程在两次检查停止信号之间尝试的nonce数量
+	sealRound = 1 << 14
+)
+
+var (
+	errOlderBlockTime    = errors.New("timestamp not after median time past")
+	errInvalidDifficulty = errors.New("invalid difficulty")
+	errInvalidGasLimit   = errors.New("invalid gas limit")
+	errInvalidGasUsed    = errors.New("gas used exceeds gas limit")
+	errInvalidPoW        = errors.New("invalid proof-of-work")
+	errInvalidMixDigest  = errors.New("invalid mix digest")
+)
+
+// NogoPow implements consensus.Engine, the engine methods work on the caches and datasets of the header's epoch
+// NogoPow 实现 consensus.Engine，引擎方法使用区块头所在纪元的缓存和数据集
+var _ consensus.Engine = (*NogoPow)(nil)
+
+// Author Return the coinbase of the header, the miner who sealed it
+// Author 获取区块头的 coinbase，即封装区块的矿工
+func (n *NogoPow) Author(header *types.BlockHeader) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+// VerifyHeader Check a header against its parent and verify its proof-of-work
+// VerifyHeader 根据父区块验证区块头并验证工作量证明
+func (n *NogoPow) VerifyHeader(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consensus.ErrInvalidNumber
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return n.verifyHeader(chain, header, parent)
+}
+
+// VerifyHeaders Verify a batch of headers concurrently, each header may use the previous one as its parent
+// VerifyHeaders 并发验证一批区块头，每个区块头可以以前一个区块头为父区块
+func (n *NogoPow) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.BlockHeader) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	// Verify in parallel, then report the errors in order
+	// 并行验证，然后按顺序返回错误
+	errs := make([]error, len(headers))
+	done := make([]chan struct{}, len(headers))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	batch := consensus.NewBatchHeaderReader(chain, headers)
+	workers := runtime.GOMAXPROCS(0)
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				errs[i] = n.verifyHeaderAt(batch, headers, i)
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range headers {
+			select {
+			case jobs <- i:
+			case <-abort:
+				return
+			}
+		}
+	}()
+	go func() {
+		for i := range headers {
+			select {
+			case <-done[i]:
+				results <- errs[i]
+			case <-abort:
+				return
+			}
+		}
+	}()
+
+	return abort, results
+}
+
+// verifyHeaderAt Verify the header at index i of a batch
+// verifyHeaderAt 验证批次中第 i 个区块头
+func (n *NogoPow) verifyHeaderAt(chain consensus.ChainHeaderReader, headers []*types.BlockHeader, i int) error {
+	header := headers[i]
+	var parent *types.BlockHeader
+	if i > 0 && headers[i-1].Hash() == header.ParentHash {
+		parent = headers[i-1]
+	} else if number := header.Number.Uint64(); number > 0 {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return n.verifyHeader(chain, header, parent)
+}
+
+// verifyHeader Check the header fields against the parent, then the seal
+// verifyHeader 根据父区块检查区块头字段，然后验证封装
+func (n *NogoPow) verifyHeader(chain consensus.ChainHeaderReader, header, parent *types.BlockHeader) error {
+	if header.Number.Cmp(new(big.Int).Add(parent.Number, big.NewInt(1))) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+	if consensus.IsFutureBlock(header, time.Now()) {
+		return consensus.ErrFutureBlock
+	}
+	median, err := consensus.MedianTimePast(chain, parent)
+	if err != nil {
+		return err
+	}
+	if header.Time <= median {
+		return errOlderBlockTime
+	}
+	if header.GasLimit > parent.GasLimit*105/100 || header.GasLimit < parent.GasLimit*95/100 {
+		return errInvalidGasLimit
+	}
+	if header.GasUsed > header.GasLimit {
+		return errInvalidGasUsed
+	}
+	expected := n.CalcDifficulty(chain, header.Time, parent)
+	if expected == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Difficulty == nil || expected.Cmp(header.Difficulty) != 0 {
+		return errInvalidDifficulty
+	}
+	return n.VerifySeal(chain, header)
+}
+
+// VerifySeal Verify the proof-of-work and MixDigest of a header from the epoch cache only
+// VerifySeal 只使用纪元缓存验证区块头的工作量证明和 MixDigest
+func (n *NogoPow) VerifySeal(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	return VerifyWork(header, header.Difficulty)
+}
+
+// VerifyUncles Check that the uncles of a block are recent side-chain headers included once, and verify each uncle header
+// VerifyUncles 检查区块的叔块是否为只被包含一次的最近侧链区块头，并验证每个叔块区块头
+func (n *NogoPow) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if block.Header.UncleHash != types.CalcUncleHash(block.Uncles) {
+		return consensus.ErrInvalidUncleHash
+	}
+	parents, err := consensus.VerifyUncleAncestry(chain, block.Header, block.Uncles)
+	if err != nil {
+		return err
+	}
+	for i, uncle := range block.Uncles {
+		if err := n.verifyHeader(chain, uncle, parents[i]); err != nil {
+			// A future uncle must not queue the including block as a future block
+			// 超前的叔块不能使包含它的区块进入未来区块队列
+			if err == consensus.ErrFutureBlock {
+				return consensus.ErrFutureUncle
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Prepare Set the difficulty of a header from its parent
+// Prepare 根据父区块设置区块头难度
+func (n *NogoPow) Prepare(chain consensus.ChainHeaderReader, header *types.BlockHeader) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consensus.ErrInvalidNumber
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Difficulty = n.CalcDifficulty(chain, header.Time, parent); header.Difficulty == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return nil
+}
+
+// Finalize Credit the block, uncle and nephew rewards, set the header roots and assemble the block
+// Finalize 计入区块、叔块和侄块奖励，设置区块头根哈希并组装区块
+func (n *NogoPow) Finalize(chain consensus.ChainHeaderReader, header *types.BlockHeader, stateDB state.StateDB, txs []*types.Transaction, uncles []*types.BlockHeader) (*types.Block, error) {
+	AccumulateRewards(stateDB, header, uncles)
+
+	if memState, ok := stateDB.(*state.MemoryStateDB); ok {
+		header.Root = memState.CalculateStateRoot()
+	}
+	header.TxHash = types.CalcTxHash(txs)
+	header.UncleHash = types.CalcUncleHash(uncles)
+
+	return types.NewBlockWithHeader(header, &types.Body{Transactions: txs, Uncles: uncles}), nil
+}
+
+// Seal Search a nonce for the block on all cores in the background using the full epoch dataset
+// Seal 在后台使用纪元完整数据集在所有核心上搜索区块的nonce
+func (n *NogoPow) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	header := block.Header
+	if header.Difficulty == nil || header.Difficulty.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+	data := SealHash(header).Bytes()
+	target := ToTarget(header.Difficulty)
+	threads := runtime.GOMAXPROCS(0)
+
+	go func() {
+		pow := GetDataset(header.Number.Uint64())
+		for start := uint64(0); ; start += uint64(threads) * sealRound {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				found     bool
+				nonce     uint64
+				mixDigest []byte
+			)
+			for i := 0; i < threads; i++ {
+				wg.Add(1)
+				go func(from uint64) {
+					defer wg.Done()
+					localNonce, _, localMix, ok := pow.Mine(data, target, from, sealRound)
+					if ok {
+						mu.Lock()
+						if !found || localNonce < nonce {
+							found, nonce, mixDigest = true, localNonce, localMix
+						}
+						mu.Unlock()
+					}
+				}(start + uint64(i)*sealRound)
+			}
+			wg.Wait()
+			if !found {
+				continue
+			}
+
+			sealed := *header
+			sealed.Nonce = nonce
+			sealed.MixDigest = common.BytesToHash(mixDigest)
+			select {
+			case results <- types.NewBlockWithHeader(&sealed, block.Body()):
+			case <-stop:
+			}
+			return
+		}
+	}()
+	return nil
+}
+
+// CalcDifficulty Return the difficulty of a block created at time on top of parent, nil if ancestors are missing
+// CalcDifficulty 计算在 parent 之上、时间为 time 的区块难度，缺少祖先区块时返回 nil
+func (n *NogoPow) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.BlockHeader) *big.Int {
+	return CalculateDifficulty(n.ChainConfig(), chain, time, parent)
+}
+
+// unixTime Convert a header timestamp to time.Time
+// unixTime 将区块头时间戳转换为 time.Time
+func unixTime(timestamp uint64) time.Time {
+	return time.Unix(int64(timestamp), 0)
+}
@@ -0,0 +1,178 @@
+package nogopow
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// testChain 按哈希保存区块头的测试链
+type testChain map[common.Hash]*types.BlockHeader
+
+func (c testChain) CurrentHeader() *types.BlockHeader { return nil }
+
+func (c testChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	if header := c[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+func (c testChain) GetHeaderByNumber(number uint64) *types.BlockHeader { return nil }
+
+func (c testChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader { return c[hash] }
+
+func TestEngineVerifyHeader(t *testing.T) {
+	engine := NewNogoPow()
+	genesis := &types.BlockHeader{
+		Difficulty: big.NewInt(InitialDifficulty),
+		Number:     big.NewInt(0),
+		GasLimit:   10000000,
+		Time:       1700000000 - TargetBlockTime,
+	}
+	parent := &types.BlockHeader{
+		ParentHash: genesis.Hash(),
+		Coinbase:   common.Address{0x01},
+		Difficulty: big.NewInt(InitialDifficulty),
+		Number:     big.NewInt(1),
+		GasLimit:   10000000,
+		Time:       1700000000,
+	}
+	chain := testChain{genesis.Hash(): genesis, parent.Hash(): parent}
+
+	newHeader := func() *types.BlockHeader {
+		header := &types.BlockHeader{
+			ParentHash: parent.Hash(),
+			Coinbase:   common.Address{0x02},
+			Number:     big.NewInt(2),
+			GasLimit:   10000000,
+			Time:       parent.Time + TargetBlockTime,
+		}
+		if err := engine.Prepare(chain, header); err != nil {
+			t.Fatalf("Prepare failed: %v", err)
+		}
+		return header
+	}
+
+	if author, _ := engine.Author(parent); author != parent.Coinbase {
+		t.Errorf("Author = %x, want coinbase", author)
+	}
+	if header := newHeader(); header.Difficulty.Int64() != InitialDifficulty {
+		t.Errorf("Prepare set difficulty %v", header.Difficulty)
+	}
+
+	orphan := newHeader()
+	orphan.ParentHash = common.Hash{0xff}
+	if err := engine.VerifyHeader(chain, orphan); err != consensus.ErrUnknownAncestor {
+		t.Errorf("orphan header: got %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+
+	older := newHeader()
+	older.Time = parent.Time
+	if err := engine.VerifyHeader(chain, older); err != errOlderBlockTime {
+		t.Errorf("older header: got %v, want %v", err, errOlderBlockTime)
+	}
+
+	future := newHeader()
+	future.Time = uint64(time.Now().Unix()) + params.MaxFutureBlockTime + 10
+	if err := engine.VerifyHeader(chain, future); err != consensus.ErrFutureBlock {
+		t.Errorf("future header: got %v, want %v", err, consensus.ErrFutureBlock)
+	}
+
+	gas := newHeader()
+	gas.GasUsed = gas.GasLimit + 1
+	if err := engine.VerifyHeader(chain, gas); err != errInvalidGasUsed {
+		t.Errorf("gas used header: got %v, want %v", err, errInvalidGasUsed)
+	}
+
+	difficulty := newHeader()
+	difficulty.Difficulty = big.NewInt(1)
+	if err := engine.VerifyHeader(chain, difficulty); err != errInvalidDifficulty {
+		t.Errorf("difficulty header: got %v, want %v", err, errInvalidDifficulty)
+	}
+
+	// 字段正确但未封装的区块头在工作量证明验证中失败
+	if err := engine.VerifyHeader(chain, newHeader()); err != errInvalidPoW {
+		t.Errorf("unsealed header: got %v, want %v", err, errInvalidPoW)
+	}
+
+	// 批量验证按顺序返回结果，后一个区块头可以以前一个为父区块
+	first := newHeader()
+	second := &types.BlockHeader{ParentHash: first.Hash(), Number: big.NewInt(3), GasLimit: 10000000, Time: parent.Time}
+	abort, results := engine.VerifyHeaders(chain, []*types.BlockHeader{first, second})
+	defer close(abort)
+	if err := <-results; err != errInvalidPoW {
+		t.Errorf("batch header 0: got %v, want %v", err, errInvalidPoW)
+	}
+	if err := <-results; err != errOlderBlockTime {
+		t.Errorf("batch header 1: got %v, want %v", err, errOlderBlockTime)
+	}
+}
+
+func TestEngineVerifyUncles(t *testing.T) {
+	engine := NewNogoPow()
+	block := &types.Block{Header: &types.BlockHeader{Number: big.NewInt(1)}}
+	for i := 0; i <= params.MaxUncles; i++ {
+		block.Uncles = append(block.Uncles, &types.BlockHeader{Number: big.NewInt(0)})
+	}
+	block.Header.UncleHash = types.CalcUncleHash(block.Uncles)
+	if err := engine.VerifyUncles(nil, block); err != consensus.ErrTooManyUncles {
+		t.Errorf("got %v, want %v", err, consensus.ErrTooManyUncles)
+	}
+	block.Uncles = block.Uncles[:params.MaxUncles]
+	if err := engine.VerifyUncles(nil, block); err != consensus.ErrInvalidUncleHash {
+		t.Errorf("got %v, want %v", err, consensus.ErrInvalidUncleHash)
+	}
+	block.Header.UncleHash = types.CalcUncleHash(block.Uncles)
+	if err := engine.VerifyUncles(nil, block); err != consensus.ErrUnknownAncestor {
+		t.Errorf("got %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	block.Uncles, block.Header.UncleHash = nil, common.Hash{}
+	if err := engine.VerifyUncles(nil, block); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngineSeal(t *testing.T) {
+	engine := NewNogoPow()
+	block := types.NewBlockWithHeader(&types.BlockHeader{
+		Coinbase:   common.Address{0x01},
+		Difficulty: big.NewInt(16),
+		Number:     big.NewInt(1),
+		GasLimit:   10000000,
+		Time:       1700000000,
+	}, &types.Body{})
+
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := engine.Seal(nil, block, results, stop); err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	select {
+	case sealed := <-results:
+		if err := engine.VerifySeal(nil, sealed.Header); err != nil {
+			t.Errorf("sealed block failed verification: %v", err)
+		}
+		if sealed.Header.MixDigest == (common.Hash{}) {
+			t.Errorf("sealed block has no mix digest")
+		}
+		if block.Header.MixDigest != (common.Hash{}) {
+			t.Errorf("Seal modified the input block")
+		}
+		tampered := *sealed.Header
+		tampered.MixDigest[0] ^= 0xff
+		if err := engine.VerifySeal(nil, &tampered); err != errInvalidPoW && err != errInvalidMixDigest {
+			t.Errorf("tampered mix digest: got %v, want %v or %v", err, errInvalidPoW, errInvalidMixDigest)
+		}
+	case <-time.After(time.Minute):
+		t.Fatalf("sealing timed out")
+	}
+}
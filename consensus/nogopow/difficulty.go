@@ -3,6 +3,10 @@ package nogopow
 import (
 	"math/big"
 	"time"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+	"nogochain/params"
 )
 
 const (
@@ -23,10 +27,28 @@ const (
 	InitialDifficulty = 1000000
 )
 
-// CalculateDifficulty Calculate new difficulty
-// CalculateDifficulty 计算新难度
+// CalculateDifficulty Return the difficulty of a block created at time on top of parent
+// Blocks before the fork block of config keep the legacy rule, later blocks use the configured per-block algorithm
+// Returns nil if an ancestor needed by the algorithm is not available from chain
+// CalculateDifficulty 计算在 parent 之上、时间为 time 的区块难度
+// 配置的分叉区块之前使用原有算法，之后使用配置的逐块调整算法
+// 算法需要的祖先区块无法从 chain 获取时返回 nil
+func CalculateDifficulty(config *params.ChainConfig, chain consensus.ChainHeaderReader, time uint64, parent *types.BlockHeader) *big.Int {
+	number := parent.Number.Uint64() + 1
+	switch config.DifficultyAlgorithm(number) {
+	case params.DifficultyLWMA:
+		return lwmaDifficulty(config.Difficulty, chain, parent)
+	case params.DifficultyASERT:
+		return asertDifficulty(config.Difficulty, chain, parent)
+	default:
+		return legacyDifficulty(unixTime(parent.Time), unixTime(time), parent.Difficulty, number)
+	}
+}
+
+// legacyDifficulty Calculate new difficulty with the legacy rule, kept unchanged for blocks before the fork
+// legacyDifficulty 使用原有规则计算新难度，分叉之前的区块保持不变
 // 每10个区块调整一次，目标出块时间为20秒，限制调整幅度在±50%
-func CalculateDifficulty(parentTimestamp time.Time, currentTimestamp time.Time, parentDifficulty *big.Int, height uint64) *big.Int {
+func legacyDifficulty(parentTimestamp time.Time, currentTimestamp time.Time, parentDifficulty *big.Int, height uint64) *big.Int {
 	// Initial difficulty: First 10 blocks use fixed initial difficulty
 	// 初始难度：前10个区块使用固定初始难度
 	if height < DifficultyAdjustmentInterval {
@@ -82,6 +104,149 @@ func CalculateDifficulty(parentTimestamp time.Time, currentTimestamp time.Time,
 	return result
 }
 
+// lwmaDifficulty Linearly weighted moving average over the solve times of the last window blocks
+// Recent blocks weigh more, so the difficulty follows hashrate changes within a few blocks
+// lwmaDifficulty 对最近 window 个区块的出块时间做线性加权移动平均
+// 越近的区块权重越大，难度在几个区块内跟随算力变化
+func lwmaDifficulty(config *params.DifficultyConfig, chain consensus.ChainHeaderReader, parent *types.BlockHeader) *big.Int {
+	target := int64(config.BlockTime())
+	window := config.Window()
+	if number := parent.Number.Uint64(); number < window {
+		window = number
+	}
+	if window == 0 {
+		return new(big.Int).Set(parent.Difficulty)
+	}
+
+	// Collect the parent and its window ancestors, oldest first
+	// 收集父区块及其 window 个祖先区块，从旧到新排列
+	headers := make([]*types.BlockHeader, window+1)
+	headers[window] = parent
+	for i := window; i > 0; i-- {
+		if chain == nil {
+			return nil
+		}
+		ancestor := chain.GetHeader(headers[i].ParentHash, headers[i].Number.Uint64()-1)
+		if ancestor == nil {
+			return nil
+		}
+		headers[i-1] = ancestor
+	}
+
+	// Solve times are taken against the latest timestamp seen and limited to 6 target times,
+	// so out of order or far future timestamps cannot move the difficulty much
+	// 出块时间相对已出现的最大时间戳计算，并限制在6倍目标时间以内，
+	// 使乱序或超前的时间戳无法大幅影响难度
+	var (
+		weighted   int64
+		difficulty = new(big.Int)
+		previous   = int64(headers[0].Time)
+	)
+	for i := uint64(1); i <= window; i++ {
+		timestamp := int64(headers[i].Time)
+		if timestamp <= previous {
+			timestamp = previous + 1
+		}
+		solveTime := timestamp - previous
+		if solveTime > 6*target {
+			solveTime = 6 * target
+		}
+		previous = timestamp
+		weighted += solveTime * int64(i)
+		difficulty.Add(difficulty, headers[i].Difficulty)
+	}
+
+	// next = sum(D) * T * (N+1) / (2 * sum(i * solveTime)), increasing at most 10 times the average
+	// next = sum(D) * T * (N+1) / (2 * sum(i * solveTime))，最多上调到平均难度的10倍
+	n := int64(window)
+	if minimum := n * (n + 1) / 2 * target / 10; weighted < minimum {
+		weighted = minimum
+	}
+	difficulty.Mul(difficulty, big.NewInt(target*(n+1)))
+	difficulty.Div(difficulty, big.NewInt(2*weighted))
+	return clampDifficulty(config, difficulty)
+}
+
+// asertDifficulty Absolutely scheduled exponential adjustment against the anchor block, the parent of the fork block
+// The difficulty doubles or halves for every half-life the chain is ahead of or behind the schedule,
+// 2^x is approximated with the cubic polynomial of aserti3-2d so the result is exact integer arithmetic
+// As in aserti3-2d the schedule starts at the timestamp of the anchor's parent and the anchor itself counts as a scheduled block,
+// a genesis anchor has no parent and is treated as mined on schedule
+// asertDifficulty 以锚定区块（分叉区块的父区块）为基准的绝对调度指数调整
+// 链每超前或落后计划一个半衰期，难度翻倍或减半，
+// 2^x 使用 aserti3-2d 的三次多项式近似，计算结果为精确的整数运算
+// 与 aserti3-2d 相同，计划从锚定区块的父区块时间戳开始，锚定区块本身计为一个计划内区块，
+// 创世锚定区块没有父区块，视为按计划出块
+func asertDifficulty(config *params.DifficultyConfig, chain consensus.ChainHeaderReader, parent *types.BlockHeader) *big.Int {
+	var anchorNumber uint64
+	if config.ForkBlock > 0 {
+		anchorNumber = config.ForkBlock - 1
+	}
+	anchor := parent
+	if parent.Number.Uint64() != anchorNumber {
+		if chain == nil {
+			return nil
+		}
+		if anchor = chain.GetHeaderByNumber(anchorNumber); anchor == nil {
+			return nil
+		}
+	}
+	target := int64(config.BlockTime())
+	anchorParentTime := int64(anchor.Time) - target
+	if anchorNumber > 0 {
+		if chain == nil {
+			return nil
+		}
+		anchorParent := chain.GetHeader(anchor.ParentHash, anchorNumber-1)
+		if anchorParent == nil {
+			return nil
+		}
+		anchorParentTime = int64(anchorParent.Time)
+	}
+
+	// exponent = (timeDelta - T * (heightDelta + 1)) / halfLife, in 16 bit fixed point
+	// exponent = (timeDelta - T * (heightDelta + 1)) / halfLife，16位定点数
+	var (
+		timeDelta   = int64(parent.Time) - anchorParentTime
+		heightDelta = parent.Number.Int64() - anchor.Number.Int64()
+		exponent    = (timeDelta - target*(heightDelta+1)) * 65536 / int64(config.HalfLife())
+		shifts      = exponent >> 16
+		frac        = big.NewInt(exponent & 0xffff)
+	)
+
+	// factor = 65536 * 2^(frac/65536)
+	factor := new(big.Int).Mul(big.NewInt(195766423245049), frac)
+	square := new(big.Int).Mul(frac, frac)
+	factor.Add(factor, new(big.Int).Mul(big.NewInt(971821376), square))
+	factor.Add(factor, new(big.Int).Mul(big.NewInt(5127), new(big.Int).Mul(square, frac)))
+	factor.Add(factor, new(big.Int).Lsh(big.NewInt(1), 47))
+	factor.Rsh(factor, 48)
+	factor.Add(factor, big.NewInt(65536))
+
+	// A target multiplied by 2^exponent is a difficulty divided by it
+	// 目标值乘以 2^exponent 即难度除以 2^exponent
+	difficulty := new(big.Int).Lsh(anchor.Difficulty, 16)
+	difficulty.Div(difficulty, factor)
+	if shifts < 0 {
+		difficulty.Lsh(difficulty, uint(-shifts))
+	} else {
+		difficulty.Rsh(difficulty, uint(shifts))
+	}
+	return clampDifficulty(config, difficulty)
+}
+
+// clampDifficulty Keep a difficulty between the configured minimum and the largest target
+// clampDifficulty 将难度限制在配置的最低难度和最大目标值之间
+func clampDifficulty(config *params.DifficultyConfig, difficulty *big.Int) *big.Int {
+	if minimum := new(big.Int).SetUint64(config.Minimum()); difficulty.Cmp(minimum) < 0 {
+		return minimum
+	}
+	if maximum := new(big.Int).Lsh(big.NewInt(1), 256); difficulty.Cmp(maximum) >= 0 {
+		return maximum.Sub(maximum, big.NewInt(1))
+	}
+	return difficulty
+}
+
 // ToTarget Convert difficulty to target value
 // In PoW algorithm, miners need to find a nonce such that block hash is less than target value
 // Higher difficulty means smaller target value, making it harder to find valid nonce
@@ -0,0 +1,175 @@
+package nogopow
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// simChain A simulated chain indexed by number, ancestors are looked up by number only
+// simChain 按区块号索引的模拟链，祖先区块只按区块号查找
+type simChain []*types.BlockHeader
+
+func (c simChain) CurrentHeader() *types.BlockHeader { return c[len(c)-1] }
+func (c simChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	return c.GetHeaderByNumber(number)
+}
+func (c simChain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+func (c simChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader { return nil }
+
+// simulate Mine blocks with the hashrate of each phase, a block takes an exponential time with mean difficulty/hashrate
+// simulate 按各阶段算力出块，出块时间服从均值为 难度/算力 的指数分布
+func simulate(t *testing.T, config *params.ChainConfig, hashrates []float64, blocksPerPhase int) simChain {
+	rng := rand.New(rand.NewSource(1))
+	chain := simChain{{Number: big.NewInt(0), Difficulty: big.NewInt(InitialDifficulty), Time: 1700000000}}
+	for _, hashrate := range hashrates {
+		for i := 0; i < blocksPerPhase; i++ {
+			parent := chain.CurrentHeader()
+			difficulty := CalculateDifficulty(config, chain, 0, parent)
+			if difficulty == nil {
+				t.Fatalf("block %d: missing ancestors", len(chain))
+			}
+			diff, _ := new(big.Float).SetInt(difficulty).Float64()
+			solveTime := uint64(math.Round(rng.ExpFloat64() * diff / hashrate))
+			if solveTime == 0 {
+				solveTime = 1
+			}
+			chain = append(chain, &types.BlockHeader{
+				Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+				Difficulty: difficulty,
+				Time:       parent.Time + solveTime,
+			})
+		}
+	}
+	return chain
+}
+
+// meanBlockTime Average block time of blocks (from, to]
+// meanBlockTime 区块 (from, to] 的平均出块时间
+func meanBlockTime(chain simChain, from, to int) float64 {
+	return float64(chain[to].Time-chain[from].Time) / float64(to-from)
+}
+
+func TestDifficultySimulation(t *testing.T) {
+	const (
+		blocksPerPhase = 1000
+		settle         = 300
+		baseHashrate   = float64(InitialDifficulty) / TargetBlockTime
+	)
+	// Hashrate swings: tenfold increase, back to base, then a drop to a fifth
+	// 算力波动：增加到10倍，回到基础算力，然后降到五分之一
+	hashrates := []float64{baseHashrate, 10 * baseHashrate, baseHashrate, baseHashrate / 5}
+
+	tests := []struct {
+		name   string
+		config *params.DifficultyConfig
+	}{
+		{"lwma", &params.DifficultyConfig{Algorithm: params.DifficultyLWMA, ForkBlock: 1}},
+		{"asert", &params.DifficultyConfig{Algorithm: params.DifficultyASERT, ForkBlock: 1, ASERTHalfLife: 600}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := simulate(t, &params.ChainConfig{Difficulty: tt.config}, hashrates, blocksPerPhase)
+			for phase, hashrate := range hashrates {
+				from, to := phase*blocksPerPhase+settle, (phase+1)*blocksPerPhase
+				if mean := meanBlockTime(chain, from, to); mean < 0.85*TargetBlockTime || mean > 1.15*TargetBlockTime {
+					t.Errorf("phase %d: mean block time %.1fs, want about %ds", phase, mean, TargetBlockTime)
+				}
+				diff, _ := new(big.Float).SetInt(chain[to].Difficulty).Float64()
+				if ratio := diff / (hashrate * TargetBlockTime); ratio < 0.5 || ratio > 2 {
+					t.Errorf("phase %d: difficulty %.0f does not follow hashrate %.0f", phase, diff, hashrate)
+				}
+			}
+		})
+	}
+}
+
+func TestDifficultyFork(t *testing.T) {
+	config := &params.ChainConfig{Difficulty: &params.DifficultyConfig{Algorithm: params.DifficultyLWMA, ForkBlock: 20, LWMAWindow: 10}}
+	var chain simChain
+	for i := int64(0); i < 30; i++ {
+		chain = append(chain, &types.BlockHeader{Number: big.NewInt(i), Difficulty: big.NewInt(1000000), Time: 1700000000 + uint64(i)*5})
+	}
+
+	// Before the fork the legacy rule never raises the difficulty
+	// 分叉前原有算法不会提高难度
+	if got := CalculateDifficulty(config, chain, chain[18].Time+5, chain[18]); got.Cmp(big.NewInt(1000000)) != 0 {
+		t.Errorf("pre-fork difficulty = %v, want 1000000", got)
+	}
+	// From the fork block on, blocks four times too fast raise it fourfold
+	// 从分叉区块开始，出块速度为4倍时难度提高到4倍
+	if got := CalculateDifficulty(config, chain, chain[19].Time+5, chain[19]); got.Cmp(big.NewInt(4000000)) != 0 {
+		t.Errorf("fork difficulty = %v, want 4000000", got)
+	}
+	if got := CalculateDifficulty(config, nil, chain[19].Time+5, chain[19]); got != nil {
+		t.Errorf("difficulty without ancestors = %v, want nil", got)
+	}
+}
+
+func TestASERTSchedule(t *testing.T) {
+	config := &params.ChainConfig{Difficulty: &params.DifficultyConfig{Algorithm: params.DifficultyASERT, ForkBlock: 1, ASERTHalfLife: 3600}}
+	anchor := &types.BlockHeader{Number: big.NewInt(0), Difficulty: big.NewInt(1 << 20), Time: 1700000000}
+	chain := simChain{anchor}
+
+	tests := []struct {
+		number uint64
+		time   uint64
+		want   *big.Int
+	}{
+		{0, anchor.Time, big.NewInt(1 << 20)},                                // on schedule / 符合计划
+		{10, anchor.Time + 10*TargetBlockTime, big.NewInt(1 << 20)},          // on schedule / 符合计划
+		{10, anchor.Time + 10*TargetBlockTime + 3600, big.NewInt(1 << 19)},   // one half-life behind / 落后一个半衰期
+		{200, anchor.Time + 200*TargetBlockTime - 7200, big.NewInt(1 << 22)}, // two half-lives ahead / 超前两个半衰期
+	}
+	for i, tt := range tests {
+		parent := &types.BlockHeader{Number: new(big.Int).SetUint64(tt.number), Difficulty: anchor.Difficulty, Time: tt.time}
+		if got := CalculateDifficulty(config, chain, tt.time+1, parent); got.Cmp(tt.want) != 0 {
+			t.Errorf("case %d: difficulty = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+// Vectors of an anchor that is not the genesis block, the schedule starts at the anchor's parent as in aserti3-2d
+// 非创世锚定区块的测试向量，与 aserti3-2d 相同，计划从锚定区块的父区块开始
+func TestASERTAnchorParent(t *testing.T) {
+	config := &params.ChainConfig{Difficulty: &params.DifficultyConfig{Algorithm: params.DifficultyASERT, ForkBlock: 3, ASERTHalfLife: 3600}}
+	chain := simChain{
+		{Number: big.NewInt(0), Difficulty: big.NewInt(1 << 20), Time: 1700000000},
+		{Number: big.NewInt(1), Difficulty: big.NewInt(1 << 20), Time: 1700000000 + TargetBlockTime},
+		{Number: big.NewInt(2), Difficulty: big.NewInt(1 << 20), Time: 1700000000 + 2*TargetBlockTime + 1800},
+	}
+	anchorParent := chain[1]
+
+	tests := []struct {
+		number uint64
+		time   uint64
+		want   *big.Int
+	}{
+		{2, anchorParent.Time + TargetBlockTime + 1800, big.NewInt(741518)},        // anchor half a half-life late / 锚定区块晚半个半衰期
+		{2, anchorParent.Time + TargetBlockTime, big.NewInt(1 << 20)},              // anchor on schedule / 锚定区块符合计划
+		{12, anchorParent.Time + 11*TargetBlockTime, big.NewInt(1 << 20)},          // on schedule / 符合计划
+		{12, anchorParent.Time + 11*TargetBlockTime + 1800, big.NewInt(741518)},    // half a half-life behind / 落后半个半衰期
+		{12, anchorParent.Time + 11*TargetBlockTime + 3600, big.NewInt(1 << 19)},   // one half-life behind / 落后一个半衰期
+		{212, anchorParent.Time + 211*TargetBlockTime - 7200, big.NewInt(1 << 22)}, // two half-lives ahead / 超前两个半衰期
+	}
+	for i, tt := range tests {
+		parent := &types.BlockHeader{Number: new(big.Int).SetUint64(tt.number), Difficulty: chain[2].Difficulty, Time: tt.time}
+		if got := CalculateDifficulty(config, chain, tt.time+1, parent); got.Cmp(tt.want) != 0 {
+			t.Errorf("case %d: difficulty = %v, want %v", i, got, tt.want)
+		}
+	}
+	if got := CalculateDifficulty(config, nil, chain[2].Time+1, chain[2]); got != nil {
+		t.Errorf("difficulty without the anchor parent = %v, want nil", got)
+	}
+}
@@ -0,0 +1,167 @@
+package nogopow
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+const (
+	// EpochLength Number of blocks sharing one seed, cache and dataset
+	// EpochLength 共用同一种子、缓存和数据集的区块数量
+	EpochLength = 30000
+	// CachesInMem Number of epoch caches kept in memory: previous, current and next epoch
+	// CachesInMem 内存中保留的纪元缓存数量：上一个、当前和下一个纪元
+	CachesInMem = 3
+	// DatasetsInMem Number of epoch datasets kept in memory: current and next epoch
+	// DatasetsInMem 内存中保留的纪元数据集数量：当前和下一个纪元
+	DatasetsInMem = 2
+)
+
+// Epoch-indexed caches and datasets, bounded by LRU eviction
+// 按纪元索引的缓存和数据集，按最近最少使用淘汰
+var (
+	caches   = newEpochLRU(CachesInMem)
+	datasets = newEpochLRU(DatasetsInMem)
+)
+
+// Epoch Return the epoch of a block number
+// Epoch 获取区块号所在的纪元
+func Epoch(number uint64) uint64 {
+	return number / EpochLength
+}
+
+// SeedHash Return the seed of the epoch containing the block number
+// The seed of epoch 0 is 32 zero bytes, each following epoch hashes the previous seed once
+// SeedHash 获取区块号所在纪元的种子
+// 纪元 0 的种子为 32 个零字节，之后每个纪元对上一个种子做一次哈希
+func SeedHash(number uint64) []byte {
+	seed := make([]byte, 32)
+	for i := uint64(0); i < Epoch(number); i++ {
+		h := sha256.Sum256(seed)
+		seed = h[:]
+	}
+	return seed
+}
+
+// GetCache Return the NogoPow instance of the block's epoch with only the cache generated
+// The cache of the next epoch is generated in the background
+// GetCache 获取区块所在纪元只生成缓存的NogoPow实例，下一个纪元的缓存在后台生成
+func GetCache(number uint64) *NogoPow {
+	epoch := Epoch(number)
+	pow := getCache(epoch)
+	if !caches.contains(epoch + 1) {
+		go getCache(epoch + 1)
+	}
+	return pow
+}
+
+// getCache Return the cache instance of an epoch, loading or generating it on first use
+// getCache 获取纪元的缓存实例，首次使用时加载或生成
+func getCache(epoch uint64) *NogoPow {
+	pow := caches.get(epoch)
+	pow.cacheOnce.Do(func() {
+		pow.cache = pow.loadOrGenerate(kindCache, epoch, CacheItems, CachesOnDisk, func() []uint64 {
+			pow.buildCache(SeedHash(epoch * EpochLength))
+			return pow.cache
+		})
+	})
+	return pow
+}
+
+// GetDataset Return the NogoPow instance of the block's epoch with the full dataset generated
+// The dataset of the next epoch is generated in the background, so mining continues without a stall at the epoch switch
+// GetDataset 获取区块所在纪元生成完整数据集的NogoPow实例
+// 下一个纪元的数据集在后台生成，纪元切换时挖矿不会停顿
+func GetDataset(number uint64) *NogoPow {
+	epoch := Epoch(number)
+	pow := getDataset(epoch)
+	if !datasets.contains(epoch + 1) {
+		go getDataset(epoch + 1)
+	}
+	return pow
+}
+
+// getDataset Return the dataset instance of an epoch, loading or generating it on first use
+// The dataset shares the cache of the same epoch
+// getDataset 获取纪元的数据集实例，首次使用时加载或生成，数据集与同一纪元的缓存共用内存
+func getDataset(epoch uint64) *NogoPow {
+	pow := datasets.get(epoch)
+	pow.cacheOnce.Do(func() {
+		pow.cacheOwner = getCache(epoch)
+		pow.cache = pow.cacheOwner.cache
+	})
+	pow.datasetOnce.Do(func() {
+		pow.dataset = pow.loadOrGenerate(kindDataset, epoch, NumItems, DatasetsOnDisk, func() []uint64 {
+			pow.buildDataset()
+			return pow.dataset
+		})
+	})
+	return pow
+}
+
+// epochLRU Bounded LRU of NogoPow instances indexed by epoch
+// epochLRU 按纪元索引的有界LRU NogoPow实例缓存
+type epochLRU struct {
+	capacity int
+	items    map[uint64]*list.Element
+	order    *list.List
+	mu       sync.Mutex
+}
+
+// epochEntry Entry of the epoch LRU
+// epochEntry 纪元LRU的条目
+type epochEntry struct {
+	epoch uint64
+	pow   *NogoPow
+}
+
+// newEpochLRU Create an epoch LRU holding at most capacity instances
+// newEpochLRU 创建最多保留 capacity 个实例的纪元LRU
+func newEpochLRU(capacity int) *epochLRU {
+	return &epochLRU{
+		capacity: capacity,
+		items:    make(map[uint64]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get Return the instance of an epoch, creating an empty one and evicting the least recently used if needed
+// Callers generate the instance through its sync.Once, so concurrent users of a new epoch wait for one generation
+// get 获取纪元的实例，不存在时创建空实例并在超出容量时淘汰最近最少使用的实例
+// 调用者通过实例的 sync.Once 生成数据，同一纪元的并发使用者只等待一次生成
+func (l *epochLRU) get(epoch uint64) *NogoPow {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, exists := l.items[epoch]; exists {
+		l.order.MoveToFront(elem)
+		return elem.Value.(*epochEntry).pow
+	}
+
+	pow := NewNogoPow()
+	l.items[epoch] = l.order.PushFront(&epochEntry{epoch: epoch, pow: pow})
+	for l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*epochEntry).epoch)
+	}
+	return pow
+}
+
+// contains Report whether an epoch is cached, without updating its recency
+// contains 判断纪元是否已缓存，不更新使用顺序
+func (l *epochLRU) contains(epoch uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, exists := l.items[epoch]
+	return exists
+}
+
+// len Return the number of cached epochs
+// len 获取已缓存的纪元数量
+func (l *epochLRU) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
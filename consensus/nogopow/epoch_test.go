@@ -0,0 +1,67 @@
+package nogopow
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+func TestSeedHash(t *testing.T) {
+	zero := make([]byte, 32)
+	if !bytes.Equal(SeedHash(0), zero) || !bytes.Equal(SeedHash(EpochLength-1), zero) {
+		t.Errorf("seed of epoch 0 should be zero")
+	}
+
+	next := sha256.Sum256(zero)
+	if !bytes.Equal(SeedHash(EpochLength), next[:]) {
+		t.Errorf("seed of epoch 1 mismatch: %x", SeedHash(EpochLength))
+	}
+	if bytes.Equal(SeedHash(2*EpochLength), SeedHash(EpochLength)) {
+		t.Errorf("different epochs should have different seeds")
+	}
+}
+
+func TestEpochLRU(t *testing.T) {
+	lru := newEpochLRU(2)
+	first := lru.get(0)
+	lru.get(1)
+	if lru.get(0) != first {
+		t.Errorf("cached epoch should return the same instance")
+	}
+
+	// 纪元 1 最近最少使用，被淘汰
+	lru.get(2)
+	if lru.len() != 2 || lru.contains(1) || !lru.contains(0) || !lru.contains(2) {
+		t.Errorf("unexpected eviction: len %d, contains 0/1/2 = %v/%v/%v", lru.len(), lru.contains(0), lru.contains(1), lru.contains(2))
+	}
+}
+
+func TestGetCache(t *testing.T) {
+	number := uint64(5*EpochLength + 1)
+	pow := GetCache(number)
+	if len(pow.cache) != CacheItems {
+		t.Fatalf("Cache size mismatch: expected %d, got %d", CacheItems, len(pow.cache))
+	}
+	if GetCache(6*EpochLength-1) != pow {
+		t.Errorf("blocks of the same epoch should share the cache")
+	}
+
+	expected := NewNogoPow()
+	expected.buildCache(SeedHash(number))
+	if pow.cache[0] != expected.cache[0] || pow.cache[CacheItems-1] != expected.cache[CacheItems-1] {
+		t.Errorf("cache not generated from the epoch seed")
+	}
+
+	// 下一个纪元的缓存在后台生成
+	deadline := time.Now().Add(5 * time.Second)
+	for !caches.contains(6) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !caches.contains(6) {
+		t.Errorf("next epoch cache was not pregenerated")
+	}
+	if caches.len() > CachesInMem {
+		t.Errorf("cache LRU exceeds its capacity: %d", caches.len())
+	}
+}
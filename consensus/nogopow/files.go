@@ -0,0 +1,263 @@
+package nogopow
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// FileVersion Version of the cache and dataset file format, files of other versions are regenerated
+	// FileVersion 缓存和数据集文件格式版本，其他版本的文件会重新生成
+	FileVersion = 1
+	// CachesOnDisk Number of recent epoch caches kept on disk
+	// CachesOnDisk 磁盘上保留的最近纪元缓存数量
+	CachesOnDisk = 3
+	// DatasetsOnDisk Number of recent epoch datasets kept on disk
+	// DatasetsOnDisk 磁盘上保留的最近纪元数据集数量
+	DatasetsOnDisk = 2
+
+	// fileMagic Magic bytes at the start of every file
+	// fileMagic 文件开头的魔数
+	fileMagic = "NOGOPOW\x00"
+	// fileHeaderSize Size of the file header, the items follow it little-endian
+	// fileHeaderSize 文件头大小，之后为小端序的数据项
+	fileHeaderSize = 64
+
+	kindCache   = "cache"
+	kindDataset = "dataset"
+)
+
+// ErrBadFile Returned when a cache or dataset file is truncated, corrupted or of another version
+// ErrBadFile 缓存或数据集文件被截断、损坏或版本不同
+var ErrBadFile = errors.New("nogopow: invalid cache or dataset file")
+
+var (
+	fileDir   string
+	fileDirMu sync.RWMutex
+	crcTable  = crc64.MakeTable(crc64.ECMA)
+)
+
+// SetDatasetDir Set the directory where caches and datasets are persisted, an empty directory disables persistence
+// Processes using the same directory share the generated files
+// SetDatasetDir 设置缓存和数据集的持久化目录，空字符串表示不持久化
+// 使用同一目录的进程共享已生成的文件
+func SetDatasetDir(dir string) {
+	fileDirMu.Lock()
+	defer fileDirMu.Unlock()
+	fileDir = dir
+}
+
+// DatasetDir Return the directory where caches and datasets are persisted
+// DatasetDir 获取缓存和数据集的持久化目录
+func DatasetDir() string {
+	fileDirMu.RLock()
+	defer fileDirMu.RUnlock()
+	return fileDir
+}
+
+// DefaultDatasetDir Return the directory shared by all NogoChain processes of the user:
+// $NOGOPOW_DIR if set, otherwise nogopow under the user cache directory
+// DefaultDatasetDir 获取同一用户所有NogoChain进程共享的目录：
+// 优先使用 $NOGOPOW_DIR，否则为用户缓存目录下的 nogopow
+func DefaultDatasetDir() string {
+	if dir := os.Getenv("NOGOPOW_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "nogopow")
+	}
+	return filepath.Join(os.TempDir(), "nogopow")
+}
+
+// fileName Return the file name of an epoch cache or dataset
+// fileName 获取纪元缓存或数据集的文件名
+func fileName(kind string, epoch uint64) string {
+	return fmt.Sprintf("%s-v%d-%d-%x", kind, FileVersion, epoch, SeedHash(epoch * EpochLength)[:8])
+}
+
+// loadFile Map a cache or dataset file into memory and verify its header and checksum
+// loadFile 将缓存或数据集文件映射到内存，并校验文件头和校验和
+func loadFile(path, kind string, epoch uint64, items int) ([]uint64, []byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() != int64(fileHeaderSize+items*8) {
+		return nil, nil, ErrBadFile
+	}
+
+	mapped, err := mapFile(file, int(info.Size()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := mapped[:fileHeaderSize]
+	payload := mapped[fileHeaderSize:]
+	if string(header[:8]) != fileMagic ||
+		binary.LittleEndian.Uint32(header[8:]) != FileVersion ||
+		string(header[16:16+len(kind)]) != kind ||
+		binary.LittleEndian.Uint64(header[24:]) != epoch ||
+		binary.LittleEndian.Uint64(header[32:]) != uint64(items) ||
+		binary.LittleEndian.Uint64(header[40:]) != crc64.Checksum(payload, crcTable) {
+		unmapFile(mapped)
+		return nil, nil, ErrBadFile
+	}
+
+	// The items are used in place on little-endian hosts, otherwise decoded into memory
+	// 小端序主机直接使用映射的数据项，否则解码到内存
+	if !littleEndian() {
+		data := make([]uint64, items)
+		for i := range data {
+			data[i] = binary.LittleEndian.Uint64(payload[i*8:])
+		}
+		unmapFile(mapped)
+		return data, nil, nil
+	}
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&payload[0])), items), mapped, nil
+}
+
+// writeFile Write a cache or dataset file atomically: the file is written under a temporary name and renamed into place,
+// so other processes never map a partially written file
+// writeFile 原子地写入缓存或数据集文件：先写入临时文件再重命名，其他进程不会映射到未写完的文件
+func writeFile(path, kind string, epoch uint64, data []uint64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Items first, the header with the checksum is written last
+	// 先写入数据项，最后写入包含校验和的文件头
+	if _, err := tmp.Seek(fileHeaderSize, 0); err != nil {
+		tmp.Close()
+		return err
+	}
+	crc := crc64.New(crcTable)
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	item := make([]byte, 8)
+	for _, v := range data {
+		binary.LittleEndian.PutUint64(item, v)
+		w.Write(item)
+		crc.Write(item)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	header := make([]byte, fileHeaderSize)
+	copy(header, fileMagic)
+	binary.LittleEndian.PutUint32(header[8:], FileVersion)
+	copy(header[16:24], kind)
+	binary.LittleEndian.PutUint64(header[24:], epoch)
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(data)))
+	binary.LittleEndian.PutUint64(header[40:], crc.Sum64())
+	if _, err := tmp.WriteAt(header, 0); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// removeExpired Remove files of a kind whose epoch is keep or more epochs behind epoch, and files of other format versions
+// Processes that still map a removed file keep using it until they unmap it
+// removeExpired 删除落后 epoch 达到 keep 个纪元的文件和其他格式版本的文件
+// 仍在映射已删除文件的进程可以继续使用，直到解除映射
+func removeExpired(dir, kind string, epoch uint64, keep uint64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		parts := strings.SplitN(entry.Name(), "-", 4)
+		if len(parts) != 4 || parts[0] != kind {
+			continue
+		}
+		fileEpoch, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if parts[1] != fmt.Sprintf("v%d", FileVersion) || fileEpoch+keep <= epoch {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// loadOrGenerate Load the epoch cache or dataset from the persistence directory, or generate it and write it there
+// loadOrGenerate 从持久化目录加载纪元缓存或数据集，不存在时生成并写入
+func (n *NogoPow) loadOrGenerate(kind string, epoch uint64, items int, keep uint64, generate func() []uint64) []uint64 {
+	dir := DatasetDir()
+	if dir == "" {
+		return generate()
+	}
+	path := filepath.Join(dir, fileName(kind, epoch))
+
+	data, mapped, err := loadFile(path, kind, epoch, items)
+	if err == nil {
+		if mapped != nil {
+			n.addMapping(mapped)
+		}
+		return data
+	}
+	if !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("path", path).Msg("Regenerating NogoPow file")
+	}
+
+	data = generate()
+	if err := writeFile(path, kind, epoch, data); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("Failed to persist NogoPow file")
+	}
+	removeExpired(dir, kind, epoch, keep)
+	return data
+}
+
+// addMapping Keep a file mapping alive as long as the instance, it is unmapped when the instance is garbage collected
+// addMapping 文件映射与实例的生命周期相同，实例被回收时解除映射
+func (n *NogoPow) addMapping(mapped []byte) {
+	n.mappingMu.Lock()
+	defer n.mappingMu.Unlock()
+
+	if len(n.mappings) == 0 {
+		runtime.SetFinalizer(n, func(n *NogoPow) {
+			for _, mapped := range n.mappings {
+				unmapFile(mapped)
+			}
+		})
+	}
+	n.mappings = append(n.mappings, mapped)
+}
+
+// littleEndian Report whether the host is little-endian
+// littleEndian 判断主机是否为小端序
+func littleEndian() bool {
+	v := uint16(1)
+	return *(*byte)(unsafe.Pointer(&v)) == 1
+}
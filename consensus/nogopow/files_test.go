@@ -0,0 +1,118 @@
+package nogopow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDatasetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), fileName(kindCache, 3))
+	data := make([]uint64, 1000)
+	for i := range data {
+		data[i] = uint64(i) * 0x9e3779b97f4a7c15
+	}
+	if err := writeFile(path, kindCache, 3, data); err != nil {
+		t.Fatalf("writeFile failed: %v", err)
+	}
+
+	loaded, mapped, err := loadFile(path, kindCache, 3, len(data))
+	if err != nil {
+		t.Fatalf("loadFile failed: %v", err)
+	}
+	for i := range data {
+		if loaded[i] != data[i] {
+			t.Fatalf("item %d mismatch: expected %d, got %d", i, data[i], loaded[i])
+		}
+	}
+	if mapped != nil {
+		unmapFile(mapped)
+	}
+
+	// 其他纪元、类型或数据项数量的文件被拒绝
+	if _, _, err := loadFile(path, kindCache, 4, len(data)); err != ErrBadFile {
+		t.Errorf("expected ErrBadFile for wrong epoch, got %v", err)
+	}
+	if _, _, err := loadFile(path, kindDataset, 3, len(data)); err != ErrBadFile {
+		t.Errorf("expected ErrBadFile for wrong kind, got %v", err)
+	}
+	if _, _, err := loadFile(path, kindCache, 3, len(data)+1); err != ErrBadFile {
+		t.Errorf("expected ErrBadFile for wrong size, got %v", err)
+	}
+
+	// 损坏的数据项无法通过校验和
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[fileHeaderSize+100] ^= 0xff
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadFile(path, kindCache, 3, len(data)); err != ErrBadFile {
+		t.Errorf("expected ErrBadFile for corrupted file, got %v", err)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		fileName(kindCache, 1),
+		fileName(kindCache, 2),
+		fileName(kindCache, 3),
+		fileName(kindCache, 4),
+		fileName(kindDataset, 1),
+		"cache-v0-4-0000000000000000",
+		"unrelated",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removeExpired(dir, kindCache, 4, 2)
+
+	expected := map[string]bool{
+		fileName(kindCache, 3):   true,
+		fileName(kindCache, 4):   true,
+		fileName(kindDataset, 1): true,
+		"unrelated":              true,
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(expected) {
+		t.Errorf("expected %d files, got %d", len(expected), len(entries))
+	}
+	for _, entry := range entries {
+		if !expected[entry.Name()] {
+			t.Errorf("file %s should have been removed", entry.Name())
+		}
+	}
+}
+
+func TestPersistedCache(t *testing.T) {
+	dir := t.TempDir()
+	SetDatasetDir(dir)
+	defer SetDatasetDir("")
+
+	const epoch = 40
+	generated := getCache(epoch)
+	if _, err := os.Stat(filepath.Join(dir, fileName(kindCache, epoch))); err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+
+	// 新进程从文件映射缓存
+	loaded := NewNogoPow()
+	loaded.cache = loaded.loadOrGenerate(kindCache, epoch, CacheItems, CachesOnDisk, func() []uint64 {
+		t.Fatalf("cache regenerated instead of loaded")
+		return nil
+	})
+	if littleEndian() && len(loaded.mappings) != 1 {
+		t.Errorf("cache file was not memory mapped")
+	}
+	for _, i := range []int{0, 1, CacheItems / 2, CacheItems - 1} {
+		if loaded.cache[i] != generated.cache[i] {
+			t.Fatalf("cache item %d mismatch", i)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !unix
+
+package nogopow
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile Read the file into memory on platforms without mmap support
+// mapFile 不支持 mmap 的平台将文件读入内存
+func mapFile(file *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile Nothing to release for files read into memory
+// unmapFile 读入内存的文件无需释放
+func unmapFile(mapped []byte) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package nogopow
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile Map a file read-only into memory
+// mapFile 以只读方式将文件映射到内存
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapFile Unmap a file mapping
+// unmapFile 解除文件映射
+func unmapFile(mapped []byte) error {
+	return syscall.Munmap(mapped)
+}
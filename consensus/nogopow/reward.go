@@ -3,6 +3,8 @@ package nogopow
 import (
 	"math/big"
 
+	"nogochain/core/state"
+	"nogochain/core/types"
 	"nogochain/params"
 )
 
@@ -62,3 +64,36 @@ func calculateMinRewardWei() *big.Int {
 func GetRewardForBlock(blockNumber uint64) *big.Int {
 	return CalculateReward(blockNumber)
 }
+
+// CalculateUncleReward 计算叔块奖励
+// number: 包含叔块的区块高度
+// uncleNumber: 叔块高度
+// 返回: 叔块矿工获得区块奖励的 (uncleNumber+8-number)/8（单位：wei）
+func CalculateUncleReward(number, uncleNumber uint64) *big.Int {
+	if uncleNumber+params.UncleRewardDivisor <= number {
+		return new(big.Int)
+	}
+	reward := CalculateReward(number)
+	reward.Mul(reward, new(big.Int).SetUint64(uncleNumber+params.UncleRewardDivisor-number))
+	return reward.Div(reward, new(big.Int).SetUint64(params.UncleRewardDivisor))
+}
+
+// CalculateNephewReward 计算侄块奖励
+// number: 包含叔块的区块高度
+// 返回: 每包含一个叔块，区块矿工额外获得区块奖励的1/32（单位：wei）
+func CalculateNephewReward(number uint64) *big.Int {
+	reward := CalculateReward(number)
+	return reward.Div(reward, new(big.Int).SetUint64(params.NephewRewardDivisor))
+}
+
+// AccumulateRewards 将区块奖励计入状态
+// 区块矿工获得区块奖励和每个叔块的侄块奖励，叔块矿工获得叔块奖励
+func AccumulateRewards(stateDB state.StateDB, header *types.BlockHeader, uncles []*types.BlockHeader) {
+	number := header.Number.Uint64()
+	reward := CalculateReward(number)
+	for _, uncle := range uncles {
+		stateDB.AddBalance(uncle.Coinbase, CalculateUncleReward(number, uncle.Number.Uint64()))
+		reward.Add(reward, CalculateNephewReward(number))
+	}
+	stateDB.AddBalance(header.Coinbase, reward)
+}
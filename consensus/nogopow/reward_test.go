@@ -3,6 +3,11 @@ package nogopow
 import (
 	"math/big"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/types"
 )
 
 func TestCalculateReward(t *testing.T) {
@@ -52,3 +57,51 @@ func TestGetRewardForBlock(t *testing.T) {
 		t.Errorf("GetRewardForBlock 与 CalculateReward 结果不一致")
 	}
 }
+
+func TestCalculateUncleReward(t *testing.T) {
+	reward := CalculateReward(100)
+	testCases := []struct {
+		uncleNumber uint64
+		eighths     int64
+	}{
+		{99, 7},
+		{95, 3},
+		{94, 2},
+		{92, 0},
+		{80, 0},
+	}
+	for _, tc := range testCases {
+		want := new(big.Int).Mul(reward, big.NewInt(tc.eighths))
+		want.Div(want, big.NewInt(8))
+		if got := CalculateUncleReward(100, tc.uncleNumber); got.Cmp(want) != 0 {
+			t.Errorf("叔块高度 %d: 期望奖励 %s, 实际奖励 %s", tc.uncleNumber, want, got)
+		}
+	}
+
+	nephew := new(big.Int).Div(reward, big.NewInt(32))
+	if got := CalculateNephewReward(100); got.Cmp(nephew) != 0 {
+		t.Errorf("期望侄块奖励 %s, 实际奖励 %s", nephew, got)
+	}
+}
+
+func TestAccumulateRewards(t *testing.T) {
+	stateDB := state.NewMemoryStateDB()
+	header := &types.BlockHeader{Coinbase: common.Address{0x01}, Number: big.NewInt(100)}
+	uncles := []*types.BlockHeader{
+		{Coinbase: common.Address{0x02}, Number: big.NewInt(99)},
+		{Coinbase: common.Address{0x03}, Number: big.NewInt(96)},
+	}
+	AccumulateRewards(stateDB, header, uncles)
+
+	reward := CalculateReward(100)
+	miner := new(big.Int).Add(reward, new(big.Int).Mul(CalculateNephewReward(100), big.NewInt(2)))
+	if got := stateDB.GetBalance(header.Coinbase); got.Cmp(miner) != 0 {
+		t.Errorf("区块矿工: 期望余额 %s, 实际余额 %s", miner, got)
+	}
+	for _, uncle := range uncles {
+		want := CalculateUncleReward(100, uncle.Number.Uint64())
+		if got := stateDB.GetBalance(uncle.Coinbase); got.Cmp(want) != 0 {
+			t.Errorf("叔块 %d 矿工: 期望余额 %s, 实际余额 %s", uncle.Number, want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package nogopow
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+)
+
+// EncodeSealHeader Return the canonical serialization of the header fields covered by the proof-of-work
+// Fields are encoded in declaration order without Nonce and MixDigest: hashes and the address as raw bytes,
+// Bloom and Extra with a 4 byte little-endian length, Difficulty and Number as 32 byte big-endian
+// and GasLimit, GasUsed and Time as 8 byte little-endian, so miners in any language get the same bytes
+// EncodeSealHeader 获取工作量证明覆盖的区块头字段的规范序列化
+// 按声明顺序编码除 Nonce 和 MixDigest 外的字段：哈希和地址为原始字节，
+// Bloom 和 Extra 带4字节小端长度前缀，Difficulty 和 Number 为32字节大端，
+// GasLimit、GasUsed 和 Time 为8字节小端，使任何语言实现的矿工得到相同的字节
+func EncodeSealHeader(header *types.BlockHeader) []byte {
+	data := make([]byte, 0, 6*common.HashLength+len(header.Bloom)+len(header.Extra)+96)
+	data = append(data, header.ParentHash.Bytes()...)
+	data = append(data, header.UncleHash.Bytes()...)
+	data = append(data, header.Coinbase.Bytes()...)
+	data = append(data, header.Root.Bytes()...)
+	data = append(data, header.TxHash.Bytes()...)
+	data = append(data, header.ReceiptHash.Bytes()...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(header.Bloom)))
+	data = append(data, header.Bloom...)
+	data = appendBigInt(data, header.Difficulty)
+	data = appendBigInt(data, header.Number)
+	data = binary.LittleEndian.AppendUint64(data, header.GasLimit)
+	data = binary.LittleEndian.AppendUint64(data, header.GasUsed)
+	data = binary.LittleEndian.AppendUint64(data, header.Time)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(header.Extra)))
+	data = append(data, header.Extra...)
+	return data
+}
+
+// appendBigInt Append a value as 32 byte big-endian, nil as zero
+// appendBigInt 以32字节大端追加数值，nil 视为零
+func appendBigInt(data []byte, value *big.Int) []byte {
+	var word [32]byte
+	if value != nil {
+		value.FillBytes(word[:])
+	}
+	return append(data, word[:]...)
+}
+
+// SealHash Return the hash mined by the proof-of-work: the SHA-256 of EncodeSealHeader
+// Hashimoto only mixes the first 64 bytes of its input, a 32 byte hash keeps the nonce inside them
+// SealHash 获取工作量证明挖掘的哈希：EncodeSealHeader 的 SHA-256
+// Hashimoto 只混合输入的前64字节，32字节的哈希使nonce位于其中
+func SealHash(header *types.BlockHeader) common.Hash {
+	return sha256.Sum256(EncodeSealHeader(header))
+}
+
+// ComputeWork Return the proof-of-work hash and mix digest of the header with its nonce, using the epoch cache only
+// ComputeWork 只使用纪元缓存计算区块头及其nonce的工作量证明哈希和混合摘要
+func ComputeWork(header *types.BlockHeader) (hash, mixDigest common.Hash) {
+	h, mix := GetCache(header.Number.Uint64()).HashimotoLight(SealHash(header).Bytes(), header.Nonce)
+	return common.BytesToHash(h), common.BytesToHash(mix)
+}
+
+// CheckWork Cheap pre-check of a seal: recompute the final hash from the seal hash, nonce and claimed MixDigest
+// and compare it with the target of difficulty, without touching the epoch cache
+// A header passing CheckWork still needs VerifyWork to prove the MixDigest
+// CheckWork 封装的快速预检查：由封装哈希、nonce 和声明的 MixDigest 重新计算最终哈希并与难度目标比较，
+// 不需要纪元缓存
+// 通过 CheckWork 的区块头仍需 VerifyWork 证明 MixDigest
+func CheckWork(header *types.BlockHeader, difficulty *big.Int) error {
+	if difficulty == nil || difficulty.Sign() <= 0 {
+		return errInvalidDifficulty
+	}
+	hash := powHash(SealHash(header).Bytes(), header.Nonce, header.MixDigest.Bytes())
+	if new(big.Int).SetBytes(hash).Cmp(ToTarget(difficulty)) > 0 {
+		return errInvalidPoW
+	}
+	return nil
+}
+
+// VerifyWork Verify the seal of a header against difficulty: CheckWork first, then the MixDigest against Hashimoto
+// VerifyWork 按难度验证区块头的封装：先执行 CheckWork，再用 Hashimoto 验证 MixDigest
+func VerifyWork(header *types.BlockHeader, difficulty *big.Int) error {
+	if err := CheckWork(header, difficulty); err != nil {
+		return err
+	}
+	if _, mixDigest := ComputeWork(header); mixDigest != header.MixDigest {
+		return errInvalidMixDigest
+	}
+	return nil
+}
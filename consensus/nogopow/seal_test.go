@@ -0,0 +1,99 @@
+package nogopow
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+)
+
+func newSealTestHeader() *types.BlockHeader {
+	return &types.BlockHeader{
+		ParentHash: common.Hash{0x01},
+		Coinbase:   common.Address{0x02},
+		Bloom:      make([]byte, 256),
+		Difficulty: big.NewInt(16),
+		Number:     big.NewInt(1),
+		GasLimit:   10000000,
+		Time:       1700000000,
+		Extra:      []byte("extra"),
+	}
+}
+
+func TestSealHash(t *testing.T) {
+	header := newSealTestHeader()
+	want := SealHash(header)
+
+	// Nonce 和 MixDigest 不影响封装哈希
+	sealed := *header
+	sealed.Nonce = 12345
+	sealed.MixDigest = common.Hash{0x03}
+	if got := SealHash(&sealed); got != want {
+		t.Errorf("seal hash depends on nonce or mix digest")
+	}
+
+	// 其他字段都被封装哈希覆盖
+	modify := []func(h *types.BlockHeader){
+		func(h *types.BlockHeader) { h.UncleHash = common.Hash{0x04} },
+		func(h *types.BlockHeader) { h.Root = common.Hash{0x04} },
+		func(h *types.BlockHeader) { h.Difficulty = big.NewInt(17) },
+		func(h *types.BlockHeader) { h.Time++ },
+		func(h *types.BlockHeader) { h.Extra = []byte("other") },
+		// 长度前缀使 Bloom 和 Extra 之间移动字节也改变哈希
+		func(h *types.BlockHeader) { h.Bloom = append(h.Bloom, 'e'); h.Extra = []byte("xtra") },
+	}
+	for i, fn := range modify {
+		modified := *header
+		fn(&modified)
+		if SealHash(&modified) == want {
+			t.Errorf("modification %d does not change the seal hash", i)
+		}
+	}
+
+	// 编码长度固定部分加上 Bloom 和 Extra 的长度
+	if got, want := len(EncodeSealHeader(header)), 6*32-12+4+256+64+24+4+5; got != want {
+		t.Errorf("encoded length = %d, want %d", got, want)
+	}
+	if !bytes.Equal(EncodeSealHeader(header), EncodeSealHeader(&sealed)) {
+		t.Errorf("encoding depends on nonce or mix digest")
+	}
+}
+
+func TestVerifyWork(t *testing.T) {
+	header := newSealTestHeader()
+	target := ToTarget(header.Difficulty)
+	for ; ; header.Nonce++ {
+		hash, mixDigest := ComputeWork(header)
+		if new(big.Int).SetBytes(hash.Bytes()).Cmp(target) <= 0 {
+			header.MixDigest = mixDigest
+			break
+		}
+	}
+	if err := CheckWork(header, header.Difficulty); err != nil {
+		t.Errorf("CheckWork: %v", err)
+	}
+	if err := VerifyWork(header, header.Difficulty); err != nil {
+		t.Errorf("VerifyWork: %v", err)
+	}
+
+	// 难度为1时任意混合摘要都满足目标，只有完整验证能发现错误的混合摘要
+	forged := *header
+	forged.MixDigest = common.Hash{0x05}
+	if err := CheckWork(&forged, big.NewInt(1)); err != nil {
+		t.Errorf("CheckWork with difficulty 1: %v", err)
+	}
+	if err := VerifyWork(&forged, big.NewInt(1)); err != errInvalidMixDigest {
+		t.Errorf("VerifyWork: got %v, want %v", err, errInvalidMixDigest)
+	}
+
+	// 最大难度的目标无法满足，快速检查即可拒绝
+	if err := CheckWork(header, new(big.Int).Lsh(big.NewInt(1), 255)); err != errInvalidPoW {
+		t.Errorf("CheckWork: got %v, want %v", err, errInvalidPoW)
+	}
+	if err := CheckWork(header, big.NewInt(0)); err != errInvalidDifficulty {
+		t.Errorf("CheckWork: got %v, want %v", err, errInvalidDifficulty)
+	}
+}
@@ -0,0 +1,350 @@
+package nogopow
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// TestVectors Consensus test vectors produced by the reference implementation
+// Third-party miners, GPU kernels and nodes check their results against them to prove compatibility
+// TestVectors 由参考实现生成的共识测试向量
+// 第三方矿工、GPU内核和节点用其校验计算结果以证明兼容性
+type TestVectors struct {
+	Seal       []SealVector       `json:"seal"`
+	Difficulty []DifficultyVector `json:"difficulty"`
+	Reward     []RewardVector     `json:"reward"`
+}
+
+// SealVector Proof-of-work of one header and nonce
+// Header has no Nonce and MixDigest, Encoded is EncodeSealHeader(Header) and SealHash its SHA-256,
+// Hash and MixDigest are the Hashimoto outputs for Nonce, Valid tells whether Hash meets the header difficulty
+// SealVector 一个区块头和nonce的工作量证明
+// Header 不含 Nonce 和 MixDigest，Encoded 为 EncodeSealHeader(Header)，SealHash 为其 SHA-256，
+// Hash 和 MixDigest 为 Nonce 的 Hashimoto 输出，Valid 表示 Hash 是否满足区块头难度
+type SealVector struct {
+	Name      string             `json:"name"`
+	Header    *types.BlockHeader `json:"header"`
+	Encoded   hexutil.Bytes      `json:"encoded"`
+	SealHash  common.Hash        `json:"sealHash"`
+	Seed      common.Hash        `json:"seed"`
+	Nonce     hexutil.Uint64     `json:"nonce"`
+	Hash      common.Hash        `json:"hash"`
+	MixDigest common.Hash        `json:"mixDigest"`
+	Valid     bool               `json:"valid"`
+}
+
+// DifficultyVector Chain of headers where every difficulty follows from its ancestors under Config
+// DifficultyVector 在 Config 下每个区块难度都由其祖先区块计算得到的区块头链
+type DifficultyVector struct {
+	Name    string              `json:"name"`
+	Config  *params.ChainConfig `json:"config"`
+	Headers []DifficultyHeader  `json:"headers"`
+}
+
+// DifficultyHeader Header fields used by the difficulty algorithms, Headers[i] has number i
+// DifficultyHeader 难度算法使用的区块头字段，Headers[i] 的区块号为 i
+type DifficultyHeader struct {
+	Time       hexutil.Uint64 `json:"time"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+}
+
+// RewardVector Block and nephew rewards at a height and the reward of each possible uncle height
+// RewardVector 某高度的区块奖励、侄块奖励和每个可能叔块高度的叔块奖励
+type RewardVector struct {
+	Number       hexutil.Uint64      `json:"number"`
+	Reward       *hexutil.Big        `json:"reward"`
+	NephewReward *hexutil.Big        `json:"nephewReward"`
+	UncleRewards []UncleRewardVector `json:"uncleRewards"`
+}
+
+// UncleRewardVector Reward of an uncle at UncleNumber
+// UncleRewardVector 高度为 UncleNumber 的叔块奖励
+type UncleRewardVector struct {
+	UncleNumber hexutil.Uint64 `json:"uncleNumber"`
+	Reward      *hexutil.Big   `json:"reward"`
+}
+
+// vectorChain Difficulty vector headers indexed by number, implements consensus.ChainHeaderReader
+// vectorChain 按区块号索引的难度向量区块头，实现 consensus.ChainHeaderReader
+type vectorChain []*types.BlockHeader
+
+func (c vectorChain) CurrentHeader() *types.BlockHeader { return c[len(c)-1] }
+
+func (c vectorChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c vectorChain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+
+func (c vectorChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader {
+	for _, header := range c {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+// appendHeader Append a header linked to the last one
+// appendHeader 追加与最后一个区块头相连的区块头
+func (c vectorChain) appendHeader(timestamp uint64, difficulty *big.Int) vectorChain {
+	header := &types.BlockHeader{
+		Number:     big.NewInt(int64(len(c))),
+		Time:       timestamp,
+		Difficulty: difficulty,
+	}
+	if len(c) > 0 {
+		header.ParentHash = c[len(c)-1].Hash()
+	}
+	return append(c, header)
+}
+
+// vectorBlockTimes Intervals between the difficulty vector blocks: on target, fast, slow, equal and capped timestamps
+// vectorBlockTimes 难度向量区块的时间间隔：包括目标时间、过快、过慢、相同和超过上限的时间戳
+var vectorBlockTimes = []uint64{20, 20, 5, 40, 20, 1, 0, 120, 20, 3, 20, 60, 300, 10, 20, 2, 20, 45, 20, 20}
+
+// vectorSealNonce Nonce of the seal vectors that are not mined
+// vectorSealNonce 非挖矿得到的封装向量使用的nonce
+const vectorSealNonce = 0x0123456789abcdef
+
+// GenerateTestVectors Produce the consensus test vectors with the reference implementation
+// GenerateTestVectors 使用参考实现生成共识测试向量
+func GenerateTestVectors() (*TestVectors, error) {
+	vectors := new(TestVectors)
+
+	// Seal vectors in two epochs, each with a mined nonce and an arbitrary one
+	// 两个纪元的封装向量，各包含一个挖到的nonce和一个任意nonce
+	headers := []struct {
+		name   string
+		header *types.BlockHeader
+	}{
+		{"epoch0", &types.BlockHeader{
+			ParentHash: common.HexToHash("0x01"),
+			Coinbase:   common.HexToAddress("0x02"),
+			Bloom:      make([]byte, 256),
+			Difficulty: big.NewInt(16),
+			Number:     big.NewInt(1),
+			GasLimit:   params.GenesisGasLimit,
+			Time:       1700000000,
+		}},
+		{"epoch1-extra", &types.BlockHeader{
+			ParentHash:  common.HexToHash("0x03"),
+			UncleHash:   common.HexToHash("0x04"),
+			Coinbase:    common.HexToAddress("0x05"),
+			Root:        common.HexToHash("0x06"),
+			TxHash:      common.HexToHash("0x07"),
+			ReceiptHash: common.HexToHash("0x08"),
+			Bloom:       []byte{0x09},
+			Difficulty:  big.NewInt(64),
+			Number:      big.NewInt(EpochLength + 1),
+			GasLimit:    params.GenesisGasLimit,
+			GasUsed:     21000,
+			Time:        1700600000,
+			Extra:       []byte("nogochain"),
+		}},
+	}
+	for _, h := range headers {
+		nonce, err := mineVectorNonce(h.header)
+		if err != nil {
+			return nil, fmt.Errorf("seal vector %s: %v", h.name, err)
+		}
+		vectors.Seal = append(vectors.Seal, newSealVector(h.name+"-mined", h.header, nonce))
+		vectors.Seal = append(vectors.Seal, newSealVector(h.name, h.header, vectorSealNonce))
+	}
+
+	// Difficulty transitions of every algorithm, the new algorithms activate at block 8
+	// 每种算法的难度变化，新算法在区块8激活
+	configs := []struct {
+		name   string
+		config *params.ChainConfig
+	}{
+		{"legacy", params.DefaultChainConfig},
+		{"lwma-fork", &params.ChainConfig{ChainID: params.ChainID, Difficulty: &params.DifficultyConfig{
+			Algorithm: params.DifficultyLWMA, ForkBlock: 8, LWMAWindow: 5,
+		}}},
+		{"asert-fork", &params.ChainConfig{ChainID: params.ChainID, Difficulty: &params.DifficultyConfig{
+			Algorithm: params.DifficultyASERT, ForkBlock: 8, ASERTHalfLife: 120,
+		}}},
+	}
+	for _, c := range configs {
+		chain := vectorChain{}.appendHeader(1700000000, big.NewInt(InitialDifficulty))
+		for _, interval := range vectorBlockTimes {
+			parent := chain.CurrentHeader()
+			timestamp := parent.Time + interval
+			difficulty := CalculateDifficulty(c.config, chain, timestamp, parent)
+			if difficulty == nil {
+				return nil, fmt.Errorf("difficulty vector %s: missing ancestors of block %d", c.name, len(chain))
+			}
+			chain = chain.appendHeader(timestamp, difficulty)
+		}
+		vector := DifficultyVector{Name: c.name, Config: c.config}
+		for _, header := range chain {
+			vector.Headers = append(vector.Headers, DifficultyHeader{
+				Time:       hexutil.Uint64(header.Time),
+				Difficulty: (*hexutil.Big)(header.Difficulty),
+			})
+		}
+		vectors.Difficulty = append(vectors.Difficulty, vector)
+	}
+
+	// Rewards around the reductions and at the minimum reward
+	// 减产前后和最低奖励时的奖励
+	for _, number := range []uint64{1, 7, params.HalvingInterval - 1, params.HalvingInterval, 2 * params.HalvingInterval, 100 * params.HalvingInterval} {
+		vector := RewardVector{
+			Number:       hexutil.Uint64(number),
+			Reward:       (*hexutil.Big)(CalculateReward(number)),
+			NephewReward: (*hexutil.Big)(CalculateNephewReward(number)),
+		}
+		for depth := uint64(1); depth < params.MaxUncleDepth && depth <= number; depth++ {
+			vector.UncleRewards = append(vector.UncleRewards, UncleRewardVector{
+				UncleNumber: hexutil.Uint64(number - depth),
+				Reward:      (*hexutil.Big)(CalculateUncleReward(number, number-depth)),
+			})
+		}
+		vectors.Reward = append(vectors.Reward, vector)
+	}
+	return vectors, nil
+}
+
+// mineVectorNonce Search the first nonce meeting the header difficulty
+// mineVectorNonce 搜索第一个满足区块头难度的nonce
+func mineVectorNonce(header *types.BlockHeader) (uint64, error) {
+	sealed := *header
+	target := ToTarget(header.Difficulty)
+	for nonce := uint64(0); nonce < 1<<20; nonce++ {
+		sealed.Nonce = nonce
+		hash, _ := ComputeWork(&sealed)
+		if new(big.Int).SetBytes(hash.Bytes()).Cmp(target) <= 0 {
+			return nonce, nil
+		}
+	}
+	return 0, fmt.Errorf("no nonce found for difficulty %v", header.Difficulty)
+}
+
+// newSealVector Compute the seal vector of a header and nonce
+// newSealVector 计算区块头和nonce的封装向量
+func newSealVector(name string, header *types.BlockHeader, nonce uint64) SealVector {
+	unsealed := *header
+	unsealed.Nonce = 0
+	unsealed.MixDigest = common.Hash{}
+
+	sealed := unsealed
+	sealed.Nonce = nonce
+	hash, mixDigest := ComputeWork(&sealed)
+	return SealVector{
+		Name:      name,
+		Header:    &unsealed,
+		Encoded:   EncodeSealHeader(&unsealed),
+		SealHash:  SealHash(&unsealed),
+		Seed:      common.BytesToHash(SeedHash(unsealed.Number.Uint64())),
+		Nonce:     hexutil.Uint64(nonce),
+		Hash:      hash,
+		MixDigest: mixDigest,
+		Valid:     new(big.Int).SetBytes(hash.Bytes()).Cmp(ToTarget(unsealed.Difficulty)) <= 0,
+	}
+}
+
+// CheckTestVectors Check test vectors against the reference implementation, returning the first mismatch
+// CheckTestVectors 使用参考实现校验测试向量，返回第一个不一致之处
+func CheckTestVectors(vectors *TestVectors) error {
+	for _, v := range vectors.Seal {
+		if err := checkSealVector(v); err != nil {
+			return fmt.Errorf("seal vector %s: %v", v.Name, err)
+		}
+	}
+	for _, v := range vectors.Difficulty {
+		if err := checkDifficultyVector(v); err != nil {
+			return fmt.Errorf("difficulty vector %s: %v", v.Name, err)
+		}
+	}
+	for _, v := range vectors.Reward {
+		if err := checkRewardVector(v); err != nil {
+			return fmt.Errorf("reward vector %d: %v", uint64(v.Number), err)
+		}
+	}
+	return nil
+}
+
+// checkSealVector Check the serialization, seed and Hashimoto outputs of a seal vector
+// checkSealVector 校验封装向量的序列化、种子和 Hashimoto 输出
+func checkSealVector(v SealVector) error {
+	if v.Header == nil || v.Header.Number == nil || v.Header.Difficulty == nil {
+		return fmt.Errorf("incomplete header")
+	}
+	if encoded := hexutil.Bytes(EncodeSealHeader(v.Header)); encoded.String() != v.Encoded.String() {
+		return fmt.Errorf("encoded %s, want %s", encoded, v.Encoded)
+	}
+	if sealHash := SealHash(v.Header); sealHash != v.SealHash {
+		return fmt.Errorf("seal hash %s, want %s", sealHash.Hex(), v.SealHash.Hex())
+	}
+	if seed := common.BytesToHash(SeedHash(v.Header.Number.Uint64())); seed != v.Seed {
+		return fmt.Errorf("seed %s, want %s", seed.Hex(), v.Seed.Hex())
+	}
+
+	sealed := *v.Header
+	sealed.Nonce = uint64(v.Nonce)
+	hash, mixDigest := ComputeWork(&sealed)
+	if hash != v.Hash {
+		return fmt.Errorf("hash %s, want %s", hash.Hex(), v.Hash.Hex())
+	}
+	if mixDigest != v.MixDigest {
+		return fmt.Errorf("mix digest %s, want %s", mixDigest.Hex(), v.MixDigest.Hex())
+	}
+	sealed.MixDigest = mixDigest
+	if valid := VerifyWork(&sealed, sealed.Difficulty) == nil; valid != v.Valid {
+		return fmt.Errorf("valid %t, want %t", valid, v.Valid)
+	}
+	return nil
+}
+
+// checkDifficultyVector Recompute every difficulty of the vector chain from its ancestors
+// checkDifficultyVector 由祖先区块重新计算向量链中的每个难度
+func checkDifficultyVector(v DifficultyVector) error {
+	var chain vectorChain
+	for i, h := range v.Headers {
+		if h.Difficulty == nil {
+			return fmt.Errorf("block %d: missing difficulty", i)
+		}
+		if i > 0 {
+			want := (*big.Int)(h.Difficulty)
+			got := CalculateDifficulty(v.Config, chain, uint64(h.Time), chain.CurrentHeader())
+			if got == nil || got.Cmp(want) != 0 {
+				return fmt.Errorf("block %d: difficulty %v, want %v", i, got, want)
+			}
+		}
+		chain = chain.appendHeader(uint64(h.Time), (*big.Int)(h.Difficulty))
+	}
+	return nil
+}
+
+// checkRewardVector Check the block, nephew and uncle rewards at a height
+// checkRewardVector 校验某高度的区块、侄块和叔块奖励
+func checkRewardVector(v RewardVector) error {
+	number := uint64(v.Number)
+	if reward := CalculateReward(number); v.Reward == nil || reward.Cmp(v.Reward.ToInt()) != 0 {
+		return fmt.Errorf("reward %v, want %v", reward, v.Reward)
+	}
+	if reward := CalculateNephewReward(number); v.NephewReward == nil || reward.Cmp(v.NephewReward.ToInt()) != 0 {
+		return fmt.Errorf("nephew reward %v, want %v", reward, v.NephewReward)
+	}
+	for _, u := range v.UncleRewards {
+		if reward := CalculateUncleReward(number, uint64(u.UncleNumber)); u.Reward == nil || reward.Cmp(u.Reward.ToInt()) != 0 {
+			return fmt.Errorf("uncle %d: reward %v, want %v", uint64(u.UncleNumber), reward, u.Reward)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package nogopow
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// 测试向量文件由 nogotest -type consensus-vectors 生成
+const vectorsFile = "testdata/vectors.json"
+
+func TestConsensusVectors(t *testing.T) {
+	data, err := os.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatalf("read vectors: %v", err)
+	}
+	var vectors TestVectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("decode vectors: %v", err)
+	}
+	if len(vectors.Seal) == 0 || len(vectors.Difficulty) == 0 || len(vectors.Reward) == 0 {
+		t.Fatalf("incomplete vectors: %d seal, %d difficulty, %d reward", len(vectors.Seal), len(vectors.Difficulty), len(vectors.Reward))
+	}
+	if err := CheckTestVectors(&vectors); err != nil {
+		t.Fatalf("vectors do not match the implementation: %v", err)
+	}
+
+	// 挖到的nonce必须有效
+	for _, v := range vectors.Seal {
+		if v.Nonce != vectorSealNonce && !v.Valid {
+			t.Errorf("seal vector %s: mined nonce is not valid", v.Name)
+		}
+	}
+
+	// 修改任一结果都会被发现
+	tampered := vectors
+	tampered.Seal = append([]SealVector(nil), vectors.Seal...)
+	tampered.Seal[0].MixDigest[0] ^= 0xff
+	if err := CheckTestVectors(&tampered); err == nil {
+		t.Errorf("tampered mix digest not detected")
+	}
+	tampered = vectors
+	tampered.Difficulty = append([]DifficultyVector(nil), vectors.Difficulty...)
+	headers := append([]DifficultyHeader(nil), vectors.Difficulty[1].Headers...)
+	headers[10].Difficulty = (*hexutil.Big)(new(big.Int).Add(headers[10].Difficulty.ToInt(), big.NewInt(1)))
+	tampered.Difficulty[1].Headers = headers
+	if err := CheckTestVectors(&tampered); err == nil {
+		t.Errorf("tampered difficulty not detected")
+	}
+}
@@ -1,7 +1,6 @@
 package nogopow
 
 import (
-	"crypto/sha256"
 	"math/big"
 	"time"
 )
@@ -15,31 +14,24 @@ func VerifyBlock(header []byte, nonce uint64, difficulty *big.Int, timestamp tim
 
 	// 验证难度
 	target := ToTarget(difficulty)
-	
-	// 优化：使用缓存的NogoPow实例
-	seedHash := sha256.Sum256(header)
-	seedHashStr := string(seedHash[:])
-	pow := GetCachedNogoPow(seedHashStr)
-	pow.Initialize(header)
+
+	// 只使用区块所在纪元的缓存验证，不需要构建完整数据集
+	pow := GetCache(height)
 
 	// 验证工作量证明
-	return pow.Verify(header, nonce, target)
+	return pow.VerifyLight(header, nonce, target)
 }
 
-// VerifyDifficulty 验证难度调整
+// VerifyDifficulty 按原有算法验证难度调整，分叉后的区块由引擎的 CalcDifficulty 验证
 func VerifyDifficulty(parentDifficulty *big.Int, currentDifficulty *big.Int, parentTimestamp time.Time, currentTimestamp time.Time, height uint64) bool {
-	expectedDifficulty := CalculateDifficulty(parentTimestamp, currentTimestamp, parentDifficulty, height)
+	expectedDifficulty := legacyDifficulty(parentTimestamp, currentTimestamp, parentDifficulty, height)
 	return expectedDifficulty.Cmp(currentDifficulty) == 0
 }
 
-// VerifyNonce 验证nonce
-func VerifyNonce(header []byte, nonce uint64, target *big.Int) bool {
-	// 优化：使用缓存的NogoPow实例
-	seedHash := sha256.Sum256(header)
-	seedHashStr := string(seedHash[:])
-	pow := GetCachedNogoPow(seedHashStr)
-	pow.Initialize(header)
-	return pow.Verify(header, nonce, target)
+// VerifyNonce 验证nonce，height 为区块号，用于确定纪元
+func VerifyNonce(header []byte, height uint64, nonce uint64, target *big.Int) bool {
+	pow := GetCache(height)
+	return pow.VerifyLight(header, nonce, target)
 }
 
 // GetBlockReward 获取区块奖励
@@ -0,0 +1,38 @@
+package consensus
+
+import (
+	"slices"
+	"time"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// IsFutureBlock reports whether a header timestamp is more than params.MaxFutureBlockTime ahead of now
+// IsFutureBlock 判断区块头时间戳是否超前 now 超过 params.MaxFutureBlockTime
+func IsFutureBlock(header *types.BlockHeader, now time.Time) bool {
+	return header.Time > uint64(now.Unix())+params.MaxFutureBlockTime
+}
+
+// MedianTimePast returns the median timestamp of parent and its ancestors, params.MedianTimeSpan blocks in total
+// Near genesis fewer blocks are used, a missing ancestor returns ErrUnknownAncestor
+// MedianTimePast 获取父区块及其祖先区块共 params.MedianTimeSpan 个区块时间戳的中位数
+// 接近创世区块时使用较少的区块，缺少祖先区块时返回 ErrUnknownAncestor
+func MedianTimePast(chain ChainHeaderReader, parent *types.BlockHeader) (uint64, error) {
+	timestamps := make([]uint64, 0, params.MedianTimeSpan)
+	for header := parent; ; {
+		timestamps = append(timestamps, header.Time)
+		number := header.Number.Uint64()
+		if number == 0 || uint64(len(timestamps)) == params.MedianTimeSpan {
+			break
+		}
+		if chain == nil {
+			return 0, ErrUnknownAncestor
+		}
+		if header = chain.GetHeader(header.ParentHash, number-1); header == nil {
+			return 0, ErrUnknownAncestor
+		}
+	}
+	slices.Sort(timestamps)
+	return timestamps[len(timestamps)/2], nil
+}
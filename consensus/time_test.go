@@ -0,0 +1,92 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// testChain 按区块号索引的测试链
+type testChain []*types.BlockHeader
+
+func (c testChain) CurrentHeader() *types.BlockHeader { return c[len(c)-1] }
+
+func (c testChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	return c.GetHeaderByNumber(number)
+}
+
+func (c testChain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+
+func (c testChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader { return nil }
+
+func newTestChain(timestamps ...uint64) testChain {
+	chain := make(testChain, len(timestamps))
+	for i, timestamp := range timestamps {
+		chain[i] = &types.BlockHeader{Number: big.NewInt(int64(i)), Time: timestamp}
+	}
+	return chain
+}
+
+func TestMedianTimePast(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []uint64
+		want       uint64
+	}{
+		{"genesis only", []uint64{100}, 100},
+		{"near genesis", []uint64{100, 130, 110}, 110},
+		// 只使用最近11个区块，第一个区块的时间戳不参与计算
+		{"full span", []uint64{1000, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 5}, 50},
+		// 乱序的时间戳不影响中位数
+		{"out of order", []uint64{0, 100, 300, 200, 400, 250, 350, 150, 500, 450, 120, 600}, 300},
+	}
+	for _, tt := range tests {
+		chain := newTestChain(tt.timestamps...)
+		got, err := MedianTimePast(chain, chain.CurrentHeader())
+		if err != nil || got != tt.want {
+			t.Errorf("%s: MedianTimePast = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	chain := newTestChain(100, 110, 120)
+	if _, err := MedianTimePast(chain[:1], chain[2]); err != ErrUnknownAncestor {
+		t.Errorf("missing ancestor: got %v, want %v", err, ErrUnknownAncestor)
+	}
+	if _, err := MedianTimePast(nil, chain[2]); err != ErrUnknownAncestor {
+		t.Errorf("nil chain: got %v, want %v", err, ErrUnknownAncestor)
+	}
+}
+
+func TestIsFutureBlock(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	header := &types.BlockHeader{Time: 1700000000 + params.MaxFutureBlockTime}
+	if IsFutureBlock(header, now) {
+		t.Errorf("header at the drift limit should be accepted")
+	}
+	header.Time++
+	if !IsFutureBlock(header, now) {
+		t.Errorf("header beyond the drift limit should be a future block")
+	}
+}
+
+func TestBatchHeaderReader(t *testing.T) {
+	chain := newTestChain(100, 110)
+	batch := []*types.BlockHeader{{ParentHash: common.Hash{0x01}, Number: big.NewInt(2), Time: 120}}
+	reader := NewBatchHeaderReader(chain, batch)
+	if reader.GetHeader(batch[0].Hash(), 2) != batch[0] || reader.GetHeaderByHash(batch[0].Hash()) != batch[0] {
+		t.Errorf("batch header not found")
+	}
+	if reader.GetHeader(common.Hash{}, 1) != chain[1] {
+		t.Errorf("chain header not found")
+	}
+}
@@ -0,0 +1,94 @@
+package consensus
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+var (
+	// ErrTooManyUncles is returned when a block includes more than params.MaxUncles uncles
+	// ErrTooManyUncles 区块包含的叔块超过 params.MaxUncles 个
+	ErrTooManyUncles = errors.New("too many uncles")
+
+	// ErrInvalidUncleHash is returned when the header uncle hash does not match the uncles of the block
+	// ErrInvalidUncleHash 区块头的叔块哈希与区块的叔块不一致
+	ErrInvalidUncleHash = errors.New("invalid uncle hash")
+
+	// ErrDuplicateUncle is returned when an uncle is included twice, in the block or by a recent ancestor
+	// ErrDuplicateUncle 叔块在区块中或最近的祖先区块中已被包含
+	ErrDuplicateUncle = errors.New("duplicate uncle")
+
+	// ErrUncleIsAncestor is returned when an uncle is an ancestor of the block
+	// ErrUncleIsAncestor 叔块是区块的祖先区块
+	ErrUncleIsAncestor = errors.New("uncle is ancestor")
+
+	// ErrDanglingUncle is returned when the parent of an uncle is not a recent ancestor other than the block parent
+	// ErrDanglingUncle 叔块的父区块不是除区块父区块之外的最近祖先区块
+	ErrDanglingUncle = errors.New("uncle's parent is not ancestor")
+
+	// ErrFutureUncle is returned when an uncle is too far in the future, unlike ErrFutureBlock it
+	// rejects the including block instead of queueing it
+	// ErrFutureUncle 叔块时间戳超前太多，与 ErrFutureBlock 不同，包含它的区块被拒绝而不是进入队列
+	ErrFutureUncle = errors.New("uncle in the future")
+)
+
+// VerifyUncleAncestry checks that uncles are recent side-chain headers for a block with the given header:
+// their parents are among the last params.MaxUncleDepth ancestors but not the block parent,
+// and neither the block nor those ancestors include them already
+// It returns the parent of each uncle, so the engine can verify the uncle headers
+// VerifyUncleAncestry 检查叔块是否为区块的最近侧链区块头：
+// 叔块的父区块是最近 params.MaxUncleDepth 个祖先区块之一但不是区块的父区块，
+// 且区块和这些祖先区块都没有包含过该叔块
+// 返回每个叔块的父区块，供共识引擎验证叔块区块头
+func VerifyUncleAncestry(chain ChainReader, header *types.BlockHeader, uncles []*types.BlockHeader) ([]*types.BlockHeader, error) {
+	if len(uncles) > params.MaxUncles {
+		return nil, ErrTooManyUncles
+	}
+	if len(uncles) == 0 {
+		return nil, nil
+	}
+	if chain == nil {
+		return nil, ErrUnknownAncestor
+	}
+
+	// Gather the recent ancestors and the uncles they include
+	// 收集最近的祖先区块及其包含的叔块
+	ancestors := make(map[common.Hash]*types.BlockHeader)
+	included := map[common.Hash]bool{header.Hash(): true}
+	hash, number := header.ParentHash, header.Number.Uint64()
+	for i := uint64(0); i < params.MaxUncleDepth && number > 0; i++ {
+		number--
+		ancestor := chain.GetBlock(hash)
+		if ancestor == nil || ancestor.NumberU64() != number {
+			break
+		}
+		ancestors[hash] = ancestor.Header
+		for _, uncle := range ancestor.Uncles {
+			included[uncle.Hash()] = true
+		}
+		hash = ancestor.ParentHash()
+	}
+
+	parents := make([]*types.BlockHeader, len(uncles))
+	for i, uncle := range uncles {
+		hash := uncle.Hash()
+		if included[hash] {
+			return nil, ErrDuplicateUncle
+		}
+		included[hash] = true
+
+		if ancestors[hash] != nil {
+			return nil, ErrUncleIsAncestor
+		}
+		parent := ancestors[uncle.ParentHash]
+		if parent == nil || uncle.ParentHash == header.ParentHash {
+			return nil, ErrDanglingUncle
+		}
+		parents[i] = parent
+	}
+	return parents, nil
+}
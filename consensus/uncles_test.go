@@ -0,0 +1,100 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// testBlockChain 按哈希保存区块的测试链
+type testBlockChain map[common.Hash]*types.Block
+
+func (c testBlockChain) CurrentHeader() *types.BlockHeader { return nil }
+
+func (c testBlockChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	return c.GetHeaderByHash(hash)
+}
+
+func (c testBlockChain) GetHeaderByNumber(number uint64) *types.BlockHeader { return nil }
+
+func (c testBlockChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader {
+	if block := c[hash]; block != nil {
+		return block.Header
+	}
+	return nil
+}
+
+func (c testBlockChain) GetBlock(hash common.Hash) *types.Block { return c[hash] }
+
+// sideHeader 创建 parent 的一个侧链子区块头
+func sideHeader(parent *types.BlockHeader, coinbase byte) *types.BlockHeader {
+	return &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Coinbase:   common.Address{coinbase},
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Time:       parent.Time + 1,
+	}
+}
+
+func TestVerifyUncleAncestry(t *testing.T) {
+	// 主链 0..10，区块5已包含区块4的一个侧链子区块
+	chain := make(testBlockChain)
+	canonical := make([]*types.BlockHeader, 11)
+	var included *types.BlockHeader
+	for i := range canonical {
+		header := &types.BlockHeader{Number: big.NewInt(int64(i)), Time: uint64(i * 17)}
+		if i > 0 {
+			header.ParentHash = canonical[i-1].Hash()
+		}
+		var uncles []*types.BlockHeader
+		if i == 5 {
+			included = sideHeader(canonical[3], 0xee)
+			uncles = []*types.BlockHeader{included}
+		}
+		header.UncleHash = types.CalcUncleHash(uncles)
+		block := types.NewBlockWithHeader(header, &types.Body{Uncles: uncles})
+		canonical[i] = block.Header
+		chain[block.Hash()] = block
+	}
+	header := sideHeader(canonical[10], 0x01)
+
+	tests := []struct {
+		name   string
+		chain  ChainReader
+		uncles []*types.BlockHeader
+		want   error
+	}{
+		{"no uncles", nil, nil, nil},
+		{"recent side block", chain, []*types.BlockHeader{sideHeader(canonical[9], 0x02)}, nil},
+		{"two uncles", chain, []*types.BlockHeader{sideHeader(canonical[9], 0x02), sideHeader(canonical[4], 0x03)}, nil},
+		{"oldest allowed depth", chain, []*types.BlockHeader{sideHeader(canonical[11-params.MaxUncleDepth], 0x02)}, nil},
+		{"too deep", chain, []*types.BlockHeader{sideHeader(canonical[10-params.MaxUncleDepth], 0x02)}, ErrDanglingUncle},
+		{"sibling", chain, []*types.BlockHeader{sideHeader(canonical[10], 0x02)}, ErrDanglingUncle},
+		{"unknown parent", chain, []*types.BlockHeader{sideHeader(header, 0x02)}, ErrDanglingUncle},
+		{"ancestor", chain, []*types.BlockHeader{canonical[8]}, ErrUncleIsAncestor},
+		{"duplicate in block", chain, []*types.BlockHeader{sideHeader(canonical[9], 0x02), sideHeader(canonical[9], 0x02)}, ErrDuplicateUncle},
+		{"included by ancestor", chain, []*types.BlockHeader{included}, ErrDuplicateUncle},
+		{"the block itself", chain, []*types.BlockHeader{header}, ErrDuplicateUncle},
+		{"too many", chain, []*types.BlockHeader{sideHeader(canonical[9], 0x02), sideHeader(canonical[8], 0x03), sideHeader(canonical[7], 0x04)}, ErrTooManyUncles},
+		{"no chain", nil, []*types.BlockHeader{sideHeader(canonical[9], 0x02)}, ErrUnknownAncestor},
+	}
+	for _, tt := range tests {
+		parents, err := VerifyUncleAncestry(tt.chain, header, tt.uncles)
+		if err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		for i, uncle := range tt.uncles {
+			if parents[i].Hash() != uncle.ParentHash {
+				t.Errorf("%s: parent of uncle %d = %x, want %x", tt.name, i, parents[i].Hash(), uncle.ParentHash)
+			}
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+// BackupManifestFile is the manifest written last into a completed backup
+// BackupManifestFile 备份完成时最后写入的清单文件
+const BackupManifestFile = "backup.json"
+
+var (
+	// ErrNoDatabase is returned when the blockchain has no persistent storage
+	// ErrNoDatabase 区块链没有持久化存储
+	ErrNoDatabase = errors.New("blockchain has no persistent storage")
+
+	// ErrBackupUnsupported is returned when the storage cannot create snapshots
+	// ErrBackupUnsupported 存储不支持快照
+	ErrBackupUnsupported = errors.New("storage does not support snapshots")
+
+	// ErrBackupExists is returned when the backup directory is not empty
+	// ErrBackupExists 备份目录不为空
+	ErrBackupExists = errors.New("backup directory is not empty")
+
+	// ErrInvalidBackup is returned when a directory is not a completed backup
+	// ErrInvalidBackup 目录不是完整的备份
+	ErrInvalidBackup = errors.New("directory is not a completed backup")
+
+	// ErrDataDirExists is returned when restoring over existing chain data without force
+	// ErrDataDirExists 恢复目标已有链数据
+	ErrDataDirExists = errors.New("chain data already exists")
+)
+
+// BackupInfo describes a backup
+// BackupInfo 备份信息
+type BackupInfo struct {
+	SchemaVersion uint64      `json:"schemaVersion"`
+	Head          uint64      `json:"head"`
+	HeadHash      common.Hash `json:"headHash"`
+	Ancients      uint64      `json:"ancients"`
+	Keys          int         `json:"keys"`
+	Bytes         int64       `json:"bytes"`
+	Created       time.Time   `json:"created"`
+	Duration      string      `json:"duration"`
+}
+
+// checkpointer is a storage that can create consistent snapshots
+// checkpointer 可以创建一致快照的存储
+type checkpointer interface {
+	Checkpoint(dir string) (*storage.CheckpointInfo, error)
+}
+
+// Backup creates a consistent copy of the chain data in dir while the node keeps running
+// Block commits are paused only while the database snapshot is taken; the freezer is copied afterwards
+// Backup 在节点运行时将链数据的一致副本写入 dir
+// 只在创建数据库快照期间暂停区块提交，冻结库随后复制
+func (bc *Blockchain) Backup(dir string) (*BackupInfo, error) {
+	start := time.Now()
+
+	bc.mu.RLock()
+	db := bc.db
+	ancients := bc.ancients
+	if db == nil {
+		bc.mu.RUnlock()
+		return nil, ErrNoDatabase
+	}
+	info, err := backupChainData(db, dir)
+	bc.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return finishBackup(info, ancients, dir, start)
+}
+
+// BackupDatabase creates a copy of a database that is not attached to a running blockchain
+// BackupDatabase 备份未被运行中的区块链使用的数据库
+func BackupDatabase(db storage.BatchStorage, ancients *freezer.Freezer, dir string) (*BackupInfo, error) {
+	start := time.Now()
+
+	info, err := backupChainData(db, dir)
+	if err != nil {
+		return nil, err
+	}
+	return finishBackup(info, ancients, dir, start)
+}
+
+// backupChainData snapshots the database into dir/chaindata
+// The database snapshot is taken before the freezer, so blocks frozen in between are
+// present in both copies instead of missing from both
+// backupChainData 将数据库快照写入 dir/chaindata
+// 数据库快照先于冻结库创建，期间被冻结的区块在两份副本中都存在，而不是都丢失
+func backupChainData(db storage.BatchStorage, dir string) (*BackupInfo, error) {
+	cp, ok := db.(checkpointer)
+	if !ok {
+		return nil, ErrBackupUnsupported
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, ErrBackupExists
+	}
+
+	info := &BackupInfo{Created: time.Now().UTC()}
+	info.SchemaVersion, _ = storage.ReadSchemaVersion(db)
+	if value, exists := db.Get(storage.HeadBlockKey); exists {
+		if hash, err := decodeHash(value); err == nil {
+			info.HeadHash = hash
+			if value, exists := db.Get(storage.HeaderKey(hash)); exists {
+				header := new(types.BlockHeader)
+				if err := decodeStored(value, header); err == nil && header.Number != nil {
+					info.Head = header.Number.Uint64()
+				}
+			}
+		}
+	}
+
+	snapshot, err := cp.Checkpoint(filepath.Join(dir, storage.ChainDataDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot database: %v", err)
+	}
+	info.Keys = snapshot.Keys
+	info.Bytes = snapshot.Bytes
+	return info, nil
+}
+
+// finishBackup copies the freezer and writes the manifest
+// finishBackup 复制冻结库并写入清单
+func finishBackup(info *BackupInfo, ancients *freezer.Freezer, dir string, start time.Time) (*BackupInfo, error) {
+	if ancients != nil {
+		frozen, err := ancients.Checkpoint(filepath.Join(dir, storage.ChainDataDir, storage.AncientDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot freezer: %v", err)
+		}
+		info.Ancients = frozen
+	}
+	info.Duration = time.Since(start).String()
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, BackupManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ReadBackupInfo reads the manifest of a completed backup
+// ReadBackupInfo 读取完整备份的清单
+func ReadBackupInfo(dir string) (*BackupInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if err != nil {
+		return nil, ErrInvalidBackup
+	}
+	info := new(BackupInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, ErrInvalidBackup
+	}
+	return info, nil
+}
+
+// RestoreBackup restores a backup into the chain data directory of dataDir; the node must be stopped
+// The backup is copied next to the target first and renamed into place, so an interrupted restore
+// never leaves a partially restored database
+// RestoreBackup 将备份恢复到 dataDir 的链数据目录，恢复时节点必须停止
+// 备份先复制到目标旁边再重命名，中断的恢复不会留下不完整的数据库
+func RestoreBackup(backupDir, dataDir string, force bool) (*BackupInfo, error) {
+	info, err := ReadBackupInfo(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	if info.SchemaVersion > storage.SchemaVersion {
+		return nil, storage.ErrNewerSchema
+	}
+
+	target := filepath.Join(dataDir, storage.ChainDataDir)
+	if _, err := os.Stat(target); err == nil {
+		if !force {
+			return nil, ErrDataDirExists
+		}
+
+		// A running node holds the lock of its chain data
+		// 运行中的节点持有链数据目录锁
+		lock, err := storage.LockDir(target)
+		if err != nil {
+			return nil, err
+		}
+		defer lock.Release()
+	}
+
+	tmp := target + ".restore"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := copyDir(filepath.Join(backupDir, storage.ChainDataDir), tmp); err != nil {
+		os.RemoveAll(tmp)
+		return nil, fmt.Errorf("failed to copy backup: %v", err)
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// copyDir recursively copies the regular files of src into dst
+// copyDir 递归复制 src 中的普通文件到 dst
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Sync(); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,117 @@
+package blockchain
+
+import (
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+// 测试节点运行时备份，恢复后的数据库一致且头部为备份时的头部
+func TestBackupRestore(t *testing.T) {
+	dataDir := t.TempDir()
+	chainDir := filepath.Join(dataDir, storage.ChainDataDir)
+	db := storage.NewOptimizedStorage(chainDir, 100, 1024*1024, time.Hour)
+	defer db.Close()
+	ancients, err := freezer.New(filepath.Join(chainDir, storage.AncientDir), freezer.Options{Compress: true})
+	if err != nil {
+		t.Fatalf("freezer.New returned error: %v", err)
+	}
+	defer ancients.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+	if err := bc.SetFreezer(ancients, 3); err != nil {
+		t.Fatalf("SetFreezer returned error: %v", err)
+	}
+
+	parent := bc.Genesis()
+	addBlocks := func(n int) {
+		for i := 0; i < n; i++ {
+			block := types.NewBlock(
+				parent.Hash(),
+				common.Address{0x01},
+				common.Hash{},
+				common.Hash{},
+				common.Hash{},
+				big.NewInt(1000000),
+				new(big.Int).Add(parent.Header.Number, big.NewInt(1)),
+				10000000,
+				0,
+				parent.Header.Time+10,
+				[]byte("Backup Block"),
+				common.Hash{},
+				parent.Header.Nonce+1,
+				[]*types.Transaction{},
+				[]*types.BlockHeader{},
+			)
+			if err := bc.AddBlock(block); err != nil {
+				t.Errorf("AddBlock returned error: %v", err)
+				return
+			}
+			parent = block
+		}
+	}
+	addBlocks(10)
+	if _, err := bc.Freeze(); err != nil {
+		t.Fatalf("Freeze returned error: %v", err)
+	}
+
+	// 备份期间继续出块和冻结
+	backupDir := filepath.Join(t.TempDir(), "backup")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		addBlocks(20)
+		bc.Freeze()
+	}()
+	info, err := bc.Backup(backupDir)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Backup returned error: %v", err)
+	}
+	if info.SchemaVersion != storage.SchemaVersion || info.Ancients == 0 {
+		t.Errorf("unexpected backup info: %+v", info)
+	}
+	if _, err := bc.Backup(backupDir); err != ErrBackupExists {
+		t.Errorf("expected ErrBackupExists, got %v", err)
+	}
+
+	restoreDir := t.TempDir()
+	if _, err := RestoreBackup(backupDir, restoreDir, false); err != nil {
+		t.Fatalf("RestoreBackup returned error: %v", err)
+	}
+	if _, err := RestoreBackup(backupDir, restoreDir, false); err != ErrDataDirExists {
+		t.Errorf("expected ErrDataDirExists, got %v", err)
+	}
+
+	restoredChain := filepath.Join(restoreDir, storage.ChainDataDir)
+	restoredDB := storage.NewOptimizedStorage(restoredChain, 100, 1024*1024, time.Hour)
+	defer restoredDB.Close()
+	restoredAncients, err := freezer.New(filepath.Join(restoredChain, storage.AncientDir), freezer.Options{Compress: true})
+	if err != nil {
+		t.Fatalf("freezer.New returned error: %v", err)
+	}
+	defer restoredAncients.Close()
+
+	report, err := VerifyDatabase(restoredDB, restoredAncients)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	if !report.Consistent() {
+		t.Fatalf("restored database is not consistent: %v", report.Issues)
+	}
+	if report.Head != info.Head || report.HeadHash != info.HeadHash {
+		t.Errorf("restored head #%d %s, backup head #%d %s", report.Head, report.HeadHash.Hex(), info.Head, info.HeadHash.Hex())
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
 
+	"nogochain/consensus"
 	"nogochain/core/state"
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
 	"nogochain/core/types"
 	"nogochain/metrics"
 )
@@ -20,7 +24,50 @@ type Blockchain struct {
 	stateDB     state.StateDB
 	genesis     *types.Block
 	currentHead *types.Block
+	db          storage.BatchStorage
 	mu          sync.RWMutex
+
+	// Ancient store for finalized blocks
+	// 已确定区块的冻结库
+	ancients         *freezer.Freezer
+	freezerThreshold uint64
+	frozenHashes     map[common.Hash]uint64
+	freezerStop      chan struct{}
+	freezerWg        sync.WaitGroup
+
+	// History pruning for non-archive nodes
+	// 非归档节点的历史裁剪
+	pruneConfig PruneConfig
+	bodyTail    uint64
+	stateTail   uint64
+	pruneStop   chan struct{}
+	pruneWg     sync.WaitGroup
+
+	// Transaction lookup index, kept in memory when there is no database
+	// 交易查找索引，没有数据库时保存在内存中
+	txLookups     map[common.Hash]common.Hash
+	txIndexConfig TxIndexConfig
+	txIndexTail   uint64
+	txIndexStop   chan struct{}
+	txIndexWg     sync.WaitGroup
+
+	// Consensus engine verifying added blocks
+	// 验证新增区块的共识引擎
+	engine consensus.Engine
+
+	// Blocks slightly ahead of the local clock, imported once their time has come
+	// 略微超前于本地时钟的区块，到达时间后导入
+	futureBlocks map[common.Hash]*types.Block
+	futureStop   chan struct{}
+	futureWg     sync.WaitGroup
+
+	// Recent blocks by height, including side chains, offered as uncles
+	// 按高度索引的最近区块（包括侧链），作为叔块候选
+	recentBlocks map[uint64][]common.Hash
+
+	// Checkpoints and finality depth protecting against deep reorganizations
+	// 防止深度重组的检查点和最终性深度
+	finality FinalityConfig
 }
 
 // NewBlockchain creates a new blockchain instance
@@ -36,13 +83,122 @@ func NewBlockchain(genesis *types.Block) *Blockchain {
 	blocks[genesis.Hash()] = genesis
 	blockNumber[genesis.NumberU64()] = genesis.Hash()
 
-	return &Blockchain{
+	bc := &Blockchain{
 		blocks:      blocks,
 		blockNumber: blockNumber,
 		stateDB:     state.NewMemoryStateDB(),
 		genesis:     genesis,
 		currentHead: genesis,
+		txLookups:   make(map[common.Hash]common.Hash),
+	}
+	bc.writeTxLookups(nil, []*types.Block{genesis})
+	return bc
+}
+
+// NewBlockchainWithStorage creates a blockchain that persists every committed block
+// NewBlockchainWithStorage 创建将每个提交的区块持久化到存储的区块链
+func NewBlockchainWithStorage(genesis *types.Block, db storage.BatchStorage) (*Blockchain, error) {
+	bc := NewBlockchain(genesis)
+	bc.db = db
+	bc.txLookups = nil
+
+	// Refuse newer schemas and upgrade older data directories in place
+	// 拒绝打开更新版本的数据库，并就地升级旧版本的数据目录
+	if err := storage.UpgradeSchema(db, logMigrationProgress); err != nil {
+		return nil, err
+	}
+
+	// Persist the genesis block on first start and restore the stored chain on restart
+	// 首次启动时持久化创世区块，重启时恢复已存储的链
+	if _, exists := db.Get(storage.HeadBlockKey); !exists {
+		if err := bc.commitBlock(bc.genesis, []*types.Block{bc.genesis}); err != nil {
+			return nil, err
+		}
+	} else if err := bc.loadChain(); err != nil {
+		return nil, err
+	}
+
+	return bc, nil
+}
+
+// logMigrationProgress logs the progress of a database migration
+// logMigrationProgress 记录数据库迁移进度
+func logMigrationProgress(progress storage.MigrationProgress) {
+	log.Info().Uint64("version", progress.Version).Str("name", progress.Name).
+		Int("done", progress.Done).Int("total", progress.Total).Msg("Migrating database")
+}
+
+// commitBlock writes the header, body and state of a block in one atomic batch
+// When the block becomes head, the canonical mappings of the new branch and the head pointer are written in the same batch
+// commitBlock 在一个原子批次中写入区块头、区块体和状态
+// 区块成为头部时，在同一批次中写入新分支的规范链映射和头部指针
+func (bc *Blockchain) commitBlock(block *types.Block, branch []*types.Block) error {
+	hash := block.Hash()
+
+	batch := storage.NewBatch()
+	batch.Set(storage.HeaderKey(hash), block.Header)
+	batch.Set(storage.BodyKey(hash), block.Body())
+	bc.writeTxLookups(batch, branch)
+	if memState, ok := bc.stateDB.(*state.MemoryStateDB); ok {
+		// Only the live state of the block is stored, the genesis header carries no state root
+		// 只存储属于该区块的当前状态，创世区块头不包含状态根
+		if dump := memState.Dump(); dump.Root == block.Header.Root || block.NumberU64() == 0 {
+			batch.Set(storage.StateKey(block.Header.Root), dump)
+		}
+	}
+	if len(branch) > 0 {
+		for _, canonical := range branch {
+			batch.Set(storage.CanonicalHashKey(canonical.NumberU64()), canonical.Hash())
+		}
+		batch.Set(storage.HeadBlockKey, hash)
+	}
+	if len(branch) > 0 && block.NumberU64() == 0 {
+		// A new database indexes transactions from genesis
+		// 新数据库从创世区块开始索引交易
+		batch.Set(storage.TxIndexTailKey, uint64(0))
+	}
+
+	return bc.db.Write(batch)
+}
+
+// newBranch returns the blocks that become canonical when block becomes head, from the first block
+// after the common ancestor with the current canonical chain up to block, the caller must hold bc.mu
+// The new head is higher than the old one, so the mappings of the new branch replace all displaced ones
+// newBranch 获取区块成为头部时加入规范链的区块，从与当前规范链的共同祖先之后的第一个区块到该区块，调用者需持有 bc.mu
+// 新头部高于旧头部，因此新分支的映射会替换所有被取代的映射
+func (bc *Blockchain) newBranch(block *types.Block) []*types.Block {
+	var branch []*types.Block
+	for current := block; current != nil; current = bc.blocks[current.ParentHash()] {
+		if hash, exists := bc.blockNumber[current.NumberU64()]; exists && hash == current.Hash() {
+			break
+		}
+		branch = append(branch, current)
+		if current.NumberU64() == 0 {
+			break
+		}
+	}
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
 	}
+	return branch
+}
+
+// Close stops background work and closes the underlying storage
+// Close 停止后台任务并关闭底层存储
+func (bc *Blockchain) Close() error {
+	bc.StopFreezer()
+	bc.StopPruner()
+	bc.StopTxIndexer()
+	bc.StopFutureBlocks()
+	if bc.ancients != nil {
+		if err := bc.ancients.Close(); err != nil {
+			return err
+		}
+	}
+	if bc.db == nil {
+		return nil
+	}
+	return bc.db.Close()
 }
 
 // createGenesisBlock creates the genesis block
@@ -91,7 +247,13 @@ func (bc *Blockchain) CurrentHead() *types.Block {
 func (bc *Blockchain) GetBlock(hash common.Hash) *types.Block {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
-	return bc.blocks[hash]
+	if block, exists := bc.blocks[hash]; exists {
+		return block
+	}
+	if number, frozen := bc.frozenHashes[hash]; frozen {
+		return bc.readAncientBlock(number)
+	}
+	return nil
 }
 
 // GetBlockByNumber retrieves a block by its number
@@ -102,43 +264,77 @@ func (bc *Blockchain) GetBlockByNumber(number uint64) *types.Block {
 	if hash, exists := bc.blockNumber[number]; exists {
 		return bc.blocks[hash]
 	}
-	return nil
+	return bc.readAncientBlock(number)
 }
 
 // AddBlock adds a new block to the blockchain
+// The block is verified by the engine without holding the chain lock, so readers are not blocked behind seal checks
 // AddBlock 添加区块
+// 共识引擎验证区块时不持有区块链锁，读取者不会被封装验证阻塞
 func (bc *Blockchain) AddBlock(block *types.Block) error {
 	startTime := time.Now()
+
+	bc.mu.Lock()
+	parent, err := bc.blockParent(block)
+	engine := bc.engine
+	bc.mu.Unlock()
+	if parent == nil || err != nil {
+		return err
+	}
+
+	// Verify block with the consensus engine before it can affect the chain or the reorg alerts
+	// 在区块影响区块链或重组告警之前使用共识引擎验证
+	if err := verifyBlock(engine, bc, block); err != nil {
+		if err == consensus.ErrFutureBlock {
+			bc.mu.Lock()
+			defer bc.mu.Unlock()
+			return bc.queueFutureBlock(block)
+		}
+		return err
+	}
+
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
-	// Check if block already exists
-	// 检查区块是否已存在
+	// The block may have been added while it was verified
+	// 验证期间区块可能已被添加
 	if _, exists := bc.blocks[block.Hash()]; exists {
 		return nil
 	}
 
-	// Check if parent block exists
-	// 检查父区块是否存在
-	parent := bc.blocks[block.ParentHash()]
-	if parent == nil {
-		return nil
+	// Refuse to reorganize past a checkpoint or the finality depth
+	// 拒绝越过检查点或超过最终性深度的重组
+	if err := bc.checkReorg(block, parent); err != nil {
+		return err
 	}
 
-	// Check if block number is correct
-	// 检查区块号是否正确
-	if block.NumberU64() != parent.NumberU64()+1 {
-		return nil
+	// Blocks higher than the head become canonical together with their branch
+	// 高于头部的区块与其分支一起成为规范链
+	var branch []*types.Block
+	if block.NumberU64() > bc.currentHead.NumberU64() {
+		branch = bc.newBranch(block)
+	}
+
+	// Commit block to persistent storage atomically
+	// 原子地提交区块到持久化存储
+	if bc.db != nil {
+		if err := bc.commitBlock(block, branch); err != nil {
+			return err
+		}
+	} else {
+		bc.writeTxLookups(nil, branch)
 	}
 
 	// Add block to storage
 	// 添加区块到存储
 	bc.blocks[block.Hash()] = block
-	bc.blockNumber[block.NumberU64()] = block.Hash()
+	for _, canonical := range branch {
+		bc.blockNumber[canonical.NumberU64()] = canonical.Hash()
+	}
 
 	// Update current head
 	// 更新当前头部
-	if block.NumberU64() > bc.currentHead.NumberU64() {
+	if len(branch) > 0 {
 		bc.currentHead = block
 		// Update block height metric
 		// 更新区块高度指标
@@ -150,6 +346,7 @@ func (bc *Blockchain) AddBlock(block *types.Block) error {
 		// 更新交易计数指标
 		metrics.TransactionCount.Add(float64(len(block.Transactions)))
 	}
+	bc.recordRecentBlock(block)
 
 	// Record block processing time
 	// 记录区块处理时间
@@ -158,6 +355,36 @@ func (bc *Blockchain) AddBlock(block *types.Block) error {
 	return nil
 }
 
+// blockParent returns the parent of a block that can be added, or nil when the block is known, is not the child
+// of a known block or was queued with its future parent, the caller must hold bc.mu
+// blockParent 获取可以添加的区块的父区块；区块已存在、不是已知区块的子区块或与未来父区块一起排队时返回 nil，调用者需持有 bc.mu
+func (bc *Blockchain) blockParent(block *types.Block) (*types.Block, error) {
+	// Check if block already exists
+	// 检查区块是否已存在
+	if _, exists := bc.blocks[block.Hash()]; exists {
+		return nil, nil
+	}
+
+	// Check if parent block exists
+	// 检查父区块是否存在
+	parent := bc.blocks[block.ParentHash()]
+	if parent == nil {
+		// Children of queued future blocks wait in the queue with their parent
+		// 排队的未来区块的子区块与父区块一起在队列中等待
+		if _, queued := bc.futureBlocks[block.ParentHash()]; queued {
+			return nil, bc.queueFutureBlock(block)
+		}
+		return nil, nil
+	}
+
+	// Check if block number is correct
+	// 检查区块号是否正确
+	if block.NumberU64() != parent.NumberU64()+1 {
+		return nil, nil
+	}
+	return parent, nil
+}
+
 // Length returns the length of the blockchain
 // Length 获取链长度
 func (bc *Blockchain) Length() uint64 {
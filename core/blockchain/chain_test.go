@@ -3,9 +3,12 @@ package blockchain
 import (
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
 	"nogochain/core/types"
 )
 
@@ -548,3 +551,216 @@ func TestBlockchainWithTransactions(t *testing.T) {
 		t.Errorf("Transaction pool should be empty after removing transactions in block, got %d", size)
 	}
 }
+
+// 测试区块提交到持久化存储
+func TestBlockchainWithStorage(t *testing.T) {
+	dataDir := t.TempDir()
+	db := storage.NewOptimizedStorage(dataDir, 100, 1024*1024, time.Hour)
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+	genesis := bc.Genesis()
+
+	block := types.NewBlock(
+		genesis.Hash(),
+		common.Address{0x01},
+		common.Hash{},
+		common.Hash{},
+		common.Hash{},
+		big.NewInt(1000000),
+		big.NewInt(1),
+		10000000,
+		0,
+		genesis.Header.Time+10,
+		[]byte("Stored Block"),
+		common.Hash{},
+		1,
+		[]*types.Transaction{},
+		[]*types.BlockHeader{},
+	)
+	if err := bc.AddBlock(block); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// 重新打开存储，检查区块的所有部分都已落盘
+	reopened := storage.NewOptimizedStorage(dataDir, 100, 1024*1024, time.Hour)
+	defer reopened.Close()
+
+	keys := []string{
+		storage.HeaderKey(block.Hash()),
+		storage.BodyKey(block.Hash()),
+		storage.CanonicalHashKey(1),
+		storage.StateKey(block.Header.Root),
+		storage.HeadBlockKey,
+	}
+	for _, key := range keys {
+		if _, exists := reopened.Get(key); !exists {
+			t.Errorf("key %s not found after reopen", key)
+		}
+	}
+}
+
+// 测试侧链区块不改变规范链映射，更长的分支在重组时重写规范链映射
+func TestCanonicalReorg(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+	canonicalHash := func(number uint64) common.Hash {
+		value, _ := db.Get(storage.CanonicalHashKey(number))
+		hash, _ := decodeHash(value)
+		return hash
+	}
+
+	a1 := newTxBlock(bc.Genesis(), "A")
+	a2 := newTxBlock(a1, "A")
+	b1 := newTxBlock(bc.Genesis(), "B")
+	for _, block := range []*types.Block{a1, a2, b1} {
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+	}
+	if canonicalHash(1) != a1.Hash() || bc.GetBlockByNumber(1).Hash() != a1.Hash() {
+		t.Fatalf("side block replaced the canonical block")
+	}
+
+	// B 分支超过 A 分支成为规范链
+	b2 := newTxBlock(b1, "B")
+	b3 := newTxBlock(b2, "B")
+	for _, block := range []*types.Block{b2, b3} {
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+	}
+	for _, block := range []*types.Block{b1, b2, b3} {
+		number := block.NumberU64()
+		if canonicalHash(number) != block.Hash() || bc.GetBlockByNumber(number).Hash() != block.Hash() {
+			t.Errorf("block %d of the new branch is not canonical", number)
+		}
+	}
+	if head, _ := db.Get(storage.HeadBlockKey); head != b3.Hash() {
+		t.Errorf("head pointer not updated: %v", head)
+	}
+}
+
+// 测试旧区块移入冻结库后仍可读取
+func TestBlockchainFreezer(t *testing.T) {
+	bc := NewBlockchain(nil)
+	ancients, err := freezer.New(t.TempDir(), freezer.Options{Compress: true})
+	if err != nil {
+		t.Fatalf("freezer.New returned error: %v", err)
+	}
+	defer ancients.Close()
+
+	if err := bc.SetFreezer(ancients, 3); err != nil {
+		t.Fatalf("SetFreezer returned error: %v", err)
+	}
+
+	parent := bc.Genesis()
+	blocks := []*types.Block{parent}
+	for i := 1; i <= 10; i++ {
+		block := types.NewBlock(
+			parent.Hash(),
+			common.Address{0x01},
+			common.Hash{},
+			common.Hash{},
+			common.Hash{},
+			big.NewInt(1000000),
+			big.NewInt(int64(i)),
+			10000000,
+			0,
+			parent.Header.Time+10,
+			[]byte("Freezer Block"),
+			common.Hash{},
+			uint64(i),
+			[]*types.Transaction{},
+			[]*types.BlockHeader{},
+		)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block
+	}
+
+	frozen, err := bc.Freeze()
+	if err != nil {
+		t.Fatalf("Freeze returned error: %v", err)
+	}
+	if frozen != 8 || ancients.Ancients() != 8 {
+		t.Fatalf("expected 8 frozen blocks, got %d (ancients %d)", frozen, ancients.Ancients())
+	}
+
+	for _, block := range blocks {
+		byNumber := bc.GetBlockByNumber(block.NumberU64())
+		if byNumber == nil || byNumber.Hash() != block.Hash() {
+			t.Errorf("block %d not readable by number after freezing", block.NumberU64())
+		}
+		if byHash := bc.GetBlock(block.Hash()); byHash == nil {
+			t.Errorf("block %d not readable by hash after freezing", block.NumberU64())
+		}
+	}
+}
+
+// 测试冻结沿头部回溯的规范链，收据一并移入冻结库，侧链区块不被冻结
+func TestFreezeCanonicalReceipts(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+	ancients, err := freezer.New(t.TempDir(), freezer.Options{Compress: true})
+	if err != nil {
+		t.Fatalf("freezer.New returned error: %v", err)
+	}
+	defer ancients.Close()
+	if err := bc.SetFreezer(ancients, 2); err != nil {
+		t.Fatalf("SetFreezer returned error: %v", err)
+	}
+
+	blocks := []*types.Block{bc.Genesis()}
+	for i := 1; i <= 5; i++ {
+		block := newTxBlock(blocks[i-1], "main")
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	side := newTxBlock(blocks[1], "side")
+	if err := bc.AddBlock(side); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+
+	receipts := types.Receipts{{Status: 1, GasUsed: 21000, TxHash: common.Hash{0x01}}}
+	batch := storage.NewBatch()
+	batch.Set(storage.ReceiptsKey(blocks[2].Hash()), receipts)
+	if err := db.Write(batch); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+
+	if frozen, err := bc.Freeze(); err != nil || frozen != 4 {
+		t.Fatalf("expected 4 frozen blocks, got %d: %v", frozen, err)
+	}
+	for number := uint64(0); number < 4; number++ {
+		data, err := ancients.Ancient(freezer.HashTable, number)
+		if err != nil || common.BytesToHash(data) != blocks[number].Hash() {
+			t.Errorf("frozen block %d is not canonical", number)
+		}
+	}
+	if got := bc.GetReceipts(blocks[2].Hash()); len(got) != 1 || got[0].GasUsed != 21000 {
+		t.Errorf("frozen receipts not readable: %v", got)
+	}
+	if _, exists := db.Get(storage.ReceiptsKey(blocks[2].Hash())); exists {
+		t.Errorf("receipts of frozen block left in the database")
+	}
+}
@@ -0,0 +1,137 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/types"
+	"nogochain/metrics"
+	"nogochain/params"
+)
+
+var (
+	// ErrCheckpointMismatch is returned for a block that conflicts with a checkpoint
+	// ErrCheckpointMismatch 区块与检查点冲突
+	ErrCheckpointMismatch = errors.New("block conflicts with checkpoint")
+
+	// ErrReorgTooDeep is returned for a block that would reorganize deeper than the finality depth
+	// ErrReorgTooDeep 区块导致的重组超过最终性深度
+	ErrReorgTooDeep = errors.New("reorg exceeds finality depth")
+)
+
+// FinalityConfig configures the protection against deep reorganizations
+// FinalityConfig 防止深度重组的配置
+type FinalityConfig struct {
+	// Checkpoints maps block numbers to the hashes the chain never reorganizes past
+	// Checkpoints 区块号到区块哈希的映射，链不会重组越过这些检查点
+	Checkpoints map[uint64]common.Hash `json:"checkpoints"`
+
+	// Depth rejects reorganizations rolling back more than Depth blocks, 0 disables the limit
+	// Depth 拒绝回滚超过 Depth 个区块的重组，0 表示不限制
+	Depth uint64 `json:"depth"`
+}
+
+// NewFinalityConfig returns the finality configuration of a chain configuration
+// NewFinalityConfig 根据链配置创建最终性配置
+func NewFinalityConfig(config *params.ChainConfig) FinalityConfig {
+	if config == nil {
+		return FinalityConfig{}
+	}
+	checkpoints := make(map[uint64]common.Hash, len(config.Checkpoints))
+	for number, hash := range config.Checkpoints {
+		checkpoints[number] = hash
+	}
+	return FinalityConfig{Checkpoints: checkpoints, Depth: config.FinalityDepth}
+}
+
+// SetFinality sets the checkpoints and finality depth, failing if the canonical chain conflicts with a checkpoint
+// SetFinality 设置检查点和最终性深度，规范链与检查点冲突时返回错误
+func (bc *Blockchain) SetFinality(config FinalityConfig) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	for number, hash := range config.Checkpoints {
+		if block := bc.canonicalBlock(number); block != nil && block.Hash() != hash {
+			return fmt.Errorf("%v: block %d is %x, checkpoint %x", ErrCheckpointMismatch, number, block.Hash(), hash)
+		}
+	}
+	bc.finality = config
+	return nil
+}
+
+// Finality returns the checkpoints and finality depth of the chain
+// Finality 获取区块链的检查点和最终性深度
+func (bc *Blockchain) Finality() FinalityConfig {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.finality
+}
+
+// checkReorg rejects a block conflicting with a checkpoint or reorganizing past a checkpoint or the finality depth,
+// the caller must hold bc.mu
+// checkReorg 拒绝与检查点冲突、重组越过检查点或超过最终性深度的区块，调用者需持有 bc.mu
+func (bc *Blockchain) checkReorg(block, parent *types.Block) error {
+	number := block.NumberU64()
+	if hash, exists := bc.finality.Checkpoints[number]; exists && hash != block.Hash() {
+		return bc.rejectReorg(block, 0, ErrCheckpointMismatch, fmt.Sprintf("checkpoint %d is %x", number, hash))
+	}
+
+	// Blocks extending the head do not roll anything back
+	// 扩展头部的区块不会回滚任何区块
+	head := bc.currentHead.NumberU64()
+	fork := bc.forkNumber(parent)
+	if fork >= head {
+		return nil
+	}
+	depth := head - fork
+
+	for checkpoint := range bc.finality.Checkpoints {
+		if checkpoint > fork && checkpoint <= head {
+			return bc.rejectReorg(block, depth, ErrCheckpointMismatch, fmt.Sprintf("fork at %d reorganizes checkpoint %d", fork, checkpoint))
+		}
+	}
+	if bc.finality.Depth > 0 && depth > bc.finality.Depth {
+		return bc.rejectReorg(block, depth, ErrReorgTooDeep, fmt.Sprintf("fork at %d exceeds finality depth %d", fork, bc.finality.Depth))
+	}
+	return nil
+}
+
+// forkNumber returns the number of the last block shared by the branch ending at parent and the head,
+// the caller must hold bc.mu
+// forkNumber 获取以 parent 结尾的分支与头部共有的最后一个区块号，调用者需持有 bc.mu
+func (bc *Blockchain) forkNumber(parent *types.Block) uint64 {
+	branch, canonical := parent, bc.currentHead
+	for canonical.NumberU64() > branch.NumberU64() {
+		if canonical = bc.blocks[canonical.ParentHash()]; canonical == nil {
+			return branch.NumberU64()
+		}
+	}
+	for branch.NumberU64() > canonical.NumberU64() {
+		if branch = bc.blocks[branch.ParentHash()]; branch == nil {
+			return canonical.NumberU64()
+		}
+	}
+	for branch.Hash() != canonical.Hash() {
+		// Blocks missing from memory are frozen and therefore shared
+		// 内存中缺失的区块已冻结，因此是共有区块
+		number := branch.NumberU64()
+		branch, canonical = bc.blocks[branch.ParentHash()], bc.blocks[canonical.ParentHash()]
+		if branch == nil || canonical == nil {
+			return number - 1
+		}
+	}
+	return branch.NumberU64()
+}
+
+// rejectReorg records and logs a rejected reorganization
+// rejectReorg 记录被拒绝的重组并输出日志
+func (bc *Blockchain) rejectReorg(block *types.Block, depth uint64, err error, reason string) error {
+	metrics.RejectedReorgs.Inc()
+	metrics.RejectedReorgDepth.Set(float64(depth))
+	log.Warn().Err(err).Uint64("number", block.NumberU64()).Str("hash", block.Hash().Hex()).
+		Uint64("head", bc.currentHead.NumberU64()).Uint64("depth", depth).Str("reason", reason).Msg("Rejected deep reorg")
+	return err
+}
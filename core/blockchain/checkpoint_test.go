@@ -0,0 +1,100 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// 测试链拒绝越过检查点或超过最终性深度的重组
+func TestReorgProtection(t *testing.T) {
+	bc := NewBlockchain(nil)
+	genesis := bc.Genesis()
+
+	// 主链 genesis <- 1 <- ... <- 6
+	canonical := []*types.Block{genesis}
+	for i := 1; i <= 6; i++ {
+		block := newTxBlock(canonical[i-1], "main")
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+		canonical = append(canonical, block)
+	}
+
+	if err := bc.SetFinality(FinalityConfig{Checkpoints: map[uint64]common.Hash{2: common.Hash{0x01}}}); err == nil {
+		t.Fatalf("conflicting checkpoint accepted")
+	}
+	if err := bc.SetFinality(FinalityConfig{Checkpoints: map[uint64]common.Hash{2: canonical[2].Hash()}, Depth: 2}); err != nil {
+		t.Fatalf("SetFinality failed: %v", err)
+	}
+
+	// 与检查点高度上的哈希不同的区块
+	if err := bc.AddBlock(newTxBlock(canonical[1], "side")); err != ErrCheckpointMismatch {
+		t.Errorf("block at checkpoint: got %v, want %v", err, ErrCheckpointMismatch)
+	}
+	// 分叉点在检查点之下的区块
+	if err := bc.AddBlock(newTxBlock(genesis, "side")); err != ErrCheckpointMismatch {
+		t.Errorf("reorg past checkpoint: got %v, want %v", err, ErrCheckpointMismatch)
+	}
+	// 回滚3个区块超过最终性深度
+	if err := bc.AddBlock(newTxBlock(canonical[3], "side")); err != ErrReorgTooDeep {
+		t.Errorf("deep reorg: got %v, want %v", err, ErrReorgTooDeep)
+	}
+
+	// 最终性深度内的侧链区块及其子区块可以导入
+	side := newTxBlock(canonical[4], "side")
+	if err := bc.AddBlock(side); err != nil {
+		t.Fatalf("shallow side block rejected: %v", err)
+	}
+	if err := bc.AddBlock(newTxBlock(side, "side")); err != nil {
+		t.Errorf("child of shallow side block rejected: %v", err)
+	}
+	if err := bc.AddBlock(newTxBlock(canonical[6], "main")); err != nil {
+		t.Errorf("block extending the head rejected: %v", err)
+	}
+
+	// 检查点由链配置提供
+	config := NewFinalityConfig(&params.ChainConfig{Checkpoints: params.MainnetCheckpoints, FinalityDepth: 10})
+	if config.Depth != 10 || config.Checkpoints[0] != genesis.Hash() {
+		t.Errorf("unexpected finality config %+v", config)
+	}
+	if err := bc.SetFinality(config); err != nil {
+		t.Errorf("mainnet checkpoints conflict with genesis: %v", err)
+	}
+}
+
+// 测试配置的检查点合并到硬编码检查点之上并阻止越过它的重组
+func TestConfiguredCheckpoint(t *testing.T) {
+	bc := NewBlockchain(nil)
+	canonical := []*types.Block{bc.Genesis()}
+	for i := 1; i <= 4; i++ {
+		block := newTxBlock(canonical[i-1], "main")
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+		canonical = append(canonical, block)
+	}
+
+	config := NewFinalityConfig(params.DefaultChainConfig.WithCheckpoints(map[uint64]common.Hash{2: canonical[2].Hash()}))
+	if config.Checkpoints[0] != bc.Genesis().Hash() || config.Checkpoints[2] != canonical[2].Hash() {
+		t.Fatalf("checkpoints not merged: %v", config.Checkpoints)
+	}
+	if len(params.DefaultChainConfig.Checkpoints) != len(params.MainnetCheckpoints) {
+		t.Fatalf("merging modified the default chain config")
+	}
+	if err := bc.SetFinality(config); err != nil {
+		t.Fatalf("SetFinality failed: %v", err)
+	}
+
+	// 从检查点之下分叉的更长分支被拒绝
+	side := newTxBlock(canonical[1], "side")
+	if err := bc.AddBlock(side); err != ErrCheckpointMismatch {
+		t.Errorf("reorg past configured checkpoint: got %v, want %v", err, ErrCheckpointMismatch)
+	}
+	if err := bc.AddBlock(newTxBlock(canonical[2], "side")); err != nil {
+		t.Errorf("side block above the checkpoint rejected: %v", err)
+	}
+}
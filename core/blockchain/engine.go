@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+)
+
+// Blockchain implements consensus.ChainHeaderReader for the consensus engines
+// Blockchain 为共识引擎实现 consensus.ChainHeaderReader
+var _ consensus.ChainHeaderReader = (*Blockchain)(nil)
+
+// SetEngine sets the consensus engine verifying added blocks, nil disables verification
+// SetEngine 设置验证新增区块的共识引擎，nil 表示不验证
+func (bc *Blockchain) SetEngine(engine consensus.Engine) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.engine = engine
+}
+
+// Engine returns the consensus engine of the chain, or nil
+// Engine 获取区块链的共识引擎，未设置时返回 nil
+func (bc *Blockchain) Engine() consensus.Engine {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.engine
+}
+
+// CurrentHeader returns the header of the current head block
+// CurrentHeader 获取当前头部区块的区块头
+func (bc *Blockchain) CurrentHeader() *types.BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return headerReader{bc}.CurrentHeader()
+}
+
+// GetHeader retrieves a header by hash and number
+// GetHeader 通过哈希和区块号获取区块头
+func (bc *Blockchain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return headerReader{bc}.GetHeader(hash, number)
+}
+
+// GetHeaderByNumber retrieves the canonical header with the given number
+// GetHeaderByNumber 通过区块号获取规范链区块头
+func (bc *Blockchain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return headerReader{bc}.GetHeaderByNumber(number)
+}
+
+// GetHeaderByHash retrieves a header by hash
+// GetHeaderByHash 通过哈希获取区块头
+func (bc *Blockchain) GetHeaderByHash(hash common.Hash) *types.BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return headerReader{bc}.GetHeaderByHash(hash)
+}
+
+// headerReader reads headers without locking, the caller must hold bc.mu
+// headerReader 不加锁地读取区块头，调用者需持有 bc.mu
+type headerReader struct {
+	bc *Blockchain
+}
+
+func (r headerReader) CurrentHeader() *types.BlockHeader {
+	return r.bc.currentHead.Header
+}
+
+func (r headerReader) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	header := r.GetHeaderByHash(hash)
+	if header == nil || header.Number.Uint64() != number {
+		return nil
+	}
+	return header
+}
+
+func (r headerReader) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if block := r.bc.canonicalBlock(number); block != nil {
+		return block.Header
+	}
+	return nil
+}
+
+func (r headerReader) GetHeaderByHash(hash common.Hash) *types.BlockHeader {
+	if block, exists := r.bc.blocks[hash]; exists {
+		return block.Header
+	}
+	if number, frozen := r.bc.frozenHashes[hash]; frozen {
+		if block := r.bc.readAncientBlock(number); block != nil {
+			return block.Header
+		}
+	}
+	return nil
+}
+
+func (r headerReader) GetBlock(hash common.Hash) *types.Block {
+	if block, exists := r.bc.blocks[hash]; exists {
+		return block
+	}
+	if number, frozen := r.bc.frozenHashes[hash]; frozen {
+		return r.bc.readAncientBlock(number)
+	}
+	return nil
+}
+
+// verifyBlock verifies the header, seal and uncles of a block with the engine, the caller must not hold bc.mu
+// verifyBlock 使用共识引擎验证区块头、封装和叔块，调用者不能持有 bc.mu
+func verifyBlock(engine consensus.Engine, chain consensus.ChainReader, block *types.Block) error {
+	if engine == nil {
+		return nil
+	}
+	if err := engine.VerifyHeader(chain, block.Header); err != nil {
+		return err
+	}
+	return engine.VerifyUncles(chain, block)
+}
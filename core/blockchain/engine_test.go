@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"nogochain/consensus/fake"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/types"
+	"nogochain/metrics"
+)
+
+// 测试设置共识引擎后拒绝无效区块，未设置时保持原有行为
+func TestAddBlockVerifiesWithEngine(t *testing.T) {
+	bc := NewBlockchain(nil)
+	genesis := bc.Genesis()
+
+	invalid := newTxBlock(genesis, "older")
+	invalid.Header.Time = genesis.Header.Time
+
+	bc.SetEngine(nogopow.NewNogoPow())
+	if bc.Engine() == nil {
+		t.Fatalf("engine not set")
+	}
+	if err := bc.AddBlock(invalid); err == nil {
+		t.Errorf("invalid block should be rejected")
+	}
+	if bc.CurrentHead().Hash() != genesis.Hash() || bc.GetBlock(invalid.Hash()) != nil {
+		t.Errorf("rejected block should not be added")
+	}
+
+	bc.SetEngine(nil)
+	if err := bc.AddBlock(invalid); err != nil {
+		t.Fatalf("AddBlock without engine failed: %v", err)
+	}
+	if bc.CurrentHeader().Hash() != invalid.Hash() {
+		t.Errorf("current header should be the added block")
+	}
+	if header := bc.GetHeader(invalid.Hash(), 1); header == nil || bc.GetHeaderByNumber(1) != header {
+		t.Errorf("header lookups mismatch")
+	}
+	if bc.GetHeader(invalid.Hash(), 2) != nil {
+		t.Errorf("header with wrong number should not be found")
+	}
+}
+
+// 测试未通过验证的侧链区块在重组检查之前被拒绝，不触发重组告警
+func TestInvalidBlockSkipsReorgCheck(t *testing.T) {
+	bc := NewBlockchain(nil)
+	canonical := []*types.Block{bc.Genesis()}
+	for i := 1; i <= 6; i++ {
+		block := newTxBlock(canonical[i-1], "main")
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+		canonical = append(canonical, block)
+	}
+	if err := bc.SetFinality(FinalityConfig{Depth: 2}); err != nil {
+		t.Fatalf("SetFinality failed: %v", err)
+	}
+
+	// 回滚3个区块的侧链区块封装无效
+	bc.SetEngine(fake.NewFakeFailer(4))
+	rejected := testutil.ToFloat64(metrics.RejectedReorgs)
+	if err := bc.AddBlock(newTxBlock(canonical[3], "side")); err != fake.ErrFakeSeal {
+		t.Errorf("unsealed deep reorg: got %v, want %v", err, fake.ErrFakeSeal)
+	}
+	if got := testutil.ToFloat64(metrics.RejectedReorgs); got != rejected {
+		t.Errorf("unsealed block counted as rejected reorg: %v -> %v", rejected, got)
+	}
+
+	// 有效的深度重组区块仍被拒绝
+	bc.SetEngine(fake.NewFaker())
+	if err := bc.AddBlock(newTxBlock(canonical[3], "side")); err != ErrReorgTooDeep {
+		t.Errorf("valid deep reorg: got %v, want %v", err, ErrReorgTooDeep)
+	}
+}
+
+// 测试引擎验证区块时不阻塞区块链的读取者
+func TestVerifyWithoutChainLock(t *testing.T) {
+	bc := NewBlockchain(nil)
+	bc.SetEngine(fake.NewFakeDelayer(500 * time.Millisecond))
+	block := newTxBlock(bc.Genesis(), "slow")
+
+	done := make(chan error, 1)
+	go func() { done <- bc.AddBlock(block) }()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	bc.CurrentHead()
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("reader blocked for %v during verification", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("AddBlock failed: %v", err)
+	}
+	if bc.CurrentHead().Hash() != block.Hash() {
+		t.Errorf("verified block not added")
+	}
+}
@@ -0,0 +1,269 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+const (
+	// DefaultFreezerThreshold is the default depth after which blocks are moved to the freezer
+	// DefaultFreezerThreshold 区块移入冻结库的默认深度
+	DefaultFreezerThreshold = 90000
+
+	// freezerBatchLimit is the maximum number of blocks frozen per round
+	// freezerBatchLimit 每轮最多冻结的区块数量
+	freezerBatchLimit = 30000
+)
+
+// SetFreezer attaches an ancient store; blocks deeper than threshold are moved into it
+// SetFreezer 设置冻结库，深度超过 threshold 的区块会被移入冻结库
+func (bc *Blockchain) SetFreezer(ancients *freezer.Freezer, threshold uint64) error {
+	frozenHashes := make(map[common.Hash]uint64)
+	for n := uint64(0); n < ancients.Ancients(); n++ {
+		data, err := ancients.Ancient(freezer.HashTable, n)
+		if err != nil {
+			return err
+		}
+		frozenHashes[common.BytesToHash(data)] = n
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.ancients = ancients
+	bc.freezerThreshold = threshold
+	bc.frozenHashes = frozenHashes
+
+	// Drop in-memory copies of blocks that are already frozen
+	// 丢弃已冻结区块的内存副本
+	for hash, number := range frozenHashes {
+		if number == 0 {
+			continue
+		}
+		delete(bc.blocks, hash)
+		delete(bc.blockNumber, number)
+	}
+
+	return nil
+}
+
+// StartFreezer starts the background worker that moves old blocks into the freezer
+// StartFreezer 启动后台冻结任务
+func (bc *Blockchain) StartFreezer(interval time.Duration) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.ancients == nil || bc.freezerStop != nil {
+		return
+	}
+
+	bc.freezerStop = make(chan struct{})
+	bc.freezerWg.Add(1)
+	go bc.freezerLoop(interval, bc.freezerStop)
+}
+
+// StopFreezer stops the background freezer worker
+// StopFreezer 停止后台冻结任务
+func (bc *Blockchain) StopFreezer() {
+	bc.mu.Lock()
+	stop := bc.freezerStop
+	bc.freezerStop = nil
+	bc.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		bc.freezerWg.Wait()
+	}
+}
+
+// freezerLoop periodically freezes blocks
+// freezerLoop 定期冻结区块
+func (bc *Blockchain) freezerLoop(interval time.Duration, stop chan struct{}) {
+	defer bc.freezerWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			frozen, err := bc.Freeze()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to freeze blocks")
+				continue
+			}
+			if frozen > 0 {
+				log.Info().Int("blocks", frozen).Uint64("ancients", bc.ancients.Ancients()).Msg("Moved blocks to freezer")
+			}
+		}
+	}
+}
+
+// Freeze moves canonical blocks deeper than the threshold into the freezer and returns how many were moved
+// Freeze 将深度超过阈值的规范链区块移入冻结库，返回移动的区块数量
+func (bc *Blockchain) Freeze() (int, error) {
+	bc.mu.RLock()
+	ancients := bc.ancients
+	threshold := bc.freezerThreshold
+	head := bc.currentHead
+	bc.mu.RUnlock()
+
+	if ancients == nil || head.NumberU64() < threshold {
+		return 0, nil
+	}
+	limit := head.NumberU64() - threshold
+	start := ancients.Ancients()
+	if start > limit {
+		return 0, nil
+	}
+
+	// Walk the canonical chain back from the head, side blocks at the same heights are never frozen
+	// 从头部区块沿父区块回溯规范链，同一高度的侧链区块不会被冻结
+	canonical := make([]*types.Block, 0)
+	bc.mu.RLock()
+	for block := head; block != nil && block.NumberU64() >= start; block = bc.blocks[block.ParentHash()] {
+		if block.NumberU64() <= limit {
+			canonical = append(canonical, block)
+		}
+		if block.NumberU64() == 0 {
+			break
+		}
+	}
+	bc.mu.RUnlock()
+
+	// The chain must continue the freezer without gaps
+	// 规范链必须与冻结库连续衔接
+	if len(canonical) == 0 || canonical[len(canonical)-1].NumberU64() != start {
+		return 0, nil
+	}
+	for i, j := 0, len(canonical)-1; i < j; i, j = i+1, j-1 {
+		canonical[i], canonical[j] = canonical[j], canonical[i]
+	}
+	if len(canonical) > freezerBatchLimit {
+		canonical = canonical[:freezerBatchLimit]
+	}
+
+	// Total difficulty of the last frozen block
+	// 最后一个冻结区块的总难度
+	td := new(big.Int)
+	if start > 0 {
+		data, err := ancients.Ancient(freezer.DifficultyTable, start-1)
+		if err != nil {
+			return 0, err
+		}
+		td.SetBytes(data)
+	}
+
+	frozen := make([]*types.Block, 0, len(canonical))
+	for _, block := range canonical {
+		header, err := json.Marshal(block.Header)
+		if err != nil {
+			return len(frozen), err
+		}
+		body, err := json.Marshal(block.Body())
+		if err != nil {
+			return len(frozen), err
+		}
+		receipts, err := bc.encodeReceipts(block.Hash())
+		if err != nil {
+			return len(frozen), err
+		}
+		td.Add(td, block.Header.Difficulty)
+
+		hash := block.Hash()
+		if err := ancients.AppendAncient(block.NumberU64(), hash.Bytes(), header, body, receipts, td.Bytes()); err != nil {
+			return len(frozen), err
+		}
+		frozen = append(frozen, block)
+	}
+
+	if len(frozen) == 0 {
+		return 0, nil
+	}
+	if err := ancients.Sync(); err != nil {
+		return 0, err
+	}
+
+	// Remove frozen blocks from the hot database and memory
+	// 从热数据库和内存中删除已冻结的区块
+	batch := storage.NewBatch()
+	bc.mu.Lock()
+	for _, block := range frozen {
+		hash := block.Hash()
+		number := block.NumberU64()
+		bc.frozenHashes[hash] = number
+		if number == 0 {
+			continue
+		}
+		delete(bc.blocks, hash)
+		delete(bc.blockNumber, number)
+		batch.Delete(storage.HeaderKey(hash))
+		batch.Delete(storage.BodyKey(hash))
+		batch.Delete(storage.ReceiptsKey(hash))
+		batch.Delete(storage.CanonicalHashKey(number))
+	}
+	bc.mu.Unlock()
+
+	if bc.db != nil {
+		if err := bc.db.Write(batch); err != nil {
+			return len(frozen), err
+		}
+	}
+
+	return len(frozen), nil
+}
+
+// encodeReceipts returns the stored receipts of a block encoded for the freezer, nil when the block has none
+// encodeReceipts 获取区块已存储的收据并编码为冻结库格式，没有收据时返回 nil
+func (bc *Blockchain) encodeReceipts(hash common.Hash) ([]byte, error) {
+	if bc.db == nil {
+		return nil, nil
+	}
+	value, exists := bc.db.Get(storage.ReceiptsKey(hash))
+	if !exists {
+		return nil, nil
+	}
+	var receipts types.Receipts
+	if err := decodeStored(value, &receipts); err != nil {
+		return nil, err
+	}
+	return json.Marshal(receipts)
+}
+
+// readAncientBlock reads a block from the freezer
+// readAncientBlock 从冻结库读取区块
+func (bc *Blockchain) readAncientBlock(number uint64) *types.Block {
+	if bc.ancients == nil || !bc.ancients.HasAncient(freezer.HeaderTable, number) {
+		return nil
+	}
+
+	headerData, err := bc.ancients.Ancient(freezer.HeaderTable, number)
+	if err != nil {
+		return nil
+	}
+	bodyData, err := bc.ancients.Ancient(freezer.BodyTable, number)
+	if err != nil {
+		return nil
+	}
+
+	header := new(types.BlockHeader)
+	if err := json.Unmarshal(headerData, header); err != nil {
+		return nil
+	}
+	body := new(types.Body)
+	if err := json.Unmarshal(bodyData, body); err != nil {
+		return nil
+	}
+
+	return types.NewBlockWithHeader(header, body)
+}
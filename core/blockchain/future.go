@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+)
+
+const (
+	// maxFutureBlocks Maximum number of blocks waiting in the future queue
+	// maxFutureBlocks 未来区块队列中等待的最大区块数
+	maxFutureBlocks = 256
+
+	// maxTimeFutureBlocks Blocks further ahead of the local clock are rejected instead of queued (seconds)
+	// maxTimeFutureBlocks 时间戳超前本地时钟超过该值的区块直接拒绝，不进入队列（秒）
+	maxTimeFutureBlocks = 30
+
+	// futureBlocksInterval Interval between retries of the queued blocks
+	// futureBlocksInterval 重试队列中区块的间隔
+	futureBlocksInterval = 5 * time.Second
+)
+
+// queueFutureBlock keeps a block rejected as slightly in the future for a later import, the caller must hold bc.mu
+// queueFutureBlock 保存因时间戳略微超前而被拒绝的区块以便稍后导入，调用者需持有 bc.mu
+func (bc *Blockchain) queueFutureBlock(block *types.Block) error {
+	if block.Header.Time > uint64(time.Now().Unix())+maxTimeFutureBlocks {
+		return consensus.ErrFutureBlock
+	}
+	if bc.futureBlocks == nil {
+		bc.futureBlocks = make(map[common.Hash]*types.Block)
+	}
+	if _, queued := bc.futureBlocks[block.Hash()]; !queued && len(bc.futureBlocks) >= maxFutureBlocks {
+		return consensus.ErrFutureBlock
+	}
+	bc.futureBlocks[block.Hash()] = block
+	return nil
+}
+
+// ProcessFutureBlocks retries the import of the queued future blocks in number order,
+// blocks still ahead of the local clock are queued again
+// ProcessFutureBlocks 按区块号顺序重试导入队列中的未来区块，仍然超前于本地时钟的区块重新入队
+func (bc *Blockchain) ProcessFutureBlocks() {
+	bc.mu.Lock()
+	blocks := make([]*types.Block, 0, len(bc.futureBlocks))
+	for _, block := range bc.futureBlocks {
+		blocks = append(blocks, block)
+	}
+	bc.futureBlocks = nil
+	bc.mu.Unlock()
+
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].NumberU64() < blocks[j].NumberU64()
+	})
+	for _, block := range blocks {
+		if err := bc.AddBlock(block); err != nil {
+			log.Warn().Err(err).Uint64("number", block.NumberU64()).Str("hash", block.Hash().Hex()).Msg("Failed to import future block")
+		}
+	}
+}
+
+// StartFutureBlocks starts the background worker retrying queued future blocks
+// StartFutureBlocks 启动重试未来区块的后台任务
+func (bc *Blockchain) StartFutureBlocks() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.futureStop != nil {
+		return
+	}
+	bc.futureStop = make(chan struct{})
+	bc.futureWg.Add(1)
+	go bc.futureBlocksLoop(bc.futureStop)
+}
+
+// StopFutureBlocks stops the background worker retrying queued future blocks
+// StopFutureBlocks 停止重试未来区块的后台任务
+func (bc *Blockchain) StopFutureBlocks() {
+	bc.mu.Lock()
+	stop := bc.futureStop
+	bc.futureStop = nil
+	bc.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		bc.futureWg.Wait()
+	}
+}
+
+// futureBlocksLoop periodically retries the queued future blocks
+// futureBlocksLoop 定期重试队列中的未来区块
+func (bc *Blockchain) futureBlocksLoop(stop chan struct{}) {
+	defer bc.futureWg.Done()
+
+	ticker := time.NewTicker(futureBlocksInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			bc.ProcessFutureBlocks()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"nogochain/consensus"
+	"nogochain/consensus/fake"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// 测试时间戳略微超前的区块进入队列，到达时间后与其子区块一起导入
+func TestFutureBlockQueue(t *testing.T) {
+	bc := NewBlockchain(nil)
+	bc.SetEngine(fake.NewFaker())
+	genesis := bc.Genesis()
+
+	// 超过允许的超前时间但在队列范围内
+	now := uint64(time.Now().Unix())
+	future := newTxBlock(genesis, "future")
+	future.Header.Time = now + params.MaxFutureBlockTime + 1
+	child := newTxBlock(future, "child")
+	child.Header.Time = future.Header.Time + 1
+
+	if err := bc.AddBlock(future); err != nil {
+		t.Fatalf("future block should be queued: %v", err)
+	}
+	if err := bc.AddBlock(child); err != nil {
+		t.Fatalf("child of future block should be queued: %v", err)
+	}
+	if bc.GetBlock(future.Hash()) != nil || len(bc.futureBlocks) != 2 {
+		t.Fatalf("future blocks should wait in the queue, queued %d", len(bc.futureBlocks))
+	}
+
+	// 超前太多的区块直接拒绝
+	far := newTxBlock(genesis, "far")
+	far.Header.Time = now + maxTimeFutureBlocks + 60
+	if err := bc.AddBlock(far); err != consensus.ErrFutureBlock {
+		t.Errorf("far future block: got %v, want %v", err, consensus.ErrFutureBlock)
+	}
+
+	// 时间未到时重新入队
+	bc.ProcessFutureBlocks()
+	if bc.GetBlock(future.Hash()) != nil && uint64(time.Now().Unix())+params.MaxFutureBlockTime < future.Header.Time {
+		t.Fatalf("future block imported too early")
+	}
+
+	for uint64(time.Now().Unix())+params.MaxFutureBlockTime < child.Header.Time {
+		time.Sleep(100 * time.Millisecond)
+	}
+	bc.ProcessFutureBlocks()
+	if bc.CurrentHead().Hash() != child.Hash() || len(bc.futureBlocks) != 0 {
+		t.Errorf("queued blocks not imported, head %d, queued %d", bc.CurrentHead().NumberU64(), len(bc.futureBlocks))
+	}
+}
+
+// 测试包含超前叔块的区块被拒绝而不是进入未来区块队列
+func TestFutureUncleRejected(t *testing.T) {
+	bc := NewBlockchain(nil)
+	bc.SetEngine(fake.NewFaker())
+	genesis := bc.Genesis()
+
+	parent := newTxBlock(genesis, "parent")
+	if err := bc.AddBlock(parent); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+
+	uncle := newTxBlock(genesis, "uncle")
+	uncle.Header.Time = uint64(time.Now().Unix()) + params.MaxFutureBlockTime + 1
+	block := newTxBlock(parent, "nephew")
+	block.Uncles = []*types.BlockHeader{uncle.Header}
+	block.Header.UncleHash = types.CalcUncleHash(block.Uncles)
+
+	if err := bc.AddBlock(block); err != consensus.ErrFutureUncle {
+		t.Errorf("got %v, want %v", err, consensus.ErrFutureUncle)
+	}
+	if len(bc.futureBlocks) != 0 {
+		t.Errorf("block with a future uncle was queued")
+	}
+}
@@ -0,0 +1,229 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/state"
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+const (
+	// DefaultFreezerInterval is the default interval between background freezer rounds
+	// DefaultFreezerInterval 后台冻结任务的默认间隔
+	DefaultFreezerInterval = time.Minute
+)
+
+var (
+	// ErrGenesisMismatch is returned when the stored chain has a different genesis block
+	// ErrGenesisMismatch 存储的链与创世区块不一致
+	ErrGenesisMismatch = errors.New("stored chain has a different genesis block")
+)
+
+// DatabaseConfig configures the persistent storage of a node's blockchain
+// DatabaseConfig 节点区块链的持久化存储配置
+type DatabaseConfig struct {
+	// FreezerThreshold moves canonical blocks deeper than the threshold into the freezer, 0 disables the freezer
+	// FreezerThreshold 将深度超过阈值的规范链区块移入冻结库，0 表示不启用冻结库
+	FreezerThreshold uint64 `json:"freezerThreshold"`
+
+	// Pruning configures history pruning, zero retentions keep the full history
+	// Pruning 历史裁剪配置，保留数量为 0 时保留完整历史
+	Pruning PruneConfig `json:"pruning"`
+
+	// TxIndex configures the transaction lookup index
+	// TxIndex 交易查找索引配置
+	TxIndex TxIndexConfig `json:"txIndex"`
+}
+
+// DefaultDatabaseConfig returns the default database configuration of an archive node
+// DefaultDatabaseConfig 获取归档节点的默认数据库配置
+func DefaultDatabaseConfig() DatabaseConfig {
+	pruning := DefaultPruneConfig()
+	pruning.BlockRetention = 0
+	pruning.StateRetention = 0
+	return DatabaseConfig{
+		FreezerThreshold: DefaultFreezerThreshold,
+		Pruning:          pruning,
+		TxIndex:          DefaultTxIndexConfig(),
+	}
+}
+
+// OpenBlockchain opens the chain data under dataDir, restores the stored chain and starts the freezer,
+// pruner and transaction indexer; the caller must Close the returned blockchain
+// OpenBlockchain 打开 dataDir 下的链数据，恢复已存储的链并启动冻结、裁剪和交易索引任务，调用者需关闭返回的区块链
+func OpenBlockchain(dataDir string, genesis *types.Block, config DatabaseConfig) (*Blockchain, error) {
+	chainDir := filepath.Join(dataDir, storage.ChainDataDir)
+	if err := os.MkdirAll(chainDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create chain data directory: %v", err)
+	}
+
+	db, err := storage.OpenOptimizedStorage(chainDir, storage.ChainDatabaseConfig())
+	if err != nil {
+		return nil, err
+	}
+	bc, err := NewBlockchainWithStorage(genesis, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := bc.openServices(chainDir, config); err != nil {
+		bc.Close()
+		return nil, err
+	}
+	return bc, nil
+}
+
+// openServices attaches the freezer, pruning and transaction index of config and starts their workers
+// openServices 根据配置设置冻结库、裁剪和交易索引并启动后台任务
+func (bc *Blockchain) openServices(chainDir string, config DatabaseConfig) error {
+	if config.FreezerThreshold > 0 {
+		ancients, err := freezer.New(filepath.Join(chainDir, storage.AncientDir), freezer.Options{Compress: true})
+		if err != nil {
+			return fmt.Errorf("failed to open freezer: %v", err)
+		}
+		if err := bc.SetFreezer(ancients, config.FreezerThreshold); err != nil {
+			ancients.Close()
+			return err
+		}
+		bc.StartFreezer(DefaultFreezerInterval)
+	}
+
+	if err := bc.SetPruning(config.Pruning); err != nil {
+		return err
+	}
+	bc.StartPruner()
+
+	if err := bc.SetTxIndexing(config.TxIndex); err != nil {
+		return err
+	}
+	bc.StartTxIndexer()
+	return nil
+}
+
+// loadChain restores the canonical blocks and the head state from the database
+// Frozen blocks are not in the database and are served from the freezer once it is attached
+// loadChain 从数据库恢复规范链区块和头部状态，已冻结的区块不在数据库中，设置冻结库后从冻结库读取
+func (bc *Blockchain) loadChain() error {
+	value, exists := bc.db.Get(storage.CanonicalHashKey(0))
+	if !exists {
+		return fmt.Errorf("%v: genesis mapping is missing", ErrGenesisMismatch)
+	}
+	if hash, err := decodeHash(value); err != nil || hash != bc.genesis.Hash() {
+		return fmt.Errorf("%v: stored %x, expected %x", ErrGenesisMismatch, hash, bc.genesis.Hash())
+	}
+
+	value, _ = bc.db.Get(storage.HeadBlockKey)
+	headHash, err := decodeHash(value)
+	if err != nil {
+		return fmt.Errorf("failed to read head block: %v", err)
+	}
+	head, err := bc.readBlock(headHash)
+	if err != nil {
+		return fmt.Errorf("failed to read head block %x: %v", headHash, err)
+	}
+
+	// Walk the canonical mappings down from the head until the frozen blocks or genesis
+	// 从头部沿规范链映射向下遍历，直到已冻结的区块或创世区块
+	blocks := []*types.Block{head}
+	for number := head.NumberU64(); number > 1; number-- {
+		value, exists := bc.db.Get(storage.CanonicalHashKey(number - 1))
+		if !exists {
+			break
+		}
+		hash, err := decodeHash(value)
+		if err != nil {
+			return fmt.Errorf("failed to read canonical hash %d: %v", number-1, err)
+		}
+		block, err := bc.readBlock(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read block %d: %v", number-1, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	stateDB := state.NewMemoryStateDB()
+	if value, exists := bc.db.Get(storage.StateKey(head.Header.Root)); exists {
+		dump := new(state.Dump)
+		if err := decodeStateDump(value, dump); err != nil {
+			return fmt.Errorf("failed to read head state: %v", err)
+		}
+		restoreState(stateDB, dump)
+	} else if head.NumberU64() > 0 {
+		log.Warn().Uint64("number", head.NumberU64()).Str("root", head.Header.Root.Hex()).Msg("Head state is missing")
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	for _, block := range blocks {
+		bc.blocks[block.Hash()] = block
+		bc.blockNumber[block.NumberU64()] = block.Hash()
+	}
+	bc.currentHead = head
+	bc.stateDB = stateDB
+
+	log.Info().Uint64("number", head.NumberU64()).Str("hash", headHash.Hex()).Int("blocks", len(blocks)).Msg("Loaded stored chain")
+	return nil
+}
+
+// readBlock reads a block from the database, a pruned body is restored as an empty body
+// readBlock 从数据库读取区块，已裁剪的区块体恢复为空区块体
+func (bc *Blockchain) readBlock(hash common.Hash) (*types.Block, error) {
+	if hash == bc.genesis.Hash() {
+		return bc.genesis, nil
+	}
+
+	value, exists := bc.db.Get(storage.HeaderKey(hash))
+	if !exists {
+		return nil, errMissing
+	}
+	header := new(types.BlockHeader)
+	if err := decodeStored(value, header); err != nil {
+		return nil, err
+	}
+
+	body := new(types.Body)
+	if value, exists := bc.db.Get(storage.BodyKey(hash)); exists {
+		if err := decodeStored(value, body); err != nil {
+			return nil, err
+		}
+	}
+	return types.NewBlockWithHeader(header, body), nil
+}
+
+// decodeStateDump converts a stored state dump
+// decodeStateDump 转换存储的状态导出
+func decodeStateDump(value interface{}, dump *state.Dump) error {
+	if stored, ok := value.(*state.Dump); ok {
+		*dump = *stored
+		return nil
+	}
+	return decodeStored(value, dump)
+}
+
+// restoreState writes the accounts of a state dump into an empty state database
+// restoreState 将状态导出中的账户写入空的状态数据库
+func restoreState(stateDB *state.MemoryStateDB, dump *state.Dump) {
+	for addr, account := range dump.Accounts {
+		stateDB.CreateAccount(addr)
+		if account.Balance != nil {
+			stateDB.AddBalance(addr, account.Balance)
+		}
+		stateDB.SetNonce(addr, account.Nonce)
+		if len(account.Code) > 0 {
+			stateDB.SetCode(addr, account.Code)
+		}
+		for key, value := range account.Storage {
+			stateDB.SetState(addr, key, value)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+)
+
+// 测试重新打开数据目录后恢复头部、规范链和交易索引，并能继续扩展链
+func TestOpenBlockchainRestart(t *testing.T) {
+	dataDir := t.TempDir()
+	config := DefaultDatabaseConfig()
+
+	bc, err := OpenBlockchain(dataDir, nil, config)
+	if err != nil {
+		t.Fatalf("OpenBlockchain returned error: %v", err)
+	}
+	tx := newTestTx(1)
+	canonical := []*types.Block{bc.Genesis()}
+	for i := 1; i <= 3; i++ {
+		var txs []*types.Transaction
+		if i == 2 {
+			txs = append(txs, tx)
+		}
+		block := newTxBlock(canonical[i-1], "main", txs...)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+		canonical = append(canonical, block)
+	}
+	if err := bc.AddBlock(newTxBlock(canonical[1], "side")); err != nil {
+		t.Fatalf("failed to add side block: %v", err)
+	}
+	if err := bc.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	bc, err = OpenBlockchain(dataDir, nil, config)
+	if err != nil {
+		t.Fatalf("reopen returned error: %v", err)
+	}
+	defer bc.Close()
+
+	if head := bc.CurrentHead(); head.Hash() != canonical[3].Hash() {
+		t.Fatalf("head after restart: got #%d %x, want #3 %x", head.NumberU64(), head.Hash(), canonical[3].Hash())
+	}
+	for number, block := range canonical {
+		if got := bc.GetBlockByNumber(uint64(number)); got == nil || got.Hash() != block.Hash() {
+			t.Errorf("block %d not restored", number)
+		}
+	}
+	if lookup := bc.GetTransaction(tx.Hash()); lookup == nil || lookup.BlockHash != canonical[2].Hash() {
+		t.Errorf("transaction lookup not restored: %+v", lookup)
+	}
+
+	block := newTxBlock(canonical[3], "main")
+	if err := bc.AddBlock(block); err != nil {
+		t.Fatalf("failed to extend restored chain: %v", err)
+	}
+	if bc.CurrentHead().Hash() != block.Hash() {
+		t.Errorf("restored chain did not advance")
+	}
+}
+
+// 测试数据目录属于其他创世区块时拒绝打开
+func TestOpenBlockchainGenesisMismatch(t *testing.T) {
+	dataDir := t.TempDir()
+
+	bc, err := OpenBlockchain(dataDir, nil, DefaultDatabaseConfig())
+	if err != nil {
+		t.Fatalf("OpenBlockchain returned error: %v", err)
+	}
+	bc.Close()
+
+	genesis := types.NewBlockWithHeader(&types.BlockHeader{
+		ParentHash: common.Hash{0x01},
+		Number:     bc.Genesis().Header.Number,
+		Difficulty: bc.Genesis().Header.Difficulty,
+	}, &types.Body{})
+	if _, err := OpenBlockchain(dataDir, genesis, DefaultDatabaseConfig()); err == nil {
+		t.Fatalf("chain with a different genesis opened")
+	}
+}
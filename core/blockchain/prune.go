@@ -0,0 +1,296 @@
+package blockchain
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/storage"
+	"nogochain/core/types"
+	"nogochain/metrics"
+)
+
+// PruneConfig configures history pruning for non-archive nodes
+// PruneConfig 非归档节点的历史裁剪配置
+type PruneConfig struct {
+	// BlockRetention keeps bodies and receipts of the latest N blocks, 0 disables body pruning
+	// BlockRetention 保留最近 N 个区块的区块体和收据，0 表示不裁剪区块体
+	BlockRetention uint64 `json:"blockRetention"`
+
+	// StateRetention keeps the states of the latest N blocks, 0 disables state pruning
+	// StateRetention 保留最近 N 个区块的状态，0 表示不裁剪状态
+	StateRetention uint64 `json:"stateRetention"`
+
+	// Interval between background pruning rounds
+	// Interval 后台裁剪间隔
+	Interval time.Duration `json:"interval"`
+
+	// BatchLimit is the maximum number of bodies or states deleted per round
+	// BatchLimit 每轮最多删除的区块体或状态数量
+	BatchLimit int `json:"batchLimit"`
+}
+
+// DefaultPruneConfig returns the default pruning configuration
+// DefaultPruneConfig 获取默认裁剪配置
+func DefaultPruneConfig() PruneConfig {
+	return PruneConfig{
+		BlockRetention: 90000,
+		StateRetention: 128,
+		Interval:       time.Minute,
+		BatchLimit:     10000,
+	}
+}
+
+// PruneResult is the outcome of a pruning round
+// PruneResult 一轮裁剪的结果
+type PruneResult struct {
+	Bodies    int
+	States    int
+	BodyTail  uint64
+	StateTail uint64
+}
+
+// keyLister is a storage that can enumerate keys
+// keyLister 可以遍历键的存储
+type keyLister interface {
+	Keys(prefix string) []string
+}
+
+// SetPruning enables pruning with the given configuration and loads the persisted prune tails
+// SetPruning 启用裁剪并加载已持久化的裁剪位置
+func (bc *Blockchain) SetPruning(config PruneConfig) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.db == nil {
+		return ErrNoDatabase
+	}
+	if config.BatchLimit <= 0 {
+		config.BatchLimit = DefaultPruneConfig().BatchLimit
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultPruneConfig().Interval
+	}
+
+	bc.pruneConfig = config
+	bc.bodyTail = readTail(bc.db, storage.BodyTailKey)
+	bc.stateTail = readTail(bc.db, storage.StateTailKey)
+	metrics.PruneTail.Set(float64(bc.bodyTail))
+	return nil
+}
+
+// StartPruner starts the background pruning worker
+// StartPruner 启动后台裁剪任务
+func (bc *Blockchain) StartPruner() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.db == nil || bc.pruneStop != nil {
+		return
+	}
+	if bc.pruneConfig.BlockRetention == 0 && bc.pruneConfig.StateRetention == 0 {
+		return
+	}
+
+	bc.pruneStop = make(chan struct{})
+	bc.pruneWg.Add(1)
+	go bc.pruneLoop(bc.pruneConfig.Interval, bc.pruneStop)
+}
+
+// StopPruner stops the background pruning worker
+// StopPruner 停止后台裁剪任务
+func (bc *Blockchain) StopPruner() {
+	bc.mu.Lock()
+	stop := bc.pruneStop
+	bc.pruneStop = nil
+	bc.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		bc.pruneWg.Wait()
+	}
+}
+
+// pruneLoop periodically prunes old history
+// pruneLoop 定期裁剪旧历史
+func (bc *Blockchain) pruneLoop(interval time.Duration, stop chan struct{}) {
+	defer bc.pruneWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			result, err := bc.Prune()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to prune history")
+				continue
+			}
+			if result.Bodies > 0 || result.States > 0 {
+				log.Info().Int("bodies", result.Bodies).Int("states", result.States).
+					Uint64("bodyTail", result.BodyTail).Uint64("stateTail", result.StateTail).Msg("Pruned history")
+			}
+		}
+	}
+}
+
+// Prune runs one incremental pruning round
+// Prune 执行一轮增量裁剪
+func (bc *Blockchain) Prune() (*PruneResult, error) {
+	start := time.Now()
+	defer func() {
+		metrics.PruneDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	bodies, err := bc.pruneBodies()
+	if err != nil {
+		return nil, err
+	}
+	states, err := bc.pruneStates()
+	if err != nil {
+		return nil, err
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return &PruneResult{
+		Bodies:    bodies,
+		States:    states,
+		BodyTail:  bc.bodyTail,
+		StateTail: bc.stateTail,
+	}, nil
+}
+
+// pruneBodies deletes bodies and receipts of blocks older than the retention, keeping headers
+// Blocks already in the freezer are skipped, their history is kept in the ancient store
+// pruneBodies 删除超出保留范围的区块体和收据，保留区块头
+// 已冻结的区块不在数据库中，跳过
+func (bc *Blockchain) pruneBodies() (int, error) {
+	bc.mu.RLock()
+	db := bc.db
+	retention := bc.pruneConfig.BlockRetention
+	limit := bc.pruneConfig.BatchLimit
+	head := bc.currentHead.NumberU64()
+	tail := bc.bodyTail
+	if bc.ancients != nil && bc.ancients.Ancients() > tail {
+		tail = bc.ancients.Ancients()
+	}
+	if tail == 0 {
+		// The genesis block is never pruned
+		// 创世区块不裁剪
+		tail = 1
+	}
+
+	if db == nil || retention == 0 || head < retention || tail > head-retention {
+		bc.mu.RUnlock()
+		return 0, nil
+	}
+
+	pruned := make([]common.Hash, 0)
+	number := tail
+	for ; number <= head-retention && len(pruned) < limit; number++ {
+		if hash, exists := bc.blockNumber[number]; exists {
+			pruned = append(pruned, hash)
+		}
+	}
+	bc.mu.RUnlock()
+
+	batch := storage.NewBatch()
+	for _, hash := range pruned {
+		batch.Delete(storage.BodyKey(hash))
+		batch.Delete(storage.ReceiptsKey(hash))
+	}
+	batch.Set(storage.BodyTailKey, number)
+	if err := db.Write(batch); err != nil {
+		return 0, err
+	}
+
+	// Keep only the headers of pruned blocks in memory
+	// 内存中只保留已裁剪区块的区块头
+	bc.mu.Lock()
+	for _, hash := range pruned {
+		if block, exists := bc.blocks[hash]; exists {
+			bc.blocks[hash] = types.NewBlockWithHeader(block.Header, &types.Body{})
+		}
+	}
+	bc.bodyTail = number
+	bc.mu.Unlock()
+
+	metrics.PrunedBlocks.Add(float64(len(pruned)))
+	metrics.PruneTail.Set(float64(number))
+	return len(pruned), nil
+}
+
+// pruneStates deletes states that are not the state of one of the latest blocks
+// Block commits are paused while the retained roots are computed and the deletions written,
+// so the state of a block committed meanwhile cannot be deleted
+// pruneStates 删除不属于最近区块的状态
+// 计算保留的状态根和写入删除期间暂停区块提交，避免删除新提交区块的状态
+func (bc *Blockchain) pruneStates() (int, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	retention := bc.pruneConfig.StateRetention
+	lister, ok := bc.db.(keyLister)
+	if bc.db == nil || retention == 0 || !ok {
+		return 0, nil
+	}
+
+	head := bc.currentHead.NumberU64()
+	stateTail := uint64(0)
+	if head+1 > retention {
+		stateTail = head + 1 - retention
+	}
+
+	// The genesis state is always kept as the last resort for db repair
+	// 始终保留创世状态，作为数据库修复的最后回退点
+	keep := make(map[common.Hash]bool)
+	keep[bc.genesis.Header.Root] = true
+	for number := stateTail; number <= head; number++ {
+		if block := bc.blocks[bc.blockNumber[number]]; block != nil {
+			keep[block.Header.Root] = true
+		}
+	}
+
+	batch := storage.NewBatch()
+	pruned := 0
+	for _, key := range lister.Keys(storage.StateKeyPrefix) {
+		if pruned >= bc.pruneConfig.BatchLimit {
+			break
+		}
+		root, ok := storage.StateRootFromKey(key)
+		if !ok || keep[root] {
+			continue
+		}
+		batch.Delete(key)
+		pruned++
+	}
+	if pruned == 0 && stateTail == bc.stateTail {
+		return 0, nil
+	}
+
+	batch.Set(storage.StateTailKey, stateTail)
+	if err := bc.db.Write(batch); err != nil {
+		return 0, err
+	}
+	bc.stateTail = stateTail
+
+	metrics.PrunedStates.Add(float64(pruned))
+	return pruned, nil
+}
+
+// readTail reads a persisted prune tail
+// readTail 读取已持久化的裁剪位置
+func readTail(db storage.Storage, key string) uint64 {
+	value, exists := db.Get(key)
+	if !exists {
+		return 0
+	}
+	tail, _ := value.(uint64)
+	return tail
+}
@@ -0,0 +1,98 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/storage"
+	"nogochain/core/types"
+)
+
+// 测试裁剪旧区块体和状态后保留区块头，数据库仍通过校验
+func TestPrune(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+
+	parent := bc.Genesis()
+	blocks := []*types.Block{parent}
+	memState := bc.StateDB().(*state.MemoryStateDB)
+	for i := 1; i <= 10; i++ {
+		// 每个区块改变状态，使状态根互不相同
+		memState.AddBalance(common.Address{0x01}, big.NewInt(1))
+		block := types.NewBlock(
+			parent.Hash(),
+			common.Address{0x01},
+			memState.CalculateStateRoot(),
+			common.Hash{},
+			common.Hash{},
+			big.NewInt(1000000),
+			big.NewInt(int64(i)),
+			10000000,
+			0,
+			parent.Header.Time+10,
+			[]byte("Prune Block"),
+			common.Hash{},
+			uint64(i),
+			[]*types.Transaction{},
+			[]*types.BlockHeader{},
+		)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block
+	}
+
+	if err := bc.SetPruning(PruneConfig{BlockRetention: 3, StateRetention: 2, BatchLimit: 4}); err != nil {
+		t.Fatalf("SetPruning returned error: %v", err)
+	}
+
+	// 每轮最多裁剪 4 项，两轮完成
+	bodies, states := 0, 0
+	for round := 0; round < 3; round++ {
+		result, err := bc.Prune()
+		if err != nil {
+			t.Fatalf("Prune returned error: %v", err)
+		}
+		bodies += result.Bodies
+		states += result.States
+	}
+	if bodies != 7 || states != 8 {
+		t.Fatalf("expected 7 pruned bodies and 8 pruned states, got %d and %d", bodies, states)
+	}
+
+	for _, block := range blocks {
+		number := block.NumberU64()
+		_, hasBody := db.Get(storage.BodyKey(block.Hash()))
+		if wantBody := number == 0 || number > 7; hasBody != wantBody {
+			t.Errorf("block %d: body stored %v, want %v", number, hasBody, wantBody)
+		}
+		if _, exists := db.Get(storage.HeaderKey(block.Hash())); !exists {
+			t.Errorf("block %d: header was pruned", number)
+		}
+		_, hasState := db.Get(storage.StateKey(block.Header.Root))
+		if wantState := number == 0 || number > 8; hasState != wantState {
+			t.Errorf("block %d: state stored %v, want %v", number, hasState, wantState)
+		}
+	}
+	if pruned := bc.GetBlock(blocks[2].Hash()); pruned == nil || pruned.Header.Number.Uint64() != 2 {
+		t.Errorf("header of pruned block not readable")
+	}
+
+	report, err := VerifyDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	if !report.Consistent() || len(report.Issues) != 0 || report.LastGood != 10 {
+		t.Errorf("pruned database should verify cleanly, got last good %d issues %v", report.LastGood, report.Issues)
+	}
+}
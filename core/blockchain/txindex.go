@@ -0,0 +1,351 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+// TxIndexConfig configures the transaction lookup index
+// TxIndexConfig 交易查找索引配置
+type TxIndexConfig struct {
+	// Limit indexes the transactions of the latest N blocks only, 0 indexes the whole chain
+	// Limit 只索引最近 N 个区块的交易，0 表示索引整条链
+	Limit uint64 `json:"limit"`
+
+	// Interval between background indexing rounds
+	// Interval 后台索引间隔
+	Interval time.Duration `json:"interval"`
+
+	// BatchLimit is the maximum number of blocks indexed or unindexed per round
+	// BatchLimit 每轮最多索引或删除索引的区块数量
+	BatchLimit int `json:"batchLimit"`
+}
+
+// DefaultTxIndexConfig returns the default transaction index configuration
+// DefaultTxIndexConfig 获取默认交易索引配置
+func DefaultTxIndexConfig() TxIndexConfig {
+	return TxIndexConfig{
+		Limit:      0,
+		Interval:   10 * time.Second,
+		BatchLimit: 1000,
+	}
+}
+
+// TxIndexResult is the outcome of an indexing round
+// TxIndexResult 一轮索引的结果
+type TxIndexResult struct {
+	Indexed   int
+	Unindexed int
+	Tail      uint64
+}
+
+// TxLookup is the position of a transaction in the canonical chain
+// TxLookup 交易在规范链中的位置
+type TxLookup struct {
+	Tx          *types.Transaction
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Index       uint64
+}
+
+// SetTxIndexing configures the transaction index and loads the persisted index tail
+// An existing chain without an index starts with an empty index that the background indexer fills from the head down
+// SetTxIndexing 配置交易索引并加载已持久化的索引位置
+// 没有索引的已有链从空索引开始，由后台索引任务从头部向下补全
+func (bc *Blockchain) SetTxIndexing(config TxIndexConfig) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.db == nil {
+		return ErrNoDatabase
+	}
+	if config.BatchLimit <= 0 {
+		config.BatchLimit = DefaultTxIndexConfig().BatchLimit
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultTxIndexConfig().Interval
+	}
+	bc.txIndexConfig = config
+
+	if _, exists := bc.db.Get(storage.TxIndexTailKey); exists {
+		bc.txIndexTail = readTail(bc.db, storage.TxIndexTailKey)
+		return nil
+	}
+
+	// Blocks committed from now on are indexed on insertion
+	// 此后提交的区块在插入时建立索引
+	tail := bc.currentHead.NumberU64() + 1
+	if bc.currentHead.NumberU64() == 0 {
+		tail = 0
+	}
+	batch := storage.NewBatch()
+	batch.Set(storage.TxIndexTailKey, tail)
+	if err := bc.db.Write(batch); err != nil {
+		return err
+	}
+	bc.txIndexTail = tail
+	return nil
+}
+
+// StartTxIndexer starts the background worker that builds and trims the transaction index
+// StartTxIndexer 启动后台交易索引任务
+func (bc *Blockchain) StartTxIndexer() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.db == nil || bc.txIndexStop != nil {
+		return
+	}
+
+	bc.txIndexStop = make(chan struct{})
+	bc.txIndexWg.Add(1)
+	go bc.txIndexLoop(bc.txIndexConfig.Interval, bc.txIndexStop)
+}
+
+// StopTxIndexer stops the background transaction indexer
+// StopTxIndexer 停止后台交易索引任务
+func (bc *Blockchain) StopTxIndexer() {
+	bc.mu.Lock()
+	stop := bc.txIndexStop
+	bc.txIndexStop = nil
+	bc.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		bc.txIndexWg.Wait()
+	}
+}
+
+// txIndexLoop periodically moves the index tail towards the configured limit
+// txIndexLoop 定期将索引位置移动到配置的范围
+func (bc *Blockchain) txIndexLoop(interval time.Duration, stop chan struct{}) {
+	defer bc.txIndexWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			result, err := bc.IndexTransactions()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to index transactions")
+				continue
+			}
+			if result.Indexed > 0 || result.Unindexed > 0 {
+				log.Info().Int("indexed", result.Indexed).Int("unindexed", result.Unindexed).
+					Uint64("tail", result.Tail).Msg("Updated transaction index")
+			}
+		}
+	}
+}
+
+// IndexTransactions runs one indexing round: blocks below the tail are indexed until the tail
+// reaches the limit, and blocks that fell out of the limit are unindexed
+// Block commits are paused during the round, so a reorg cannot interleave with the written entries
+// IndexTransactions 执行一轮索引：索引位置以下的区块逐步建立索引直到达到范围下限，超出范围的区块删除索引
+// 索引期间暂停区块提交，避免与重组交错写入
+func (bc *Blockchain) IndexTransactions() (*TxIndexResult, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if bc.db == nil {
+		return nil, ErrNoDatabase
+	}
+
+	head := bc.currentHead.NumberU64()
+	target := uint64(0)
+	if limit := bc.txIndexConfig.Limit; limit > 0 && head+1 > limit {
+		target = head + 1 - limit
+	}
+
+	result := &TxIndexResult{Tail: bc.txIndexTail}
+	tail := bc.txIndexTail
+	batch := storage.NewBatch()
+
+	switch {
+	case tail > target:
+		// Index downwards from the tail, so the index always covers a contiguous range up to the head
+		// 从索引位置向下建立索引，使索引始终覆盖到头部的连续区间
+		for tail > target && result.Indexed < bc.txIndexConfig.BatchLimit {
+			block := bc.canonicalBlock(tail - 1)
+			if block == nil {
+				break
+			}
+			for _, tx := range block.Transactions {
+				batch.Set(storage.TxLookupKey(tx.Hash()), block.Hash())
+			}
+			tail--
+			result.Indexed++
+		}
+	case tail < target:
+		for tail < target && result.Unindexed < bc.txIndexConfig.BatchLimit {
+			if block := bc.canonicalBlock(tail); block != nil {
+				for _, tx := range block.Transactions {
+					batch.Delete(storage.TxLookupKey(tx.Hash()))
+				}
+			}
+			tail++
+			result.Unindexed++
+		}
+	}
+	if tail == bc.txIndexTail {
+		return result, nil
+	}
+
+	batch.Set(storage.TxIndexTailKey, tail)
+	if err := bc.db.Write(batch); err != nil {
+		return nil, err
+	}
+	bc.txIndexTail = tail
+	result.Tail = tail
+	return result, nil
+}
+
+// writeTxLookups indexes the transactions of a branch that becomes canonical, from the first block after
+// the common ancestor up to the new head, and removes the entries of the canonical blocks it displaces
+// It must be called before the number index is updated to the new branch
+// writeTxLookups 为成为规范链的分支建立交易索引（从共同祖先之后的第一个区块到新头部），并删除被替换的规范链区块的索引
+// 需要在区块号索引更新为新分支之前调用
+func (bc *Blockchain) writeTxLookups(batch *storage.Batch, branch []*types.Block) {
+	if len(branch) == 0 {
+		return
+	}
+	head := branch[len(branch)-1].NumberU64()
+	indexed := func(number uint64) bool {
+		return bc.db == nil || bc.txIndexConfig.Limit == 0 || number+bc.txIndexConfig.Limit > head
+	}
+
+	included := make(map[common.Hash]common.Hash)
+	for _, block := range branch {
+		if !indexed(block.NumberU64()) {
+			continue
+		}
+		for _, tx := range block.Transactions {
+			included[tx.Hash()] = block.Hash()
+		}
+	}
+
+	// Unindex the old branch down to the common ancestor
+	// 删除旧分支直到共同祖先的索引
+	for number := branch[0].NumberU64(); number <= bc.currentHead.NumberU64(); number++ {
+		displaced := bc.blocks[bc.blockNumber[number]]
+		if displaced == nil {
+			continue
+		}
+		for _, tx := range displaced.Transactions {
+			if hash := tx.Hash(); included[hash] == (common.Hash{}) {
+				if bc.db != nil {
+					batch.Delete(storage.TxLookupKey(hash))
+				} else {
+					delete(bc.txLookups, hash)
+				}
+			}
+		}
+	}
+
+	for hash, blockHash := range included {
+		if bc.db != nil {
+			batch.Set(storage.TxLookupKey(hash), blockHash)
+		} else {
+			bc.txLookups[hash] = blockHash
+		}
+	}
+}
+
+// GetTransaction looks up a canonical transaction by hash
+// Returns nil if the transaction is unknown, outside the indexed range or its block body was pruned
+// GetTransaction 通过哈希查找规范链上的交易
+// 交易未知、不在索引范围内或所在区块体已被裁剪时返回 nil
+func (bc *Blockchain) GetTransaction(hash common.Hash) *TxLookup {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var blockHash common.Hash
+	if bc.db != nil {
+		value, exists := bc.db.Get(storage.TxLookupKey(hash))
+		if !exists {
+			return nil
+		}
+		decoded, err := decodeHash(value)
+		if err != nil {
+			return nil
+		}
+		blockHash = decoded
+	} else {
+		found, exists := bc.txLookups[hash]
+		if !exists {
+			return nil
+		}
+		blockHash = found
+	}
+
+	// Entries left behind by an interrupted reorg or repair must point to a canonical block
+	// 中断的重组或修复可能留下旧索引，所在区块必须在规范链上
+	var block *types.Block
+	if number, frozen := bc.frozenHashes[blockHash]; frozen {
+		block = bc.readAncientBlock(number)
+	} else if found := bc.blocks[blockHash]; found != nil && bc.blockNumber[found.NumberU64()] == blockHash {
+		block = found
+	}
+	if block == nil {
+		return nil
+	}
+
+	for i, tx := range block.Transactions {
+		if tx.Hash() == hash {
+			return &TxLookup{Tx: tx, BlockHash: blockHash, BlockNumber: block.NumberU64(), Index: uint64(i)}
+		}
+	}
+	return nil
+}
+
+// GetReceipts retrieves the stored receipts of a block from the database or the freezer
+// GetReceipts 从数据库或冻结库读取区块的收据
+func (bc *Blockchain) GetReceipts(hash common.Hash) types.Receipts {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if number, frozen := bc.frozenHashes[hash]; frozen {
+		data, err := bc.ancients.Ancient(freezer.ReceiptTable, number)
+		if err != nil || len(data) == 0 {
+			return nil
+		}
+		var receipts types.Receipts
+		if err := json.Unmarshal(data, &receipts); err != nil {
+			return nil
+		}
+		return receipts
+	}
+
+	if bc.db == nil {
+		return nil
+	}
+	value, exists := bc.db.Get(storage.ReceiptsKey(hash))
+	if !exists {
+		return nil
+	}
+	var receipts types.Receipts
+	if err := decodeStored(value, &receipts); err != nil {
+		return nil
+	}
+	return receipts
+}
+
+// canonicalBlock returns the canonical block with the given number, the caller must hold bc.mu
+// canonicalBlock 获取指定高度的规范链区块，调用者需持有 bc.mu
+func (bc *Blockchain) canonicalBlock(number uint64) *types.Block {
+	if hash, exists := bc.blockNumber[number]; exists {
+		return bc.blocks[hash]
+	}
+	return bc.readAncientBlock(number)
+}
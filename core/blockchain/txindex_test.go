@@ -0,0 +1,185 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/storage"
+	"nogochain/core/types"
+)
+
+// newTxBlock 创建包含指定交易的子区块
+func newTxBlock(parent *types.Block, extra string, txs ...*types.Transaction) *types.Block {
+	return types.NewBlock(
+		parent.Hash(),
+		common.Address{0x01},
+		common.Hash{},
+		common.Hash{},
+		common.Hash{},
+		big.NewInt(1000000),
+		new(big.Int).Add(parent.Header.Number, big.NewInt(1)),
+		10000000,
+		0,
+		parent.Header.Time+10,
+		[]byte(extra),
+		common.Hash{},
+		0,
+		txs,
+		[]*types.BlockHeader{},
+	)
+}
+
+// newTestTx 创建测试交易，nonce 区分不同交易
+func newTestTx(nonce uint64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+}
+
+// 测试侧链区块不改变交易索引，重组时删除旧分支的索引并为新分支建立索引
+func TestTxLookupReorg(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+
+	txA, txB, txC, txD := newTestTx(1), newTestTx(2), newTestTx(3), newTestTx(4)
+	a1 := newTxBlock(bc.Genesis(), "A", txA, txB)
+	a2 := newTxBlock(a1, "A", txD)
+	for _, block := range []*types.Block{a1, a2} {
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+	}
+
+	lookup := bc.GetTransaction(txB.Hash())
+	if lookup == nil || lookup.BlockHash != a1.Hash() || lookup.BlockNumber != 1 || lookup.Index != 1 {
+		t.Fatalf("unexpected lookup for indexed transaction: %+v", lookup)
+	}
+
+	// 侧链区块不改变规范链交易的索引
+	b1 := newTxBlock(bc.Genesis(), "B", txC, txB)
+	if err := bc.AddBlock(b1); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+	if lookup := bc.GetTransaction(txA.Hash()); lookup == nil || lookup.BlockHash != a1.Hash() {
+		t.Errorf("side block changed the lookup of a canonical transaction: %+v", lookup)
+	}
+	if lookup := bc.GetTransaction(txB.Hash()); lookup == nil || lookup.BlockHash != a1.Hash() {
+		t.Errorf("side block changed the lookup of a shared transaction: %+v", lookup)
+	}
+	if _, exists := db.Get(storage.TxLookupKey(txC.Hash())); exists {
+		t.Errorf("transaction of side block indexed")
+	}
+
+	// 更长的 B 分支成为规范链
+	b2 := newTxBlock(b1, "B")
+	b3 := newTxBlock(b2, "B")
+	for _, block := range []*types.Block{b2, b3} {
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+	}
+
+	for _, tx := range []*types.Transaction{txA, txD} {
+		if lookup := bc.GetTransaction(tx.Hash()); lookup != nil {
+			t.Errorf("transaction of displaced block still indexed: %+v", lookup)
+		}
+		if _, exists := db.Get(storage.TxLookupKey(tx.Hash())); exists {
+			t.Errorf("lookup entry of displaced block not deleted")
+		}
+	}
+	if lookup := bc.GetTransaction(txB.Hash()); lookup == nil || lookup.BlockHash != b1.Hash() || lookup.Index != 1 {
+		t.Errorf("transaction in both branches should point to the new block: %+v", lookup)
+	}
+	if lookup := bc.GetTransaction(txC.Hash()); lookup == nil || lookup.Index != 0 {
+		t.Errorf("transaction of new branch not indexed: %+v", lookup)
+	}
+}
+
+// 测试内存区块链同样维护交易索引
+func TestTxLookupMemory(t *testing.T) {
+	bc := NewBlockchain(nil)
+	tx := newTestTx(1)
+	block := newTxBlock(bc.Genesis(), "A", tx)
+	if err := bc.AddBlock(block); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+	if lookup := bc.GetTransaction(tx.Hash()); lookup == nil || lookup.BlockHash != block.Hash() {
+		t.Errorf("unexpected lookup: %+v", lookup)
+	}
+	if lookup := bc.GetTransaction(newTestTx(2).Hash()); lookup != nil {
+		t.Errorf("unknown transaction should not be found: %+v", lookup)
+	}
+}
+
+// 测试后台索引按范围删除旧索引，取消范围后重新建立索引
+func TestIndexTransactions(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+
+	parent := bc.Genesis()
+	txs := make([]*types.Transaction, 0)
+	for i := 1; i <= 5; i++ {
+		tx := newTestTx(uint64(i))
+		block := newTxBlock(parent, "Index Block", tx)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+		txs = append(txs, tx)
+		parent = block
+	}
+
+	check := func(tail uint64) {
+		t.Helper()
+		for i, tx := range txs {
+			number := uint64(i + 1)
+			if found := bc.GetTransaction(tx.Hash()) != nil; found != (number >= tail) {
+				t.Errorf("transaction of block %d: indexed %v with tail %d", number, found, tail)
+			}
+		}
+	}
+
+	// 只保留最近 2 个区块，每轮最多处理 2 个区块
+	if err := bc.SetTxIndexing(TxIndexConfig{Limit: 2, BatchLimit: 2}); err != nil {
+		t.Fatalf("SetTxIndexing returned error: %v", err)
+	}
+	for _, want := range []uint64{2, 4, 4} {
+		result, err := bc.IndexTransactions()
+		if err != nil {
+			t.Fatalf("IndexTransactions returned error: %v", err)
+		}
+		if result.Tail != want {
+			t.Fatalf("expected tail %d, got %d", want, result.Tail)
+		}
+	}
+	check(4)
+	if tail := readTail(db, storage.TxIndexTailKey); tail != 4 {
+		t.Errorf("expected persisted tail 4, got %d", tail)
+	}
+
+	// 取消范围限制后从索引位置向下重建
+	if err := bc.SetTxIndexing(TxIndexConfig{BatchLimit: 3}); err != nil {
+		t.Fatalf("SetTxIndexing returned error: %v", err)
+	}
+	result, err := bc.IndexTransactions()
+	if err != nil {
+		t.Fatalf("IndexTransactions returned error: %v", err)
+	}
+	if result.Indexed != 3 || result.Tail != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	check(1)
+	if result, _ := bc.IndexTransactions(); result.Tail != 0 {
+		t.Errorf("expected tail 0, got %d", result.Tail)
+	}
+}
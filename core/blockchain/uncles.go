@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// recordRecentBlock indexes a block by height so recent side-chain blocks can be offered as uncles,
+// heights too old to hold uncles are dropped, the caller must hold bc.mu
+// recordRecentBlock 按高度索引区块，以便将最近的侧链区块作为叔块，
+// 过旧无法作为叔块的高度被删除，调用者需持有 bc.mu
+func (bc *Blockchain) recordRecentBlock(block *types.Block) {
+	if bc.recentBlocks == nil {
+		bc.recentBlocks = make(map[uint64][]common.Hash)
+	}
+	number := block.NumberU64()
+	bc.recentBlocks[number] = append(bc.recentBlocks[number], block.Hash())
+
+	head := bc.currentHead.NumberU64()
+	for n := range bc.recentBlocks {
+		if n+params.MaxUncleDepth < head {
+			delete(bc.recentBlocks, n)
+		}
+	}
+}
+
+// SideBlocks returns the recent blocks that are not ancestors of the current head, newest first
+// These are the uncle candidates for a block on top of the head
+// SideBlocks 获取不是当前头部祖先的最近区块，较新的区块在前
+// 这些区块是在头部之上构建区块时的叔块候选
+func (bc *Blockchain) SideBlocks() []*types.Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	head := bc.currentHead
+	canonical := make(map[common.Hash]bool)
+	for block := head; block != nil && block.NumberU64()+params.MaxUncleDepth >= head.NumberU64(); block = bc.blocks[block.ParentHash()] {
+		canonical[block.Hash()] = true
+		if block.NumberU64() == 0 {
+			break
+		}
+	}
+
+	var side []*types.Block
+	for number := head.NumberU64(); ; number-- {
+		for _, hash := range bc.recentBlocks[number] {
+			if block := bc.blocks[hash]; block != nil && !canonical[hash] {
+				side = append(side, block)
+			}
+		}
+		if number == 0 || number+params.MaxUncleDepth <= head.NumberU64() {
+			break
+		}
+	}
+	return side
+}
@@ -0,0 +1,555 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/storage"
+	"nogochain/core/storage/freezer"
+	"nogochain/core/types"
+)
+
+// Severity of a database verification issue
+// 数据库校验问题的严重程度
+const (
+	// SeverityError marks corruption that makes a block unusable
+	// SeverityError 导致区块不可用的损坏
+	SeverityError = "error"
+
+	// SeverityWarning marks missing optional data
+	// SeverityWarning 可选数据缺失
+	SeverityWarning = "warning"
+)
+
+// Kinds of database verification issues
+// 数据库校验问题的类型
+const (
+	IssueMissingHead      = "missing-head"
+	IssueMissingCanonical = "missing-canonical"
+	IssueMissingHeader    = "missing-header"
+	IssueBadHeader        = "bad-header"
+	IssueHashMismatch     = "hash-mismatch"
+	IssueNumberMismatch   = "number-mismatch"
+	IssueBrokenLink       = "broken-link"
+	IssueMissingBody      = "missing-body"
+	IssueBadBody          = "bad-body"
+	IssueTxRootMismatch   = "tx-root-mismatch"
+	IssueUncleMismatch    = "uncle-hash-mismatch"
+	IssueBadReceipts      = "bad-receipts"
+	IssueReceiptMismatch  = "receipt-root-mismatch"
+	IssueMissingReceipts  = "missing-receipts"
+	IssueMissingState     = "missing-state"
+)
+
+var (
+	// ErrNoHead is returned when the database has no head block
+	// ErrNoHead 数据库中没有头部区块
+	ErrNoHead = errors.New("database has no head block")
+
+	// ErrUnrepairable is returned when not even the genesis block is consistent
+	// ErrUnrepairable 连创世区块都不一致，无法修复
+	ErrUnrepairable = errors.New("database has no consistent block to rewind to")
+)
+
+// VerifyIssue describes a single problem found in the database
+// VerifyIssue 数据库中发现的单个问题
+type VerifyIssue struct {
+	Number   uint64      `json:"number"`
+	Hash     common.Hash `json:"hash"`
+	Key      string      `json:"key"`
+	Kind     string      `json:"kind"`
+	Severity string      `json:"severity"`
+	Message  string      `json:"message"`
+}
+
+// String formats the issue with its location
+// String 格式化问题及其位置
+func (i VerifyIssue) String() string {
+	return fmt.Sprintf("%s #%d %s [%s] %s: %s", i.Severity, i.Number, i.Hash.Hex(), i.Key, i.Kind, i.Message)
+}
+
+// VerifyReport is the result of walking the canonical chain
+// VerifyReport 遍历规范链的校验结果
+type VerifyReport struct {
+	Head         uint64        `json:"head"`
+	HeadHash     common.Hash   `json:"headHash"`
+	Checked      uint64        `json:"checked"`
+	Frozen       uint64        `json:"frozen"`
+	LastGood     uint64        `json:"lastGood"`
+	LastGoodHash common.Hash   `json:"lastGoodHash"`
+	HasGood      bool          `json:"hasGood"`
+	Issues       []VerifyIssue `json:"issues"`
+}
+
+// Errors returns the issues with error severity
+// Errors 获取错误级别的问题
+func (r *VerifyReport) Errors() []VerifyIssue {
+	errs := make([]VerifyIssue, 0)
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	return errs
+}
+
+// Consistent reports whether no errors were found
+// Consistent 是否没有发现错误
+func (r *VerifyReport) Consistent() bool {
+	return len(r.Errors()) == 0
+}
+
+// verifier holds the state of a single verification run
+// verifier 单次校验的状态
+type verifier struct {
+	db        storage.Storage
+	ancients  *freezer.Freezer
+	frozen    uint64
+	bodyTail  uint64
+	stateTail uint64
+	states    map[common.Hash]bool
+	report    *VerifyReport
+}
+
+// addIssue records an issue
+// addIssue 记录问题
+func (v *verifier) addIssue(number uint64, hash common.Hash, key, kind, severity, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, VerifyIssue{
+		Number:   number,
+		Hash:     hash,
+		Key:      key,
+		Kind:     kind,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ancientKey formats the location of an item in the freezer
+// ancientKey 格式化冻结库中数据的位置
+func ancientKey(table string, number uint64) string {
+	return fmt.Sprintf("ancient/%s/%d", table, number)
+}
+
+// VerifyDatabase walks the canonical chain from genesis to the stored head, checking header links,
+// body and transaction root consistency, receipt roots and state availability
+// VerifyDatabase 从创世区块遍历到存储的头部区块，检查区块头链接、区块体与交易根、收据根以及状态是否可用
+func VerifyDatabase(db storage.Storage, ancients *freezer.Freezer) (*VerifyReport, error) {
+	v := &verifier{
+		db:       db,
+		ancients: ancients,
+		states:   make(map[common.Hash]bool),
+		report:   &VerifyReport{Issues: make([]VerifyIssue, 0)},
+	}
+	if ancients != nil {
+		v.frozen = ancients.Ancients()
+	}
+	v.report.Frozen = v.frozen
+	v.bodyTail = readTail(db, storage.BodyTailKey)
+	v.stateTail = readTail(db, storage.StateTailKey)
+
+	head, headHash, err := v.findHead()
+	if err != nil {
+		return nil, err
+	}
+	v.report.Head = head
+	v.report.HeadHash = headHash
+
+	var prev common.Hash
+	havePrev := false
+	consistent := true
+	for number := uint64(0); number <= head; number++ {
+		hash, stateAvailable, ok := v.verifyBlock(number, prev, havePrev, number == head)
+		v.report.Checked++
+
+		if !ok {
+			consistent = false
+		}
+		if consistent && stateAvailable {
+			v.report.LastGood = number
+			v.report.LastGoodHash = hash
+			v.report.HasGood = true
+		}
+		prev, havePrev = hash, hash != (common.Hash{})
+	}
+
+	if headHash != (common.Hash{}) {
+		if canonical, ok := v.canonicalHash(head); ok && canonical != headHash {
+			v.addIssue(head, headHash, storage.HeadBlockKey, IssueHashMismatch, SeverityError,
+				"head hash does not match canonical hash %s", canonical.Hex())
+		}
+	}
+
+	return v.report, nil
+}
+
+// findHead returns the number and hash of the stored head block
+// When the head header is unreadable the highest contiguous canonical number is used instead
+// findHead 获取存储的头部区块号和哈希，头部区块头不可读时使用最高的连续规范链区块号
+func (v *verifier) findHead() (uint64, common.Hash, error) {
+	value, exists := v.db.Get(storage.HeadBlockKey)
+	if !exists {
+		if v.frozen == 0 {
+			return 0, common.Hash{}, ErrNoHead
+		}
+		v.addIssue(0, common.Hash{}, storage.HeadBlockKey, IssueMissingHead, SeverityError, "head block pointer is missing")
+		return v.highestCanonical(), common.Hash{}, nil
+	}
+
+	headHash, err := decodeHash(value)
+	if err != nil {
+		v.addIssue(0, common.Hash{}, storage.HeadBlockKey, IssueMissingHead, SeverityError, "head block pointer is unreadable: %v", err)
+		return v.highestCanonical(), common.Hash{}, nil
+	}
+
+	header, _, err := v.readHeader(0, headHash, false)
+	if err != nil || header.Number == nil {
+		v.addIssue(0, headHash, storage.HeaderKey(headHash), IssueMissingHead, SeverityError, "head block header is unreadable")
+		return v.highestCanonical(), headHash, nil
+	}
+	return header.Number.Uint64(), headHash, nil
+}
+
+// highestCanonical returns the highest block number reachable through contiguous canonical mappings
+// highestCanonical 获取通过连续规范链映射能到达的最高区块号
+func (v *verifier) highestCanonical() uint64 {
+	number := uint64(0)
+	for {
+		if _, ok := v.canonicalHash(number + 1); !ok {
+			return number
+		}
+		number++
+	}
+}
+
+// canonicalHash reads the canonical hash of a block number from the freezer or the database
+// canonicalHash 从冻结库或数据库读取区块号对应的规范链哈希
+func (v *verifier) canonicalHash(number uint64) (common.Hash, bool) {
+	if number < v.frozen {
+		data, err := v.ancients.Ancient(freezer.HashTable, number)
+		if err != nil {
+			return common.Hash{}, false
+		}
+		return common.BytesToHash(data), true
+	}
+
+	value, exists := v.db.Get(storage.CanonicalHashKey(number))
+	if !exists {
+		return common.Hash{}, false
+	}
+	hash, err := decodeHash(value)
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return hash, true
+}
+
+// get reads a database entry; entries that exist but cannot be decoded are reported as corrupt
+// get 读取数据库条目，存在但无法解码的条目视为损坏
+func (v *verifier) get(key string) (interface{}, error) {
+	value, exists := v.db.Get(key)
+	if exists {
+		return value, nil
+	}
+	if checker, ok := v.db.(interface{ Has(key string) bool }); ok && checker.Has(key) {
+		return nil, errCorrupt
+	}
+	return nil, errMissing
+}
+
+// readHeader reads a header from the freezer or the database
+// readHeader 从冻结库或数据库读取区块头
+func (v *verifier) readHeader(number uint64, hash common.Hash, frozen bool) (*types.BlockHeader, string, error) {
+	if frozen {
+		key := ancientKey(freezer.HeaderTable, number)
+		data, err := v.ancients.Ancient(freezer.HeaderTable, number)
+		if err != nil {
+			return nil, key, err
+		}
+		header := new(types.BlockHeader)
+		return header, key, json.Unmarshal(data, header)
+	}
+
+	key := storage.HeaderKey(hash)
+	value, err := v.get(key)
+	if err != nil {
+		return nil, key, err
+	}
+	header := new(types.BlockHeader)
+	return header, key, decodeStored(value, header)
+}
+
+// readBody reads a body from the freezer or the database
+// readBody 从冻结库或数据库读取区块体
+func (v *verifier) readBody(number uint64, hash common.Hash, frozen bool) (*types.Body, string, error) {
+	if frozen {
+		key := ancientKey(freezer.BodyTable, number)
+		data, err := v.ancients.Ancient(freezer.BodyTable, number)
+		if err != nil {
+			return nil, key, err
+		}
+		body := new(types.Body)
+		return body, key, json.Unmarshal(data, body)
+	}
+
+	key := storage.BodyKey(hash)
+	value, err := v.get(key)
+	if err != nil {
+		return nil, key, err
+	}
+	body := new(types.Body)
+	return body, key, decodeStored(value, body)
+}
+
+// readReceipts reads the receipts of a block from the freezer or the database
+// readReceipts 从冻结库或数据库读取区块收据
+func (v *verifier) readReceipts(number uint64, hash common.Hash, frozen bool) (types.Receipts, string, error) {
+	if frozen {
+		key := ancientKey(freezer.ReceiptTable, number)
+		data, err := v.ancients.Ancient(freezer.ReceiptTable, number)
+		if err != nil {
+			return nil, key, err
+		}
+		if len(data) == 0 {
+			return nil, key, errMissing
+		}
+		var receipts types.Receipts
+		return receipts, key, json.Unmarshal(data, &receipts)
+	}
+
+	key := storage.ReceiptsKey(hash)
+	value, err := v.get(key)
+	if err != nil {
+		return nil, key, err
+	}
+	var receipts types.Receipts
+	return receipts, key, decodeStored(value, &receipts)
+}
+
+// verifyBlock checks a single canonical block and returns its hash, whether its state is available
+// and whether it is free of errors
+// verifyBlock 校验单个规范链区块，返回区块哈希、状态是否可用以及是否没有错误
+func (v *verifier) verifyBlock(number uint64, prev common.Hash, havePrev, isHead bool) (common.Hash, bool, bool) {
+	frozen := number < v.frozen
+	bodyPruned := !frozen && number < v.bodyTail
+	ok := true
+
+	hash, exists := v.canonicalHash(number)
+	if !exists {
+		key := storage.CanonicalHashKey(number)
+		if frozen {
+			key = ancientKey(freezer.HashTable, number)
+		}
+		v.addIssue(number, common.Hash{}, key, IssueMissingCanonical, SeverityError, "canonical hash is missing")
+		return common.Hash{}, false, false
+	}
+
+	// Header: presence, hash, number and parent link
+	// 区块头：存在性、哈希、区块号和父区块链接
+	header, key, err := v.readHeader(number, hash, frozen)
+	if err == errMissing {
+		v.addIssue(number, hash, key, IssueMissingHeader, SeverityError, "header is missing")
+		return hash, false, false
+	}
+	if err != nil {
+		v.addIssue(number, hash, key, IssueBadHeader, SeverityError, "header is unreadable: %v", err)
+		return hash, false, false
+	}
+	if computed := header.Hash(); computed != hash {
+		v.addIssue(number, hash, key, IssueHashMismatch, SeverityError, "header hashes to %s", computed.Hex())
+		ok = false
+	}
+	if header.Number == nil || header.Number.Uint64() != number {
+		v.addIssue(number, hash, key, IssueNumberMismatch, SeverityError, "header number is %v", header.Number)
+		ok = false
+	}
+	if number > 0 && havePrev && header.ParentHash != prev {
+		v.addIssue(number, hash, key, IssueBrokenLink, SeverityError,
+			"parent hash %s does not match canonical block %d (%s)", header.ParentHash.Hex(), number-1, prev.Hex())
+		ok = false
+	}
+
+	// Body: transaction root and uncle hash
+	// 区块体：交易根和叔区块哈希
+	body, key, err := v.readBody(number, hash, frozen)
+	switch {
+	case err == errMissing && bodyPruned:
+		// Pruned history
+		// 已裁剪的历史
+	case err == errMissing:
+		v.addIssue(number, hash, key, IssueMissingBody, SeverityError, "body is missing")
+		ok = false
+	case err != nil:
+		v.addIssue(number, hash, key, IssueBadBody, SeverityError, "body is unreadable: %v", err)
+		ok = false
+	default:
+		if computed := types.CalcTxHash(body.Transactions); computed != header.TxHash {
+			v.addIssue(number, hash, key, IssueTxRootMismatch, SeverityError,
+				"transaction root %s does not match header %s", computed.Hex(), header.TxHash.Hex())
+			ok = false
+		}
+		if computed := types.CalcUncleHash(body.Uncles); computed != header.UncleHash {
+			v.addIssue(number, hash, key, IssueUncleMismatch, SeverityError,
+				"uncle hash %s does not match header %s", computed.Hex(), header.UncleHash.Hex())
+			ok = false
+		}
+	}
+
+	// Receipts are optional, but stored receipts must match the header
+	// 收据是可选的，但已存储的收据必须与区块头一致
+	receipts, key, err := v.readReceipts(number, hash, frozen)
+	switch {
+	case err == errMissing:
+		if header.ReceiptHash != (common.Hash{}) && !bodyPruned {
+			v.addIssue(number, hash, key, IssueMissingReceipts, SeverityWarning, "receipts are missing")
+		}
+	case err != nil:
+		v.addIssue(number, hash, key, IssueBadReceipts, SeverityError, "receipts are unreadable: %v", err)
+		ok = false
+	default:
+		if computed := types.CalcReceiptHash(receipts); computed != header.ReceiptHash {
+			v.addIssue(number, hash, key, IssueReceiptMismatch, SeverityError,
+				"receipt root %s does not match header %s", computed.Hex(), header.ReceiptHash.Hex())
+			ok = false
+		}
+	}
+
+	// State is only required for the head block, pruned states are not reported
+	// 只有头部区块要求状态可用，已裁剪的状态不报告
+	available, checked := v.states[header.Root]
+	if !checked {
+		_, available = v.db.Get(storage.StateKey(header.Root))
+		v.states[header.Root] = available
+	}
+	if !available && (isHead || number >= v.stateTail) {
+		severity := SeverityWarning
+		if isHead {
+			severity = SeverityError
+		}
+		v.addIssue(number, hash, storage.StateKey(header.Root), IssueMissingState, severity,
+			"state root %s is not available", header.Root.Hex())
+	}
+
+	return hash, available, ok
+}
+
+// RepairDatabase rewinds the head to the last consistent block of a verification report,
+// removing canonical mappings and block data above it
+// RepairDatabase 将头部区块回退到校验结果中最后一个一致的区块，并删除其上的规范链映射和区块数据
+func RepairDatabase(db storage.BatchStorage, ancients *freezer.Freezer, report *VerifyReport) error {
+	if !report.HasGood {
+		return ErrUnrepairable
+	}
+	if report.Consistent() && report.LastGood == report.Head {
+		return nil
+	}
+
+	v := &verifier{db: db, ancients: ancients, report: &VerifyReport{}}
+	if ancients != nil {
+		v.frozen = ancients.Ancients()
+	}
+
+	batch := storage.NewBatch()
+	for number := report.LastGood + 1; number <= report.Head; number++ {
+		if hash, ok := v.canonicalHash(number); ok {
+			v.deleteTxLookups(batch, number, hash)
+			if number >= v.frozen {
+				batch.Delete(storage.HeaderKey(hash))
+				batch.Delete(storage.BodyKey(hash))
+				batch.Delete(storage.ReceiptsKey(hash))
+			}
+		}
+		batch.Delete(storage.CanonicalHashKey(number))
+	}
+	batch.Set(storage.HeadBlockKey, report.LastGoodHash)
+
+	// The transaction index cannot start above the new head
+	// 交易索引的起点不能高于新头部
+	if _, exists := db.Get(storage.TxIndexTailKey); exists && readTail(db, storage.TxIndexTailKey) > report.LastGood+1 {
+		batch.Set(storage.TxIndexTailKey, report.LastGood+1)
+	}
+
+	// Frozen blocks above the new head are dropped from the freezer first,
+	// so the database never points at blocks that only exist in the freezer
+	// 先从冻结库删除新头部之上的区块，避免数据库指向只存在于冻结库中的区块
+	if ancients != nil && v.frozen > report.LastGood+1 {
+		if err := ancients.TruncateAncients(report.LastGood + 1); err != nil {
+			return err
+		}
+	}
+
+	return db.Write(batch)
+}
+
+// deleteTxLookups deletes the lookup entries pointing at a rewound block
+// Entries of a block whose body can no longer be read are left to GetTransaction, which ignores non-canonical blocks
+// deleteTxLookups 删除指向被回退区块的交易查找索引
+// 区块体已无法读取时保留其索引，GetTransaction 会忽略不在规范链上的区块
+func (v *verifier) deleteTxLookups(batch *storage.Batch, number uint64, hash common.Hash) {
+	body, _, err := v.readBody(number, hash, number < v.frozen)
+	if err != nil {
+		return
+	}
+	for _, tx := range body.Transactions {
+		key := storage.TxLookupKey(tx.Hash())
+		if value, exists := v.db.Get(key); exists {
+			if lookup, err := decodeHash(value); err == nil && lookup == hash {
+				batch.Delete(key)
+			}
+		}
+	}
+}
+
+var (
+	// errMissing marks a missing database entry
+	// errMissing 数据库条目不存在
+	errMissing = errors.New("missing")
+
+	// errCorrupt marks a database entry that exists but cannot be decoded
+	// errCorrupt 数据库条目存在但无法解码
+	errCorrupt = errors.New("entry cannot be decoded")
+)
+
+// decodeHash converts a stored hash value
+// decodeHash 转换存储的哈希值
+func decodeHash(value interface{}) (common.Hash, error) {
+	switch v := value.(type) {
+	case common.Hash:
+		return v, nil
+	case *common.Hash:
+		return *v, nil
+	}
+	var hash common.Hash
+	return hash, decodeStored(value, &hash)
+}
+
+// decodeStored converts a stored value into out
+// Typed values are copied directly, legacy values decoded from JSON are converted through JSON
+// decodeStored 将存储的值转换为 out，类型化的值直接复制，旧版本 JSON 解码得到的值通过 JSON 转换
+func decodeStored(value interface{}, out interface{}) error {
+	switch target := out.(type) {
+	case *types.BlockHeader:
+		if header, ok := value.(*types.BlockHeader); ok {
+			*target = *header
+			return nil
+		}
+	case *types.Body:
+		if body, ok := value.(*types.Body); ok {
+			*target = *body
+			return nil
+		}
+	case *types.Receipts:
+		if receipts, ok := value.(types.Receipts); ok {
+			*target = receipts
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,160 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/storage"
+	"nogochain/core/types"
+)
+
+// newStoredChain 创建持久化到存储的区块链并添加 n 个区块
+func newStoredChain(t *testing.T, db storage.BatchStorage, n int) []*types.Block {
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+
+	parent := bc.Genesis()
+	blocks := []*types.Block{parent}
+	for i := 1; i <= n; i++ {
+		block := types.NewBlock(
+			parent.Hash(),
+			common.Address{0x01},
+			common.Hash{},
+			common.Hash{},
+			common.Hash{},
+			big.NewInt(1000000),
+			big.NewInt(int64(i)),
+			10000000,
+			0,
+			parent.Header.Time+10,
+			[]byte("Verify Block"),
+			common.Hash{},
+			uint64(i),
+			[]*types.Transaction{},
+			[]*types.BlockHeader{},
+		)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock returned error: %v", err)
+		}
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return blocks
+}
+
+// 测试一致的数据库通过校验
+func TestVerifyDatabase(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+	blocks := newStoredChain(t, db, 5)
+
+	report, err := VerifyDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	if !report.Consistent() {
+		t.Fatalf("expected consistent database, got issues %v", report.Issues)
+	}
+	if report.Head != 5 || report.Checked != 6 || report.LastGood != 5 || report.LastGoodHash != blocks[5].Hash() {
+		t.Errorf("unexpected report: head %d checked %d last good %d", report.Head, report.Checked, report.LastGood)
+	}
+}
+
+// 测试损坏的区块体被定位，修复后头部回退到最后一个一致的区块
+func TestRepairDatabase(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+	blocks := newStoredChain(t, db, 5)
+
+	// 区块 3 的区块体包含与交易根不符的交易，区块 4 的区块头丢失
+	tx := types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	db.Set(storage.BodyKey(blocks[3].Hash()), &types.Body{Transactions: []*types.Transaction{tx}})
+	db.Delete(storage.HeaderKey(blocks[4].Hash()))
+
+	report, err := VerifyDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	errs := report.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", report.Issues)
+	}
+	if errs[0].Number != 3 || errs[0].Kind != IssueTxRootMismatch || errs[0].Key != storage.BodyKey(blocks[3].Hash()) {
+		t.Errorf("unexpected first error: %s", errs[0])
+	}
+	if errs[1].Number != 4 || errs[1].Kind != IssueMissingHeader || errs[1].Key != storage.HeaderKey(blocks[4].Hash()) {
+		t.Errorf("unexpected second error: %s", errs[1])
+	}
+	if report.LastGood != 2 {
+		t.Fatalf("expected last good block 2, got %d", report.LastGood)
+	}
+
+	if err := RepairDatabase(db, nil, report); err != nil {
+		t.Fatalf("RepairDatabase returned error: %v", err)
+	}
+
+	repaired, err := VerifyDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	if !repaired.Consistent() || repaired.Head != 2 || repaired.HeadHash != blocks[2].Hash() {
+		t.Errorf("expected consistent database at block 2, got head %d issues %v", repaired.Head, repaired.Issues)
+	}
+	if _, exists := db.Get(storage.CanonicalHashKey(3)); exists {
+		t.Errorf("canonical mapping above the new head was not removed")
+	}
+}
+
+// 测试修复在同一批次中删除被回退区块的交易索引，并将索引起点限制在新头部之上
+func TestRepairDatabaseTxLookups(t *testing.T) {
+	db := storage.NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer db.Close()
+	bc, err := NewBlockchainWithStorage(nil, db)
+	if err != nil {
+		t.Fatalf("NewBlockchainWithStorage returned error: %v", err)
+	}
+
+	blocks := []*types.Block{bc.Genesis()}
+	for i := 1; i <= 4; i++ {
+		block := newTxBlock(blocks[i-1], "repair", newTestTx(uint64(i)))
+		header := *block.Header
+		header.TxHash = types.CalcTxHash(block.Transactions)
+		block = types.NewBlockWithHeader(&header, block.Body())
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock %d returned error: %v", i, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	// 区块 3 的区块头丢失，索引起点位于旧头部之上
+	db.Delete(storage.HeaderKey(blocks[3].Hash()))
+	db.Set(storage.TxIndexTailKey, uint64(5))
+
+	report, err := VerifyDatabase(db, nil)
+	if err != nil {
+		t.Fatalf("VerifyDatabase returned error: %v", err)
+	}
+	if report.LastGood != 2 {
+		t.Fatalf("expected last good block 2, got %d: %v", report.LastGood, report.Issues)
+	}
+	if err := RepairDatabase(db, nil, report); err != nil {
+		t.Fatalf("RepairDatabase returned error: %v", err)
+	}
+
+	for i, block := range blocks[1:] {
+		_, exists := db.Get(storage.TxLookupKey(block.Transactions[0].Hash()))
+		if number := i + 1; number <= 2 && !exists {
+			t.Errorf("lookup of kept block %d was removed", number)
+		} else if number > 2 && exists {
+			t.Errorf("lookup of rewound block %d was not removed", number)
+		}
+	}
+	if tail := readTail(db, storage.TxIndexTailKey); tail != 3 {
+		t.Errorf("tx index tail = %d, want 3", tail)
+	}
+}
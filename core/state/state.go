@@ -396,3 +396,43 @@ func (s *MemoryStateDB) CalculateStateRoot() common.Hash {
 
 	return root
 }
+
+// DumpAccount represents an account in a state dump
+// DumpAccount 状态导出中的账户
+type DumpAccount struct {
+	Nonce   uint64                      `json:"nonce"`
+	Balance *big.Int                    `json:"balance"`
+	Code    []byte                      `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// Dump represents a full state dump
+// Dump 完整状态导出
+type Dump struct {
+	Root     common.Hash                    `json:"root"`
+	Accounts map[common.Address]DumpAccount `json:"accounts"`
+}
+
+// Dump exports all accounts, code and storage
+// Dump 导出所有账户、代码和存储
+func (s *MemoryStateDB) Dump() *Dump {
+	dump := &Dump{
+		Root:     s.CalculateStateRoot(),
+		Accounts: make(map[common.Address]DumpAccount, len(s.accounts)),
+	}
+	for addr, acc := range s.accounts {
+		account := DumpAccount{
+			Nonce:   acc.Nonce,
+			Balance: new(big.Int).Set(acc.Balance),
+			Code:    s.code[addr],
+		}
+		if storage := s.storage[addr]; len(storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(storage))
+			for key, value := range storage {
+				account.Storage[key] = value
+			}
+		}
+		dump.Accounts[addr] = account
+	}
+	return dump
+}
@@ -1,12 +1,15 @@
 package cache
 
 import (
-	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"nogochain/core/storage/codec"
 )
 
 // CacheItem 缓存项
@@ -14,6 +17,17 @@ import (
 	Value      interface{}
 	Expiration int64
 	AccessTime int64
+	Size       int64
+}
+
+// Config 内存缓存配置
+ type Config struct {
+	// Capacity 最大缓存项数量
+	Capacity int `json:"capacity"`
+	// Policy 淘汰策略：lru、lfu、arc、size、ttl，为空时使用 lru
+	Policy string `json:"policy"`
+	// MaxBytes 缓存项总大小上限，0 表示不限制
+	MaxBytes int64 `json:"maxBytes"`
 }
 
 // MemoryCache 内存缓存
@@ -21,10 +35,15 @@ import (
 	data      map[string]*CacheItem
 	mutex     sync.RWMutex
 	capacity  int
+	maxBytes  int64
+	bytes     int64
 	eviction  EvictionPolicy
+	tracking  TrackingPolicy
+	sized     bool
 	hits      int64
 	misses    int64
 	evictions int64
+	onEvict   func(key string, value interface{})
 }
 
 // EvictionPolicy 缓存淘汰策略
@@ -32,9 +51,6 @@ import (
 	SelectVictim(cache *MemoryCache) string
 }
 
-// LRUCache LRU缓存淘汰策略
- type LRUCache struct {}
-
 // NewMemoryCache 创建内存缓存
 func NewMemoryCache(capacity int, eviction EvictionPolicy) *MemoryCache {
 	if eviction == nil {
@@ -47,16 +63,40 @@ func NewMemoryCache(capacity int, eviction EvictionPolicy) *MemoryCache {
 		eviction: eviction,
 	}
 
+	// 跟踪型策略在缓存写锁内维护自己的数据结构，淘汰时不需要扫描缓存
+	if tracking, ok := eviction.(TrackingPolicy); ok {
+		tracking.Init(capacity)
+		cache.tracking = tracking
+	}
+	if sized, ok := eviction.(interface{ needsSize() bool }); ok {
+		cache.sized = sized.needsSize()
+	}
+
 	// 启动过期清理协程
 	go cache.cleanExpired()
 
 	return cache
 }
 
+// NewMemoryCacheWithConfig 根据配置创建内存缓存
+func NewMemoryCacheWithConfig(config Config) (*MemoryCache, error) {
+	policy, err := NewEvictionPolicy(config.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := NewMemoryCache(config.Capacity, policy)
+	if config.MaxBytes > 0 {
+		cache.maxBytes = config.MaxBytes
+		cache.sized = true
+	}
+	return cache, nil
+}
+
 // Get 获取缓存项
 func (c *MemoryCache) Get(key string) (interface{}, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
 	item, exists := c.data[key]
 	if !exists {
@@ -66,29 +106,51 @@ func (c *MemoryCache) Get(key string) (interface{}, bool) {
 
 	// 检查是否过期
 	if item.Expiration > 0 && item.Expiration < time.Now().UnixNano() {
+		c.remove(key, item)
 		c.misses++
 		return nil, false
 	}
 
 	// 更新访问时间
 	item.AccessTime = time.Now().UnixNano()
+	if c.tracking != nil {
+		c.tracking.OnAccess(key)
+	}
 	c.hits++
 	return item.Value, true
 }
 
 // Set 设置缓存项
 func (c *MemoryCache) Set(key string, value interface{}, expiration time.Duration) {
+	var size int64
+	if c.sized {
+		size = estimateSize(value)
+	}
+
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
 
 	// 检查容量
-	if len(c.data) >= c.capacity {
+	var evicted []*CacheItem
+	var evictedKeys []string
+	old, exists := c.data[key]
+	if exists {
+		c.bytes -= old.Size
+	}
+	for len(c.data) > 0 && ((!exists && len(c.data) >= c.capacity) || (c.maxBytes > 0 && c.bytes+size > c.maxBytes)) {
 		// 执行淘汰
-		victim := c.eviction.SelectVictim(c)
-		if victim != "" {
-			delete(c.data, victim)
-			c.evictions++
+		victim := c.selectVictim(key)
+		if victim == "" || victim == key {
+			break
 		}
+		item, ok := c.data[victim]
+		if !ok {
+			continue
+		}
+		evictedKeys = append(evictedKeys, victim)
+		evicted = append(evicted, item)
+		delete(c.data, victim)
+		c.bytes -= item.Size
+		c.evictions++
 	}
 
 	// 设置缓存项
@@ -101,6 +163,62 @@ func (c *MemoryCache) Set(key string, value interface{}, expiration time.Duratio
 		Value:      value,
 		Expiration: exp,
 		AccessTime: time.Now().UnixNano(),
+		Size:       size,
+	}
+	c.bytes += size
+	if c.tracking != nil {
+		c.tracking.OnInsert(key, size, exp)
+	}
+	onEvict := c.onEvict
+	c.mutex.Unlock()
+
+	// 在锁外通知淘汰，回调可以安全地写入下一级存储
+	if onEvict != nil {
+		for i, item := range evicted {
+			onEvict(evictedKeys[i], item.Value)
+		}
+	}
+}
+
+// selectVictim 选择淘汰项，跟踪型策略会同时从自身结构中移除该项
+func (c *MemoryCache) selectVictim(incoming string) string {
+	if c.tracking != nil {
+		return c.tracking.Victim(incoming)
+	}
+	return c.eviction.SelectVictim(c)
+}
+
+// remove 删除缓存项并通知淘汰策略，调用方需持有写锁
+func (c *MemoryCache) remove(key string, item *CacheItem) {
+	delete(c.data, key)
+	c.bytes -= item.Size
+	if c.tracking != nil {
+		c.tracking.OnRemove(key)
+	}
+}
+
+// SetOnEvict 设置容量淘汰回调，过期清理不会触发回调
+func (c *MemoryCache) SetOnEvict(fn func(key string, value interface{})) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.onEvict = fn
+}
+
+// Range 遍历所有未过期的缓存项，fn 返回 false 时停止遍历
+// 遍历期间持有读锁，fn 中不能修改缓存
+func (c *MemoryCache) Range(fn func(key string, value interface{}) bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now().UnixNano()
+	for key, item := range c.data {
+		if item.Expiration > 0 && item.Expiration < now {
+			continue
+		}
+		if !fn(key, item.Value) {
+			return
+		}
 	}
 }
 
@@ -109,7 +227,9 @@ func (c *MemoryCache) Delete(key string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	delete(c.data, key)
+	if item, exists := c.data[key]; exists {
+		c.remove(key, item)
+	}
 }
 
 // Clear 清空缓存
@@ -118,9 +238,13 @@ func (c *MemoryCache) Clear() {
 	defer c.mutex.Unlock()
 
 	c.data = make(map[string]*CacheItem)
+	c.bytes = 0
 	c.hits = 0
 	c.misses = 0
 	c.evictions = 0
+	if c.tracking != nil {
+		c.tracking.Init(c.capacity)
+	}
 }
 
 // GetStats 获取缓存统计信息
@@ -134,7 +258,34 @@ func (c *MemoryCache) GetStats() map[string]int64 {
 		"evictions": c.evictions,
 		"size":      int64(len(c.data)),
 		"capacity":  int64(c.capacity),
+		"bytes":     c.bytes,
+	}
+}
+
+// GetPolicyStats 获取淘汰策略及其命中、未命中和淘汰统计
+func (c *MemoryCache) GetPolicyStats() map[string]interface{} {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	stats := map[string]interface{}{
+		"policy":    c.policyName(),
+		"hits":      c.hits,
+		"misses":    c.misses,
+		"evictions": c.evictions,
+		"hit_rate":  c.hitRate(),
 	}
+	if c.tracking != nil {
+		stats["internal"] = c.tracking.Stats()
+	}
+	return stats
+}
+
+// policyName 获取淘汰策略名称
+func (c *MemoryCache) policyName() string {
+	if c.tracking != nil {
+		return c.tracking.Name()
+	}
+	return "custom"
 }
 
 // GetHitRate 获取缓存命中率
@@ -142,6 +293,11 @@ func (c *MemoryCache) GetHitRate() float64 {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
+	return c.hitRate()
+}
+
+// hitRate 计算命中率，调用方需持有锁
+func (c *MemoryCache) hitRate() float64 {
 	total := c.hits + c.misses
 	if total == 0 {
 		return 0
@@ -161,7 +317,7 @@ func (c *MemoryCache) cleanExpired() {
 
 		for key, item := range c.data {
 			if item.Expiration > 0 && item.Expiration < now {
-				delete(c.data, key)
+				c.remove(key, item)
 			}
 		}
 
@@ -169,19 +325,20 @@ func (c *MemoryCache) cleanExpired() {
 	}
 }
 
-// SelectVictim 选择淘汰的缓存项
-func (lru *LRUCache) SelectVictim(cache *MemoryCache) string {
-	var victimKey string
-	var oldestAccessTime int64 = time.Now().UnixNano()
-
-	for key, item := range cache.data {
-		if item.AccessTime < oldestAccessTime {
-			oldestAccessTime = item.AccessTime
-			victimKey = key
-		}
+// estimateSize 估算缓存项大小
+func estimateSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case []byte:
+		return int64(len(v))
+	case string:
+		return int64(len(v))
+	case interface{ Size() int64 }:
+		return v.Size()
 	}
-
-	return victimKey
+	if data, err := codec.Encode(value); err == nil {
+		return int64(len(data))
+	}
+	return 1
 }
 
 // DiskCache 磁盘缓存
@@ -190,6 +347,7 @@ func (lru *LRUCache) SelectVictim(cache *MemoryCache) string {
 	capacity int64
 	mutex    sync.RWMutex
 	size     int64
+	codec    *codec.Registry
 }
 
 // NewDiskCache 创建磁盘缓存
@@ -199,13 +357,39 @@ func NewDiskCache(path string, capacity int64) *DiskCache {
 		panic(err)
 	}
 
+	// 统计已有文件大小，清理崩溃时遗留的临时文件
+	var size int64
+	if files, err := os.ReadDir(path); err == nil {
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			if filepath.Ext(file.Name()) == ".tmp" {
+				os.Remove(filepath.Join(path, file.Name()))
+				continue
+			}
+			if info, err := file.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+	}
+
 	return &DiskCache{
 		path:     path,
 		capacity: capacity,
-		size:     0,
+		size:     size,
+		codec:    codec.Default,
 	}
 }
 
+// SetCodec 设置值编解码器，需要在写入前调用
+func (c *DiskCache) SetCodec(registry *codec.Registry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.codec = registry
+}
+
 // Get 获取缓存项
 func (c *DiskCache) Get(key string) (interface{}, bool) {
 	c.mutex.RLock()
@@ -225,9 +409,9 @@ func (c *DiskCache) Get(key string) (interface{}, bool) {
 		return nil, false
 	}
 
-	// 反序列化数据
-	var value interface{}
-	if err := json.Unmarshal(data, &value); err != nil {
+	// 按类型标签反序列化数据
+	value, err := c.codec.Decode(data)
+	if err != nil {
 		return nil, false
 	}
 
@@ -239,8 +423,8 @@ func (c *DiskCache) Set(key string, value interface{}, expiration time.Duration)
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	// 序列化数据
-	data, err := json.Marshal(value)
+	// 序列化数据，写入类型标签
+	data, err := c.codec.Encode(value)
 	if err != nil {
 		return
 	}
@@ -254,12 +438,12 @@ func (c *DiskCache) Set(key string, value interface{}, expiration time.Duration)
 	}
 
 	// 检查是否需要清理空间
-	if c.size-fileSize+int64(len(data)) > c.capacity {
+	if c.overCapacity(c.size - fileSize + int64(len(data))) {
 		c.evict()
 	}
 
 	// 写入文件
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := writeFileAtomic(filePath, data, false); err != nil {
 		return
 	}
 
@@ -271,6 +455,121 @@ func (c *DiskCache) Set(key string, value interface{}, expiration time.Duration)
 	}
 }
 
+// ReadRaw 读取未反序列化的缓存项
+func (c *DiskCache) ReadRaw(key string) ([]byte, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	data, err := os.ReadFile(filepath.Join(c.path, key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Has 检查缓存项是否存在
+func (c *DiskCache) Has(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, err := os.Stat(filepath.Join(c.path, key))
+	return err == nil
+}
+
+// Keys 获取以 prefix 开头的所有键，按字典序排列
+func (c *DiskCache) Keys(prefix string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	keys := make([]string, 0)
+	files, err := os.ReadDir(c.path)
+	if err != nil {
+		return keys
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) == ".tmp" || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// Checkpoint 将所有缓存文件硬链接到 dir，无法硬链接时（如跨文件系统）复制，返回文件数和总大小
+// 缓存文件只通过原子重命名替换，链接后的文件内容不会再被修改，因此 dir 是一致的快照
+func (c *DiskCache) Checkpoint(dir string) (int, int64, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, 0, err
+	}
+	files, err := os.ReadDir(c.path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := 0
+	var size int64
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) == ".tmp" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return count, size, err
+		}
+
+		src := filepath.Join(c.path, file.Name())
+		dst := filepath.Join(dir, file.Name())
+		if err := os.Link(src, dst); err != nil {
+			if err := copyFile(src, dst); err != nil {
+				return count, size, err
+			}
+		}
+		count++
+		size += info.Size()
+	}
+	return count, size, nil
+}
+
+// overCapacity 判断写入后的大小是否超过容量，容量不大于 0 时不限制
+func (c *DiskCache) overCapacity(size int64) bool {
+	return c.capacity > 0 && size > c.capacity
+}
+
+// Size 获取磁盘缓存已使用的大小
+func (c *DiskCache) Size() int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.size
+}
+
+// WriteRaw 原子写入已序列化的缓存项，sync 为 true 时在返回前落盘
+func (c *DiskCache) WriteRaw(key string, data []byte, sync bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	filePath := filepath.Join(c.path, key)
+	var fileSize int64
+	if fileInfo, err := os.Stat(filePath); err == nil {
+		fileSize = fileInfo.Size()
+	}
+
+	if c.overCapacity(c.size - fileSize + int64(len(data))) {
+		c.evict()
+	}
+
+	if err := writeFileAtomic(filePath, data, sync); err != nil {
+		return err
+	}
+
+	c.size = c.size - fileSize + int64(len(data))
+	return nil
+}
+
 // Delete 删除缓存项
 func (c *DiskCache) Delete(key string) {
 	c.mutex.Lock()
@@ -372,6 +671,59 @@ func (c *DiskCache) evict() {
 	}
 }
 
+// writeFileAtomic 先写临时文件再重命名，避免崩溃时留下写了一半的文件
+func writeFileAtomic(filePath string, data []byte, sync bool) error {
+	tmpPath := filePath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filePath)
+}
+
+// copyFile 复制文件并落盘
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 // MultiLevelCache 多级缓存
  type MultiLevelCache struct {
 	memoryCache *MemoryCache
@@ -413,6 +765,11 @@ func (c *MultiLevelCache) Set(key string, value interface{}, expiration time.Dur
 	c.diskCache.Set(key, value, expiration)
 }
 
+// SetCodec 设置磁盘缓存的值编解码器
+func (c *MultiLevelCache) SetCodec(registry *codec.Registry) {
+	c.diskCache.SetCodec(registry)
+}
+
 // Delete 删除缓存项
 func (c *MultiLevelCache) Delete(key string) {
 	// 从内存缓存删除
@@ -0,0 +1,669 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"errors"
+	"math"
+)
+
+// 淘汰策略名称
+const (
+	PolicyLRU  = "lru"
+	PolicyLFU  = "lfu"
+	PolicyARC  = "arc"
+	PolicySize = "size"
+	PolicyTTL  = "ttl"
+)
+
+// ErrUnknownPolicy 未知的淘汰策略
+var ErrUnknownPolicy = errors.New("cache: unknown eviction policy")
+
+// TrackingPolicy 跟踪缓存项生命周期的淘汰策略，选择淘汰项时不需要扫描缓存
+// 所有方法都在缓存写锁内调用，每个缓存需要使用独立的策略实例
+type TrackingPolicy interface {
+	EvictionPolicy
+	// Name 策略名称
+	Name() string
+	// Init 按缓存容量初始化，清空已跟踪的缓存项
+	Init(capacity int)
+	// OnInsert 插入或更新缓存项，expiration 为 0 表示不过期
+	OnInsert(key string, size int64, expiration int64)
+	// OnAccess 缓存项被命中
+	OnAccess(key string)
+	// OnRemove 缓存项被删除或过期
+	OnRemove(key string)
+	// Victim 选择并移除一个淘汰项，incoming 为即将插入的键
+	Victim(incoming string) string
+	// Stats 策略内部统计
+	Stats() map[string]int64
+}
+
+// NewEvictionPolicy 根据名称创建淘汰策略，名称为空时使用 LRU
+func NewEvictionPolicy(name string) (TrackingPolicy, error) {
+	switch name {
+	case "", PolicyLRU:
+		return &LRUCache{}, nil
+	case PolicyLFU:
+		return &LFUCache{}, nil
+	case PolicyARC:
+		return &ARCCache{}, nil
+	case PolicySize:
+		return &SizeAwareCache{}, nil
+	case PolicyTTL:
+		return &TTLCache{}, nil
+	default:
+		return nil, ErrUnknownPolicy
+	}
+}
+
+// LRUCache LRU缓存淘汰策略，双向链表实现，所有操作 O(1)
+type LRUCache struct {
+	order *list.List
+	items map[string]*list.Element
+}
+
+// Name 策略名称
+func (lru *LRUCache) Name() string {
+	return PolicyLRU
+}
+
+// Init 初始化
+func (lru *LRUCache) Init(capacity int) {
+	lru.order = list.New()
+	lru.items = make(map[string]*list.Element)
+}
+
+// OnInsert 插入缓存项，放到链表头部
+func (lru *LRUCache) OnInsert(key string, size int64, expiration int64) {
+	if elem, exists := lru.items[key]; exists {
+		lru.order.MoveToFront(elem)
+		return
+	}
+	lru.items[key] = lru.order.PushFront(key)
+}
+
+// OnAccess 命中缓存项，移到链表头部
+func (lru *LRUCache) OnAccess(key string) {
+	if elem, exists := lru.items[key]; exists {
+		lru.order.MoveToFront(elem)
+	}
+}
+
+// OnRemove 删除缓存项
+func (lru *LRUCache) OnRemove(key string) {
+	if elem, exists := lru.items[key]; exists {
+		lru.order.Remove(elem)
+		delete(lru.items, key)
+	}
+}
+
+// Victim 淘汰链表尾部最久未使用的缓存项
+func (lru *LRUCache) Victim(incoming string) string {
+	elem := lru.order.Back()
+	if elem == nil {
+		return ""
+	}
+	key := elem.Value.(string)
+	lru.OnRemove(key)
+	return key
+}
+
+// SelectVictim 选择淘汰的缓存项
+func (lru *LRUCache) SelectVictim(cache *MemoryCache) string {
+	return lru.Victim("")
+}
+
+// Stats 策略内部统计
+func (lru *LRUCache) Stats() map[string]int64 {
+	return map[string]int64{
+		"tracked": int64(len(lru.items)),
+	}
+}
+
+// lfuEntry LFU 缓存项
+type lfuEntry struct {
+	key  string
+	freq int64
+	elem *list.Element
+}
+
+// LFUCache LFU缓存淘汰策略，按访问频率分桶，同频率内按 LRU 淘汰，所有操作 O(1)
+type LFUCache struct {
+	items   map[string]*lfuEntry
+	buckets map[int64]*list.List
+	minFreq int64
+}
+
+// Name 策略名称
+func (lfu *LFUCache) Name() string {
+	return PolicyLFU
+}
+
+// Init 初始化
+func (lfu *LFUCache) Init(capacity int) {
+	lfu.items = make(map[string]*lfuEntry)
+	lfu.buckets = make(map[int64]*list.List)
+	lfu.minFreq = 0
+}
+
+// OnInsert 插入缓存项，新项频率为 1，更新已有项视为一次访问
+func (lfu *LFUCache) OnInsert(key string, size int64, expiration int64) {
+	if _, exists := lfu.items[key]; exists {
+		lfu.OnAccess(key)
+		return
+	}
+	entry := &lfuEntry{key: key, freq: 1}
+	entry.elem = lfu.bucket(1).PushFront(entry)
+	lfu.items[key] = entry
+	lfu.minFreq = 1
+}
+
+// OnAccess 命中缓存项，频率加一
+func (lfu *LFUCache) OnAccess(key string) {
+	entry, exists := lfu.items[key]
+	if !exists {
+		return
+	}
+	lfu.unlink(entry)
+	entry.freq++
+	entry.elem = lfu.bucket(entry.freq).PushFront(entry)
+}
+
+// OnRemove 删除缓存项
+func (lfu *LFUCache) OnRemove(key string) {
+	if entry, exists := lfu.items[key]; exists {
+		lfu.unlink(entry)
+		delete(lfu.items, key)
+	}
+}
+
+// Victim 淘汰访问频率最低的缓存项
+func (lfu *LFUCache) Victim(incoming string) string {
+	if len(lfu.items) == 0 {
+		return ""
+	}
+	// 删除操作可能使最低频率的桶变空，需要向上查找
+	for lfu.buckets[lfu.minFreq] == nil {
+		lfu.minFreq++
+	}
+	entry := lfu.buckets[lfu.minFreq].Back().Value.(*lfuEntry)
+	lfu.OnRemove(entry.key)
+	return entry.key
+}
+
+// SelectVictim 选择淘汰的缓存项
+func (lfu *LFUCache) SelectVictim(cache *MemoryCache) string {
+	return lfu.Victim("")
+}
+
+// Stats 策略内部统计
+func (lfu *LFUCache) Stats() map[string]int64 {
+	return map[string]int64{
+		"tracked":  int64(len(lfu.items)),
+		"buckets":  int64(len(lfu.buckets)),
+		"min_freq": lfu.minFreq,
+	}
+}
+
+// bucket 获取频率桶，不存在时创建
+func (lfu *LFUCache) bucket(freq int64) *list.List {
+	b, exists := lfu.buckets[freq]
+	if !exists {
+		b = list.New()
+		lfu.buckets[freq] = b
+	}
+	return b
+}
+
+// unlink 将缓存项从所在的频率桶移除，空桶同时删除
+func (lfu *LFUCache) unlink(entry *lfuEntry) {
+	b := lfu.buckets[entry.freq]
+	b.Remove(entry.elem)
+	if b.Len() == 0 {
+		delete(lfu.buckets, entry.freq)
+		if lfu.minFreq == entry.freq {
+			lfu.minFreq++
+		}
+	}
+}
+
+// ARC 的四个链表
+const (
+	arcT1 = iota // 最近只访问过一次的缓存项
+	arcT2        // 最近访问过至少两次的缓存项
+	arcB1        // 从 T1 淘汰的幽灵项
+	arcB2        // 从 T2 淘汰的幽灵项
+)
+
+// arcEntry ARC 链表项
+type arcEntry struct {
+	key   string
+	where int
+	elem  *list.Element
+}
+
+// ARCCache ARC（自适应替换）缓存淘汰策略
+// 同时维护最近访问和频繁访问两个链表，根据幽灵链表的命中情况自适应调整两者的目标大小
+type ARCCache struct {
+	lists     [4]*list.List
+	items     map[string]*arcEntry
+	capacity  int
+	p         int
+	adapted   string
+	ghostHits [2]int64
+}
+
+// Name 策略名称
+func (arc *ARCCache) Name() string {
+	return PolicyARC
+}
+
+// Init 初始化
+func (arc *ARCCache) Init(capacity int) {
+	for i := range arc.lists {
+		arc.lists[i] = list.New()
+	}
+	arc.items = make(map[string]*arcEntry)
+	arc.capacity = capacity
+	arc.p = 0
+	arc.adapted = ""
+	arc.ghostHits = [2]int64{}
+}
+
+// OnInsert 插入缓存项，幽灵链表命中时进入 T2，否则进入 T1
+func (arc *ARCCache) OnInsert(key string, size int64, expiration int64) {
+	entry, exists := arc.items[key]
+	if exists && (entry.where == arcT1 || entry.where == arcT2) {
+		arc.OnAccess(key)
+		return
+	}
+
+	if arc.adapted != key {
+		arc.adapt(key)
+	}
+	arc.adapted = ""
+
+	if exists {
+		// 幽灵命中，说明该项被过早淘汰
+		arc.move(entry, arcT2)
+		return
+	}
+
+	arc.items[key] = &arcEntry{key: key, where: arcT1}
+	arc.items[key].elem = arc.lists[arcT1].PushFront(arc.items[key])
+
+	// 限制幽灵链表长度
+	if arc.lists[arcT1].Len()+arc.lists[arcB1].Len() > arc.capacity {
+		arc.dropLRU(arcB1)
+	}
+	if arc.total() > 2*arc.capacity {
+		arc.dropLRU(arcB2)
+	}
+}
+
+// OnAccess 命中缓存项，移到 T2 头部
+func (arc *ARCCache) OnAccess(key string) {
+	if entry, exists := arc.items[key]; exists && (entry.where == arcT1 || entry.where == arcT2) {
+		arc.move(entry, arcT2)
+	}
+}
+
+// OnRemove 删除缓存项，显式删除不保留幽灵项
+func (arc *ARCCache) OnRemove(key string) {
+	if entry, exists := arc.items[key]; exists {
+		arc.lists[entry.where].Remove(entry.elem)
+		delete(arc.items, key)
+	}
+}
+
+// Victim 按目标大小 p 从 T1 或 T2 淘汰，被淘汰项进入对应的幽灵链表
+func (arc *ARCCache) Victim(incoming string) string {
+	// 先根据即将插入的键调整目标大小
+	arc.adapt(incoming)
+	arc.adapted = incoming
+
+	t1 := arc.lists[arcT1].Len()
+	from := arcT2
+	if t1 > 0 && (t1 > arc.p || arc.lists[arcT2].Len() == 0) {
+		from = arcT1
+	} else if t1 > 0 && t1 == arc.p {
+		if entry, exists := arc.items[incoming]; exists && entry.where == arcB2 {
+			from = arcT1
+		}
+	}
+
+	elem := arc.lists[from].Back()
+	if elem == nil {
+		return ""
+	}
+	entry := elem.Value.(*arcEntry)
+	if from == arcT1 {
+		arc.move(entry, arcB1)
+	} else {
+		arc.move(entry, arcB2)
+	}
+	return entry.key
+}
+
+// SelectVictim 选择淘汰的缓存项
+func (arc *ARCCache) SelectVictim(cache *MemoryCache) string {
+	return arc.Victim("")
+}
+
+// Stats 策略内部统计
+func (arc *ARCCache) Stats() map[string]int64 {
+	return map[string]int64{
+		"target_t1":     int64(arc.p),
+		"t1":            int64(arc.lists[arcT1].Len()),
+		"t2":            int64(arc.lists[arcT2].Len()),
+		"b1":            int64(arc.lists[arcB1].Len()),
+		"b2":            int64(arc.lists[arcB2].Len()),
+		"b1_ghost_hits": arc.ghostHits[0],
+		"b2_ghost_hits": arc.ghostHits[1],
+	}
+}
+
+// adapt 根据幽灵命中调整 T1 的目标大小
+func (arc *ARCCache) adapt(key string) {
+	entry, exists := arc.items[key]
+	if !exists || key == arc.adapted {
+		return
+	}
+	b1 := arc.lists[arcB1].Len()
+	b2 := arc.lists[arcB2].Len()
+	switch entry.where {
+	case arcB1:
+		// 最近访问的项被过早淘汰，增大 T1
+		delta := 1
+		if b1 > 0 && b2/b1 > 1 {
+			delta = b2 / b1
+		}
+		arc.p = minInt(arc.capacity, arc.p+delta)
+		arc.ghostHits[0]++
+	case arcB2:
+		// 频繁访问的项被过早淘汰，减小 T1
+		delta := 1
+		if b2 > 0 && b1/b2 > 1 {
+			delta = b1 / b2
+		}
+		arc.p = maxInt(0, arc.p-delta)
+		arc.ghostHits[1]++
+	}
+}
+
+// move 将链表项移到目标链表头部
+func (arc *ARCCache) move(entry *arcEntry, to int) {
+	arc.lists[entry.where].Remove(entry.elem)
+	entry.where = to
+	entry.elem = arc.lists[to].PushFront(entry)
+}
+
+// dropLRU 丢弃链表尾部的项
+func (arc *ARCCache) dropLRU(which int) {
+	if elem := arc.lists[which].Back(); elem != nil {
+		entry := elem.Value.(*arcEntry)
+		arc.lists[which].Remove(elem)
+		delete(arc.items, entry.key)
+	}
+}
+
+// total 四个链表的总长度
+func (arc *ARCCache) total() int {
+	n := 0
+	for _, l := range arc.lists {
+		n += l.Len()
+	}
+	return n
+}
+
+// heapEntry 基于优先级淘汰的缓存项
+type heapEntry struct {
+	key      string
+	size     int64
+	priority float64
+	seq      uint64
+	index    int
+}
+
+// entryHeap 按优先级排序的最小堆，优先级相同时先淘汰较早访问的项
+type entryHeap []*heapEntry
+
+func (h entryHeap) Len() int { return len(h) }
+
+func (h entryHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	entry := x.(*heapEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// priorityIndex 可按键更新优先级的堆
+type priorityIndex struct {
+	heap  entryHeap
+	items map[string]*heapEntry
+	seq   uint64
+}
+
+// reset 清空
+func (p *priorityIndex) reset() {
+	p.heap = nil
+	p.items = make(map[string]*heapEntry)
+	p.seq = 0
+}
+
+// upsert 插入或更新优先级
+func (p *priorityIndex) upsert(key string, size int64, priority float64) {
+	p.seq++
+	if entry, exists := p.items[key]; exists {
+		entry.size = size
+		entry.priority = priority
+		entry.seq = p.seq
+		heap.Fix(&p.heap, entry.index)
+		return
+	}
+	entry := &heapEntry{key: key, size: size, priority: priority, seq: p.seq}
+	heap.Push(&p.heap, entry)
+	p.items[key] = entry
+}
+
+// remove 删除
+func (p *priorityIndex) remove(key string) {
+	if entry, exists := p.items[key]; exists {
+		heap.Remove(&p.heap, entry.index)
+		delete(p.items, key)
+	}
+}
+
+// pop 弹出优先级最低的项
+func (p *priorityIndex) pop() *heapEntry {
+	if len(p.heap) == 0 {
+		return nil
+	}
+	entry := heap.Pop(&p.heap).(*heapEntry)
+	delete(p.items, entry.key)
+	return entry
+}
+
+// SizeAwareCache 按大小感知的缓存淘汰策略（GreedyDual-Size）
+// 优先级为 L + 1/size，大的缓存项更早被淘汰，淘汰时 L 提升到被淘汰项的优先级，使长期未访问的小项也会老化
+type SizeAwareCache struct {
+	index     priorityIndex
+	inflation float64
+	bytes     int64
+}
+
+// Name 策略名称
+func (s *SizeAwareCache) Name() string {
+	return PolicySize
+}
+
+// Init 初始化
+func (s *SizeAwareCache) Init(capacity int) {
+	s.index.reset()
+	s.inflation = 0
+	s.bytes = 0
+}
+
+// OnInsert 插入或更新缓存项
+func (s *SizeAwareCache) OnInsert(key string, size int64, expiration int64) {
+	if size <= 0 {
+		size = 1
+	}
+	if entry, exists := s.index.items[key]; exists {
+		s.bytes -= entry.size
+	}
+	s.bytes += size
+	s.index.upsert(key, size, s.inflation+1/float64(size))
+}
+
+// OnAccess 命中缓存项，恢复优先级
+func (s *SizeAwareCache) OnAccess(key string) {
+	if entry, exists := s.index.items[key]; exists {
+		s.index.upsert(key, entry.size, s.inflation+1/float64(entry.size))
+	}
+}
+
+// OnRemove 删除缓存项
+func (s *SizeAwareCache) OnRemove(key string) {
+	if entry, exists := s.index.items[key]; exists {
+		s.bytes -= entry.size
+		s.index.remove(key)
+	}
+}
+
+// Victim 淘汰优先级最低的缓存项
+func (s *SizeAwareCache) Victim(incoming string) string {
+	entry := s.index.pop()
+	if entry == nil {
+		return ""
+	}
+	s.inflation = entry.priority
+	s.bytes -= entry.size
+	return entry.key
+}
+
+// SelectVictim 选择淘汰的缓存项
+func (s *SizeAwareCache) SelectVictim(cache *MemoryCache) string {
+	return s.Victim("")
+}
+
+// Stats 策略内部统计
+func (s *SizeAwareCache) Stats() map[string]int64 {
+	return map[string]int64{
+		"tracked": int64(len(s.index.items)),
+		"bytes":   s.bytes,
+	}
+}
+
+// needsSize 该策略需要缓存项大小
+func (s *SizeAwareCache) needsSize() bool {
+	return true
+}
+
+// TTLCache 感知过期时间的缓存淘汰策略
+// 优先淘汰最先过期的缓存项，没有过期时间的缓存项按 LRU 排在所有带过期时间的项之后
+type TTLCache struct {
+	index    priorityIndex
+	expiring int64
+}
+
+// Name 策略名称
+func (t *TTLCache) Name() string {
+	return PolicyTTL
+}
+
+// Init 初始化
+func (t *TTLCache) Init(capacity int) {
+	t.index.reset()
+	t.expiring = 0
+}
+
+// OnInsert 插入或更新缓存项
+func (t *TTLCache) OnInsert(key string, size int64, expiration int64) {
+	t.OnRemove(key)
+	priority := math.Inf(1)
+	if expiration > 0 {
+		priority = float64(expiration)
+		t.expiring++
+	}
+	t.index.upsert(key, size, priority)
+}
+
+// OnAccess 命中缓存项，只更新同优先级内的访问顺序
+func (t *TTLCache) OnAccess(key string) {
+	if entry, exists := t.index.items[key]; exists {
+		t.index.upsert(key, entry.size, entry.priority)
+	}
+}
+
+// OnRemove 删除缓存项
+func (t *TTLCache) OnRemove(key string) {
+	if entry, exists := t.index.items[key]; exists {
+		if !math.IsInf(entry.priority, 1) {
+			t.expiring--
+		}
+		t.index.remove(key)
+	}
+}
+
+// Victim 淘汰最先过期的缓存项
+func (t *TTLCache) Victim(incoming string) string {
+	entry := t.index.pop()
+	if entry == nil {
+		return ""
+	}
+	if !math.IsInf(entry.priority, 1) {
+		t.expiring--
+	}
+	return entry.key
+}
+
+// SelectVictim 选择淘汰的缓存项
+func (t *TTLCache) SelectVictim(cache *MemoryCache) string {
+	return t.Victim("")
+}
+
+// Stats 策略内部统计
+func (t *TTLCache) Stats() map[string]int64 {
+	return map[string]int64{
+		"tracked":  int64(len(t.index.items)),
+		"expiring": t.expiring,
+	}
+}
+
+// minInt 较小值
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt 较大值
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 按顺序访问缓存，返回被淘汰的键
+func collectEvictions(c *MemoryCache) *[]string {
+	var evicted []string
+	c.SetOnEvict(func(key string, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	return &evicted
+}
+
+// 测试 LRU 淘汰最久未使用的项
+func TestLRUPolicy(t *testing.T) {
+	c := NewMemoryCache(3, &LRUCache{})
+	evicted := collectEvictions(c)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("c", 3, 0)
+	c.Get("a")
+	c.Set("d", 4, 0)
+	c.Set("b", 5, 0) // b 已被淘汰，重新插入淘汰 c
+
+	if fmt.Sprint(*evicted) != "[b c]" {
+		t.Errorf("unexpected LRU evictions: %v", *evicted)
+	}
+}
+
+// 测试 LFU 淘汰访问频率最低的项
+func TestLFUPolicy(t *testing.T) {
+	c, err := NewMemoryCacheWithConfig(Config{Capacity: 3, Policy: PolicyLFU})
+	if err != nil {
+		t.Fatalf("NewMemoryCacheWithConfig failed: %v", err)
+	}
+	evicted := collectEvictions(c)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Set("c", 3, 0)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("d", 4, 0)
+	c.Set("e", 5, 0)
+
+	if fmt.Sprint(*evicted) != "[c d]" {
+		t.Errorf("unexpected LFU evictions: %v", *evicted)
+	}
+	if _, exists := c.Get("a"); !exists {
+		t.Errorf("frequently used item should be kept")
+	}
+}
+
+// 测试 ARC 在顺序扫描后保留频繁访问的项
+func TestARCPolicyScanResistance(t *testing.T) {
+	c, err := NewMemoryCacheWithConfig(Config{Capacity: 4, Policy: PolicyARC})
+	if err != nil {
+		t.Fatalf("NewMemoryCacheWithConfig failed: %v", err)
+	}
+
+	c.Set("hot1", 1, 0)
+	c.Set("hot2", 2, 0)
+	c.Get("hot1")
+	c.Get("hot2")
+
+	// 一次性扫描不应冲掉频繁访问的项
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("scan%d", i), i, 0)
+	}
+
+	for _, key := range []string{"hot1", "hot2"} {
+		if _, exists := c.Get(key); !exists {
+			t.Errorf("%s evicted by scan", key)
+		}
+	}
+
+	stats := c.GetPolicyStats()
+	if stats["policy"] != PolicyARC {
+		t.Errorf("unexpected policy name: %v", stats["policy"])
+	}
+	internal := stats["internal"].(map[string]int64)
+	if internal["t1"]+internal["t2"] != 4 {
+		t.Errorf("unexpected ARC resident size: %v", internal)
+	}
+}
+
+// 测试大小感知策略优先淘汰大项，并遵守总大小上限
+func TestSizeAwarePolicy(t *testing.T) {
+	c, err := NewMemoryCacheWithConfig(Config{Capacity: 10, Policy: PolicySize, MaxBytes: 100})
+	if err != nil {
+		t.Fatalf("NewMemoryCacheWithConfig failed: %v", err)
+	}
+	evicted := collectEvictions(c)
+
+	c.Set("small1", make([]byte, 10), 0)
+	c.Set("large", make([]byte, 60), 0)
+	c.Set("small2", make([]byte, 10), 0)
+	c.Set("medium", make([]byte, 40), 0)
+
+	if fmt.Sprint(*evicted) != "[large]" {
+		t.Errorf("unexpected size-aware evictions: %v", *evicted)
+	}
+	if bytes := c.GetStats()["bytes"]; bytes != 60 {
+		t.Errorf("expected 60 bytes cached, got %d", bytes)
+	}
+}
+
+// 测试 TTL 策略优先淘汰最先过期的项
+func TestTTLPolicy(t *testing.T) {
+	c, err := NewMemoryCacheWithConfig(Config{Capacity: 3, Policy: PolicyTTL})
+	if err != nil {
+		t.Fatalf("NewMemoryCacheWithConfig failed: %v", err)
+	}
+	evicted := collectEvictions(c)
+
+	c.Set("forever", 1, 0)
+	c.Set("long", 2, time.Hour)
+	c.Set("short", 3, time.Minute)
+	c.Set("new", 4, 0)
+	c.Set("newer", 5, 0)
+
+	if fmt.Sprint(*evicted) != "[short long]" {
+		t.Errorf("unexpected TTL evictions: %v", *evicted)
+	}
+}
+
+// 测试未知策略名称
+func TestUnknownPolicy(t *testing.T) {
+	if _, err := NewMemoryCacheWithConfig(Config{Capacity: 1, Policy: "mru"}); err != ErrUnknownPolicy {
+		t.Errorf("expected ErrUnknownPolicy, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CheckpointInfo 存储快照信息
+type CheckpointInfo struct {
+	Keys  int   `json:"keys"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Checkpoint 在 dir 下创建存储的一致快照，快照目录可以直接作为数据目录打开
+// 快照期间阻塞批次写入和分层迁移：已应用的批次都在冷数据存储中，通过硬链接复制；
+// 尚未降级的热数据单独编码写入快照，源存储中的数据不受影响
+func (s *OptimizedStorage) Checkpoint(dir string) (*CheckpointInfo, error) {
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	coldDir := filepath.Join(dir, "cold")
+	keys, bytes, err := s.coldStorage.Checkpoint(coldDir)
+	if err != nil {
+		return nil, err
+	}
+	info := &CheckpointInfo{Keys: keys, Bytes: bytes}
+
+	// 写入尚未降级的热数据
+	var writeErr error
+	s.hotStorage.Range(func(key string, value interface{}) bool {
+		item := value.(*StorageItem)
+		if !item.Dirty {
+			return true
+		}
+
+		data, err := s.codec.Encode(item.Value)
+		if err != nil {
+			writeErr = err
+			return false
+		}
+		if compressed, err := s.compressor.Compress(data); err == nil {
+			data = compressed
+		}
+
+		// 冷数据存储中可能有同名的旧副本（硬链接），先删除再写入
+		path := filepath.Join(coldDir, key)
+		os.Remove(path)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			writeErr = err
+			return false
+		}
+		if !s.coldStorage.Has(key) {
+			info.Keys++
+		}
+		info.Bytes += int64(len(data))
+		return true
+	})
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return info, nil
+}
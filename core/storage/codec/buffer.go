@@ -0,0 +1,217 @@
+package codec
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Encoder 二进制编码缓冲区
+// 字节串和列表的长度前缀为 长度+1，0 表示 nil，保证 nil 和空值编码后可以区分
+type Encoder struct {
+	buf []byte
+}
+
+// Bytes 获取已编码的数据
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// WriteUint64 写入无符号整数
+func (e *Encoder) WriteUint64(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// WriteInt64 写入有符号整数
+func (e *Encoder) WriteInt64(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+// WriteBool 写入布尔值
+func (e *Encoder) WriteBool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// WriteBytes 写入字节串
+func (e *Encoder) WriteBytes(v []byte) {
+	if v == nil {
+		e.WriteUint64(0)
+		return
+	}
+	e.WriteUint64(uint64(len(v)) + 1)
+	e.buf = append(e.buf, v...)
+}
+
+// WriteString 写入字符串
+func (e *Encoder) WriteString(v string) {
+	e.WriteUint64(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// WriteHash 写入哈希
+func (e *Encoder) WriteHash(v common.Hash) {
+	e.buf = append(e.buf, v.Bytes()...)
+}
+
+// WriteAddress 写入地址
+func (e *Encoder) WriteAddress(v common.Address) {
+	e.buf = append(e.buf, v.Bytes()...)
+}
+
+// WriteBigInt 写入大整数，标记字节 0 为 nil，1 为非负数，2 为负数
+func (e *Encoder) WriteBigInt(v *big.Int) {
+	switch {
+	case v == nil:
+		e.buf = append(e.buf, 0)
+		return
+	case v.Sign() < 0:
+		e.buf = append(e.buf, 2)
+	default:
+		e.buf = append(e.buf, 1)
+	}
+	e.WriteBytes(v.Bytes())
+}
+
+// WriteLen 写入列表长度，nil 列表写入 0
+func (e *Encoder) WriteLen(n int, isNil bool) {
+	if isNil {
+		e.WriteUint64(0)
+		return
+	}
+	e.WriteUint64(uint64(n) + 1)
+}
+
+// Decoder 二进制解码缓冲区，出错后的读取全部返回零值，由 Err 报告第一个错误
+type Decoder struct {
+	buf []byte
+	err error
+}
+
+// NewDecoder 创建解码器
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{buf: data}
+}
+
+// Err 获取解码过程中的第一个错误，数据未读完也视为错误
+func (d *Decoder) Err() error {
+	if d.err == nil && len(d.buf) > 0 {
+		return ErrTrailingData
+	}
+	return d.err
+}
+
+// ReadUint64 读取无符号整数
+func (d *Decoder) ReadUint64() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = ErrShortData
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// ReadInt64 读取有符号整数
+func (d *Decoder) ReadInt64() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = ErrShortData
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// ReadBool 读取布尔值
+func (d *Decoder) ReadBool() bool {
+	b := d.next(1)
+	return b != nil && b[0] == 1
+}
+
+// ReadBytes 读取字节串
+func (d *Decoder) ReadBytes() []byte {
+	n := d.ReadUint64()
+	if n == 0 {
+		return nil
+	}
+	b := d.next(n - 1)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// ReadString 读取字符串
+func (d *Decoder) ReadString() string {
+	n := d.ReadUint64()
+	return string(d.next(n))
+}
+
+// ReadHash 读取哈希
+func (d *Decoder) ReadHash() common.Hash {
+	return common.BytesToHash(d.next(common.HashLength))
+}
+
+// ReadAddress 读取地址
+func (d *Decoder) ReadAddress() common.Address {
+	return common.BytesToAddress(d.next(common.AddressLength))
+}
+
+// ReadBigInt 读取大整数
+func (d *Decoder) ReadBigInt() *big.Int {
+	flag := d.next(1)
+	if flag == nil || flag[0] == 0 {
+		return nil
+	}
+	v := new(big.Int).SetBytes(d.ReadBytes())
+	if flag[0] == 2 {
+		v.Neg(v)
+	}
+	return v
+}
+
+// ReadLen 读取列表长度，返回的 isNil 表示原列表为 nil
+func (d *Decoder) ReadLen() (int, bool) {
+	n := d.ReadUint64()
+	if n == 0 {
+		return 0, true
+	}
+	// 每个元素至少占一个字节，防止伪造的长度导致超大内存分配
+	if n-1 > uint64(len(d.buf)) {
+		d.fail(ErrShortData)
+		return 0, true
+	}
+	return int(n - 1), false
+}
+
+// next 读取 n 个字节
+func (d *Decoder) next(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if uint64(len(d.buf)) < n {
+		d.err = ErrShortData
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// fail 记录错误
+func (d *Decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
@@ -0,0 +1,211 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// 编码格式：魔数(1) + 类型标签(1) + 载荷
+// 魔数不是合法 JSON 的首字节，没有魔数的数据按旧格式 JSON 解码
+const magic = 0xCD
+
+// Tag 值类型标签
+type Tag byte
+
+// 内置类型标签，持久化后不能修改
+const (
+	// TagJSON 未注册类型，载荷为 JSON，解码为 interface{}
+	TagJSON Tag = iota
+	TagBytes
+	TagString
+	TagUint64
+	TagInt64
+	TagInt
+	TagBool
+	TagBigInt
+	TagHash
+	TagAddress
+	TagHeader
+	TagBlock
+	TagBody
+	TagTransaction
+	TagReceipt
+	TagReceipts
+	TagAccount
+	TagStateDump
+)
+
+var (
+	// ErrShortData 数据不完整
+	ErrShortData = errors.New("codec: short data")
+	// ErrTrailingData 解码后还有多余数据
+	ErrTrailingData = errors.New("codec: trailing data")
+	// ErrUnknownTag 未注册的类型标签
+	ErrUnknownTag = errors.New("codec: unknown type tag")
+	// ErrDuplicateTag 类型标签或类型已注册
+	ErrDuplicateTag = errors.New("codec: duplicate registration")
+)
+
+// EncodeFunc 将值编码到编码器
+type EncodeFunc func(e *Encoder, v interface{}) error
+
+// DecodeFunc 从解码器解码值
+type DecodeFunc func(d *Decoder) (interface{}, error)
+
+// entry 注册项
+type entry struct {
+	tag    Tag
+	typ    reflect.Type
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+// Registry 类型化编解码器注册表
+type Registry struct {
+	byTag  map[Tag]*entry
+	byType map[reflect.Type]*entry
+	mutex  sync.RWMutex
+}
+
+// NewRegistry 创建只包含基础类型的注册表
+func NewRegistry() *Registry {
+	r := &Registry{
+		byTag:  make(map[Tag]*entry),
+		byType: make(map[reflect.Type]*entry),
+	}
+	registerBasicTypes(r)
+	return r
+}
+
+// Register 注册类型，prototype 为该类型的任意值，用于确定 Go 类型
+func (r *Registry) Register(tag Tag, prototype interface{}, encode EncodeFunc, decode DecodeFunc) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	typ := reflect.TypeOf(prototype)
+	if tag == TagJSON || typ == nil {
+		return fmt.Errorf("codec: invalid registration for tag %d", tag)
+	}
+	if _, exists := r.byTag[tag]; exists {
+		return ErrDuplicateTag
+	}
+	if _, exists := r.byType[typ]; exists {
+		return ErrDuplicateTag
+	}
+
+	e := &entry{tag: tag, typ: typ, encode: encode, decode: decode}
+	r.byTag[tag] = e
+	r.byType[typ] = e
+	return nil
+}
+
+// mustRegister 注册内置类型，失败说明标签冲突，属于编程错误
+func (r *Registry) mustRegister(tag Tag, prototype interface{}, encode EncodeFunc, decode DecodeFunc) {
+	if err := r.Register(tag, prototype, encode, decode); err != nil {
+		panic(err)
+	}
+}
+
+// Encode 编码值，未注册的类型按 JSON 编码
+func (r *Registry) Encode(v interface{}) ([]byte, error) {
+	r.mutex.RLock()
+	e, exists := r.byType[reflect.TypeOf(v)]
+	r.mutex.RUnlock()
+
+	if !exists {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{magic, byte(TagJSON)}, data...), nil
+	}
+
+	enc := &Encoder{buf: []byte{magic, byte(e.tag)}}
+	if err := e.encode(enc, v); err != nil {
+		return nil, fmt.Errorf("codec: encode %v: %v", e.typ, err)
+	}
+	return enc.Bytes(), nil
+}
+
+// Decode 解码值，返回与编码前相同的 Go 类型
+func (r *Registry) Decode(data []byte) (interface{}, error) {
+	if len(data) < 2 || data[0] != magic {
+		// 旧格式数据
+		return decodeJSON(data)
+	}
+
+	tag := Tag(data[1])
+	if tag == TagJSON {
+		return decodeJSON(data[2:])
+	}
+
+	r.mutex.RLock()
+	e, exists := r.byTag[tag]
+	r.mutex.RUnlock()
+	if !exists {
+		return nil, ErrUnknownTag
+	}
+
+	dec := NewDecoder(data[2:])
+	v, err := e.decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("codec: decode %v: %v", e.typ, err)
+	}
+	if err := dec.Err(); err != nil {
+		return nil, fmt.Errorf("codec: decode %v: %v", e.typ, err)
+	}
+	return v, nil
+}
+
+// IsLegacy 检查数据是否为没有魔数的旧格式 JSON
+func IsLegacy(data []byte) bool {
+	return len(data) < 2 || data[0] != magic
+}
+
+// TagOf 获取值的类型标签，未注册的类型返回 TagJSON
+func (r *Registry) TagOf(v interface{}) Tag {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if e, exists := r.byType[reflect.TypeOf(v)]; exists {
+		return e.tag
+	}
+	return TagJSON
+}
+
+// decodeJSON 将 JSON 解码为 interface{}
+func decodeJSON(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Default 默认注册表，包含基础类型和链上数据类型
+var Default = newDefaultRegistry()
+
+// newDefaultRegistry 创建默认注册表
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	registerChainTypes(r)
+	return r
+}
+
+// Register 在默认注册表中注册类型
+func Register(tag Tag, prototype interface{}, encode EncodeFunc, decode DecodeFunc) error {
+	return Default.Register(tag, prototype, encode, decode)
+}
+
+// Encode 使用默认注册表编码
+func Encode(v interface{}) ([]byte, error) {
+	return Default.Encode(v)
+}
+
+// Decode 使用默认注册表解码
+func Decode(data []byte) (interface{}, error) {
+	return Default.Decode(data)
+}
@@ -0,0 +1,108 @@
+package codec
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+// 测试链上数据类型编码后解码为相同的 Go 类型
+func TestChainTypesRoundTrip(t *testing.T) {
+	to := common.HexToAddress("0x02")
+	tx := types.NewTransaction(1, to, big.NewInt(100), 21000, big.NewInt(1), []byte{})
+	uncle := &types.BlockHeader{Number: big.NewInt(4), Difficulty: big.NewInt(10), Extra: []byte("uncle")}
+	block := types.NewBlock(common.HexToHash("0x01"), common.HexToAddress("0x03"), common.Hash{}, common.Hash{}, common.Hash{},
+		big.NewInt(1000), big.NewInt(5), 8000000, 21000, 1700000000, []byte{}, common.HexToHash("0x04"), 42,
+		[]*types.Transaction{tx, types.NewContractCreation(2, big.NewInt(0), 50000, big.NewInt(1), []byte{0x60})},
+		[]*types.BlockHeader{uncle})
+
+	values := []interface{}{
+		block,
+		block.Header,
+		block.Body(),
+		tx,
+		&types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			Logs:        []*types.Log{{Address: to, Topics: []common.Hash{{0x01}}, Data: []byte("log")}},
+			TxHash:      common.HexToHash("0x05"),
+			BlockNumber: big.NewInt(5),
+		},
+		types.Receipts{{Status: types.ReceiptStatusFailed}},
+		&state.Account{Nonce: 3, Balance: big.NewInt(-7), CodeHash: []byte{0xaa}},
+		&state.Dump{Root: common.HexToHash("0x06"), Accounts: map[common.Address]state.DumpAccount{
+			to: {Nonce: 1, Balance: big.NewInt(9), Storage: map[common.Hash]common.Hash{{0x01}: {0x02}}},
+		}},
+		common.HexToHash("0x07"),
+		uint64(1) << 40,
+		"head",
+		[]byte{},
+	}
+
+	for _, value := range values {
+		data, err := Encode(value)
+		if err != nil {
+			t.Fatalf("encode %T failed: %v", value, err)
+		}
+		if Default.TagOf(value) == TagJSON {
+			t.Errorf("%T is not registered", value)
+		}
+		decoded, err := Decode(data)
+		if err != nil {
+			t.Fatalf("decode %T failed: %v", value, err)
+		}
+		if !reflect.DeepEqual(value, decoded) {
+			t.Errorf("%T round trip mismatch:\n%#v\n%#v", value, value, decoded)
+		}
+	}
+
+	// 区块哈希基于 JSON 编码，nil 和空字节串必须保持不变
+	data, _ := Encode(block)
+	decoded, _ := Decode(data)
+	if decoded.(*types.Block).Hash() != block.Hash() {
+		t.Errorf("block hash changed after round trip")
+	}
+}
+
+// 测试未注册类型和旧格式数据按 JSON 解码
+func TestJSONFallback(t *testing.T) {
+	data, err := Encode(map[string]interface{}{"a": float64(1)})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if Tag(data[1]) != TagJSON {
+		t.Errorf("expected JSON tag, got %d", data[1])
+	}
+	value, err := Decode(data)
+	if err != nil || value.(map[string]interface{})["a"] != float64(1) {
+		t.Errorf("unexpected JSON fallback result: %v %v", value, err)
+	}
+
+	value, err = Decode([]byte(`"legacy"`))
+	if err != nil || value != "legacy" {
+		t.Errorf("unexpected legacy result: %v %v", value, err)
+	}
+}
+
+// 测试损坏数据和重复注册
+func TestRegistryErrors(t *testing.T) {
+	data, _ := Encode(common.HexToHash("0x01"))
+	if _, err := Decode(data[:len(data)-1]); err == nil {
+		t.Errorf("expected error for truncated data")
+	}
+	if _, err := Decode(append(data, 0x00)); err == nil {
+		t.Errorf("expected error for trailing data")
+	}
+	if _, err := Decode([]byte{magic, 0xff}); err != ErrUnknownTag {
+		t.Errorf("expected ErrUnknownTag, got %v", err)
+	}
+
+	r := NewRegistry()
+	if err := r.Register(TagString, int32(0), nil, nil); err != ErrDuplicateTag {
+		t.Errorf("expected ErrDuplicateTag, got %v", err)
+	}
+}
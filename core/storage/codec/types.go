@@ -0,0 +1,494 @@
+package codec
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/core/state"
+	"nogochain/core/types"
+)
+
+// registerBasicTypes 注册基础类型
+func registerBasicTypes(r *Registry) {
+	r.mustRegister(TagBytes, []byte(nil),
+		func(e *Encoder, v interface{}) error {
+			e.WriteBytes(v.([]byte))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadBytes(), nil
+		})
+	r.mustRegister(TagString, "",
+		func(e *Encoder, v interface{}) error {
+			e.WriteString(v.(string))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadString(), nil
+		})
+	r.mustRegister(TagUint64, uint64(0),
+		func(e *Encoder, v interface{}) error {
+			e.WriteUint64(v.(uint64))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadUint64(), nil
+		})
+	r.mustRegister(TagInt64, int64(0),
+		func(e *Encoder, v interface{}) error {
+			e.WriteInt64(v.(int64))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadInt64(), nil
+		})
+	r.mustRegister(TagInt, int(0),
+		func(e *Encoder, v interface{}) error {
+			e.WriteInt64(int64(v.(int)))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return int(d.ReadInt64()), nil
+		})
+	r.mustRegister(TagBool, false,
+		func(e *Encoder, v interface{}) error {
+			e.WriteBool(v.(bool))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadBool(), nil
+		})
+	r.mustRegister(TagBigInt, (*big.Int)(nil),
+		func(e *Encoder, v interface{}) error {
+			e.WriteBigInt(v.(*big.Int))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadBigInt(), nil
+		})
+	r.mustRegister(TagHash, common.Hash{},
+		func(e *Encoder, v interface{}) error {
+			e.WriteHash(v.(common.Hash))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadHash(), nil
+		})
+	r.mustRegister(TagAddress, common.Address{},
+		func(e *Encoder, v interface{}) error {
+			e.WriteAddress(v.(common.Address))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return d.ReadAddress(), nil
+		})
+}
+
+// registerChainTypes 注册区块、交易、收据和账户类型
+func registerChainTypes(r *Registry) {
+	r.mustRegister(TagHeader, (*types.BlockHeader)(nil),
+		func(e *Encoder, v interface{}) error {
+			writeHeader(e, v.(*types.BlockHeader))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return readHeader(d), nil
+		})
+	r.mustRegister(TagBlock, (*types.Block)(nil),
+		func(e *Encoder, v interface{}) error {
+			block := v.(*types.Block)
+			e.WriteBool(block != nil)
+			if block != nil {
+				writeHeader(e, block.Header)
+				writeTransactions(e, block.Transactions)
+				writeHeaders(e, block.Uncles)
+			}
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			if !d.ReadBool() {
+				return (*types.Block)(nil), nil
+			}
+			return &types.Block{
+				Header:       readHeader(d),
+				Transactions: readTransactions(d),
+				Uncles:       readHeaders(d),
+			}, nil
+		})
+	r.mustRegister(TagBody, (*types.Body)(nil),
+		func(e *Encoder, v interface{}) error {
+			body := v.(*types.Body)
+			e.WriteBool(body != nil)
+			if body != nil {
+				writeTransactions(e, body.Transactions)
+				writeHeaders(e, body.Uncles)
+			}
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			if !d.ReadBool() {
+				return (*types.Body)(nil), nil
+			}
+			return &types.Body{
+				Transactions: readTransactions(d),
+				Uncles:       readHeaders(d),
+			}, nil
+		})
+	r.mustRegister(TagTransaction, (*types.Transaction)(nil),
+		func(e *Encoder, v interface{}) error {
+			writeTransaction(e, v.(*types.Transaction))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return readTransaction(d), nil
+		})
+	r.mustRegister(TagReceipt, (*types.Receipt)(nil),
+		func(e *Encoder, v interface{}) error {
+			writeReceipt(e, v.(*types.Receipt))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return readReceipt(d), nil
+		})
+	r.mustRegister(TagReceipts, types.Receipts(nil),
+		func(e *Encoder, v interface{}) error {
+			receipts := v.(types.Receipts)
+			e.WriteLen(len(receipts), receipts == nil)
+			for _, receipt := range receipts {
+				writeReceipt(e, receipt)
+			}
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			n, isNil := d.ReadLen()
+			if isNil {
+				return types.Receipts(nil), nil
+			}
+			receipts := make(types.Receipts, n)
+			for i := range receipts {
+				receipts[i] = readReceipt(d)
+			}
+			return receipts, nil
+		})
+	r.mustRegister(TagAccount, (*state.Account)(nil),
+		func(e *Encoder, v interface{}) error {
+			account := v.(*state.Account)
+			e.WriteBool(account != nil)
+			if account != nil {
+				e.WriteUint64(account.Nonce)
+				e.WriteBigInt(account.Balance)
+				e.WriteHash(account.Root)
+				e.WriteBytes(account.CodeHash)
+			}
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			if !d.ReadBool() {
+				return (*state.Account)(nil), nil
+			}
+			return &state.Account{
+				Nonce:    d.ReadUint64(),
+				Balance:  d.ReadBigInt(),
+				Root:     d.ReadHash(),
+				CodeHash: d.ReadBytes(),
+			}, nil
+		})
+	r.mustRegister(TagStateDump, (*state.Dump)(nil),
+		func(e *Encoder, v interface{}) error {
+			writeDump(e, v.(*state.Dump))
+			return nil
+		},
+		func(d *Decoder) (interface{}, error) {
+			return readDump(d), nil
+		})
+}
+
+// writeHeader 编码区块头，先写入是否为 nil
+func writeHeader(e *Encoder, h *types.BlockHeader) {
+	e.WriteBool(h != nil)
+	if h == nil {
+		return
+	}
+	e.WriteHash(h.ParentHash)
+	e.WriteHash(h.UncleHash)
+	e.WriteAddress(h.Coinbase)
+	e.WriteHash(h.Root)
+	e.WriteHash(h.TxHash)
+	e.WriteHash(h.ReceiptHash)
+	e.WriteBytes(h.Bloom)
+	e.WriteBigInt(h.Difficulty)
+	e.WriteBigInt(h.Number)
+	e.WriteUint64(h.GasLimit)
+	e.WriteUint64(h.GasUsed)
+	e.WriteUint64(h.Time)
+	e.WriteBytes(h.Extra)
+	e.WriteHash(h.MixDigest)
+	e.WriteUint64(h.Nonce)
+}
+
+// readHeader 解码区块头
+func readHeader(d *Decoder) *types.BlockHeader {
+	if !d.ReadBool() {
+		return nil
+	}
+	return &types.BlockHeader{
+		ParentHash:  d.ReadHash(),
+		UncleHash:   d.ReadHash(),
+		Coinbase:    d.ReadAddress(),
+		Root:        d.ReadHash(),
+		TxHash:      d.ReadHash(),
+		ReceiptHash: d.ReadHash(),
+		Bloom:       d.ReadBytes(),
+		Difficulty:  d.ReadBigInt(),
+		Number:      d.ReadBigInt(),
+		GasLimit:    d.ReadUint64(),
+		GasUsed:     d.ReadUint64(),
+		Time:        d.ReadUint64(),
+		Extra:       d.ReadBytes(),
+		MixDigest:   d.ReadHash(),
+		Nonce:       d.ReadUint64(),
+	}
+}
+
+// writeHeaders 编码区块头列表
+func writeHeaders(e *Encoder, headers []*types.BlockHeader) {
+	e.WriteLen(len(headers), headers == nil)
+	for _, h := range headers {
+		writeHeader(e, h)
+	}
+}
+
+// readHeaders 解码区块头列表
+func readHeaders(d *Decoder) []*types.BlockHeader {
+	n, isNil := d.ReadLen()
+	if isNil {
+		return nil
+	}
+	headers := make([]*types.BlockHeader, n)
+	for i := range headers {
+		headers[i] = readHeader(d)
+	}
+	return headers
+}
+
+// writeTransaction 编码交易
+func writeTransaction(e *Encoder, tx *types.Transaction) {
+	e.WriteBool(tx != nil)
+	if tx == nil {
+		return
+	}
+	e.WriteUint64(tx.Nonce)
+	e.WriteBigInt(tx.GasPrice)
+	e.WriteUint64(tx.Gas)
+	e.WriteBool(tx.To != nil)
+	if tx.To != nil {
+		e.WriteAddress(*tx.To)
+	}
+	e.WriteBigInt(tx.Value)
+	e.WriteBytes(tx.Data)
+	e.WriteBigInt(tx.V)
+	e.WriteBigInt(tx.R)
+	e.WriteBigInt(tx.S)
+}
+
+// readTransaction 解码交易
+func readTransaction(d *Decoder) *types.Transaction {
+	if !d.ReadBool() {
+		return nil
+	}
+	tx := &types.Transaction{
+		Nonce:    d.ReadUint64(),
+		GasPrice: d.ReadBigInt(),
+		Gas:      d.ReadUint64(),
+	}
+	if d.ReadBool() {
+		to := d.ReadAddress()
+		tx.To = &to
+	}
+	tx.Value = d.ReadBigInt()
+	tx.Data = d.ReadBytes()
+	tx.V = d.ReadBigInt()
+	tx.R = d.ReadBigInt()
+	tx.S = d.ReadBigInt()
+	return tx
+}
+
+// writeTransactions 编码交易列表
+func writeTransactions(e *Encoder, txs []*types.Transaction) {
+	e.WriteLen(len(txs), txs == nil)
+	for _, tx := range txs {
+		writeTransaction(e, tx)
+	}
+}
+
+// readTransactions 解码交易列表
+func readTransactions(d *Decoder) []*types.Transaction {
+	n, isNil := d.ReadLen()
+	if isNil {
+		return nil
+	}
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		txs[i] = readTransaction(d)
+	}
+	return txs
+}
+
+// writeReceipt 编码收据
+func writeReceipt(e *Encoder, receipt *types.Receipt) {
+	e.WriteBool(receipt != nil)
+	if receipt == nil {
+		return
+	}
+	e.WriteUint64(receipt.Status)
+	e.WriteUint64(receipt.CumulativeGasUsed)
+	e.WriteBytes(receipt.Bloom)
+	e.WriteLen(len(receipt.Logs), receipt.Logs == nil)
+	for _, log := range receipt.Logs {
+		writeLog(e, log)
+	}
+	e.WriteHash(receipt.TxHash)
+	e.WriteAddress(receipt.ContractAddress)
+	e.WriteUint64(receipt.GasUsed)
+	e.WriteHash(receipt.BlockHash)
+	e.WriteBigInt(receipt.BlockNumber)
+	e.WriteUint64(uint64(receipt.TransactionIndex))
+}
+
+// readReceipt 解码收据
+func readReceipt(d *Decoder) *types.Receipt {
+	if !d.ReadBool() {
+		return nil
+	}
+	receipt := &types.Receipt{
+		Status:            d.ReadUint64(),
+		CumulativeGasUsed: d.ReadUint64(),
+		Bloom:             d.ReadBytes(),
+	}
+	if n, isNil := d.ReadLen(); !isNil {
+		receipt.Logs = make([]*types.Log, n)
+		for i := range receipt.Logs {
+			receipt.Logs[i] = readLog(d)
+		}
+	}
+	receipt.TxHash = d.ReadHash()
+	receipt.ContractAddress = d.ReadAddress()
+	receipt.GasUsed = d.ReadUint64()
+	receipt.BlockHash = d.ReadHash()
+	receipt.BlockNumber = d.ReadBigInt()
+	receipt.TransactionIndex = uint(d.ReadUint64())
+	return receipt
+}
+
+// writeLog 编码日志
+func writeLog(e *Encoder, log *types.Log) {
+	e.WriteBool(log != nil)
+	if log == nil {
+		return
+	}
+	e.WriteAddress(log.Address)
+	e.WriteLen(len(log.Topics), log.Topics == nil)
+	for _, topic := range log.Topics {
+		e.WriteHash(topic)
+	}
+	e.WriteBytes(log.Data)
+	e.WriteUint64(log.BlockNumber)
+	e.WriteHash(log.TxHash)
+	e.WriteUint64(uint64(log.TxIndex))
+	e.WriteHash(log.BlockHash)
+	e.WriteUint64(uint64(log.Index))
+}
+
+// readLog 解码日志
+func readLog(d *Decoder) *types.Log {
+	if !d.ReadBool() {
+		return nil
+	}
+	log := &types.Log{Address: d.ReadAddress()}
+	if n, isNil := d.ReadLen(); !isNil {
+		log.Topics = make([]common.Hash, n)
+		for i := range log.Topics {
+			log.Topics[i] = d.ReadHash()
+		}
+	}
+	log.Data = d.ReadBytes()
+	log.BlockNumber = d.ReadUint64()
+	log.TxHash = d.ReadHash()
+	log.TxIndex = uint(d.ReadUint64())
+	log.BlockHash = d.ReadHash()
+	log.Index = uint(d.ReadUint64())
+	return log
+}
+
+// writeDump 编码状态导出，账户和存储按键排序保证编码结果确定
+func writeDump(e *Encoder, dump *state.Dump) {
+	e.WriteBool(dump != nil)
+	if dump == nil {
+		return
+	}
+	e.WriteHash(dump.Root)
+
+	addrs := make([]common.Address, 0, len(dump.Accounts))
+	for addr := range dump.Accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+
+	e.WriteLen(len(addrs), dump.Accounts == nil)
+	for _, addr := range addrs {
+		account := dump.Accounts[addr]
+		e.WriteAddress(addr)
+		e.WriteUint64(account.Nonce)
+		e.WriteBigInt(account.Balance)
+		e.WriteBytes(account.Code)
+
+		keys := make([]common.Hash, 0, len(account.Storage))
+		for key := range account.Storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i].Bytes(), keys[j].Bytes()) < 0
+		})
+		e.WriteLen(len(keys), account.Storage == nil)
+		for _, key := range keys {
+			e.WriteHash(key)
+			e.WriteHash(account.Storage[key])
+		}
+	}
+}
+
+// readDump 解码状态导出
+func readDump(d *Decoder) *state.Dump {
+	if !d.ReadBool() {
+		return nil
+	}
+	dump := &state.Dump{Root: d.ReadHash()}
+
+	n, isNil := d.ReadLen()
+	if isNil {
+		return dump
+	}
+	dump.Accounts = make(map[common.Address]state.DumpAccount, n)
+	for i := 0; i < n; i++ {
+		addr := d.ReadAddress()
+		account := state.DumpAccount{
+			Nonce:   d.ReadUint64(),
+			Balance: d.ReadBigInt(),
+			Code:    d.ReadBytes(),
+		}
+		if slots, isNil := d.ReadLen(); !isNil {
+			account.Storage = make(map[common.Hash]common.Hash, slots)
+			for j := 0; j < slots; j++ {
+				key := d.ReadHash()
+				account.Storage[key] = d.ReadHash()
+			}
+		}
+		dump.Accounts[addr] = account
+	}
+	return dump
+}
@@ -0,0 +1,139 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BenchmarkResult 单个压缩器的基准测试结果
+ type BenchmarkResult struct {
+	Name            string
+	OriginalSize    int64
+	CompressedSize  int64
+	Ratio           float64 // 压缩后大小 / 原始大小
+	CompressSpeed   float64 // 压缩速度（MB/s，按原始大小计算）
+	DecompressSpeed float64 // 解压速度（MB/s，按原始大小计算）
+}
+
+// Benchmark 对样本数据逐个运行压缩器，每个压缩器至少运行 minDuration
+// names 为空时测试注册表中的所有压缩器
+func (r *Registry) Benchmark(samples [][]byte, names []string, minDuration time.Duration) ([]BenchmarkResult, error) {
+	if len(names) == 0 {
+		names = r.Names()
+	}
+
+	var original int64
+	for _, sample := range samples {
+		original += int64(len(sample))
+	}
+	if original == 0 {
+		return nil, fmt.Errorf("compression: empty benchmark samples")
+	}
+
+	results := make([]BenchmarkResult, 0, len(names))
+	for _, name := range names {
+		c, err := r.Lookup(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		// 压缩并校验往返结果
+		compressed := make([][]byte, len(samples))
+		var compressedSize int64
+		for i, sample := range samples {
+			data, err := c.Compress(sample)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			decompressed, err := c.Decompress(data)
+			if err != nil || !bytes.Equal(decompressed, sample) {
+				return nil, fmt.Errorf("%s: round trip mismatch", name)
+			}
+			compressed[i] = data
+			compressedSize += int64(len(data))
+		}
+
+		compressTime := measure(minDuration, func() {
+			for _, sample := range samples {
+				c.Compress(sample)
+			}
+		})
+		decompressTime := measure(minDuration, func() {
+			for _, data := range compressed {
+				c.Decompress(data)
+			}
+		})
+
+		results = append(results, BenchmarkResult{
+			Name:            name,
+			OriginalSize:    original,
+			CompressedSize:  compressedSize,
+			Ratio:           float64(compressedSize) / float64(original),
+			CompressSpeed:   throughput(original, compressTime),
+			DecompressSpeed: throughput(original, decompressTime),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Ratio < results[j].Ratio
+	})
+	return results, nil
+}
+
+// Benchmark 使用默认注册表运行基准测试
+func Benchmark(samples [][]byte, names []string, minDuration time.Duration) ([]BenchmarkResult, error) {
+	return Default.Benchmark(samples, names, minDuration)
+}
+
+// PickBest 在压缩和解压速度都不低于 minSpeed（MB/s）的压缩器中选择压缩率最好的
+// 没有压缩器满足速度要求时选择压缩最快的
+func PickBest(results []BenchmarkResult, minSpeed float64) (BenchmarkResult, bool) {
+	if len(results) == 0 {
+		return BenchmarkResult{}, false
+	}
+
+	var best *BenchmarkResult
+	for i := range results {
+		result := &results[i]
+		if result.CompressSpeed < minSpeed || result.DecompressSpeed < minSpeed {
+			continue
+		}
+		if best == nil || result.Ratio < best.Ratio {
+			best = result
+		}
+	}
+	if best != nil {
+		return *best, true
+	}
+
+	fastest := results[0]
+	for _, result := range results[1:] {
+		if result.CompressSpeed > fastest.CompressSpeed {
+			fastest = result
+		}
+	}
+	return fastest, true
+}
+
+// measure 重复运行 fn 至少 minDuration，返回单次平均耗时
+func measure(minDuration time.Duration, fn func()) time.Duration {
+	start := time.Now()
+	runs := 0
+	for {
+		fn()
+		runs++
+		if elapsed := time.Since(start); elapsed >= minDuration {
+			return elapsed / time.Duration(runs)
+		}
+	}
+}
+
+// throughput 计算吞吐量（MB/s）
+func throughput(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(size) / (1024 * 1024) / elapsed.Seconds()
+}
@@ -0,0 +1,106 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 生成可压缩的样本数据
+func sampleData() []byte {
+	var buf bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&buf, `{"number":%d,"parentHash":"0x%064x","gasLimit":8000000,"transactions":[]}`, i, i*7919)
+	}
+	return buf.Bytes()
+}
+
+// 测试所有已注册压缩器的往返和算法标签
+func TestRegistryRoundTrip(t *testing.T) {
+	inputs := [][]byte{nil, []byte("a"), []byte("short input"), sampleData(), bytes.Repeat([]byte{0}, 100000)}
+
+	for _, name := range Names() {
+		c, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("Lookup(%s) failed: %v", name, err)
+		}
+		for _, input := range inputs {
+			data, err := c.Compress(input)
+			if err != nil {
+				t.Fatalf("%s: compress failed: %v", name, err)
+			}
+			if Algorithm(data[0]) != c.Algorithm() || !IsTagged(data) {
+				t.Errorf("%s: missing algorithm tag", name)
+			}
+			// 任意压缩器都能按标签解压
+			decompressed, err := Decompress(data)
+			if err != nil || !bytes.Equal(decompressed, input) {
+				t.Errorf("%s: round trip mismatch for %d bytes: %v", name, len(input), err)
+			}
+		}
+	}
+}
+
+// 测试 LZ 压缩率和损坏数据检测
+func TestLZCompressor(t *testing.T) {
+	c := NewLZCompressor()
+	input := sampleData()
+	data, err := c.Compress(input)
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	if len(data) >= len(input)/2 {
+		t.Errorf("poor lz compression: %d -> %d", len(input), len(data))
+	}
+
+	for _, corrupt := range [][]byte{
+		{},
+		data[:len(data)/2],
+		append([]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, data[1:]...),
+		{0x04, 0x01, 0x00},
+	} {
+		if _, err := c.Decompress(corrupt); err == nil {
+			t.Errorf("expected error for corrupt data %x", corrupt)
+		}
+	}
+}
+
+// 测试旧格式数据和未压缩数据
+func TestDecompressLegacy(t *testing.T) {
+	input := sampleData()
+	legacy, _ := NewGzipCompressor(gzip.BestSpeed).Compress(input)
+
+	data, err := DecompressAny(legacy)
+	if err != nil || !bytes.Equal(data, input) {
+		t.Errorf("legacy gzip not readable: %v", err)
+	}
+	data, err = DecompressAny(input)
+	if err != nil || !bytes.Equal(data, input) {
+		t.Errorf("uncompressed data should be returned as is: %v", err)
+	}
+	if err := Register("custom", 0x7b, &NoneCompressor{}); err != ErrInvalidAlgorithm {
+		t.Errorf("expected ErrInvalidAlgorithm, got %v", err)
+	}
+}
+
+// 测试基准测试选择压缩器
+func TestBenchmark(t *testing.T) {
+	results, err := Benchmark([][]byte{sampleData()}, []string{NameNone, NameGzipBest, NameLZ}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if len(results) != 3 || results[len(results)-1].Name != NameNone {
+		t.Errorf("unexpected benchmark order: %+v", results)
+	}
+
+	best, ok := PickBest(results, 0)
+	if !ok || best.Name != NameGzipBest {
+		t.Errorf("expected gzip-best without speed limit, got %s", best.Name)
+	}
+	best, _ = PickBest(results, 1e12)
+	if best.CompressSpeed < results[0].CompressSpeed && best.CompressSpeed < results[1].CompressSpeed {
+		t.Errorf("expected fastest compressor when no result meets the speed limit, got %s", best.Name)
+	}
+}
@@ -0,0 +1,63 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+)
+
+// FlateCompressor DEFLATE压缩器，没有 gzip 的头部和校验和，适合短数据
+ type FlateCompressor struct {
+	level int
+}
+
+// NewFlateCompressor 创建DEFLATE压缩器
+func NewFlateCompressor(level int) *FlateCompressor {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+
+	return &FlateCompressor{
+		level: level,
+	}
+}
+
+// Compress 压缩数据
+func (c *FlateCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress 解压缩数据
+func (c *FlateCompressor) Decompress(data []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// NoneCompressor 不压缩，用于关闭压缩后仍然写入算法标签
+ type NoneCompressor struct{}
+
+// Compress 返回原数据的副本
+func (c *NoneCompressor) Compress(data []byte) ([]byte, error) {
+	return append([]byte{}, data...), nil
+}
+
+// Decompress 返回原数据的副本
+func (c *NoneCompressor) Decompress(data []byte) ([]byte, error) {
+	return append([]byte{}, data...), nil
+}
@@ -0,0 +1,210 @@
+package compression
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// LZ 快速压缩，数据格式与 Snappy 块格式相同：
+// 解压后长度(uvarint) + 元素序列，元素标签字节的低 2 位表示类型
+//   00 字面量：高 6 位为长度-1，60-63 表示后续 1-4 字节小端长度-1
+//   01 复制：长度 4-11，偏移 11 位
+//   10 复制：高 6 位为长度-1，后续 2 字节小端偏移
+//   11 复制：高 6 位为长度-1，后续 4 字节小端偏移
+const (
+	lzTagLiteral = 0x00
+	lzTagCopy1   = 0x01
+	lzTagCopy2   = 0x02
+	lzTagCopy4   = 0x03
+
+	// lzTableBits 匹配哈希表大小
+	lzTableBits = 14
+	// lzMinInput 小于该长度的数据直接作为字面量
+	lzMinInput = 17
+	// lzMaxOffset 编码时使用的最大回溯距离
+	lzMaxOffset = 1<<16 - 1
+	// lzMaxExpansion 每个输入字节最多解出的字节数，用于拒绝伪造的解压长度
+	lzMaxExpansion = 32
+)
+
+// ErrCorruptLZ LZ 数据损坏
+var ErrCorruptLZ = errors.New("compression: corrupt lz data")
+
+// LZCompressor 基于 LZ77 的快速压缩器，压缩率低于 gzip，速度高一个数量级
+ type LZCompressor struct{}
+
+// NewLZCompressor 创建LZ压缩器
+func NewLZCompressor() *LZCompressor {
+	return &LZCompressor{}
+}
+
+// Compress 压缩数据
+func (c *LZCompressor) Compress(data []byte) ([]byte, error) {
+	dst := binary.AppendUvarint(make([]byte, 0, len(data)/2+8), uint64(len(data)))
+	if len(data) < lzMinInput {
+		return lzEmitLiteral(dst, data), nil
+	}
+
+	var table [1 << lzTableBits]int32
+	nextEmit := 0
+	// 最后几个字节不足以构成匹配，直接作为字面量
+	limit := len(data) - 4
+
+	for s := 0; s <= limit; {
+		cur := binary.LittleEndian.Uint32(data[s:])
+		h := lzHash(cur)
+		candidate := int(table[h]) - 1
+		table[h] = int32(s + 1)
+
+		if candidate < 0 || s-candidate > lzMaxOffset || binary.LittleEndian.Uint32(data[candidate:]) != cur {
+			s++
+			continue
+		}
+
+		dst = lzEmitLiteral(dst, data[nextEmit:s])
+
+		// 向后延伸匹配
+		base := s
+		s += 4
+		for m := candidate + 4; s < len(data) && data[s] == data[m]; m++ {
+			s++
+		}
+		dst = lzEmitCopy(dst, base-candidate, s-base)
+		nextEmit = s
+	}
+
+	return lzEmitLiteral(dst, data[nextEmit:]), nil
+}
+
+// Decompress 解压缩数据
+func (c *LZCompressor) Decompress(data []byte) ([]byte, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data))*lzMaxExpansion {
+		return nil, ErrCorruptLZ
+	}
+	src := data[n:]
+	dst := make([]byte, 0, length)
+
+	for len(src) > 0 {
+		tag := src[0]
+		switch tag & 0x03 {
+		case lzTagLiteral:
+			size := uint64(tag >> 2)
+			src = src[1:]
+			if size >= 60 {
+				extra := int(size - 59)
+				if len(src) < extra {
+					return nil, ErrCorruptLZ
+				}
+				size = 0
+				for i := extra - 1; i >= 0; i-- {
+					size = size<<8 | uint64(src[i])
+				}
+				src = src[extra:]
+			}
+			size++
+			if uint64(len(src)) < size || uint64(len(dst))+size > length {
+				return nil, ErrCorruptLZ
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+			continue
+
+		case lzTagCopy1:
+			if len(src) < 2 {
+				return nil, ErrCorruptLZ
+			}
+			size := int(tag>>2&0x07) + 4
+			offset := int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+			var err error
+			if dst, err = lzCopy(dst, offset, size, length); err != nil {
+				return nil, err
+			}
+
+		case lzTagCopy2:
+			if len(src) < 3 {
+				return nil, ErrCorruptLZ
+			}
+			size := int(tag>>2) + 1
+			offset := int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+			var err error
+			if dst, err = lzCopy(dst, offset, size, length); err != nil {
+				return nil, err
+			}
+
+		case lzTagCopy4:
+			if len(src) < 5 {
+				return nil, ErrCorruptLZ
+			}
+			size := int(tag>>2) + 1
+			offset := int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+			var err error
+			if dst, err = lzCopy(dst, offset, size, length); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if uint64(len(dst)) != length {
+		return nil, ErrCorruptLZ
+	}
+	return dst, nil
+}
+
+// lzHash 计算 4 字节序列的哈希
+func lzHash(v uint32) uint32 {
+	return (v * 0x1e35a7bd) >> (32 - lzTableBits)
+}
+
+// lzEmitLiteral 写入字面量
+func lzEmitLiteral(dst []byte, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := uint64(len(lit) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|lzTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|lzTagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|lzTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|lzTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|lzTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// lzEmitCopy 写入复制，长度超过单个元素上限时拆分
+func lzEmitCopy(dst []byte, offset, length int) []byte {
+	for length >= 68 {
+		dst = append(dst, 63<<2|lzTagCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		// 保证剩余长度不小于 4
+		dst = append(dst, 59<<2|lzTagCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length >= 12 || offset >= 2048 {
+		return append(dst, byte(length-1)<<2|lzTagCopy2, byte(offset), byte(offset>>8))
+	}
+	return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|lzTagCopy1, byte(offset))
+}
+
+// lzCopy 从已解压的数据中复制，允许重叠
+func lzCopy(dst []byte, offset, size int, length uint64) ([]byte, error) {
+	if offset <= 0 || offset > len(dst) || uint64(len(dst)+size) > length {
+		return nil, ErrCorruptLZ
+	}
+	start := len(dst) - offset
+	for i := 0; i < size; i++ {
+		dst = append(dst, dst[start+i])
+	}
+	return dst, nil
+}
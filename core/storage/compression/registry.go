@@ -0,0 +1,254 @@
+package compression
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Algorithm 压缩算法标签，写在压缩数据的第一个字节，解压时据此选择算法，与压缩配置无关
+// 标签是第 1、2 位均为 1 的控制字符：这两位对应 DEFLATE 保留的块类型，因此不会与旧版本未加标签的
+// flate 数据混淆；控制字符也不会与 JSON 或类型化编码的首字节混淆
+type Algorithm byte
+
+const (
+	AlgorithmNone  Algorithm = 0x06
+	AlgorithmGzip  Algorithm = 0x0E
+	AlgorithmFlate Algorithm = 0x16
+	AlgorithmLZ    Algorithm = 0x1E
+)
+
+// 内置压缩器名称
+const (
+	NameNone      = "none"
+	NameGzip      = "gzip"
+	NameGzipFast  = "gzip-fast"
+	NameGzipBest  = "gzip-best"
+	NameFlate     = "flate"
+	NameFlateFast = "flate-fast"
+	NameFlateBest = "flate-best"
+	NameLZ        = "lz"
+)
+
+// gzipMagic gzip 数据的前两个字节，用于识别未加标签的旧数据
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var (
+	// ErrUnknownCompressor 未注册的压缩器名称
+	ErrUnknownCompressor = errors.New("compression: unknown compressor")
+	// ErrUnknownAlgorithm 数据的算法标签未注册
+	ErrUnknownAlgorithm = errors.New("compression: unknown algorithm")
+	// ErrDuplicateCompressor 压缩器名称已注册
+	ErrDuplicateCompressor = errors.New("compression: duplicate compressor")
+	// ErrInvalidAlgorithm 算法标签可能与未加标签的数据混淆
+	ErrInvalidAlgorithm = errors.New("compression: invalid algorithm tag")
+)
+
+// valid 检查算法标签是否可用，0x1f 是 gzip 魔数的首字节
+func (a Algorithm) valid() bool {
+	return a < 0x20 && a&0x06 == 0x06 && a != 0x1f
+}
+
+// TaggedCompressor 在压缩结果前写入一字节算法标签的压缩器
+// 解压时按标签选择算法，因此可以读取任意已注册算法压缩的数据
+ type TaggedCompressor struct {
+	name      string
+	algorithm Algorithm
+	inner     Compressor
+	registry  *Registry
+}
+
+// Name 压缩器名称
+func (c *TaggedCompressor) Name() string {
+	return c.name
+}
+
+// Algorithm 压缩算法标签
+func (c *TaggedCompressor) Algorithm() Algorithm {
+	return c.algorithm
+}
+
+// Compress 压缩数据并写入算法标签
+func (c *TaggedCompressor) Compress(data []byte) ([]byte, error) {
+	compressed, err := c.inner.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(c.algorithm)}, compressed...), nil
+}
+
+// Decompress 按算法标签解压数据
+func (c *TaggedCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.registry.Decompress(data)
+}
+
+// Registry 压缩器注册表
+ type Registry struct {
+	byName   map[string]*TaggedCompressor
+	decoders map[Algorithm]Compressor
+	mutex    sync.RWMutex
+}
+
+// NewRegistry 创建包含内置压缩器的注册表
+func NewRegistry() *Registry {
+	r := &Registry{
+		byName:   make(map[string]*TaggedCompressor),
+		decoders: make(map[Algorithm]Compressor),
+	}
+
+	r.mustRegister(NameNone, AlgorithmNone, &NoneCompressor{})
+	r.mustRegister(NameGzip, AlgorithmGzip, NewGzipCompressor(gzip.DefaultCompression))
+	r.mustRegister(NameGzipFast, AlgorithmGzip, NewGzipCompressor(gzip.BestSpeed))
+	r.mustRegister(NameGzipBest, AlgorithmGzip, NewGzipCompressor(gzip.BestCompression))
+	r.mustRegister(NameFlate, AlgorithmFlate, NewFlateCompressor(DefaultCompression))
+	r.mustRegister(NameFlateFast, AlgorithmFlate, NewFlateCompressor(BestSpeed))
+	r.mustRegister(NameFlateBest, AlgorithmFlate, NewFlateCompressor(BestCompression))
+	r.mustRegister(NameLZ, AlgorithmLZ, NewLZCompressor())
+
+	// 按级别命名的 gzip 和 flate 压缩器
+	for level := BestSpeed; level <= BestCompression; level++ {
+		r.mustRegister(fmt.Sprintf("%s-%d", NameGzip, level), AlgorithmGzip, NewGzipCompressor(level))
+		r.mustRegister(fmt.Sprintf("%s-%d", NameFlate, level), AlgorithmFlate, NewFlateCompressor(level))
+	}
+
+	return r
+}
+
+// Register 注册压缩器，同一算法的第一个压缩器同时作为该算法的解压器
+// 内置算法之外可用的标签只有 0x07、0x0F 和 0x17
+func (r *Registry) Register(name string, algorithm Algorithm, compressor Compressor) error {
+	if !algorithm.valid() {
+		return ErrInvalidAlgorithm
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.byName[name]; exists {
+		return ErrDuplicateCompressor
+	}
+	r.byName[name] = &TaggedCompressor{
+		name:      name,
+		algorithm: algorithm,
+		inner:     compressor,
+		registry:  r,
+	}
+	if _, exists := r.decoders[algorithm]; !exists {
+		r.decoders[algorithm] = compressor
+	}
+	return nil
+}
+
+// mustRegister 注册内置压缩器
+func (r *Registry) mustRegister(name string, algorithm Algorithm, compressor Compressor) {
+	if err := r.Register(name, algorithm, compressor); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup 按名称获取压缩器
+func (r *Registry) Lookup(name string) (*TaggedCompressor, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.byName[name]
+	if !exists {
+		return nil, ErrUnknownCompressor
+	}
+	return c, nil
+}
+
+// Names 获取所有已注册的压缩器名称
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsTagged 检查数据是否以已注册的算法标签开头
+func (r *Registry) IsTagged(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.decoders[Algorithm(data[0])]
+	return exists
+}
+
+// Decompress 按算法标签解压数据，未加标签的 gzip 数据按旧格式解压
+func (r *Registry) Decompress(data []byte) ([]byte, error) {
+	if isGzip(data) {
+		return legacyGzip.Decompress(data)
+	}
+	if len(data) == 0 {
+		return nil, ErrUnknownAlgorithm
+	}
+
+	r.mutex.RLock()
+	decoder, exists := r.decoders[Algorithm(data[0])]
+	r.mutex.RUnlock()
+	if !exists {
+		return nil, ErrUnknownAlgorithm
+	}
+	return decoder.Decompress(data[1:])
+}
+
+// DecompressAny 解压加了标签或旧格式 gzip 的数据，其他数据视为未压缩，原样返回
+func (r *Registry) DecompressAny(data []byte) ([]byte, error) {
+	if isGzip(data) || r.IsTagged(data) {
+		return r.Decompress(data)
+	}
+	return data, nil
+}
+
+// legacyGzip 用于解压未加标签的旧数据
+var legacyGzip = NewGzipCompressor(DefaultCompression)
+
+// isGzip 检查数据是否为 gzip 格式
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == gzipMagic[0] && data[1] == gzipMagic[1]
+}
+
+// Default 默认压缩器注册表
+var Default = NewRegistry()
+
+// Register 在默认注册表中注册压缩器
+func Register(name string, algorithm Algorithm, compressor Compressor) error {
+	return Default.Register(name, algorithm, compressor)
+}
+
+// Lookup 从默认注册表按名称获取压缩器
+func Lookup(name string) (*TaggedCompressor, error) {
+	return Default.Lookup(name)
+}
+
+// Names 获取默认注册表中的压缩器名称
+func Names() []string {
+	return Default.Names()
+}
+
+// IsTagged 检查数据是否以默认注册表中的算法标签开头
+func IsTagged(data []byte) bool {
+	return Default.IsTagged(data)
+}
+
+// Decompress 使用默认注册表按算法标签解压数据
+func Decompress(data []byte) ([]byte, error) {
+	return Default.Decompress(data)
+}
+
+// DecompressAny 使用默认注册表解压数据，未压缩的数据原样返回
+func DecompressAny(data []byte) ([]byte, error) {
+	return Default.DecompressAny(data)
+}
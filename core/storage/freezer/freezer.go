@@ -0,0 +1,303 @@
+package freezer
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"nogochain/core/storage/compression"
+)
+
+// 冻结表名称
+const (
+	HeaderTable     = "headers"
+	BodyTable       = "bodies"
+	ReceiptTable    = "receipts"
+	HashTable       = "hashes"
+	DifficultyTable = "difficulties"
+)
+
+// tableCompression 各表是否适合压缩，哈希和难度是高熵短数据，压缩没有收益
+var tableCompression = map[string]bool{
+	HeaderTable:     true,
+	BodyTable:       true,
+	ReceiptTable:    true,
+	HashTable:       false,
+	DifficultyTable: false,
+}
+
+var (
+	// ErrUnknownTable 未知的冻结表
+	ErrUnknownTable = errors.New("freezer: unknown table")
+	// ErrClosed 冻结库已关闭
+	ErrClosed = errors.New("freezer: closed")
+)
+
+// Options 冻结库配置
+type Options struct {
+	// Compress 是否压缩区块头、区块体和收据
+	Compress bool
+	// Compressor 压缩器名称，为空时使用 gzip-fast
+	// 每项数据带有算法标签，修改该配置或关闭压缩后已冻结的数据仍然可读
+	Compressor string
+}
+
+// Freezer 只追加的古老区块存储
+// 已经足够深、不会再被重组的区块从热数据库移入这里，每张表一对扁平文件
+type Freezer struct {
+	dir    string
+	tables map[string]*table
+	frozen uint64
+	closed bool
+	mutex  sync.RWMutex
+}
+
+// New 打开或创建冻结库，并将所有表截断到相同的项数
+func New(dir string, opts Options) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	f := &Freezer{
+		dir:    dir,
+		tables: make(map[string]*table),
+	}
+
+	var compressor compression.Compressor
+	if opts.Compress {
+		name := opts.Compressor
+		if name == "" {
+			name = compression.NameGzipFast
+		}
+		c, err := compression.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		compressor = c
+	}
+
+	for name, compressible := range tableCompression {
+		var tableCompressor compression.Compressor
+		if compressible {
+			tableCompressor = compressor
+		}
+		t, err := openTable(dir, name, compressible, tableCompressor)
+		if err != nil {
+			f.closeTables()
+			return nil, err
+		}
+		f.tables[name] = t
+	}
+
+	if err := f.repair(); err != nil {
+		f.closeTables()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// repair 将所有表截断到最短表的项数，丢弃崩溃时只写了一部分的区块
+func (f *Freezer) repair() error {
+	min := uint64(0)
+	first := true
+	for _, t := range f.tables {
+		if items := t.count(); first || items < min {
+			min = items
+			first = false
+		}
+	}
+
+	for _, t := range f.tables {
+		if err := t.truncate(min); err != nil {
+			return err
+		}
+	}
+
+	f.frozen = min
+	return nil
+}
+
+// Ancients 获取已冻结的区块数量，即下一个待冻结的区块号
+func (f *Freezer) Ancients() uint64 {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.frozen
+}
+
+// HasAncient 检查区块是否已冻结
+func (f *Freezer) HasAncient(kind string, number uint64) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if _, exists := f.tables[kind]; !exists {
+		return false
+	}
+	return number < f.frozen
+}
+
+// Ancient 读取冻结表中的一项
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if f.closed {
+		return nil, ErrClosed
+	}
+	t, exists := f.tables[kind]
+	if !exists {
+		return nil, ErrUnknownTable
+	}
+	if number >= f.frozen {
+		return nil, ErrOutOfBounds
+	}
+	return t.retrieve(number)
+}
+
+// AppendAncient 追加一个区块的全部数据，number 必须等于 Ancients()
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, difficulty []byte) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	if number != f.frozen {
+		return ErrOutOfBounds
+	}
+
+	items := map[string][]byte{
+		HashTable:       hash,
+		HeaderTable:     header,
+		BodyTable:       body,
+		ReceiptTable:    receipts,
+		DifficultyTable: difficulty,
+	}
+	for name, item := range items {
+		if err := f.tables[name].append(number, item); err != nil {
+			// 回滚已写入的表，保持各表项数一致
+			for _, t := range f.tables {
+				t.truncate(number)
+			}
+			return err
+		}
+	}
+
+	f.frozen++
+	return nil
+}
+
+// TruncateAncients 截断冻结库，只保留前 items 个区块
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	if items >= f.frozen {
+		return nil
+	}
+	for _, t := range f.tables {
+		if err := t.truncate(items); err != nil {
+			return err
+		}
+	}
+
+	f.frozen = items
+	return nil
+}
+
+// Sync 将所有表落盘
+func (f *Freezer) Sync() error {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if f.closed {
+		return ErrClosed
+	}
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checkpoint 将冻结库复制到 dir，返回快照中的区块数量
+// 复制期间阻塞追加和截断，快照中所有表的项数相同
+func (f *Freezer) Checkpoint(dir string) (uint64, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	for _, t := range f.tables {
+		if err := t.checkpoint(dir); err != nil {
+			return 0, err
+		}
+	}
+	return f.frozen, nil
+}
+
+// Dir 获取冻结库目录
+func (f *Freezer) Dir() string {
+	return f.dir
+}
+
+// GetStats 获取冻结库统计信息
+func (f *Freezer) GetStats() map[string]interface{} {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	sizes := make(map[string]int64, len(f.tables))
+	var total int64
+	for name, t := range f.tables {
+		sizes[name] = t.size()
+		total += sizes[name]
+	}
+
+	return map[string]interface{}{
+		"frozen":      f.frozen,
+		"table_sizes": sizes,
+		"total_size":  total,
+	}
+}
+
+// Close 落盘并关闭冻结库
+func (f *Freezer) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	var firstErr error
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := f.closeTables(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// closeTables 关闭所有表
+func (f *Freezer) closeTables() error {
+	var firstErr error
+	for _, t := range f.tables {
+		if err := t.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
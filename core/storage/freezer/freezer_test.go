@@ -0,0 +1,121 @@
+package freezer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试追加、读取和重新打开
+func TestFreezerAppendAndReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := New(dir, Options{Compress: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for n := uint64(0); n < 5; n++ {
+		item := []byte(fmt.Sprintf("item-%d", n))
+		if err := f.AppendAncient(n, item, item, bytes.Repeat(item, 10), nil, []byte{byte(n)}); err != nil {
+			t.Fatalf("AppendAncient(%d) failed: %v", n, err)
+		}
+	}
+	if err := f.AppendAncient(9, nil, nil, nil, nil, nil); err != ErrOutOfBounds {
+		t.Errorf("expected ErrOutOfBounds for non-sequential append, got %v", err)
+	}
+	f.Close()
+
+	f, err = New(dir, Options{Compress: true})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer f.Close()
+
+	if f.Ancients() != 5 {
+		t.Fatalf("expected 5 ancients, got %d", f.Ancients())
+	}
+	body, err := f.Ancient(BodyTable, 3)
+	if err != nil {
+		t.Fatalf("Ancient failed: %v", err)
+	}
+	if !bytes.Equal(body, bytes.Repeat([]byte("item-3"), 10)) {
+		t.Errorf("body mismatch: %s", body)
+	}
+	receipts, err := f.Ancient(ReceiptTable, 0)
+	if err != nil || len(receipts) != 0 {
+		t.Errorf("expected empty receipts, got %v %v", receipts, err)
+	}
+
+	if err := f.TruncateAncients(2); err != nil {
+		t.Fatalf("TruncateAncients failed: %v", err)
+	}
+	if _, err := f.Ancient(HeaderTable, 2); err != ErrOutOfBounds {
+		t.Errorf("expected ErrOutOfBounds after truncate, got %v", err)
+	}
+}
+
+// 测试崩溃后各表被截断到相同的项数
+func TestFreezerRepair(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := New(dir, Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for n := uint64(0); n < 3; n++ {
+		f.AppendAncient(n, []byte{1}, []byte{2}, []byte{3}, []byte{4}, []byte{5})
+	}
+	f.Close()
+
+	// 模拟崩溃：区块头表的最后一项数据不完整
+	if err := os.Truncate(filepath.Join(dir, HeaderTable+".dat"), 2); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	f, err = New(dir, Options{})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer f.Close()
+
+	if f.Ancients() != 2 {
+		t.Errorf("expected 2 ancients after repair, got %d", f.Ancients())
+	}
+	if err := f.AppendAncient(2, []byte{1}, []byte{2}, []byte{3}, []byte{4}, []byte{5}); err != nil {
+		t.Errorf("append after repair failed: %v", err)
+	}
+}
+
+// 测试修改压缩配置后已冻结的数据仍然可读
+func TestFreezerCompressionChange(t *testing.T) {
+	dir := t.TempDir()
+	body := bytes.Repeat([]byte(`{"transactions":[]}`), 20)
+
+	configs := []Options{
+		{Compress: true},
+		{Compress: true, Compressor: "lz"},
+		{Compress: false},
+	}
+	for n, opts := range configs {
+		f, err := New(dir, opts)
+		if err != nil {
+			t.Fatalf("New(%+v) failed: %v", opts, err)
+		}
+		if err := f.AppendAncient(uint64(n), []byte{byte(n)}, body, body, nil, nil); err != nil {
+			t.Fatalf("AppendAncient(%d) failed: %v", n, err)
+		}
+		for i := 0; i <= n; i++ {
+			data, err := f.Ancient(BodyTable, uint64(i))
+			if err != nil || !bytes.Equal(data, body) {
+				t.Errorf("item %d unreadable with %+v: %v", i, opts, err)
+			}
+		}
+		f.Close()
+	}
+
+	if _, err := New(dir, Options{Compress: true, Compressor: "zstd"}); err == nil {
+		t.Errorf("expected error for unknown compressor")
+	}
+}
@@ -0,0 +1,289 @@
+package freezer
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"nogochain/core/storage/compression"
+)
+
+// indexEntrySize 索引项大小：每项记录该项数据在数据文件中的结束偏移
+const indexEntrySize = 8
+
+var (
+	// ErrOutOfBounds 请求的项不在表中
+	ErrOutOfBounds = errors.New("freezer: item out of bounds")
+	// ErrCorruptTable 表文件损坏
+	ErrCorruptTable = errors.New("freezer: corrupt table")
+)
+
+// table 只追加的扁平文件表
+// 数据文件按顺序存放所有项，索引文件存放每项的结束偏移，第 n 项位于 [offset(n-1), offset(n))
+type table struct {
+	name         string
+	data         *os.File
+	index        *os.File
+	items        uint64
+	dataSize     int64
+	compressible bool
+	compressor   compression.Compressor
+	mutex        sync.RWMutex
+}
+
+// openTable 打开表，compressor 为空时不压缩新写入的项
+// compressible 为 true 时读取按算法标签解压，兼容以不同压缩配置写入的项
+func openTable(dir string, name string, compressible bool, compressor compression.Compressor) (*table, error) {
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+
+	t := &table{
+		name:         name,
+		data:         data,
+		index:        index,
+		compressible: compressible,
+		compressor:   compressor,
+	}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// repair 使索引文件和数据文件保持一致，丢弃崩溃时未写完的尾部
+func (t *table) repair() error {
+	indexInfo, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	dataInfo, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+
+	// 截掉不完整的索引项
+	indexSize := indexInfo.Size() - indexInfo.Size()%indexEntrySize
+	items := uint64(indexSize / indexEntrySize)
+
+	// 去掉指向数据文件末尾之外的索引项
+	for items > 0 {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		if int64(end) <= dataInfo.Size() {
+			break
+		}
+		items--
+	}
+
+	var dataSize int64
+	if items > 0 {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		dataSize = int64(end)
+	}
+
+	if err := t.index.Truncate(int64(items) * indexEntrySize); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(dataSize); err != nil {
+		return err
+	}
+
+	t.items = items
+	t.dataSize = dataSize
+	return nil
+}
+
+// readOffset 读取第 n 项的结束偏移
+func (t *table) readOffset(n uint64) (uint64, error) {
+	buf := make([]byte, indexEntrySize)
+	if _, err := t.index.ReadAt(buf, int64(n)*indexEntrySize); err != nil {
+		if err == io.EOF {
+			return 0, ErrCorruptTable
+		}
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// append 追加一项，number 必须等于当前项数
+func (t *table) append(number uint64, item []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if number != t.items {
+		return ErrOutOfBounds
+	}
+
+	if t.compressor != nil {
+		compressed, err := t.compressor.Compress(item)
+		if err != nil {
+			return err
+		}
+		item = compressed
+	}
+
+	if _, err := t.data.WriteAt(item, t.dataSize); err != nil {
+		return err
+	}
+
+	buf := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint64(buf, uint64(t.dataSize)+uint64(len(item)))
+	if _, err := t.index.WriteAt(buf, int64(t.items)*indexEntrySize); err != nil {
+		return err
+	}
+
+	t.dataSize += int64(len(item))
+	t.items++
+	return nil
+}
+
+// retrieve 读取第 n 项
+func (t *table) retrieve(number uint64) ([]byte, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if number >= t.items {
+		return nil, ErrOutOfBounds
+	}
+
+	var start uint64
+	if number > 0 {
+		offset, err := t.readOffset(number - 1)
+		if err != nil {
+			return nil, err
+		}
+		start = offset
+	}
+	end, err := t.readOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, ErrCorruptTable
+	}
+
+	item := make([]byte, end-start)
+	if _, err := t.data.ReadAt(item, int64(start)); err != nil {
+		return nil, err
+	}
+
+	if t.compressible {
+		return compression.DecompressAny(item)
+	}
+	return item, nil
+}
+
+// truncate 截断表，只保留前 items 项
+func (t *table) truncate(items uint64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if items >= t.items {
+		return nil
+	}
+
+	var dataSize int64
+	if items > 0 {
+		end, err := t.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		dataSize = int64(end)
+	}
+
+	if err := t.index.Truncate(int64(items) * indexEntrySize); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(dataSize); err != nil {
+		return err
+	}
+
+	t.items = items
+	t.dataSize = dataSize
+	return nil
+}
+
+// size 获取表占用的磁盘大小
+func (t *table) size() int64 {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.dataSize + int64(t.items)*indexEntrySize
+}
+
+// count 获取项数
+func (t *table) count() uint64 {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.items
+}
+
+// checkpoint 将表中已完整写入的部分复制到 dir
+func (t *table) checkpoint(dir string) error {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if err := copyRange(t.index, int64(t.items)*indexEntrySize, filepath.Join(dir, t.name+".idx")); err != nil {
+		return err
+	}
+	return copyRange(t.data, t.dataSize, filepath.Join(dir, t.name+".dat"))
+}
+
+// copyRange 将文件的前 size 字节复制到新文件并落盘
+func copyRange(src *os.File, size int64, dst string) error {
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, io.NewSectionReader(src, 0, size)); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sync 落盘
+func (t *table) sync() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close 关闭表
+func (t *table) close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	dataErr := t.data.Close()
+	indexErr := t.index.Close()
+	if dataErr != nil {
+		return dataErr
+	}
+	return indexErr
+}
@@ -1,6 +1,9 @@
 package index
 
 import (
+	"math"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -11,18 +14,24 @@ import (
 	Delete(key []byte) error
 	Clear() error
 	Size() int
+	// NewIterator 按键升序遍历以 prefix 开头的项，从 prefix+start 开始
+	NewIterator(prefix []byte, start []byte) Iterator
+	// NewReverseIterator 按键降序遍历以 prefix 开头的项，从 prefix+start 开始，start 为空时从最后一项开始
+	NewReverseIterator(prefix []byte, start []byte) Iterator
+	// DeleteRange 删除 [start, end) 范围内的项，end 为空表示直到末尾
+	DeleteRange(start []byte, end []byte) error
 }
 
-// MemoryIndex 内存索引
+// MemoryIndex 内存索引，基于跳表按键有序存储
  type MemoryIndex struct {
-	data  map[string][]byte
+	list  *skipList
 	mutex sync.RWMutex
 }
 
 // NewMemoryIndex 创建内存索引
 func NewMemoryIndex() *MemoryIndex {
 	return &MemoryIndex{
-		data: make(map[string][]byte),
+		list: newSkipList(),
 	}
 }
 
@@ -31,7 +40,7 @@ func (i *MemoryIndex) Add(key []byte, value []byte) error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 
-	i.data[string(key)] = value
+	i.list.put(key, value)
 	return nil
 }
 
@@ -40,8 +49,7 @@ func (i *MemoryIndex) Get(key []byte) ([]byte, bool) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
-	value, exists := i.data[string(key)]
-	return value, exists
+	return i.list.get(key)
 }
 
 // Delete 删除索引项
@@ -49,7 +57,7 @@ func (i *MemoryIndex) Delete(key []byte) error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 
-	delete(i.data, string(key))
+	i.list.delete(key)
 	return nil
 }
 
@@ -58,7 +66,7 @@ func (i *MemoryIndex) Clear() error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 
-	i.data = make(map[string][]byte)
+	i.list = newSkipList()
 	return nil
 }
 
@@ -67,7 +75,35 @@ func (i *MemoryIndex) Size() int {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
-	return len(i.data)
+	return i.list.length
+}
+
+// NewIterator 创建升序迭代器
+func (i *MemoryIndex) NewIterator(prefix []byte, start []byte) Iterator {
+	return &memoryIterator{
+		index:  i,
+		prefix: append([]byte(nil), prefix...),
+		start:  append([]byte(nil), start...),
+	}
+}
+
+// NewReverseIterator 创建降序迭代器
+func (i *MemoryIndex) NewReverseIterator(prefix []byte, start []byte) Iterator {
+	return &memoryIterator{
+		index:   i,
+		prefix:  append([]byte(nil), prefix...),
+		start:   append([]byte(nil), start...),
+		reverse: true,
+	}
+}
+
+// DeleteRange 删除范围内的索引项
+func (i *MemoryIndex) DeleteRange(start []byte, end []byte) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.list.deleteRange(start, end)
+	return nil
 }
 
 // BlockIndex 区块索引
@@ -193,6 +229,128 @@ func (i *BlockIndex) Size() int {
 	return i.numberIndex.Size()
 }
 
+// NewIterator 从区块号 start 开始按区块号升序遍历区块
+func (i *BlockIndex) NewIterator(start uint64) *BlockIterator {
+	return &BlockIterator{
+		it:    i.numberIndex.NewIterator([]byte{0}, uint64ToBytes(start)),
+		index: i,
+	}
+}
+
+// NewReverseIterator 从区块号 start 开始按区块号降序遍历区块
+func (i *BlockIndex) NewReverseIterator(start uint64) *BlockIterator {
+	return &BlockIterator{
+		it:    i.numberIndex.NewReverseIterator([]byte{0}, uint64ToBytes(start)),
+		index: i,
+	}
+}
+
+// DeleteRange 删除区块号在 [from, to) 范围内的区块索引
+func (i *BlockIndex) DeleteRange(from uint64, to uint64) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if from >= to {
+		return nil
+	}
+
+	// 先删除哈希索引，再删除区块号范围
+	it := i.numberIndex.NewIterator([]byte{0}, uint64ToBytes(from))
+	defer it.Release()
+	for it.Next() {
+		if bytesToUint64(it.Key()[1:]) >= to {
+			break
+		}
+		hashKey := append([]byte{1}, it.Value()...)
+		if err := i.hashIndex.Delete(hashKey); err != nil {
+			return err
+		}
+	}
+
+	startKey := append([]byte{0}, uint64ToBytes(from)...)
+	var endKey []byte
+	if to != math.MaxUint64 {
+		endKey = append([]byte{0}, uint64ToBytes(to)...)
+	}
+	return i.numberIndex.DeleteRange(startKey, endKey)
+}
+
+// Save 将区块索引保存到目录
+func (i *BlockIndex) Save(dir string) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := i.numberIndex.Save(filepath.Join(dir, "block_number.idx")); err != nil {
+		return err
+	}
+	return i.hashIndex.Save(filepath.Join(dir, "block_hash.idx"))
+}
+
+// LoadBlockIndex 从目录加载区块索引，文件不存在时返回空索引
+func LoadBlockIndex(dir string) (*BlockIndex, error) {
+	numberIndex, err := LoadMemoryIndex(filepath.Join(dir, "block_number.idx"))
+	if err != nil {
+		return nil, err
+	}
+	hashIndex, err := LoadMemoryIndex(filepath.Join(dir, "block_hash.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockIndex{
+		numberIndex: numberIndex,
+		hashIndex:   hashIndex,
+	}, nil
+}
+
+// BlockIterator 区块迭代器
+ type BlockIterator struct {
+	it     Iterator
+	index  *BlockIndex
+	number uint64
+	hash   []byte
+	data   []byte
+}
+
+// Next 移动到下一个区块，跳过缺少数据的区块号
+func (it *BlockIterator) Next() bool {
+	for it.it.Next() {
+		hashKey := append([]byte{1}, it.it.Value()...)
+		data, exists := it.index.hashIndex.Get(hashKey)
+		if !exists {
+			continue
+		}
+		it.number = bytesToUint64(it.it.Key()[1:])
+		it.hash = it.it.Value()
+		it.data = data
+		return true
+	}
+	return false
+}
+
+// Number 获取当前区块号
+func (it *BlockIterator) Number() uint64 {
+	return it.number
+}
+
+// Hash 获取当前区块哈希
+func (it *BlockIterator) Hash() []byte {
+	return it.hash
+}
+
+// Data 获取当前区块数据
+func (it *BlockIterator) Data() []byte {
+	return it.data
+}
+
+// Release 释放迭代器
+func (it *BlockIterator) Release() {
+	it.it.Release()
+}
+
 // StateIndex 状态索引
  type StateIndex struct {
 	accountIndex *MemoryIndex // 账户地址到账户数据的索引
@@ -301,6 +459,96 @@ func (i *StateIndex) Size() int {
 	return i.accountIndex.Size() + i.storageIndex.Size()
 }
 
+// NewAccountIterator 从地址 start 开始按地址升序遍历账户，Key 为账户地址
+func (i *StateIndex) NewAccountIterator(start []byte) Iterator {
+	return &trimmedIterator{Iterator: i.accountIndex.NewIterator([]byte{0}, start), trim: 1}
+}
+
+// NewReverseAccountIterator 从地址 start 开始按地址降序遍历账户，start 为空时从最后一个账户开始
+func (i *StateIndex) NewReverseAccountIterator(start []byte) Iterator {
+	return &trimmedIterator{Iterator: i.accountIndex.NewReverseIterator([]byte{0}, start), trim: 1}
+}
+
+// NewStorageIterator 按存储键升序遍历账户存储，Key 为存储键
+func (i *StateIndex) NewStorageIterator(address []byte, start []byte) Iterator {
+	prefix := append([]byte{1}, address...)
+	return &trimmedIterator{Iterator: i.storageIndex.NewIterator(prefix, start), trim: len(prefix)}
+}
+
+// NewReverseStorageIterator 按存储键降序遍历账户存储
+func (i *StateIndex) NewReverseStorageIterator(address []byte, start []byte) Iterator {
+	prefix := append([]byte{1}, address...)
+	return &trimmedIterator{Iterator: i.storageIndex.NewReverseIterator(prefix, start), trim: len(prefix)}
+}
+
+// DeleteAccountRange 删除地址在 [start, end) 范围内的账户，end 为空表示直到末尾
+func (i *StateIndex) DeleteAccountRange(start []byte, end []byte) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	startKey := append([]byte{0}, start...)
+	endKey := []byte{1}
+	if len(end) > 0 {
+		endKey = append([]byte{0}, end...)
+	}
+	return i.accountIndex.DeleteRange(startKey, endKey)
+}
+
+// DeleteAccountStorage 删除账户的全部存储
+func (i *StateIndex) DeleteAccountStorage(address []byte) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	start, limit := PrefixRange(append([]byte{1}, address...))
+	return i.storageIndex.DeleteRange(start, limit)
+}
+
+// Save 将状态索引保存到目录
+func (i *StateIndex) Save(dir string) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := i.accountIndex.Save(filepath.Join(dir, "state_account.idx")); err != nil {
+		return err
+	}
+	return i.storageIndex.Save(filepath.Join(dir, "state_storage.idx"))
+}
+
+// LoadStateIndex 从目录加载状态索引，文件不存在时返回空索引
+func LoadStateIndex(dir string) (*StateIndex, error) {
+	accountIndex, err := LoadMemoryIndex(filepath.Join(dir, "state_account.idx"))
+	if err != nil {
+		return nil, err
+	}
+	storageIndex, err := LoadMemoryIndex(filepath.Join(dir, "state_storage.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &StateIndex{
+		accountIndex: accountIndex,
+		storageIndex: storageIndex,
+	}, nil
+}
+
+// trimmedIterator 去掉键前缀的迭代器
+ type trimmedIterator struct {
+	Iterator
+	trim int
+}
+
+// Key 获取去掉前缀后的键
+func (it *trimmedIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if len(key) < it.trim {
+		return nil
+	}
+	return key[it.trim:]
+}
+
 // uint64ToBytes 将 uint64 转换为字节数组
 func uint64ToBytes(n uint64) []byte {
 	return []byte{
@@ -314,3 +562,12 @@ func uint64ToBytes(n uint64) []byte {
 		byte(n),
 	}
 }
+
+// bytesToUint64 将字节数组转换为 uint64
+func bytesToUint64(b []byte) uint64 {
+	if len(b) < 8 {
+		return 0
+	}
+	return uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7])
+}
@@ -0,0 +1,135 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// 测试前缀迭代的顺序和边界
+func TestMemoryIndexIterator(t *testing.T) {
+	idx := NewMemoryIndex()
+	for _, key := range []string{"b3", "a1", "b1", "c1", "b2"} {
+		idx.Add([]byte(key), []byte("v"+key))
+	}
+
+	var keys []string
+	it := idx.NewIterator([]byte("b"), []byte("2"))
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	it.Release()
+	if fmt.Sprint(keys) != "[b2 b3]" {
+		t.Errorf("forward iteration mismatch: %v", keys)
+	}
+
+	keys = nil
+	it = idx.NewReverseIterator([]byte("b"), nil)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if fmt.Sprint(keys) != "[b3 b2 b1]" {
+		t.Errorf("reverse iteration mismatch: %v", keys)
+	}
+
+	keys = nil
+	it = idx.NewIterator(nil, nil)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if fmt.Sprint(keys) != "[a1 b1 b2 b3 c1]" {
+		t.Errorf("full iteration mismatch: %v", keys)
+	}
+
+	if err := idx.DeleteRange([]byte("b1"), []byte("b3")); err != nil {
+		t.Fatalf("DeleteRange failed: %v", err)
+	}
+	if idx.Size() != 3 {
+		t.Errorf("expected 3 items after DeleteRange, got %d", idx.Size())
+	}
+	if _, exists := idx.Get([]byte("b3")); !exists {
+		t.Errorf("DeleteRange end should be exclusive")
+	}
+}
+
+// 测试区块索引的范围遍历和范围删除
+func TestBlockIndexRange(t *testing.T) {
+	idx := NewBlockIndex()
+	for n := uint64(0); n < 10; n++ {
+		hash := []byte(fmt.Sprintf("hash%d", n))
+		idx.Add(n, hash, []byte(fmt.Sprintf("block%d", n)))
+	}
+
+	var numbers []uint64
+	it := idx.NewIterator(7)
+	for it.Next() {
+		numbers = append(numbers, it.Number())
+	}
+	if fmt.Sprint(numbers) != "[7 8 9]" {
+		t.Errorf("forward block iteration mismatch: %v", numbers)
+	}
+
+	numbers = nil
+	it = idx.NewReverseIterator(math.MaxUint64)
+	for it.Next() && len(numbers) < 2 {
+		numbers = append(numbers, it.Number())
+	}
+	it.Release()
+	if fmt.Sprint(numbers) != "[9 8]" {
+		t.Errorf("reverse block iteration mismatch: %v", numbers)
+	}
+
+	if err := idx.DeleteRange(0, 5); err != nil {
+		t.Fatalf("DeleteRange failed: %v", err)
+	}
+	if idx.Size() != 5 {
+		t.Errorf("expected 5 blocks after DeleteRange, got %d", idx.Size())
+	}
+	if _, exists := idx.GetByHash([]byte("hash3")); exists {
+		t.Errorf("hash index entry should be removed by DeleteRange")
+	}
+}
+
+// 测试状态索引遍历和持久化
+func TestStateIndexPersistence(t *testing.T) {
+	idx := NewStateIndex()
+	addrA := bytes.Repeat([]byte{0x0a}, 20)
+	addrB := bytes.Repeat([]byte{0x0b}, 20)
+	idx.AddAccount(addrB, []byte("accountB"))
+	idx.AddAccount(addrA, []byte("accountA"))
+	idx.AddStorage(addrA, []byte{0x02}, []byte("slot2"))
+	idx.AddStorage(addrA, []byte{0x01}, []byte("slot1"))
+	idx.AddStorage(addrB, []byte{0x01}, []byte("other"))
+
+	dir := filepath.Join(t.TempDir(), "state")
+	if err := idx.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadStateIndex(dir)
+	if err != nil {
+		t.Fatalf("LoadStateIndex failed: %v", err)
+	}
+
+	it := loaded.NewAccountIterator(nil)
+	if !it.Next() || !bytes.Equal(it.Key(), addrA) || string(it.Value()) != "accountA" {
+		t.Errorf("first account mismatch")
+	}
+
+	var slots []string
+	sit := loaded.NewStorageIterator(addrA, nil)
+	for sit.Next() {
+		slots = append(slots, string(sit.Value()))
+	}
+	if fmt.Sprint(slots) != "[slot1 slot2]" {
+		t.Errorf("storage iteration mismatch: %v", slots)
+	}
+
+	if err := loaded.DeleteAccountStorage(addrA); err != nil {
+		t.Fatalf("DeleteAccountStorage failed: %v", err)
+	}
+	if loaded.Size() != 3 {
+		t.Errorf("expected 3 items after deleting storage, got %d", loaded.Size())
+	}
+}
@@ -0,0 +1,99 @@
+package index
+
+import (
+	"bytes"
+)
+
+// Iterator 有序索引迭代器
+// 每次 Next 都在读锁下重新定位，因此迭代期间允许并发修改索引
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+// memoryIterator 内存索引迭代器
+type memoryIterator struct {
+	index    *MemoryIndex
+	prefix   []byte
+	start    []byte
+	reverse  bool
+	started  bool
+	released bool
+	key      []byte
+	value    []byte
+}
+
+// Next 移动到下一项
+func (it *memoryIterator) Next() bool {
+	if it.released {
+		return false
+	}
+
+	it.index.mutex.RLock()
+	defer it.index.mutex.RUnlock()
+
+	var node *skipNode
+	list := it.index.list
+	switch {
+	case !it.started && !it.reverse:
+		node = list.findGE(append(append([]byte(nil), it.prefix...), it.start...), nil)
+	case !it.started && it.reverse:
+		if len(it.start) > 0 {
+			node = list.findLE(append(append([]byte(nil), it.prefix...), it.start...))
+		} else if limit := prefixLimit(it.prefix); limit != nil {
+			node = list.findLT(limit)
+		} else {
+			node = list.findLast()
+		}
+	case it.reverse:
+		node = list.findLT(it.key)
+	default:
+		node = list.findGT(it.key)
+	}
+	it.started = true
+
+	if node == nil || !bytes.HasPrefix(node.key, it.prefix) {
+		it.Release()
+		return false
+	}
+
+	it.key = node.key
+	it.value = node.value
+	return true
+}
+
+// Key 获取当前键
+func (it *memoryIterator) Key() []byte {
+	return it.key
+}
+
+// Value 获取当前值
+func (it *memoryIterator) Value() []byte {
+	return it.value
+}
+
+// Release 释放迭代器
+func (it *memoryIterator) Release() {
+	it.released = true
+	it.key = nil
+	it.value = nil
+}
+
+// prefixLimit 计算大于所有以 prefix 开头的键的最小键，prefix 全为0xff或为空时返回 nil
+func prefixLimit(prefix []byte) []byte {
+	limit := append([]byte(nil), prefix...)
+	for i := len(limit) - 1; i >= 0; i-- {
+		if limit[i] < 0xff {
+			limit[i]++
+			return limit[:i+1]
+		}
+	}
+	return nil
+}
+
+// PrefixRange 获取以 prefix 开头的所有键构成的区间 [start, limit)
+func PrefixRange(prefix []byte) ([]byte, []byte) {
+	return append([]byte(nil), prefix...), prefixLimit(prefix)
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// 索引文件格式：魔数(4) + 版本(1) + 项数(8) + 项[键长(4) 键 值长(4) 值] + crc32(4)
+var indexFileMagic = []byte("NIDX")
+
+const indexFileVersion = 1
+
+var (
+	// ErrInvalidIndexFile 索引文件格式错误
+	ErrInvalidIndexFile = errors.New("index: invalid index file")
+	// ErrIndexChecksum 索引文件校验失败
+	ErrIndexChecksum = errors.New("index: checksum mismatch")
+)
+
+// Save 按键序将索引写入文件，先写临时文件再重命名
+func (i *MemoryIndex) Save(path string) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	hasher := crc32.NewIEEE()
+	writer := bufio.NewWriter(io.MultiWriter(file, hasher))
+
+	header := make([]byte, 13)
+	copy(header, indexFileMagic)
+	header[4] = indexFileVersion
+	binary.BigEndian.PutUint64(header[5:], uint64(i.list.length))
+	writer.Write(header)
+
+	buf := make([]byte, 4)
+	for node := i.list.head.next[0]; node != nil; node = node.next[0] {
+		binary.BigEndian.PutUint32(buf, uint32(len(node.key)))
+		writer.Write(buf)
+		writer.Write(node.key)
+		binary.BigEndian.PutUint32(buf, uint32(len(node.value)))
+		writer.Write(buf)
+		writer.Write(node.value)
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	binary.BigEndian.PutUint32(buf, hasher.Sum32())
+	if _, err := file.Write(buf); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// Load 从文件加载索引，替换当前内容
+func (i *MemoryIndex) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(data) < 17 || !bytes.Equal(data[:4], indexFileMagic) || data[4] != indexFileVersion {
+		return ErrInvalidIndexFile
+	}
+
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(data)-4:]) {
+		return ErrIndexChecksum
+	}
+
+	count := binary.BigEndian.Uint64(body[5:13])
+	body = body[13:]
+
+	list := newSkipList()
+	for n := uint64(0); n < count; n++ {
+		if len(body) < 4 {
+			return ErrInvalidIndexFile
+		}
+		keyLen := binary.BigEndian.Uint32(body)
+		body = body[4:]
+		if uint32(len(body)) < keyLen+4 {
+			return ErrInvalidIndexFile
+		}
+		key := body[:keyLen]
+		body = body[keyLen:]
+
+		valueLen := binary.BigEndian.Uint32(body)
+		body = body[4:]
+		if uint32(len(body)) < valueLen {
+			return ErrInvalidIndexFile
+		}
+		value := append([]byte(nil), body[:valueLen]...)
+		body = body[valueLen:]
+
+		list.put(key, value)
+	}
+
+	i.mutex.Lock()
+	i.list = list
+	i.mutex.Unlock()
+
+	return nil
+}
+
+// LoadMemoryIndex 从文件创建内存索引，文件不存在时返回空索引
+func LoadMemoryIndex(path string) (*MemoryIndex, error) {
+	index := NewMemoryIndex()
+	if err := index.Load(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return index, nil
+}
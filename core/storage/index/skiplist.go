@@ -0,0 +1,177 @@
+package index
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+const (
+	// skipListMaxLevel 跳表最大层数
+	skipListMaxLevel = 16
+	// skipListP 节点晋升到上一层的概率
+	skipListP = 0.25
+)
+
+// skipNode 跳表节点
+type skipNode struct {
+	key   []byte
+	value []byte
+	next  []*skipNode
+}
+
+// skipList 按键字典序排列的跳表
+type skipList struct {
+	head   *skipNode
+	level  int
+	length int
+	rnd    *rand.Rand
+}
+
+// newSkipList 创建跳表
+func newSkipList() *skipList {
+	return &skipList{
+		head:  &skipNode{next: make([]*skipNode, skipListMaxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewSource(0x6e6f676f)),
+	}
+}
+
+// randomLevel 随机生成节点层数
+func (l *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && l.rnd.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// findGE 查找第一个键大于等于 key 的节点，prev 不为空时记录每层的前驱节点
+func (l *skipList) findGE(key []byte, prev []*skipNode) *skipNode {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && bytes.Compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		if prev != nil {
+			prev[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// findGT 查找第一个键大于 key 的节点
+func (l *skipList) findGT(key []byte) *skipNode {
+	node := l.findGE(key, nil)
+	if node != nil && bytes.Equal(node.key, key) {
+		return node.next[0]
+	}
+	return node
+}
+
+// findLT 查找最后一个键小于 key 的节点，不存在时返回 nil
+func (l *skipList) findLT(key []byte) *skipNode {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && bytes.Compare(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+	}
+	if x == l.head {
+		return nil
+	}
+	return x
+}
+
+// findLE 查找最后一个键小于等于 key 的节点，不存在时返回 nil
+func (l *skipList) findLE(key []byte) *skipNode {
+	node := l.findGE(key, nil)
+	if node != nil && bytes.Equal(node.key, key) {
+		return node
+	}
+	return l.findLT(key)
+}
+
+// findLast 查找最后一个节点，不存在时返回 nil
+func (l *skipList) findLast() *skipNode {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil {
+			x = x.next[i]
+		}
+	}
+	if x == l.head {
+		return nil
+	}
+	return x
+}
+
+// get 获取键对应的值
+func (l *skipList) get(key []byte) ([]byte, bool) {
+	node := l.findGE(key, nil)
+	if node != nil && bytes.Equal(node.key, key) {
+		return node.value, true
+	}
+	return nil, false
+}
+
+// put 插入或更新键值
+func (l *skipList) put(key []byte, value []byte) {
+	prev := make([]*skipNode, skipListMaxLevel)
+	node := l.findGE(key, prev)
+	if node != nil && bytes.Equal(node.key, key) {
+		node.value = value
+		return
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			prev[i] = l.head
+		}
+		l.level = level
+	}
+
+	node = &skipNode{
+		key:   append([]byte(nil), key...),
+		value: value,
+		next:  make([]*skipNode, level),
+	}
+	for i := 0; i < level; i++ {
+		node.next[i] = prev[i].next[i]
+		prev[i].next[i] = node
+	}
+	l.length++
+}
+
+// delete 删除键，返回键是否存在
+func (l *skipList) delete(key []byte) bool {
+	prev := make([]*skipNode, skipListMaxLevel)
+	node := l.findGE(key, prev)
+	if node == nil || !bytes.Equal(node.key, key) {
+		return false
+	}
+
+	for i := 0; i < len(node.next); i++ {
+		if prev[i].next[i] == node {
+			prev[i].next[i] = node.next[i]
+		}
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.length--
+	return true
+}
+
+// deleteRange 删除 [start, end) 范围内的键，end 为空表示直到末尾，返回删除数量
+func (l *skipList) deleteRange(start []byte, end []byte) int {
+	count := 0
+	for {
+		node := l.findGE(start, nil)
+		if node == nil || (len(end) > 0 && bytes.Compare(node.key, end) >= 0) {
+			return count
+		}
+		l.delete(node.key)
+		count++
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LockFileName 数据目录锁文件名
+const LockFileName = "LOCK"
+
+// ErrDataDirLocked 数据目录已被其他进程或存储实例打开
+var ErrDataDirLocked = errors.New("storage: data directory is locked by another process")
+
+// DirLock 数据目录的独占锁，防止多个进程同时重放和写入同一个预写日志
+type DirLock struct {
+	file *os.File
+}
+
+// LockDir 获取数据目录的独占锁，目录已被锁定时返回 ErrDataDirLocked
+// 锁随持有锁的进程退出自动释放，残留的锁文件不会阻止下次打开
+func LockDir(dir string) (*DirLock, error) {
+	file, err := os.OpenFile(filepath.Join(dir, LockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &DirLock{file: file}, nil
+}
+
+// Release 释放数据目录锁
+func (l *DirLock) Release() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
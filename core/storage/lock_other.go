@@ -0,0 +1,15 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockFile 不支持 flock 的平台只创建锁文件，不提供互斥
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile 不支持 flock 的平台无需释放
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/state"
+	"nogochain/core/storage/codec"
+	"nogochain/core/types"
+)
+
+// SchemaVersion 当前数据库结构版本
+// 版本 0：没有记录版本的旧数据库，链数据以 JSON 编码
+// 版本 1：链数据以类型化编码存储
+const SchemaVersion uint64 = 1
+
+// migrationBatchSize 迁移时每个批次处理的键数量，每个批次与迁移游标一起原子写入
+const migrationBatchSize = 1000
+
+var (
+	// ErrNewerSchema 数据库由更新版本的节点创建，当前版本无法打开
+	ErrNewerSchema = errors.New("storage: database schema is newer than supported")
+	// ErrMigrationUnsupported 存储不支持遍历键，无法迁移
+	ErrMigrationUnsupported = errors.New("storage: database does not support migration")
+)
+
+// MigratableStorage 支持迁移的存储
+type MigratableStorage interface {
+	BatchStorage
+	Keys(prefix string) []string
+	ReadRaw(key string) ([]byte, bool)
+}
+
+// Migration 数据库结构迁移步骤，将数据库从 Version-1 升级到 Version
+// 迁移按键的字典序分批进行，每批的修改与游标一起写入，中断后从游标之后继续，
+// 因此 Apply 必须是幂等的
+type Migration struct {
+	Version  uint64
+	Name     string
+	Prefixes []string
+	Apply    func(db MigratableStorage, batch *Batch, key string) error
+}
+
+// MigrationProgress 迁移进度
+type MigrationProgress struct {
+	Version uint64
+	Name    string
+	Done    int
+	Total   int
+	Resumed bool
+}
+
+// ProgressFunc 迁移进度回调，每个批次写入后调用
+type ProgressFunc func(progress MigrationProgress)
+
+// migrations 按版本排列的迁移步骤，最后一步的版本必须等于 SchemaVersion
+var migrations = []Migration{
+	{
+		Version:  1,
+		Name:     "typed-values",
+		Prefixes: []string{headerPrefix, bodyPrefix, canonicalPrefix, receiptsPrefix, statePrefix, HeadBlockKey},
+		Apply:    migrateTypedValue,
+	},
+}
+
+// ReadSchemaVersion 读取数据库结构版本
+// 没有记录版本时，已有链数据的数据库为版本 0，空数据库返回 false
+func ReadSchemaVersion(db Storage) (uint64, bool) {
+	if value, exists := db.Get(SchemaVersionKey); exists {
+		if version, ok := value.(uint64); ok {
+			return version, true
+		}
+	}
+	if _, exists := db.Get(HeadBlockKey); exists {
+		return 0, true
+	}
+	return 0, false
+}
+
+// UpgradeSchema 检查数据库结构版本并逐步执行迁移
+// 空数据库直接写入当前版本，比当前版本新的数据库返回 ErrNewerSchema
+func UpgradeSchema(db BatchStorage, progress ProgressFunc) error {
+	version, exists := ReadSchemaVersion(db)
+	if !exists {
+		batch := NewBatch()
+		batch.Set(SchemaVersionKey, SchemaVersion)
+		return db.Write(batch)
+	}
+	if version > SchemaVersion {
+		log.Error().Uint64("version", version).Uint64("supported", SchemaVersion).Msg("Database schema is newer than supported")
+		return ErrNewerSchema
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+
+	mdb, ok := db.(MigratableStorage)
+	if !ok {
+		return ErrMigrationUnsupported
+	}
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if err := runMigration(mdb, migration, progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PendingMigrations 获取数据库需要执行的迁移步骤
+func PendingMigrations(db Storage) ([]Migration, error) {
+	version, exists := ReadSchemaVersion(db)
+	if !exists {
+		return nil, nil
+	}
+	if version > SchemaVersion {
+		return nil, ErrNewerSchema
+	}
+
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// runMigration 执行单个迁移步骤，从上次中断的游标之后继续
+func runMigration(db MigratableStorage, migration Migration, progress ProgressFunc) error {
+	keys := migrationKeys(db, migration.Prefixes)
+
+	// 跳过上次中断前已处理的键
+	start := 0
+	resumed := false
+	if value, exists := db.Get(MigrationCursorKey); exists {
+		if cursor, ok := value.(string); ok {
+			start = sort.SearchStrings(keys, cursor)
+			if start < len(keys) && keys[start] == cursor {
+				start++
+			}
+			resumed = true
+		}
+	}
+
+	log.Info().Uint64("version", migration.Version).Str("name", migration.Name).
+		Int("keys", len(keys)).Int("done", start).Bool("resumed", resumed).Msg("Starting database migration")
+
+	report := func(done int) {
+		if progress != nil {
+			progress(MigrationProgress{
+				Version: migration.Version,
+				Name:    migration.Name,
+				Done:    done,
+				Total:   len(keys),
+				Resumed: resumed,
+			})
+		}
+	}
+
+	for start < len(keys) {
+		end := start + migrationBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		batch := NewBatch()
+		for _, key := range keys[start:end] {
+			if err := migration.Apply(db, batch, key); err != nil {
+				return err
+			}
+		}
+		batch.Set(MigrationCursorKey, keys[end-1])
+		if err := db.Write(batch); err != nil {
+			return err
+		}
+
+		start = end
+		report(start)
+	}
+
+	// 更新版本并删除游标
+	batch := NewBatch()
+	batch.Set(SchemaVersionKey, migration.Version)
+	batch.Delete(MigrationCursorKey)
+	if err := db.Write(batch); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		report(0)
+	}
+
+	log.Info().Uint64("version", migration.Version).Str("name", migration.Name).Msg("Database migration completed")
+	return nil
+}
+
+// migrationKeys 获取迁移涉及的所有键，按字典序排列
+func migrationKeys(db MigratableStorage, prefixes []string) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, prefix := range prefixes {
+		for _, key := range db.Keys(prefix) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// migrateTypedValue 将旧格式 JSON 编码的链数据重新以类型化编码写入
+// 直接从原始 JSON 解码到目标类型，避免经过 interface{} 时大整数丢失精度
+func migrateTypedValue(db MigratableStorage, batch *Batch, key string) error {
+	raw, exists := db.ReadRaw(key)
+	if !exists || !codec.IsLegacy(raw) {
+		return nil
+	}
+
+	var value interface{}
+	switch {
+	case key == HeadBlockKey || strings.HasPrefix(key, canonicalPrefix):
+		value = new(common.Hash)
+	case strings.HasPrefix(key, headerPrefix):
+		value = new(types.BlockHeader)
+	case strings.HasPrefix(key, bodyPrefix):
+		value = new(types.Body)
+	case strings.HasPrefix(key, receiptsPrefix):
+		value = new(types.Receipts)
+	case strings.HasPrefix(key, statePrefix):
+		value = new(state.Dump)
+	default:
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, value); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to migrate legacy value")
+		return err
+	}
+
+	// 哈希和收据列表按值类型注册
+	switch v := value.(type) {
+	case *common.Hash:
+		batch.Set(key, *v)
+	case *types.Receipts:
+		batch.Set(key, *v)
+	default:
+		batch.Set(key, value)
+	}
+	return nil
+}
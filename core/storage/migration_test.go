@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nogochain/core/storage/codec"
+	"nogochain/core/types"
+)
+
+// writeLegacyChain 以旧格式 JSON 写入 n 个区块头和规范链映射，返回区块头
+func writeLegacyChain(t *testing.T, dataDir string, n int) []*types.BlockHeader {
+	coldDir := filepath.Join(dataDir, "cold")
+	if err := os.MkdirAll(coldDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(key string, value interface{}) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(coldDir, key), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 超过 2^53 的难度经过 interface{} 会丢失精度
+	difficulty, _ := new(big.Int).SetString("123456789012345678901", 10)
+	headers := make([]*types.BlockHeader, n)
+	for i := range headers {
+		headers[i] = &types.BlockHeader{Number: big.NewInt(int64(i)), Difficulty: difficulty, Time: uint64(i)}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		write(HeaderKey(headers[i].Hash()), headers[i])
+		write(CanonicalHashKey(uint64(i)), headers[i].Hash())
+	}
+	write(HeadBlockKey, headers[n-1].Hash())
+	return headers
+}
+
+// 测试旧数据库升级到类型化编码，大整数不丢失精度
+func TestUpgradeSchema(t *testing.T) {
+	dataDir := t.TempDir()
+	headers := writeLegacyChain(t, dataDir, 3)
+
+	s := NewOptimizedStorage(dataDir, 100, 1024*1024, time.Hour)
+	if version, exists := ReadSchemaVersion(s); !exists || version != 0 {
+		t.Fatalf("expected legacy version 0, got %d (exists %v)", version, exists)
+	}
+
+	var reports []MigrationProgress
+	if err := UpgradeSchema(s, func(p MigrationProgress) { reports = append(reports, p) }); err != nil {
+		t.Fatalf("UpgradeSchema returned error: %v", err)
+	}
+	if len(reports) == 0 || reports[len(reports)-1].Done != 7 || reports[len(reports)-1].Total != 7 {
+		t.Errorf("unexpected progress reports: %+v", reports)
+	}
+	s.Close()
+
+	reopened := NewOptimizedStorage(dataDir, 100, 1024*1024, time.Hour)
+	defer reopened.Close()
+
+	if version, _ := ReadSchemaVersion(reopened); version != SchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", SchemaVersion, version)
+	}
+	for i, header := range headers {
+		raw, _ := reopened.ReadRaw(HeaderKey(header.Hash()))
+		if codec.IsLegacy(raw) {
+			t.Errorf("header %d was not migrated", i)
+		}
+		value, _ := reopened.Get(HeaderKey(header.Hash()))
+		migrated, ok := value.(*types.BlockHeader)
+		if !ok || migrated.Hash() != header.Hash() {
+			t.Errorf("header %d does not round trip after migration", i)
+		}
+		if hash, _ := reopened.Get(CanonicalHashKey(uint64(i))); hash != header.Hash() {
+			t.Errorf("canonical hash %d not migrated to common.Hash: %v", i, hash)
+		}
+	}
+	if _, exists := reopened.Get(MigrationCursorKey); exists {
+		t.Errorf("migration cursor not removed")
+	}
+}
+
+// 测试中断的迁移从游标之后继续
+func TestUpgradeSchemaResume(t *testing.T) {
+	dataDir := t.TempDir()
+	headers := writeLegacyChain(t, dataDir, 3)
+
+	s := NewOptimizedStorage(dataDir, 100, 1024*1024, time.Hour)
+	defer s.Close()
+
+	// 模拟已处理到第一个规范链映射时中断
+	cursor := CanonicalHashKey(0)
+	batch := NewBatch()
+	batch.Set(MigrationCursorKey, cursor)
+	if err := s.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	var last MigrationProgress
+	if err := UpgradeSchema(s, func(p MigrationProgress) { last = p }); err != nil {
+		t.Fatalf("UpgradeSchema returned error: %v", err)
+	}
+	if !last.Resumed || last.Done != last.Total {
+		t.Errorf("unexpected progress: %+v", last)
+	}
+
+	// 按字典序在游标之前的键被跳过，之后的键已迁移
+	for _, key := range []string{HeadBlockKey, HeaderKey(headers[0].Hash()), CanonicalHashKey(0)} {
+		if raw, _ := s.ReadRaw(key); !codec.IsLegacy(raw) {
+			t.Errorf("key %s before cursor was migrated again", key)
+		}
+	}
+	for _, key := range []string{CanonicalHashKey(1), CanonicalHashKey(2)} {
+		if raw, _ := s.ReadRaw(key); codec.IsLegacy(raw) {
+			t.Errorf("key %s after cursor was not migrated", key)
+		}
+	}
+}
+
+// 测试空数据库写入当前版本，更新版本的数据库被拒绝
+func TestUpgradeSchemaVersions(t *testing.T) {
+	s := NewOptimizedStorage(t.TempDir(), 100, 1024*1024, time.Hour)
+	defer s.Close()
+
+	if err := UpgradeSchema(s, nil); err != nil {
+		t.Fatalf("UpgradeSchema returned error: %v", err)
+	}
+	if version, exists := ReadSchemaVersion(s); !exists || version != SchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", SchemaVersion, version)
+	}
+
+	batch := NewBatch()
+	batch.Set(SchemaVersionKey, SchemaVersion+1)
+	if err := s.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpgradeSchema(s, nil); err != ErrNewerSchema {
+		t.Errorf("expected ErrNewerSchema, got %v", err)
+	}
+	if last := migrations[len(migrations)-1]; last.Version != SchemaVersion {
+		t.Errorf("last migration version %d does not match SchemaVersion %d", last.Version, SchemaVersion)
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 链数据键前缀
+// 冷数据存储以键作为文件名，因此键只能包含文件名安全的字符
+const (
+	// HeadBlockKey 当前头部区块哈希
+	HeadBlockKey = "LastBlock"
+	// SchemaVersionKey 数据库结构版本
+	SchemaVersionKey = "SchemaVersion"
+	// MigrationCursorKey 进行中的迁移已处理的最后一个键，用于中断后继续迁移
+	MigrationCursorKey = "MigrationCursor"
+	// BodyTailKey 裁剪后仍保留区块体和收据的最小区块号
+	BodyTailKey = "BodyTail"
+	// StateTailKey 裁剪后仍保留状态的最小区块号
+	StateTailKey = "StateTail"
+	// TxIndexTailKey 交易查找索引覆盖的最小区块号
+	TxIndexTailKey = "TxIndexTail"
+
+	headerPrefix    = "h-" // 区块哈希 -> 区块头
+	bodyPrefix      = "b-" // 区块哈希 -> 区块体
+	canonicalPrefix = "n-" // 区块号 -> 规范链区块哈希
+	statePrefix     = "s-" // 状态根 -> 状态数据
+	receiptsPrefix  = "r-" // 区块哈希 -> 收据列表
+	txLookupPrefix  = "l-" // 交易哈希 -> 所在区块哈希
+)
+
+// 数据目录布局
+const (
+	// ChainDataDir 链数据库目录，位于节点数据目录下
+	ChainDataDir = "chaindata"
+	// AncientDir 冻结库目录，位于链数据库目录下
+	AncientDir = "ancient"
+)
+
+// BatchStorage 支持原子批次写入的存储
+type BatchStorage interface {
+	Storage
+	Write(batch *Batch) error
+	Close() error
+}
+
+// HeaderKey 区块头键
+func HeaderKey(hash common.Hash) string {
+	return headerPrefix + hash.Hex()[2:]
+}
+
+// BodyKey 区块体键
+func BodyKey(hash common.Hash) string {
+	return bodyPrefix + hash.Hex()[2:]
+}
+
+// CanonicalHashKey 规范链区块号键，区块号补零以保持字典序与数值序一致
+func CanonicalHashKey(number uint64) string {
+	return fmt.Sprintf("%s%020d", canonicalPrefix, number)
+}
+
+// ReceiptsKey 收据列表键
+func ReceiptsKey(hash common.Hash) string {
+	return receiptsPrefix + hash.Hex()[2:]
+}
+
+// TxLookupKey 交易查找索引键
+func TxLookupKey(hash common.Hash) string {
+	return txLookupPrefix + hash.Hex()[2:]
+}
+
+// StateKeyPrefix 状态数据键前缀，用于遍历所有状态
+const StateKeyPrefix = statePrefix
+
+// StateRootFromKey 从状态数据键解析状态根
+func StateRootFromKey(key string) (common.Hash, bool) {
+	if !strings.HasPrefix(key, statePrefix) || len(key) != len(statePrefix)+2*common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.HexToHash(key[len(statePrefix):]), true
+}
+
+// StateKey 状态数据键
+func StateKey(root common.Hash) string {
+	return statePrefix + root.Hex()[2:]
+}
@@ -3,13 +3,20 @@ package storage
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"nogochain/core/storage/cache"
+	"nogochain/core/storage/codec"
 	"nogochain/core/storage/compression"
+	"nogochain/core/storage/wal"
 )
 
+// walCheckpointSize 预写日志超过该大小且没有未应用批次时清空
+const walCheckpointSize = 4 * 1024 * 1024
+
 // StorageType 存储类型
  type StorageType int
 
@@ -26,6 +33,8 @@ const (
 	AccessTime time.Time
 	StorageType StorageType
 	Compressed bool
+	// Dirty 热数据存储中的值与冷数据存储中的副本不一致，降级时需要重新写入
+	Dirty bool
 }
 
 // Storage 存储接口
@@ -42,142 +51,505 @@ const (
 	hotStorage     *cache.MemoryCache
 	coldStorage    *cache.DiskCache
 	compressor     compression.Compressor
+	codec          *codec.Registry
 	mutex          sync.RWMutex
 	hotToColdThreshold time.Duration
-	coldAccessCount   map[string]int
-	coldAccessMutex   sync.Mutex
+	tierStats      tierStats
+	stopCh         chan struct{}
+	wg             sync.WaitGroup
+	closeOnce      sync.Once
+	dataDir        string
+	wal            *wal.WAL
+	walOptions     wal.Options
+	batchMutex     sync.Mutex
+	lock           *DirLock
+}
+
+// Batch 原子写批次，批次内的所有写入和删除要么全部生效，要么全部不生效
+ type Batch struct {
+	ops []batchOp
+}
+
+// batchOp 批次操作
+ type batchOp struct {
+	key    string
+	value  interface{}
+	delete bool
+}
+
+// NewBatch 创建写批次
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Set 添加写入操作
+func (b *Batch) Set(key string, value interface{}) {
+	b.ops = append(b.ops, batchOp{key: key, value: value})
+}
+
+// Delete 添加删除操作
+func (b *Batch) Delete(key string) {
+	b.ops = append(b.ops, batchOp{key: key, delete: true})
+}
+
+// Len 获取批次操作数量
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Reset 清空批次
+func (b *Batch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// Config 优化存储配置
+ type Config struct {
+	// HotCapacity 热数据存储最大项数
+	HotCapacity int `json:"hotCapacity"`
+	// HotPolicy 热数据存储淘汰策略：lru、lfu、arc、size、ttl
+	HotPolicy string `json:"hotPolicy"`
+	// HotMaxBytes 热数据存储总大小上限，0 表示只按项数限制
+	HotMaxBytes int64 `json:"hotMaxBytes"`
+	// ColdCapacity 冷数据存储容量（字节），不大于 0 时不限制容量，冷数据从不被淘汰
+	ColdCapacity int64 `json:"coldCapacity"`
+	// HotToColdThreshold 热数据空闲多久后迁移到冷数据存储
+	HotToColdThreshold time.Duration `json:"hotToColdThreshold"`
+	// ColdCompression 冷数据压缩器名称，为空时使用 gzip
+	ColdCompression string `json:"coldCompression"`
+}
+
+// DefaultBlockStorageConfig 区块存储默认配置
+func DefaultBlockStorageConfig() Config {
+	return Config{
+		HotCapacity:        1000, // 热数据容量
+		HotPolicy:          cache.PolicyLRU,
+		ColdCapacity:       1024 * 1024 * 1024, // 冷数据容量（1GB）
+		HotToColdThreshold: 24 * time.Hour, // 热到冷的迁移阈值
+	}
 }
 
-// NewOptimizedStorage 创建优化的存储
+// ChainDatabaseConfig 节点链数据库配置，冷数据存储是唯一的持久化副本，不限制容量以免淘汰链数据
+func ChainDatabaseConfig() Config {
+	config := DefaultBlockStorageConfig()
+	config.ColdCapacity = 0
+	return config
+}
+
+// DefaultStateStorageConfig 状态存储默认配置
+func DefaultStateStorageConfig() Config {
+	return Config{
+		HotCapacity:        5000, // 热数据容量
+		HotPolicy:          cache.PolicyLRU,
+		ColdCapacity:       2 * 1024 * 1024 * 1024, // 冷数据容量（2GB）
+		HotToColdThreshold: 12 * time.Hour, // 热到冷的迁移阈值
+	}
+}
+
+// NewOptimizedStorage 创建优化的存储，热数据存储使用 LRU 淘汰策略
 func NewOptimizedStorage(dataDir string, hotCapacity int, coldCapacity int64, hotToColdThreshold time.Duration) *OptimizedStorage {
+	return NewOptimizedStorageWithConfig(dataDir, Config{
+		HotCapacity:        hotCapacity,
+		HotPolicy:          cache.PolicyLRU,
+		ColdCapacity:       coldCapacity,
+		HotToColdThreshold: hotToColdThreshold,
+	})
+}
+
+// NewOptimizedStorageWithConfig 根据配置创建优化的存储，打开失败时 panic
+func NewOptimizedStorageWithConfig(dataDir string, config Config) *OptimizedStorage {
+	s, err := OpenOptimizedStorage(dataDir, config)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// OpenOptimizedStorage 根据配置打开优化的存储，预写日志无法打开或重放失败时返回错误
+func OpenOptimizedStorage(dataDir string, config Config) (*OptimizedStorage, error) {
 	// 确保数据目录存在
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	// 独占数据目录，其他进程不能同时重放和写入预写日志
+	lock, err := LockDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	s, err := openLocked(dataDir, config)
+	if err != nil {
+		lock.Release()
+		return nil, err
 	}
+	s.lock = lock
+	return s, nil
+}
+
+// openLocked 在已持有目录锁时打开优化的存储
+func openLocked(dataDir string, config Config) (*OptimizedStorage, error) {
+	hotToColdThreshold := config.HotToColdThreshold
+	coldCapacity := config.ColdCapacity
 
 	// 创建热数据存储（内存缓存）
-	hotStorage := cache.NewMemoryCache(hotCapacity, &cache.LRUCache{})
+	hotStorage, err := cache.NewMemoryCacheWithConfig(cache.Config{
+		Capacity: config.HotCapacity,
+		Policy:   config.HotPolicy,
+		MaxBytes: config.HotMaxBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建冷数据存储（磁盘缓存）
 	coldPath := filepath.Join(dataDir, "cold")
 	if err := os.MkdirAll(coldPath, 0755); err != nil {
-		panic(err)
+		return nil, err
 	}
 	coldStorage := cache.NewDiskCache(coldPath, coldCapacity)
 
 	// 创建压缩器
-	compressor := compression.NewGzipCompressor(compression.DefaultCompression)
+	if config.ColdCompression == "" {
+		config.ColdCompression = compression.NameGzip
+	}
+	compressor, err := compression.Lookup(config.ColdCompression)
+	if err != nil {
+		return nil, err
+	}
 
-	return &OptimizedStorage{
+	s := &OptimizedStorage{
+		dataDir:            dataDir,
 		hotStorage:         hotStorage,
 		coldStorage:        coldStorage,
 		compressor:         compressor,
+		codec:              codec.Default,
 		hotToColdThreshold: hotToColdThreshold,
-		coldAccessCount:    make(map[string]int),
+		walOptions:         wal.DefaultOptions(),
 	}
+
+	// 打开预写日志，重放崩溃前未应用完成的批次
+	log, err := wal.Open(s.walPath(), s.walOptions, s.replayBatch)
+	if err != nil {
+		return nil, err
+	}
+	s.wal = log
+
+	// 热数据存储容量淘汰时降级到冷数据存储，避免数据丢失
+	hotStorage.SetOnEvict(s.demoteEvicted)
+
+	// 启动唯一的后台分层任务
+	s.stopCh = make(chan struct{})
+	s.wg.Add(1)
+	go s.tieringLoop()
+
+	return s, nil
 }
 
 // Get 获取存储项
 func (s *OptimizedStorage) Get(key string) (interface{}, bool) {
+	if item, exists := s.GetItem(key); exists {
+		return item.Value, true
+	}
+	return nil, false
+}
+
+// GetItem 获取存储项及其所在层级，冷数据被访问时提升到热数据存储
+// 只在查找和提升热数据时持有锁，读取和解压冷数据时不阻塞其他读写
+func (s *OptimizedStorage) GetItem(key string) (StorageItem, bool) {
 	// 先从热数据存储获取
-	if value, exists := s.hotStorage.Get(key); exists {
-		return value, true
+	if item, exists := s.getHot(key); exists {
+		return item, true
 	}
 
 	// 再从冷数据存储获取
-	if value, exists := s.coldStorage.Get(key); exists {
-		// 增加访问计数
-		s.coldAccessMutex.Lock()
-		s.coldAccessCount[key]++
-		count := s.coldAccessCount[key]
-		s.coldAccessMutex.Unlock()
-
-		// 如果访问次数超过阈值，迁移到热数据存储
-		if count > 3 {
-			s.migrateToHot(key, value)
-			s.coldAccessMutex.Lock()
-			delete(s.coldAccessCount, key)
-			s.coldAccessMutex.Unlock()
-		}
+	value, compressed, size, exists := s.readCold(key)
+	if !exists {
+		return StorageItem{}, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 读取冷数据期间可能被写入或删除，热数据存储中的新值优先，已删除的数据不再提升
+	if hot, exists := s.hotStorage.Get(key); exists {
+		item := hot.(*StorageItem)
+		item.AccessTime = time.Now()
+		return *item, true
+	}
+	if !s.coldStorage.Has(key) {
+		return StorageItem{}, false
+	}
+
+	// 访问即提升到热数据存储，冷数据存储保留副本
+	s.migrateToHot(key, value, size)
+
+	return StorageItem{
+		Value:       value,
+		AccessTime:  time.Now(),
+		StorageType: ColdStorage,
+		Compressed:  compressed,
+	}, true
+}
 
-		return value, true
+// getHot 从热数据存储获取存储项并更新访问时间
+func (s *OptimizedStorage) getHot(key string) (StorageItem, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, exists := s.hotStorage.Get(key)
+	if !exists {
+		return StorageItem{}, false
 	}
+	item := value.(*StorageItem)
+	item.AccessTime = time.Now()
+	return *item, true
+}
 
-	return nil, false
+// Has 检查键是否存在，不解码也不提升数据，可用于区分数据缺失和数据损坏
+func (s *OptimizedStorage) Has(key string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.hotStorage.Get(key); exists {
+		return true
+	}
+	return s.coldStorage.Has(key)
+}
+
+// ReadRaw 读取冷数据存储中解压后、未解码的数据，用于检查磁盘上的编码格式
+func (s *OptimizedStorage) ReadRaw(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, exists := s.coldStorage.ReadRaw(key)
+	if !exists {
+		return nil, false
+	}
+	raw, err := compression.DecompressAny(data)
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+// Keys 获取热数据和冷数据存储中以 prefix 开头的所有键，按字典序排列
+func (s *OptimizedStorage) Keys(prefix string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seen := make(map[string]bool)
+	keys := s.coldStorage.Keys(prefix)
+	for _, key := range keys {
+		seen[key] = true
+	}
+	s.hotStorage.Range(func(key string, value interface{}) bool {
+		if strings.HasPrefix(key, prefix) && !seen[key] {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	sort.Strings(keys)
+	return keys
 }
 
 // Set 设置存储项
 func (s *OptimizedStorage) Set(key string, value interface{}) {
-	// 先存储到热数据存储
-	s.hotStorage.Set(key, value, 0)
-
-	// 启动后台任务，检查是否需要迁移到冷数据存储
-	go s.checkHotToColdMigration()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 先存储到热数据存储，由后台分层任务负责降级
+	s.hotStorage.Set(key, &StorageItem{
+		Value:       value,
+		AccessTime:  time.Now(),
+		StorageType: HotStorage,
+		Dirty:       true,
+	}, 0)
 }
 
 // Delete 删除存储项
 func (s *OptimizedStorage) Delete(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	// 从热数据存储删除
 	s.hotStorage.Delete(key)
 
 	// 从冷数据存储删除
 	s.coldStorage.Delete(key)
-
-	// 从冷数据访问计数中删除
-	s.coldAccessMutex.Lock()
-	delete(s.coldAccessCount, key)
-	s.coldAccessMutex.Unlock()
 }
 
 // Clear 清空存储
 func (s *OptimizedStorage) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	// 清空热数据存储
 	s.hotStorage.Clear()
 
 	// 清空冷数据存储
 	s.coldStorage.Clear()
+}
+
+// Write 原子写入批次
+// 批次先写入预写日志并按刷盘策略落盘，再写入冷数据存储和热数据存储
+func (s *OptimizedStorage) Write(batch *Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	// 序列化批次
+	logBatch := &wal.Batch{}
+	for _, op := range batch.ops {
+		if op.delete {
+			logBatch.Delete(op.key)
+			continue
+		}
+		data, err := s.codec.Encode(op.value)
+		if err != nil {
+			return err
+		}
+		logBatch.Put(op.key, data)
+	}
+
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	// 写入预写日志
+	id, err := s.wal.Append(logBatch)
+	if err != nil {
+		return err
+	}
+
+	// 应用到存储
+	if err := s.applyBatch(logBatch); err != nil {
+		// 批次已持久化在日志中，下次打开时会重放
+		return err
+	}
+	s.mutex.Lock()
+	for _, op := range batch.ops {
+		if !op.delete {
+			// 冷数据存储已有相同副本，热数据不需要再次降级写入
+			s.hotStorage.Set(op.key, &StorageItem{
+				Value:       op.value,
+				AccessTime:  time.Now(),
+				StorageType: HotStorage,
+			}, 0)
+		}
+	}
+	s.mutex.Unlock()
+
+	if err := s.wal.MarkApplied(id); err != nil {
+		return err
+	}
+
+	if s.wal.Size() > walCheckpointSize {
+		return s.wal.Checkpoint()
+	}
 
-	// 清空冷数据访问计数
-	s.coldAccessMutex.Lock()
-	s.coldAccessCount = make(map[string]int)
-	s.coldAccessMutex.Unlock()
+	return nil
 }
 
-// GetStats 获取存储统计信息
-func (s *OptimizedStorage) GetStats() map[string]interface{} {
-	return map[string]interface{}{
-		"hot_storage":  s.hotStorage.GetStats(),
-		"cache_hit_rate": s.hotStorage.GetHitRate(),
-		"cold_access_count": len(s.coldAccessCount),
+// SetCodec 设置值编解码器，需要在写入前调用
+func (s *OptimizedStorage) SetCodec(registry *codec.Registry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.codec = registry
+	s.coldStorage.SetCodec(registry)
+}
+
+// SetSyncPolicy 设置预写日志的刷盘策略，需要在写入前调用
+func (s *OptimizedStorage) SetSyncPolicy(policy wal.SyncPolicy, interval time.Duration) error {
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	if err := s.wal.Close(); err != nil {
+		return err
+	}
+
+	s.walOptions = wal.Options{Sync: policy, SyncInterval: interval}
+	log, err := wal.Open(s.walPath(), s.walOptions, s.replayBatch)
+	if err != nil {
+		return err
 	}
+	s.wal = log
+
+	return nil
 }
 
-// checkHotToColdMigration 检查是否需要将热数据迁移到冷数据存储
-func (s *OptimizedStorage) checkHotToColdMigration() {
-	// 实现热数据到冷数据的迁移逻辑
-	// 这里简化实现，实际应该定期检查热数据的访问时间
+// Close 关闭存储，停止分层任务，写入未落盘的热数据，落盘并关闭预写日志
+func (s *OptimizedStorage) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	// 无论关闭是否成功都释放数据目录锁
+	defer s.releaseLock()
+
+	// Set 写入的数据只在热数据存储中，关闭前写入冷数据存储
+	if err := s.flushDirty(); err != nil {
+		return err
+	}
+	if err := s.wal.Checkpoint(); err != nil && err != wal.ErrClosed {
+		return err
+	}
+	return s.wal.Close()
 }
 
-// migrateToHot 将冷数据迁移到热数据存储
-func (s *OptimizedStorage) migrateToHot(key string, value interface{}) {
-	// 将数据从冷数据存储迁移到热数据存储
-	s.hotStorage.Set(key, value, 0)
-	s.coldStorage.Delete(key)
+// releaseLock 释放数据目录锁，调用者需持有 batchMutex
+func (s *OptimizedStorage) releaseLock() {
+	if s.lock != nil {
+		s.lock.Release()
+		s.lock = nil
+	}
 }
 
-// migrateToCold 将热数据迁移到冷数据存储
-func (s *OptimizedStorage) migrateToCold(key string, value interface{}) {
-	// 压缩数据
-	compressed, err := compression.CompressObject(value, s.compressor)
-	if err != nil {
-		// 压缩失败，直接存储原始数据
-		s.coldStorage.Set(key, value, 0)
-		return
+// applyBatch 将批次写入冷数据存储，并使热数据存储中的旧值失效
+func (s *OptimizedStorage) applyBatch(batch *wal.Batch) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	syncWrites := s.walOptions.Sync != wal.SyncNone
+	for _, op := range batch.Ops {
+		switch op.Type {
+		case wal.OpPut:
+			if err := s.coldStorage.WriteRaw(op.Key, op.Value, syncWrites); err != nil {
+				return err
+			}
+			s.hotStorage.Delete(op.Key)
+		case wal.OpDelete:
+			s.hotStorage.Delete(op.Key)
+			s.coldStorage.Delete(op.Key)
+		}
 	}
+	return nil
+}
 
-	// 存储压缩后的数据
-	s.coldStorage.Set(key, compressed, 0)
-	s.hotStorage.Delete(key)
+// replayBatch 重放预写日志中未应用的批次
+func (s *OptimizedStorage) replayBatch(batch *wal.Batch) error {
+	return s.applyBatch(batch)
+}
+
+// walPath 获取预写日志路径
+func (s *OptimizedStorage) walPath() string {
+	return filepath.Join(s.dataDir, "wal.log")
+}
+
+// GetStats 获取存储统计信息
+func (s *OptimizedStorage) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"hot_storage":  s.hotStorage.GetStats(),
+		"cache_hit_rate": s.hotStorage.GetHitRate(),
+		"hot_policy": s.hotStorage.GetPolicyStats(),
+		"cold_access_count": s.tierStats.coldHits(),
+		"cold_size": s.coldStorage.Size(),
+		"tiering": s.tieringStats(),
+		"wal": s.wal.Stats(),
+	}
 }
 
 // BlockStorage 区块存储
@@ -188,13 +560,13 @@ func (s *OptimizedStorage) migrateToCold(key string, value interface{}) {
 
 // NewBlockStorage 创建区块存储
 func NewBlockStorage(dataDir string) *BlockStorage {
+	return NewBlockStorageWithConfig(dataDir, DefaultBlockStorageConfig())
+}
+
+// NewBlockStorageWithConfig 根据配置创建区块存储
+func NewBlockStorageWithConfig(dataDir string, config Config) *BlockStorage {
 	return &BlockStorage{
-		storage: NewOptimizedStorage(
-			filepath.Join(dataDir, "blocks"),
-			1000, // 热数据容量
-			1024*1024*1024, // 冷数据容量（1GB）
-			24*time.Hour, // 热到冷的迁移阈值
-		),
+		storage: NewOptimizedStorageWithConfig(filepath.Join(dataDir, "blocks"), config),
 	}
 }
 
@@ -223,6 +595,26 @@ func (s *BlockStorage) GetStats() map[string]interface{} {
 	return s.storage.GetStats()
 }
 
+// Write 原子写入批次
+func (s *BlockStorage) Write(batch *Batch) error {
+	return s.storage.Write(batch)
+}
+
+// Close 关闭区块存储
+func (s *BlockStorage) Close() error {
+	return s.storage.Close()
+}
+
+// Keys 获取以 prefix 开头的所有键
+func (s *BlockStorage) Keys(prefix string) []string {
+	return s.storage.Keys(prefix)
+}
+
+// ReadRaw 读取未解码的数据
+func (s *BlockStorage) ReadRaw(key string) ([]byte, bool) {
+	return s.storage.ReadRaw(key)
+}
+
 // StateStorage 状态存储
  type StateStorage struct {
 	storage *OptimizedStorage
@@ -231,13 +623,13 @@ func (s *BlockStorage) GetStats() map[string]interface{} {
 
 // NewStateStorage 创建状态存储
 func NewStateStorage(dataDir string) *StateStorage {
+	return NewStateStorageWithConfig(dataDir, DefaultStateStorageConfig())
+}
+
+// NewStateStorageWithConfig 根据配置创建状态存储
+func NewStateStorageWithConfig(dataDir string, config Config) *StateStorage {
 	return &StateStorage{
-		storage: NewOptimizedStorage(
-			filepath.Join(dataDir, "state"),
-			5000, // 热数据容量
-			2*1024*1024*1024, // 冷数据容量（2GB）
-			12*time.Hour, // 热到冷的迁移阈值
-		),
+		storage: NewOptimizedStorageWithConfig(filepath.Join(dataDir, "state"), config),
 	}
 }
 
@@ -265,3 +657,23 @@ func (s *StateStorage) Clear() {
 func (s *StateStorage) GetStats() map[string]interface{} {
 	return s.storage.GetStats()
 }
+
+// Write 原子写入批次
+func (s *StateStorage) Write(batch *Batch) error {
+	return s.storage.Write(batch)
+}
+
+// Close 关闭状态存储
+func (s *StateStorage) Close() error {
+	return s.storage.Close()
+}
+
+// Keys 获取以 prefix 开头的所有键
+func (s *StateStorage) Keys(prefix string) []string {
+	return s.storage.Keys(prefix)
+}
+
+// ReadRaw 读取未解码的数据
+func (s *StateStorage) ReadRaw(key string) ([]byte, bool) {
+	return s.storage.ReadRaw(key)
+}
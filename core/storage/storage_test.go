@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nogochain/core/types"
+)
+
+// 测试空闲热数据降级到压缩冷数据存储，访问时再提升
+func TestOptimizedStorageTiering(t *testing.T) {
+	s := NewOptimizedStorage(t.TempDir(), 10, 1024*1024, 10*time.Millisecond)
+	defer s.Close()
+
+	value := map[string]interface{}{"number": float64(7), "hash": "0xabc"}
+	s.Set("block-7", value)
+
+	time.Sleep(20 * time.Millisecond)
+	if demoted := s.DemoteIdle(); demoted != 1 {
+		t.Fatalf("expected 1 demoted item, got %d", demoted)
+	}
+
+	item, exists := s.GetItem("block-7")
+	if !exists {
+		t.Fatalf("demoted item not found")
+	}
+	if item.StorageType != ColdStorage || !item.Compressed {
+		t.Errorf("expected compressed cold item, got type %d compressed %v", item.StorageType, item.Compressed)
+	}
+	if item.Value.(map[string]interface{})["hash"] != "0xabc" {
+		t.Errorf("value mismatch after promotion: %v", item.Value)
+	}
+
+	// 提升后位于热数据存储
+	item, _ = s.GetItem("block-7")
+	if item.StorageType != HotStorage {
+		t.Errorf("expected item to be promoted to hot storage")
+	}
+
+	stats := s.GetStats()["tiering"].(map[string]interface{})
+	if stats["demotions"].(int64) != 1 || stats["promotions"].(int64) != 1 {
+		t.Errorf("unexpected tiering stats: %v", stats)
+	}
+	if stats["demoted_bytes"].(int64) == 0 || stats["promoted_bytes"].(int64) != stats["demoted_bytes"].(int64) {
+		t.Errorf("unexpected tiering sizes: %v", stats)
+	}
+}
+
+// 测试热数据容量淘汰时降级而不是丢弃
+func TestOptimizedStorageEvictionDemotes(t *testing.T) {
+	s := NewOptimizedStorage(t.TempDir(), 2, 1024*1024, time.Hour)
+	defer s.Close()
+
+	s.Set("a", "1")
+	s.Set("b", "2")
+	s.Set("c", "3")
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, exists := s.Get(key); !exists {
+			t.Errorf("item %s lost after eviction", key)
+		}
+	}
+	stats := s.GetStats()["tiering"].(map[string]interface{})
+	if stats["evicted_demotions"].(int64) == 0 {
+		t.Errorf("expected evicted demotions, got %v", stats)
+	}
+}
+
+// 测试批次写入的区块重新打开后解码为原类型
+func TestOptimizedStorageTypedValues(t *testing.T) {
+	dataDir := t.TempDir()
+	s := NewOptimizedStorage(dataDir, 10, 1024*1024, time.Hour)
+
+	header := &types.BlockHeader{Number: big.NewInt(1), Difficulty: big.NewInt(100), Extra: []byte{}}
+	batch := NewBatch()
+	batch.Set(HeaderKey(header.Hash()), header)
+	batch.Set(HeadBlockKey, header.Hash())
+	if err := s.Write(batch); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s.Close()
+
+	reopened := NewOptimizedStorage(dataDir, 10, 1024*1024, time.Hour)
+	defer reopened.Close()
+
+	value, exists := reopened.Get(HeaderKey(header.Hash()))
+	if !exists {
+		t.Fatalf("header not found after reopen")
+	}
+	decoded, ok := value.(*types.BlockHeader)
+	if !ok {
+		t.Fatalf("expected *types.BlockHeader, got %T", value)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("header hash mismatch after reopen")
+	}
+	if head, _ := reopened.Get(HeadBlockKey); head != header.Hash() {
+		t.Errorf("head mismatch: %v", head)
+	}
+}
+
+// 测试快照包含已写入的批次和尚未降级的热数据，之后的修改不影响快照
+func TestOptimizedStorageCheckpoint(t *testing.T) {
+	s := NewOptimizedStorage(t.TempDir(), 10, 1024*1024, time.Hour)
+	defer s.Close()
+
+	batch := NewBatch()
+	batch.Set("batched", "v1")
+	if err := s.Write(batch); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s.Set("dirty", "v2")
+
+	dir := t.TempDir()
+	info, err := s.Checkpoint(dir)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if info.Keys != 2 {
+		t.Errorf("expected 2 keys in checkpoint, got %d", info.Keys)
+	}
+
+	batch = NewBatch()
+	batch.Set("batched", "changed")
+	if err := s.Write(batch); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	snapshot := NewOptimizedStorage(dir, 10, 1024*1024, time.Hour)
+	defer snapshot.Close()
+	if value, _ := snapshot.Get("batched"); value != "v1" {
+		t.Errorf("expected batched value v1 in checkpoint, got %v", value)
+	}
+	if value, _ := snapshot.Get("dirty"); value != "v2" {
+		t.Errorf("expected dirty value v2 in checkpoint, got %v", value)
+	}
+}
+
+// 测试预写日志无法打开时返回错误而不是 panic
+func TestOpenOptimizedStorageError(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dataDir, "wal.log"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if s, err := OpenOptimizedStorage(dataDir, DefaultBlockStorageConfig()); err == nil {
+		s.Close()
+		t.Fatalf("expected an error for an unreadable write-ahead log")
+	}
+}
+
+// 测试数据目录同时只能被一个存储打开，关闭后释放锁
+func TestDataDirLock(t *testing.T) {
+	dataDir := t.TempDir()
+	s, err := OpenOptimizedStorage(dataDir, DefaultBlockStorageConfig())
+	if err != nil {
+		t.Fatalf("OpenOptimizedStorage failed: %v", err)
+	}
+	if other, err := OpenOptimizedStorage(dataDir, DefaultBlockStorageConfig()); err != ErrDataDirLocked {
+		if other != nil {
+			other.Close()
+		}
+		t.Fatalf("second open: got %v, want %v", err, ErrDataDirLocked)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := OpenOptimizedStorage(dataDir, DefaultBlockStorageConfig())
+	if err != nil {
+		t.Fatalf("reopen after Close failed: %v", err)
+	}
+	reopened.Close()
+}
+
+// writeAndReopen 写入 n 个 size 字节的值，关闭并重新打开存储，返回仍可读取的键数
+func writeAndReopen(t *testing.T, config Config, n, size int) int {
+	dataDir := t.TempDir()
+	s, err := OpenOptimizedStorage(dataDir, config)
+	if err != nil {
+		t.Fatalf("OpenOptimizedStorage failed: %v", err)
+	}
+	// 随机值不可压缩，冷数据大小接近写入大小
+	values := make([]string, n)
+	rng := rand.New(rand.NewSource(1))
+	for i := range values {
+		data := make([]byte, size/2)
+		rng.Read(data)
+		values[i] = hex.EncodeToString(data)
+	}
+	for i, value := range values {
+		batch := NewBatch()
+		batch.Set(fmt.Sprintf("key-%04d", i), value)
+		if err := s.Write(batch); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := OpenOptimizedStorage(dataDir, config)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	readable := 0
+	for i, value := range values {
+		if v, exists := reopened.Get(fmt.Sprintf("key-%04d", i)); exists && v == value {
+			readable++
+		}
+	}
+	return readable
+}
+
+// 测试链数据库配置不淘汰冷数据，写入超过冷数据容量的数据后所有键仍可读取
+func TestChainDatabaseNeverEvicts(t *testing.T) {
+	const n, size = 200, 4096
+
+	// 有容量限制的冷数据存储会淘汰最早写入的键
+	limited := ChainDatabaseConfig()
+	limited.ColdCapacity = n * size / 4
+	if readable := writeAndReopen(t, limited, n, size); readable == n {
+		t.Fatalf("expected a bounded cold store to evict keys")
+	}
+
+	if readable := writeAndReopen(t, ChainDatabaseConfig(), n, size); readable != n {
+		t.Errorf("chain database lost keys: %d of %d readable", readable, n)
+	}
+}
+
+// 测试 Set 写入的热数据在关闭时落盘，重新打开后仍可读取
+func TestSetSurvivesRestart(t *testing.T) {
+	dataDir := t.TempDir()
+	s := NewOptimizedStorage(dataDir, 10, 1024*1024, time.Hour)
+	s.Set("key", "value")
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened := NewOptimizedStorage(dataDir, 10, 1024*1024, time.Hour)
+	defer reopened.Close()
+	if value, exists := reopened.Get("key"); !exists || value != "value" {
+		t.Errorf("value lost across restart: %v %v", value, exists)
+	}
+}
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"nogochain/core/storage/compression"
+	"nogochain/core/storage/wal"
+)
+
+const (
+	// minTieringInterval 分层任务的最小扫描间隔
+	minTieringInterval = time.Second
+	// maxTieringInterval 分层任务的最大扫描间隔
+	maxTieringInterval = 5 * time.Minute
+)
+
+// tierStats 分层迁移统计
+type tierStats struct {
+	mutex            sync.Mutex
+	demotions        int64
+	promotions       int64
+	evictedDemotions int64
+	demotedBytes     int64
+	demotedRawBytes  int64
+	promotedBytes    int64
+}
+
+// recordDemotion 记录一次降级
+func (t *tierStats) recordDemotion(rawBytes, storedBytes int, evicted bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.demotions++
+	if evicted {
+		t.evictedDemotions++
+	}
+	t.demotedRawBytes += int64(rawBytes)
+	t.demotedBytes += int64(storedBytes)
+}
+
+// recordPromotion 记录一次提升
+func (t *tierStats) recordPromotion(storedBytes int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.promotions++
+	t.promotedBytes += int64(storedBytes)
+}
+
+// coldHits 获取冷数据命中次数
+func (t *tierStats) coldHits() int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.promotions
+}
+
+// snapshot 获取统计快照
+func (t *tierStats) snapshot() map[string]interface{} {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return map[string]interface{}{
+		"demotions":         t.demotions,
+		"promotions":        t.promotions,
+		"evicted_demotions": t.evictedDemotions,
+		"demoted_bytes":     t.demotedBytes,
+		"demoted_raw_bytes": t.demotedRawBytes,
+		"promoted_bytes":    t.promotedBytes,
+	}
+}
+
+// tieringInterval 根据迁移阈值计算扫描间隔
+func (s *OptimizedStorage) tieringInterval() time.Duration {
+	interval := s.hotToColdThreshold / 4
+	if interval < minTieringInterval {
+		interval = minTieringInterval
+	}
+	if interval > maxTieringInterval {
+		interval = maxTieringInterval
+	}
+	return interval
+}
+
+// tieringLoop 后台分层任务，定期将空闲的热数据降级到冷数据存储
+func (s *OptimizedStorage) tieringLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.tieringInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.DemoteIdle()
+		}
+	}
+}
+
+// DemoteIdle 将空闲时间超过迁移阈值的热数据降级到冷数据存储，返回降级的数量
+func (s *OptimizedStorage) DemoteIdle() int {
+	deadline := time.Now().Add(-s.hotToColdThreshold)
+
+	// 先在读锁下收集候选项，避免长时间阻塞读写
+	var candidates []string
+	s.mutex.RLock()
+	s.hotStorage.Range(func(key string, value interface{}) bool {
+		if item, ok := value.(*StorageItem); ok && item.AccessTime.Before(deadline) {
+			candidates = append(candidates, key)
+		}
+		return true
+	})
+	s.mutex.RUnlock()
+
+	demoted := 0
+	for _, key := range candidates {
+		s.mutex.Lock()
+		// 收集后可能被重新访问或删除，需要再次检查
+		if value, exists := s.hotStorage.Get(key); exists {
+			item := value.(*StorageItem)
+			if item.AccessTime.Before(deadline) {
+				if err := s.migrateToCold(key, item, false); err != nil {
+					log.Error().Err(err).Str("key", key).Msg("Failed to demote storage item")
+				} else {
+					demoted++
+				}
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	return demoted
+}
+
+// demoteEvicted 热数据存储容量淘汰回调，调用方已持有 s.mutex
+func (s *OptimizedStorage) demoteEvicted(key string, value interface{}) {
+	item, ok := value.(*StorageItem)
+	if !ok {
+		return
+	}
+	if !item.Dirty && s.coldStorage.Has(key) {
+		s.tierStats.recordDemotion(0, 0, true)
+		return
+	}
+	if err := s.writeCold(key, item, true); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to demote evicted storage item")
+	}
+}
+
+// migrateToCold 将热数据降级到冷数据存储，调用方需持有 s.mutex
+func (s *OptimizedStorage) migrateToCold(key string, item *StorageItem, evicted bool) error {
+	if item.Dirty || !s.coldStorage.Has(key) {
+		if err := s.writeCold(key, item, evicted); err != nil {
+			return err
+		}
+	} else {
+		// 冷数据存储已有相同副本，只需从热数据存储移除
+		s.tierStats.recordDemotion(0, 0, evicted)
+	}
+
+	s.hotStorage.Delete(key)
+	return nil
+}
+
+// writeCold 压缩并写入冷数据存储，压缩失败时写入未压缩的数据
+func (s *OptimizedStorage) writeCold(key string, item *StorageItem, evicted bool) error {
+	rawBytes, storedBytes, err := s.storeCold(key, item, false)
+	if err != nil {
+		return err
+	}
+	item.StorageType = ColdStorage
+	s.tierStats.recordDemotion(rawBytes, storedBytes, evicted)
+	return nil
+}
+
+// flushDirty 将热数据存储中未写入冷数据存储的数据写入冷数据存储，热数据保留
+func (s *OptimizedStorage) flushDirty() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	syncWrites := s.walOptions.Sync != wal.SyncNone
+	var flushErr error
+	s.hotStorage.Range(func(key string, value interface{}) bool {
+		item, ok := value.(*StorageItem)
+		if !ok || !item.Dirty {
+			return true
+		}
+		if _, _, err := s.storeCold(key, item, syncWrites); err != nil {
+			flushErr = err
+			return false
+		}
+		return true
+	})
+	return flushErr
+}
+
+// storeCold 编码、压缩并写入冷数据存储，返回编码后和写入的字节数，调用方需持有 s.mutex
+// 压缩结果带有算法标签，修改压缩配置后旧数据仍然可读
+func (s *OptimizedStorage) storeCold(key string, item *StorageItem, sync bool) (int, int, error) {
+	raw, err := s.codec.Encode(item.Value)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	data := raw
+	if compressed, err := s.compressor.Compress(raw); err == nil {
+		data = compressed
+	}
+
+	if err := s.coldStorage.WriteRaw(key, data, sync); err != nil {
+		return 0, 0, err
+	}
+
+	item.Dirty = false
+	item.Compressed = compression.IsTagged(data)
+	return len(raw), len(data), nil
+}
+
+// readCold 从冷数据存储读取并解压，返回值、是否压缩和磁盘上的大小
+func (s *OptimizedStorage) readCold(key string) (interface{}, bool, int, bool) {
+	data, exists := s.coldStorage.ReadRaw(key)
+	if !exists || len(data) == 0 {
+		return nil, false, 0, false
+	}
+
+	compressed := compression.IsTagged(data)
+	raw, err := compression.DecompressAny(data)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to decompress cold storage item")
+		return nil, false, 0, false
+	}
+
+	value, err := s.codec.Decode(raw)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to decode cold storage item")
+		return nil, false, 0, false
+	}
+
+	return value, compressed, len(data), true
+}
+
+// migrateToHot 将冷数据提升到热数据存储，冷数据存储保留副本，调用方需持有 s.mutex
+func (s *OptimizedStorage) migrateToHot(key string, value interface{}, size int) {
+	s.hotStorage.Set(key, &StorageItem{
+		Value:       value,
+		AccessTime:  time.Now(),
+		StorageType: HotStorage,
+	}, 0)
+	s.tierStats.recordPromotion(size)
+}
+
+// tieringStats 获取分层迁移统计信息
+func (s *OptimizedStorage) tieringStats() map[string]interface{} {
+	stats := s.tierStats.snapshot()
+	stats["threshold"] = s.hotToColdThreshold.String()
+	stats["interval"] = s.tieringInterval().String()
+	return stats
+}
@@ -0,0 +1,493 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SyncPolicy 日志刷盘策略
+type SyncPolicy int
+
+const (
+	// SyncBatch 每个批次写入后立即fsync（默认，崩溃后不丢失已提交批次）
+	SyncBatch SyncPolicy = iota
+	// SyncInterval 按固定间隔fsync，崩溃时可能丢失最后一个间隔内的批次
+	SyncInterval
+	// SyncNone 由操作系统决定何时落盘
+	SyncNone
+)
+
+// 记录类型
+const (
+	recordBatch  byte = 1 // 批次记录
+	recordCommit byte = 2 // 批次已应用标记
+)
+
+// 批次操作类型
+const (
+	OpPut    byte = 1
+	OpDelete byte = 2
+)
+
+// recordHeaderSize 记录头大小：crc32(4) + 长度(4) + 类型(1)
+const recordHeaderSize = 9
+
+// maxRecordSize 单条记录内容的最大长度，超过时视为损坏的记录头
+const maxRecordSize = 256 << 20
+
+var (
+	// ErrClosed 日志已关闭
+	ErrClosed = errors.New("wal: log closed")
+	// ErrCorruptBatch 批次数据损坏
+	ErrCorruptBatch = errors.New("wal: corrupt batch")
+	// ErrRecordTooLarge 记录超过最大长度
+	ErrRecordTooLarge = errors.New("wal: record too large")
+)
+
+// Options 预写日志配置
+type Options struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+}
+
+// DefaultOptions 默认配置
+func DefaultOptions() Options {
+	return Options{
+		Sync:         SyncBatch,
+		SyncInterval: time.Second,
+	}
+}
+
+// Op 批次中的单个操作
+type Op struct {
+	Type  byte
+	Key   string
+	Value []byte
+}
+
+// Batch 原子写批次
+type Batch struct {
+	ID  uint64
+	Ops []Op
+}
+
+// Put 添加写入操作
+func (b *Batch) Put(key string, value []byte) {
+	b.Ops = append(b.Ops, Op{Type: OpPut, Key: key, Value: value})
+}
+
+// Delete 添加删除操作
+func (b *Batch) Delete(key string) {
+	b.Ops = append(b.Ops, Op{Type: OpDelete, Key: key})
+}
+
+// Len 获取操作数量
+func (b *Batch) Len() int {
+	return len(b.Ops)
+}
+
+// WAL 预写日志
+// 每个批次先以单条带校验的记录追加到日志，再应用到存储，应用完成后追加提交标记。
+// 重新打开时，带校验的批次记录若没有提交标记则重放，尾部不完整的记录直接丢弃。
+type WAL struct {
+	path     string
+	file     *os.File
+	opts     Options
+	mutex    sync.Mutex
+	nextID   uint64
+	pending  map[uint64]struct{}
+	size     int64
+	dirty    bool
+	closed   bool
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	replayed int
+	dropped  int64
+}
+
+// Open 打开预写日志，apply 用于重放崩溃前未应用完成的批次
+func Open(path string, opts Options, apply func(*Batch) error) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAL{
+		path:    path,
+		file:    file,
+		opts:    opts,
+		nextID:  1,
+		pending: make(map[uint64]struct{}),
+		stopCh:  make(chan struct{}),
+	}
+
+	if err := w.recover(apply); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if opts.Sync == SyncInterval {
+		if w.opts.SyncInterval <= 0 {
+			w.opts.SyncInterval = time.Second
+		}
+		w.wg.Add(1)
+		go w.syncLoop()
+	}
+
+	return w, nil
+}
+
+// recover 扫描日志，重放未应用的批次并截断损坏的尾部
+func (w *WAL) recover(apply func(*Batch) error) error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(w.file)
+	batches := make(map[uint64]*Batch)
+	order := make([]uint64, 0)
+	var offset int64
+
+	for {
+		typ, payload, n, err := readRecord(reader, info.Size()-offset)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 尾部记录不完整或校验失败，属于崩溃时未写完的批次，丢弃
+			break
+		}
+
+		switch typ {
+		case recordBatch:
+			batch, err := decodeBatch(payload)
+			if err != nil {
+				return err
+			}
+			batches[batch.ID] = batch
+			order = append(order, batch.ID)
+			if batch.ID >= w.nextID {
+				w.nextID = batch.ID + 1
+			}
+		case recordCommit:
+			if len(payload) != 8 {
+				return ErrCorruptBatch
+			}
+			delete(batches, binary.BigEndian.Uint64(payload))
+		}
+		offset += n
+	}
+
+	w.dropped = info.Size() - offset
+
+	// 截断损坏的尾部
+	if w.dropped > 0 {
+		if err := w.file.Truncate(offset); err != nil {
+			return err
+		}
+	}
+	w.size = offset
+
+	// 按写入顺序重放未应用的批次
+	for _, id := range order {
+		batch, exists := batches[id]
+		if !exists {
+			continue
+		}
+		if apply != nil {
+			if err := apply(batch); err != nil {
+				return fmt.Errorf("wal: replay batch %d: %v", id, err)
+			}
+		}
+		w.replayed++
+	}
+
+	if _, err := w.file.Seek(w.size, io.SeekStart); err != nil {
+		return err
+	}
+
+	// 所有批次均已应用，日志可以清空
+	return w.truncate()
+}
+
+// Append 追加批次并按刷盘策略落盘，返回分配的批次ID
+func (w *WAL) Append(batch *Batch) (uint64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return 0, ErrClosed
+	}
+
+	batch.ID = w.nextID
+	w.nextID++
+
+	if err := w.writeRecord(recordBatch, encodeBatch(batch)); err != nil {
+		return 0, err
+	}
+
+	if w.opts.Sync == SyncBatch {
+		if err := w.file.Sync(); err != nil {
+			return 0, err
+		}
+	} else {
+		w.dirty = true
+	}
+
+	w.pending[batch.ID] = struct{}{}
+	return batch.ID, nil
+}
+
+// MarkApplied 标记批次已应用到存储
+// 提交标记不需要立即落盘：丢失时只会导致批次被幂等地重放一次
+func (w *WAL) MarkApplied(id uint64) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, id)
+	if err := w.writeRecord(recordCommit, payload); err != nil {
+		return err
+	}
+	delete(w.pending, id)
+	w.dirty = true
+
+	return nil
+}
+
+// Checkpoint 在没有未应用批次时清空日志
+func (w *WAL) Checkpoint() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	if len(w.pending) > 0 {
+		return nil
+	}
+
+	return w.truncate()
+}
+
+// Sync 强制落盘
+func (w *WAL) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	w.dirty = false
+	return w.file.Sync()
+}
+
+// Size 获取日志当前大小
+func (w *WAL) Size() int64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.size
+}
+
+// Stats 获取日志统计信息
+func (w *WAL) Stats() map[string]interface{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return map[string]interface{}{
+		"size":             w.size,
+		"pending_batches":  len(w.pending),
+		"replayed_batches": w.replayed,
+		"dropped_bytes":    w.dropped,
+	}
+}
+
+// Close 关闭日志
+func (w *WAL) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.stopCh)
+	w.mutex.Unlock()
+
+	w.wg.Wait()
+
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// syncLoop 定时刷盘
+func (w *WAL) syncLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.mutex.Lock()
+			if w.dirty && !w.closed {
+				w.file.Sync()
+				w.dirty = false
+			}
+			w.mutex.Unlock()
+		}
+	}
+}
+
+// truncate 清空日志文件（调用方需持有锁）
+func (w *WAL) truncate() error {
+	if w.size == 0 {
+		return nil
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.size = 0
+	return w.file.Sync()
+}
+
+// writeRecord 写入一条记录（调用方需持有锁）
+// 写入失败时截断到上一条完整记录之后，避免后续记录写在不完整的数据之后
+func (w *WAL) writeRecord(typ byte, payload []byte) error {
+	if len(payload) > maxRecordSize {
+		return ErrRecordTooLarge
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[4:8], uint32(len(payload)))
+	record[8] = typ
+	copy(record[recordHeaderSize:], payload)
+	binary.BigEndian.PutUint32(record[0:4], crc32.ChecksumIEEE(record[4:]))
+
+	if _, err := w.file.Write(record); err != nil {
+		if truncErr := w.file.Truncate(w.size); truncErr != nil {
+			return fmt.Errorf("%v (truncate: %v)", err, truncErr)
+		}
+		if _, seekErr := w.file.Seek(w.size, io.SeekStart); seekErr != nil {
+			return fmt.Errorf("%v (seek: %v)", err, seekErr)
+		}
+		return err
+	}
+	w.size += int64(len(record))
+	return nil
+}
+
+// readRecord 读取一条记录，返回类型、内容和占用字节数
+// remaining 为日志中剩余的字节数，长度超出剩余字节数或最大长度的记录头视为损坏的尾部
+func readRecord(reader *bufio.Reader, remaining int64) (byte, []byte, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, 0, ErrCorruptBatch
+		}
+		return 0, nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[4:8])
+	if length > maxRecordSize || int64(length) > remaining-recordHeaderSize {
+		return 0, nil, 0, ErrCorruptBatch
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, 0, ErrCorruptBatch
+	}
+
+	checksum := crc32.ChecksumIEEE(header[4:])
+	checksum = crc32.Update(checksum, crc32.IEEETable, payload)
+	if checksum != binary.BigEndian.Uint32(header[0:4]) {
+		return 0, nil, 0, ErrCorruptBatch
+	}
+
+	return header[8], payload, int64(recordHeaderSize) + int64(length), nil
+}
+
+// encodeBatch 编码批次
+func encodeBatch(batch *Batch) []byte {
+	size := 12
+	for _, op := range batch.Ops {
+		size += 9 + len(op.Key) + len(op.Value)
+	}
+
+	data := make([]byte, 12, size)
+	binary.BigEndian.PutUint64(data[0:8], batch.ID)
+	binary.BigEndian.PutUint32(data[8:12], uint32(len(batch.Ops)))
+
+	buf := make([]byte, 4)
+	for _, op := range batch.Ops {
+		data = append(data, op.Type)
+		binary.BigEndian.PutUint32(buf, uint32(len(op.Key)))
+		data = append(data, buf...)
+		data = append(data, op.Key...)
+		binary.BigEndian.PutUint32(buf, uint32(len(op.Value)))
+		data = append(data, buf...)
+		data = append(data, op.Value...)
+	}
+
+	return data
+}
+
+// decodeBatch 解码批次
+func decodeBatch(data []byte) (*Batch, error) {
+	if len(data) < 12 {
+		return nil, ErrCorruptBatch
+	}
+
+	batch := &Batch{
+		ID: binary.BigEndian.Uint64(data[0:8]),
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+	data = data[12:]
+
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 5 {
+			return nil, ErrCorruptBatch
+		}
+		op := Op{Type: data[0]}
+		keyLen := binary.BigEndian.Uint32(data[1:5])
+		data = data[5:]
+		if uint32(len(data)) < keyLen+4 {
+			return nil, ErrCorruptBatch
+		}
+		op.Key = string(data[:keyLen])
+		data = data[keyLen:]
+
+		valueLen := binary.BigEndian.Uint32(data[0:4])
+		data = data[4:]
+		if uint32(len(data)) < valueLen {
+			return nil, ErrCorruptBatch
+		}
+		if valueLen > 0 {
+			op.Value = make([]byte, valueLen)
+			copy(op.Value, data[:valueLen])
+		}
+		data = data[valueLen:]
+
+		batch.Ops = append(batch.Ops, op)
+	}
+
+	return batch, nil
+}
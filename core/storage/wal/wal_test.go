@@ -0,0 +1,166 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试未应用的批次在重新打开时被重放
+func TestReplayUnappliedBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+
+	w, err := Open(path, DefaultOptions(), nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	applied := &Batch{}
+	applied.Put("a", []byte("1"))
+	id, err := w.Append(applied)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if err := w.MarkApplied(id); err != nil {
+		t.Fatalf("MarkApplied failed: %v", err)
+	}
+
+	pending := &Batch{}
+	pending.Put("b", []byte("2"))
+	pending.Delete("a")
+	if _, err := w.Append(pending); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	w.Close()
+
+	var replayed []*Batch
+	w, err = Open(path, DefaultOptions(), func(b *Batch) error {
+		replayed = append(replayed, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer w.Close()
+
+	if len(replayed) != 1 {
+		t.Fatalf("expected 1 replayed batch, got %d", len(replayed))
+	}
+	if replayed[0].Len() != 2 || replayed[0].Ops[0].Key != "b" || replayed[0].Ops[1].Type != OpDelete {
+		t.Errorf("replayed batch mismatch: %+v", replayed[0])
+	}
+	if w.Size() != 0 {
+		t.Errorf("log should be truncated after recovery, size %d", w.Size())
+	}
+}
+
+// 测试尾部不完整的批次被丢弃
+func TestDiscardTornBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+
+	w, err := Open(path, Options{Sync: SyncNone}, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	first := &Batch{}
+	first.Put("header", []byte("h"))
+	if _, err := w.Append(first); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	second := &Batch{}
+	second.Put("body", []byte("bbbbbbbbbbbbbbbb"))
+	if _, err := w.Append(second); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	size := w.Size()
+	w.Close()
+
+	// 模拟崩溃：第二个批次只写入了一部分
+	if err := os.Truncate(path, size-5); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	var keys []string
+	w, err = Open(path, DefaultOptions(), func(b *Batch) error {
+		for _, op := range b.Ops {
+			keys = append(keys, op.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer w.Close()
+
+	if len(keys) != 1 || keys[0] != "header" {
+		t.Errorf("expected only the complete batch to be replayed, got %v", keys)
+	}
+	if dropped := w.Stats()["dropped_bytes"].(int64); dropped == 0 {
+		t.Errorf("expected torn bytes to be dropped")
+	}
+}
+
+// 测试记录头长度损坏的尾部被丢弃，不按损坏的长度分配内存
+func TestDiscardCorruptLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal.log")
+
+	w, err := Open(path, Options{Sync: SyncNone}, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	batch := &Batch{}
+	batch.Put("header", []byte("h"))
+	if _, err := w.Append(batch); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	w.Close()
+
+	// 追加一个声明长度约 4GB 的记录头
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	file.Write([]byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0xf0, recordBatch, 1, 2, 3})
+	file.Close()
+
+	replayed := 0
+	w, err = Open(path, DefaultOptions(), func(b *Batch) error {
+		replayed++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer w.Close()
+
+	if replayed != 1 {
+		t.Errorf("expected the complete batch to be replayed, got %d", replayed)
+	}
+	if dropped := w.Stats()["dropped_bytes"].(int64); dropped != 12 {
+		t.Errorf("expected 12 dropped bytes, got %d", dropped)
+	}
+}
+
+// 测试批次编解码
+func TestBatchEncoding(t *testing.T) {
+	batch := &Batch{ID: 7}
+	batch.Put("key", []byte("value"))
+	batch.Put("empty", nil)
+	batch.Delete("gone")
+
+	decoded, err := decodeBatch(encodeBatch(batch))
+	if err != nil {
+		t.Fatalf("decodeBatch failed: %v", err)
+	}
+	if decoded.ID != 7 || decoded.Len() != 3 {
+		t.Fatalf("decoded batch mismatch: %+v", decoded)
+	}
+	if string(decoded.Ops[0].Value) != "value" || decoded.Ops[2].Key != "gone" {
+		t.Errorf("decoded ops mismatch: %+v", decoded.Ops)
+	}
+
+	if _, err := decodeBatch(encodeBatch(batch)[:15]); err == nil {
+		t.Errorf("expected error for truncated batch")
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 
+	"nogochain/consensus"
+	"nogochain/consensus/nogopow"
 	"nogochain/core/blockchain"
 	"nogochain/core/types"
 	"nogochain/core/validator"
@@ -49,9 +51,16 @@ type Synchronizer struct {
 
 // NewSynchronizer 创建新的同步器
 func NewSynchronizer(blockchain *blockchain.Blockchain, mode SyncMode) *Synchronizer {
+	// 区块链设置了共识引擎时使用同一引擎验证区块，否则使用 NogoPow，祖先区块从区块链读取
+	engine := blockchain.Engine()
+	if engine == nil {
+		engine = nogopow.NewNogoPow()
+	}
+	v := validator.NewValidatorWithEngine(engine, blockchain)
+
 	return &Synchronizer{
 		blockchain: blockchain,
-		validator:  validator.NewValidator(),
+		validator:  v,
 		peers:      make(map[string]*Peer),
 		mode:       mode,
 		state: SyncState{
@@ -119,19 +128,7 @@ func (s *Synchronizer) syncBlocks(peer *Peer) {
 			continue
 		}
 
-		// 验证区块
-		parent := s.blockchain.GetBlock(block.ParentHash())
-		if parent == nil {
-			continue
-		}
-
-		// 验证区块
-		if err := s.validator.ValidateBlock(block, parent, s.blockchain.StateDB()); err != nil {
-			continue
-		}
-
-		// 添加区块到区块链
-		if err := s.blockchain.AddBlock(block); err != nil {
+		if !s.importBlock(block) {
 			continue
 		}
 
@@ -142,6 +139,26 @@ func (s *Synchronizer) syncBlocks(peer *Peer) {
 	}
 }
 
+// importBlock 验证并导入区块，返回区块是否已加入区块链
+// 略微超前于本地时钟的区块交给区块链，由区块链放入未来区块队列稍后导入
+func (s *Synchronizer) importBlock(block *types.Block) bool {
+	parent := s.blockchain.GetBlock(block.ParentHash())
+	if parent == nil {
+		return false
+	}
+
+	// 验证区块
+	if err := s.validator.ValidateBlock(block, parent, s.blockchain.StateDB()); err != nil && err != consensus.ErrFutureBlock {
+		return false
+	}
+
+	// 添加区块到区块链
+	if err := s.blockchain.AddBlock(block); err != nil {
+		return false
+	}
+	return s.blockchain.GetBlock(block.Hash()) != nil
+}
+
 // fetchBlockFromPeer 从对等节点获取区块
 func (s *Synchronizer) fetchBlockFromPeer(peer *Peer, number uint64) *types.Block {
 	// 模拟从对等节点获取区块
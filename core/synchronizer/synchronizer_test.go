@@ -3,11 +3,14 @@ package synchronizer
 import (
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
+	"nogochain/consensus/fake"
 	"nogochain/core/blockchain"
 	"nogochain/core/types"
+	"nogochain/params"
 )
 
 // 测试NewSynchronizer函数
@@ -485,3 +488,41 @@ func TestNoSyncNeeded(t *testing.T) {
 		t.Errorf("HighestBlock should remain %d, got %d", genesisNumber, state.HighestBlock)
 	}
 }
+
+// 测试同步到略微超前于本地时钟的区块时交给区块链排队，时间到达后导入
+func TestImportFutureBlock(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	bc.SetEngine(fake.NewFaker())
+	sync := NewSynchronizer(bc, FullSync)
+	genesis := bc.Genesis()
+
+	future := types.NewBlock(
+		genesis.Hash(),
+		common.Address{0x01},
+		common.Hash{},
+		common.Hash{},
+		common.Hash{},
+		big.NewInt(1000000),
+		big.NewInt(1),
+		10000000,
+		0,
+		uint64(time.Now().Unix())+params.MaxFutureBlockTime+1,
+		[]byte("Future Block"),
+		common.Hash{},
+		0,
+		[]*types.Transaction{},
+		[]*types.BlockHeader{},
+	)
+
+	if sync.importBlock(future) {
+		t.Fatalf("future block imported before its time")
+	}
+
+	for uint64(time.Now().Unix())+params.MaxFutureBlockTime < future.Header.Time {
+		time.Sleep(100 * time.Millisecond)
+	}
+	bc.ProcessFutureBlocks()
+	if bc.CurrentHead().Hash() != future.Hash() {
+		t.Errorf("future block was dropped instead of queued, head %d", bc.CurrentHead().NumberU64())
+	}
+}
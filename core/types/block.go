@@ -37,6 +37,13 @@ type Block struct {
 	Uncles       []*BlockHeader `json:"uncles"`
 }
 
+// Body represents the transactions and uncles of a block
+// Body 区块体结构
+type Body struct {
+	Transactions []*Transaction `json:"transactions"`
+	Uncles       []*BlockHeader `json:"uncles"`
+}
+
 // NewBlock creates a new block
 // NewBlock 创建新区块
 func NewBlock(parentHash common.Hash, coinbase common.Address, root common.Hash, txHash common.Hash, receiptHash common.Hash, difficulty *big.Int, number *big.Int, gasLimit uint64, gasUsed uint64, time uint64, extra []byte, mixDigest common.Hash, nonce uint64, transactions []*Transaction, uncles []*BlockHeader) *Block {
@@ -150,3 +157,23 @@ func (b *Block) TxCount() int {
 func (b *Block) UncleCount() int {
 	return len(b.Uncles)
 }
+
+// Body returns the block body
+// Body 获取区块体
+func (b *Block) Body() *Body {
+	return &Body{
+		Transactions: b.Transactions,
+		Uncles:       b.Uncles,
+	}
+}
+
+// NewBlockWithHeader assembles a block from a header and a body
+// NewBlockWithHeader 由区块头和区块体组装区块
+func NewBlockWithHeader(header *BlockHeader, body *Body) *Block {
+	block := &Block{Header: header}
+	if body != nil {
+		block.Transactions = body.Transactions
+		block.Uncles = body.Uncles
+	}
+	return block
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// ReceiptStatusFailed is the status of a failed transaction
+	// ReceiptStatusFailed 交易执行失败
+	ReceiptStatusFailed = uint64(0)
+
+	// ReceiptStatusSuccessful is the status of a successful transaction
+	// ReceiptStatusSuccessful 交易执行成功
+	ReceiptStatusSuccessful = uint64(1)
+)
+
+// Log represents a contract log event
+// Log 合约日志事件
+type Log struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        []byte         `json:"data"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     uint           `json:"transactionIndex"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Index       uint           `json:"logIndex"`
+}
+
+// Receipt represents the result of a transaction
+// Receipt 交易收据
+type Receipt struct {
+	Status            uint64         `json:"status"`
+	CumulativeGasUsed uint64         `json:"cumulativeGasUsed"`
+	Bloom             []byte         `json:"logsBloom"`
+	Logs              []*Log         `json:"logs"`
+	TxHash            common.Hash    `json:"transactionHash"`
+	ContractAddress   common.Address `json:"contractAddress"`
+	GasUsed           uint64         `json:"gasUsed"`
+	BlockHash         common.Hash    `json:"blockHash"`
+	BlockNumber       *big.Int       `json:"blockNumber"`
+	TransactionIndex  uint           `json:"transactionIndex"`
+}
+
+// Receipts is a list of receipts
+// Receipts 收据列表
+type Receipts []*Receipt
+
+// CalcReceiptHash calculates the receipt root hash
+// CalcReceiptHash 计算收据根哈希
+func CalcReceiptHash(receipts Receipts) common.Hash {
+	if len(receipts) == 0 {
+		return common.Hash{}
+	}
+	data, _ := json.Marshal(receipts)
+	return crypto.Keccak256Hash(data)
+}
@@ -1,21 +1,31 @@
 package validator
 
 import (
-	"crypto/sha256"
-	"encoding/json"
+	"errors"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
+	"nogochain/consensus"
 	"nogochain/consensus/nogopow"
 	"nogochain/core/state"
 	"nogochain/core/types"
 )
 
+var (
+	// ErrInvalidDifficulty 区块难度与共识引擎计算的难度不一致
+	ErrInvalidDifficulty = errors.New("invalid difficulty")
+
+	// ErrOlderBlockTime 区块时间戳不大于过去中位时间
+	ErrOlderBlockTime = errors.New("timestamp not after median time past")
+)
+
 // Validator 区块验证器
 type Validator struct {
-	consensus *nogopow.NogoPow
+	consensus consensus.Engine
+	chain     consensus.ChainReader // 读取难度算法和叔块验证需要的祖先区块，可以为 nil
 }
 
 // 全局验证器缓存
@@ -39,6 +49,14 @@ func NewValidator() *Validator {
 	}
 }
 
+// NewValidatorWithEngine 使用指定共识引擎创建验证器，chain 提供难度计算和叔块验证需要的祖先区块
+func NewValidatorWithEngine(engine consensus.Engine, chain consensus.ChainReader) *Validator {
+	return &Validator{
+		consensus: engine,
+		chain:     chain,
+	}
+}
+
 // ValidateBlock 验证区块
 func (v *Validator) ValidateBlock(block *types.Block, parent *types.Block, stateDB state.StateDB) error {
 	// 验证区块头
@@ -46,6 +64,11 @@ func (v *Validator) ValidateBlock(block *types.Block, parent *types.Block, state
 		return err
 	}
 
+	// 快速检查工作量证明，在验证交易和完整验证封装之前拒绝无效区块
+	if err := v.precheckPow(block.Header); err != nil {
+		return err
+	}
+
 	// 并行验证交易和状态根
 	var wg sync.WaitGroup
 	var txErr, stateErr error
@@ -72,7 +95,7 @@ func (v *Validator) ValidateBlock(block *types.Block, parent *types.Block, state
 	}
 
 	// 验证难度
-	if err := v.validateDifficulty(block.Header.Difficulty, parent.Header.Difficulty, block.Header.Time, parent.Header.Time); err != nil {
+	if err := v.validateDifficulty(block.Header, parent.Header); err != nil {
 		return err
 	}
 
@@ -81,6 +104,11 @@ func (v *Validator) ValidateBlock(block *types.Block, parent *types.Block, state
 		return err
 	}
 
+	// 验证叔块的数量、深度、祖先关系和重复包含
+	if err := v.consensus.VerifyUncles(v.chain, block); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -96,9 +124,20 @@ func (v *Validator) validateHeader(header, parent *types.BlockHeader) error {
 		return nil
 	}
 
-	// 验证时间戳
-	if header.Time <= parent.Time {
-		return nil
+	// 验证时间戳：不能超前本地时间太多，且必须大于过去中位时间
+	if consensus.IsFutureBlock(header, time.Now()) {
+		return consensus.ErrFutureBlock
+	}
+	// 没有链时无法读取更早的祖先区块，退化为与父区块时间戳比较
+	median := parent.Time
+	if v.chain != nil {
+		var err error
+		if median, err = consensus.MedianTimePast(v.chain, parent); err != nil {
+			return err
+		}
+	}
+	if header.Time <= median {
+		return ErrOlderBlockTime
 	}
 
 	// 验证Gas限制
@@ -167,27 +206,32 @@ func (v *Validator) validateStateRoot(root common.Hash, stateDB state.StateDB) e
 	return nil
 }
 
-// validateDifficulty 验证难度
-func (v *Validator) validateDifficulty(difficulty, parentDifficulty *big.Int, time, parentTime uint64) error {
-	// 简化实现，实际应该根据共识算法计算难度
+// validateDifficulty 验证难度，必须等于共识引擎按链配置的难度算法计算的难度
+func (v *Validator) validateDifficulty(header, parent *types.BlockHeader) error {
+	expected := v.consensus.CalcDifficulty(v.chain, header.Time, parent)
+	if expected == nil {
+		// 没有链或缺少祖先区块，无法计算分叉后的难度
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(expected) != 0 {
+		return ErrInvalidDifficulty
+	}
 	return nil
 }
 
-// validatePow 验证工作量证明
-func (v *Validator) validatePow(header *types.BlockHeader) error {
-	// 使用缓存的NogoPow实例进行验证
-	headerData, _ := json.Marshal(header)
-	seedHash := sha256.Sum256(headerData)
-	seedHashStr := string(seedHash[:])
-	pow := nogopow.GetCachedNogoPow(seedHashStr)
-	pow.Initialize(headerData)
-
-	target := nogopow.ToTarget(header.Difficulty)
-	if !pow.Verify(headerData, header.Nonce, target) {
+// precheckPow 快速检查工作量证明：只用区块头声明的 MixDigest 计算最终哈希，不需要纪元缓存
+// 只适用于 NogoPow 引擎，其他引擎的封装由 validatePow 验证
+func (v *Validator) precheckPow(header *types.BlockHeader) error {
+	if _, ok := v.consensus.(*nogopow.NogoPow); !ok {
 		return nil
 	}
+	return nogopow.CheckWork(header, header.Difficulty)
+}
 
-	return nil
+// validatePow 验证工作量证明
+func (v *Validator) validatePow(header *types.BlockHeader) error {
+	// 由共识引擎验证封装，NogoPow 同时验证 MixDigest 与 Hashimoto 的输出一致
+	return v.consensus.VerifySeal(v.chain, header)
 }
 
 // ValidateTransaction 验证单个交易
@@ -6,8 +6,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 
+	"nogochain/consensus"
+	"nogochain/consensus/fake"
+	"nogochain/consensus/nogopow"
 	"nogochain/core/state"
 	"nogochain/core/types"
+	"nogochain/params"
 )
 
 // 测试NewValidator函数
@@ -127,8 +131,8 @@ func TestValidateHeader(t *testing.T) {
 	}
 
 	err = validator.validateHeader(invalidTimeHeader, parentHeader)
-	if err != nil {
-		t.Errorf("validateHeader should not return error for invalid time (implementation returns nil)")
+	if err != ErrOlderBlockTime {
+		t.Errorf("validateHeader invalid time: got %v, want %v", err, ErrOlderBlockTime)
 	}
 
 	// 测试5: 无效的Gas限制
@@ -174,6 +178,20 @@ func TestValidateHeader(t *testing.T) {
 	if err != nil {
 		t.Errorf("validateHeader should not return error for invalid gas used (implementation returns nil)")
 	}
+
+	// 测试7: 没有链时非创世父区块与父区块时间戳比较
+	laterParent := *parentHeader
+	laterParent.Number = big.NewInt(5)
+	laterHeader := *validHeader
+	laterHeader.ParentHash = laterParent.Hash()
+	laterHeader.Number = big.NewInt(6)
+	if err := validator.validateHeader(&laterHeader, &laterParent); err != nil {
+		t.Errorf("validateHeader without chain: %v", err)
+	}
+	laterHeader.Time = laterParent.Time
+	if err := validator.validateHeader(&laterHeader, &laterParent); err != ErrOlderBlockTime {
+		t.Errorf("validateHeader without chain: got %v, want %v", err, ErrOlderBlockTime)
+	}
 }
 
 // 测试validateTransactions函数
@@ -289,35 +307,105 @@ func TestValidateStateRoot(t *testing.T) {
 func TestValidateDifficulty(t *testing.T) {
 	validator := NewValidator()
 
-	// 测试各种难度值
+	// 原有算法：区块时间不超过调整窗口时难度保持不变
+	parent := &types.BlockHeader{
+		Number:     big.NewInt(20),
+		Difficulty: big.NewInt(1000000),
+		Time:       1700000000,
+	}
 	testCases := []struct {
-		difficulty       *big.Int
-		parentDifficulty *big.Int
-		time             uint64
-		parentTime       uint64
+		difficulty *big.Int
+		time       uint64
+		wantErr    error
 	}{{
-		difficulty:       big.NewInt(1000000),
-		parentDifficulty: big.NewInt(1000000),
-		time:             1700000010,
-		parentTime:       1700000000,
+		difficulty: big.NewInt(1000000),
+		time:       1700000010,
 	}, {
-		difficulty:       big.NewInt(1100000),
-		parentDifficulty: big.NewInt(1000000),
-		time:             1700000005, // 区块时间较短，难度增加
-		parentTime:       1700000000,
+		difficulty: big.NewInt(1100000),
+		time:       1700000005,
+		wantErr:    ErrInvalidDifficulty,
 	}, {
-		difficulty:       big.NewInt(900000),
-		parentDifficulty: big.NewInt(1000000),
-		time:             1700000020, // 区块时间较长，难度降低
-		parentTime:       1700000000,
+		difficulty: big.NewInt(900000),
+		time:       1700000020,
+		wantErr:    ErrInvalidDifficulty,
+	}, {
+		difficulty: big.NewInt(500000),
+		time:       1700000500, // 超过2倍调整窗口，难度减半
+	}, {
+		difficulty: nil,
+		time:       1700000010,
+		wantErr:    ErrInvalidDifficulty,
 	}}
 
 	for i, tc := range testCases {
-		err := validator.validateDifficulty(tc.difficulty, tc.parentDifficulty, tc.time, tc.parentTime)
-		if err != nil {
-			t.Errorf("validateDifficulty test case %d should not return error: %v", i, err)
+		header := &types.BlockHeader{Number: big.NewInt(21), Difficulty: tc.difficulty, Time: tc.time}
+		if err := validator.validateDifficulty(header, parent); err != tc.wantErr {
+			t.Errorf("validateDifficulty test case %d: got %v, want %v", i, err, tc.wantErr)
+		}
+	}
+}
+
+// testHeaderChain 按区块号索引的测试区块头链
+type testHeaderChain []*types.BlockHeader
+
+func (c testHeaderChain) CurrentHeader() *types.BlockHeader { return c[len(c)-1] }
+func (c testHeaderChain) GetHeader(hash common.Hash, number uint64) *types.BlockHeader {
+	return c.GetHeaderByNumber(number)
+}
+func (c testHeaderChain) GetHeaderByNumber(number uint64) *types.BlockHeader {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+func (c testHeaderChain) GetHeaderByHash(hash common.Hash) *types.BlockHeader { return nil }
+func (c testHeaderChain) GetBlock(hash common.Hash) *types.Block              { return nil }
+
+// 测试分叉后按LWMA算法验证难度
+func TestValidateDifficultyLWMA(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:    params.ChainID,
+		Difficulty: &params.DifficultyConfig{Algorithm: params.DifficultyLWMA, ForkBlock: 5, LWMAWindow: 4},
+	}
+	engine := nogopow.NewNogoPowWithConfig(config)
+
+	var chain testHeaderChain
+	for i := int64(0); i < 8; i++ {
+		chain = append(chain, &types.BlockHeader{
+			Number:     big.NewInt(i),
+			Difficulty: big.NewInt(1000000),
+			Time:       1700000000 + uint64(i)*10,
+		})
+	}
+	parent := chain.CurrentHeader()
+
+	// 出块时间为目标的一半，难度翻倍
+	header := &types.BlockHeader{Number: big.NewInt(8), Difficulty: big.NewInt(2000000), Time: parent.Time + 10}
+	if err := NewValidatorWithEngine(engine, chain).validateDifficulty(header, parent); err != nil {
+		t.Errorf("validateDifficulty failed: %v", err)
+	}
+	header.Difficulty = big.NewInt(1000000)
+	if err := NewValidatorWithEngine(engine, chain).validateDifficulty(header, parent); err != ErrInvalidDifficulty {
+		t.Errorf("legacy difficulty after fork: got %v, want %v", err, ErrInvalidDifficulty)
+	}
+	// 没有链无法读取祖先区块
+	if err := NewValidatorWithEngine(engine, nil).validateDifficulty(header, parent); err != consensus.ErrUnknownAncestor {
+		t.Errorf("missing chain: got %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
+
+// sealHeader 为低难度区块头搜索满足目标的 nonce 并设置 MixDigest
+func sealHeader(t *testing.T, header *types.BlockHeader) {
+	target := nogopow.ToTarget(header.Difficulty)
+	for nonce := uint64(0); nonce < 100000; nonce++ {
+		header.Nonce = nonce
+		hash, mixDigest := nogopow.ComputeWork(header)
+		if new(big.Int).SetBytes(hash.Bytes()).Cmp(target) <= 0 {
+			header.MixDigest = mixDigest
+			return
 		}
 	}
+	t.Fatalf("no nonce found")
 }
 
 // 测试validatePow函数
@@ -331,7 +419,7 @@ func TestValidatePow(t *testing.T) {
 		Root:        common.Hash{},
 		TxHash:      common.Hash{},
 		ReceiptHash: common.Hash{},
-		Difficulty:  big.NewInt(1000000),
+		Difficulty:  big.NewInt(16),
 		Number:      big.NewInt(0),
 		GasLimit:    10000000,
 		GasUsed:     0,
@@ -340,11 +428,28 @@ func TestValidatePow(t *testing.T) {
 		MixDigest:   common.Hash{},
 		Nonce:       0,
 	}
+	sealHeader(t, header)
 
 	// 测试工作量证明验证
-	err := validator.validatePow(header)
-	if err != nil {
-		t.Errorf("validatePow should not return error (implementation returns nil): %v", err)
+	if err := validator.validatePow(header); err != nil {
+		t.Errorf("validatePow should not return error for sealed header: %v", err)
+	}
+	if err := validator.precheckPow(header); err != nil {
+		t.Errorf("precheckPow should not return error for sealed header: %v", err)
+	}
+
+	// MixDigest 与 Hashimoto 的输出不一致时被拒绝
+	tampered := *header
+	tampered.MixDigest = common.Hash{0x01}
+	if err := validator.validatePow(&tampered); err == nil {
+		t.Errorf("validatePow should reject a wrong mix digest")
+	}
+
+	// 修改封装覆盖的字段后封装失效
+	tampered = *header
+	tampered.Time++
+	if err := validator.validatePow(&tampered); err == nil {
+		t.Errorf("validatePow should reject a modified header")
 	}
 }
 
@@ -423,9 +528,9 @@ func TestValidateTransaction(t *testing.T) {
 	}
 }
 
-// 测试ValidateBlock函数
+// 测试ValidateBlock函数，区块没有挖矿，使用接受任意封装的模拟引擎
 func TestValidateBlock(t *testing.T) {
-	validator := NewValidator()
+	validator := NewValidatorWithEngine(fake.NewFaker(), nil)
 	stateDB := state.NewMemoryStateDB()
 
 	// 创建有效的父区块
@@ -515,9 +620,9 @@ func TestValidateBlock(t *testing.T) {
 	}
 }
 
-// 集成测试：测试完整的验证流程
+// 集成测试：测试完整的验证流程，区块没有挖矿，使用接受任意封装的模拟引擎
 func TestValidationIntegration(t *testing.T) {
-	validator := NewValidator()
+	validator := NewValidatorWithEngine(fake.NewFaker(), nil)
 	stateDB := state.NewMemoryStateDB()
 
 	// 创建区块链结构
@@ -608,7 +713,7 @@ func TestValidationIntegration(t *testing.T) {
 	}
 
 	// 验证难度
-	err = validator.validateDifficulty(block.Header.Difficulty, parentBlock.Header.Difficulty, block.Header.Time, parentBlock.Header.Time)
+	err = validator.validateDifficulty(block.Header, parentBlock.Header)
 	if err != nil {
 		t.Errorf("validateDifficulty should not return error in integration test: %v", err)
 	}
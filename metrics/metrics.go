@@ -104,6 +104,51 @@ var (
 		},
 	)
 
+	// 裁剪相关指标
+	PrunedBlocks = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nogochain_pruned_blocks_total",
+			Help: "Total number of blocks whose bodies and receipts were pruned",
+		},
+	)
+
+	PrunedStates = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nogochain_pruned_states_total",
+			Help: "Total number of pruned state roots",
+		},
+	)
+
+	PruneTail = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nogochain_prune_tail",
+			Help: "Lowest block number whose body is still stored",
+		},
+	)
+
+	PruneDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "nogochain_prune_duration_seconds",
+			Help:    "Time spent in a pruning round",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+		},
+	)
+
+	// 重组相关指标
+	RejectedReorgs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "nogochain_rejected_reorgs_total",
+			Help: "Total number of blocks rejected for reorganizing past a checkpoint or the finality depth",
+		},
+	)
+
+	RejectedReorgDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "nogochain_rejected_reorg_depth",
+			Help: "Depth of the last rejected reorganization",
+		},
+	)
+
 	// 错误指标
 	ErrorCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -129,6 +174,12 @@ func InitMetrics() {
 		PoolHashRate,
 		PoolMiners,
 		PoolShares,
+		PrunedBlocks,
+		PrunedStates,
+		PruneTail,
+		PruneDuration,
+		RejectedReorgs,
+		RejectedReorgDepth,
 		ErrorCount,
 	)
 }
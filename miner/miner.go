@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"nogochain/consensus/nogopow"
+	"github.com/rs/zerolog/log"
+	"nogochain/consensus"
+	"nogochain/core/state"
 	"nogochain/core/types"
 )
 
@@ -23,10 +25,21 @@ type Config struct {
 	NumThreads       int
 }
 
+// Chain 矿工构建和提交区块所需的区块链接口
+type Chain interface {
+	UncleSource
+
+	// StateDB 获取新区块奖励计入的状态数据库
+	StateDB() state.StateDB
+
+	// AddBlock 提交封装完成的区块
+	AddBlock(block *types.Block) error
+}
+
 // Miner 挖矿实例
 type Miner struct {
 	config  *Config
-	engine  *nogopow.NogoPow
+	engine  consensus.Engine
 	chain   interface{} // 区块链接口
 	stopCh  chan struct{}
 	startCh chan struct{}
@@ -39,7 +52,7 @@ type Miner struct {
 }
 
 // NewMiner 创建新的挖矿实例
-func NewMiner(config *Config, engine *nogopow.NogoPow) *Miner {
+func NewMiner(config *Config, engine consensus.Engine) *Miner {
 	if config.NumThreads <= 0 {
 		config.NumThreads = runtime.GOMAXPROCS(0)
 	}
@@ -72,7 +85,7 @@ func (m *Miner) Start() error {
 	m.startCh = make(chan struct{})
 
 	m.wg.Add(1)
-	go m.miningLoop()
+	go m.miningLoop(m.stopCh)
 
 	return nil
 }
@@ -103,8 +116,8 @@ func (m *Miner) IsRunning() bool {
 	return m.isRunning
 }
 
-// miningLoop 挖矿主循环
-func (m *Miner) miningLoop() {
+// miningLoop 挖矿主循环，stop 关闭时退出
+func (m *Miner) miningLoop(stop <-chan struct{}) {
 	defer m.wg.Done()
 
 	ticker := time.NewTicker(m.config.RecommitInterval)
@@ -112,55 +125,83 @@ func (m *Miner) miningLoop() {
 
 	for {
 		select {
-		case <-m.stopCh:
+		case <-stop:
 			return
 		case <-ticker.C:
-			m.minePending()
+			m.minePending(stop)
 		default:
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
 }
 
-// minePending 挖矿待处理区块
-func (m *Miner) minePending() {
-	// TODO: 实现区块构建和挖矿逻辑
-	// 1. 构建区块头
-	// 2. 初始化NogoPow
-	// 3. 执行挖矿
-	// 4. 提交区块
+// minePending 在当前头部之上构建区块并封装后提交到区块链
+// 重新提交间隔内未封装完成时放弃该区块，下一轮基于最新头部和叔块重新构建
+func (m *Miner) minePending(stop <-chan struct{}) {
+	chain, ok := m.chain.(Chain)
+	if !ok {
+		return
+	}
+
+	// 放弃或提交失败的区块不能留下奖励
+	stateDB := chain.StateDB()
+	snapshot := stateDB.Snapshot()
+	block, err := m.buildBlock(chain, stateDB)
+	if err != nil {
+		stateDB.RevertToSnapshot(snapshot)
+		log.Warn().Err(err).Msg("Failed to build block")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.RecommitInterval)
+	defer cancel()
+	results := make(chan *types.Block, 1)
+	if err := m.Seal(ctx, block, results, stop); err != nil || len(results) == 0 {
+		stateDB.RevertToSnapshot(snapshot)
+		return
+	}
+
+	sealed := <-results
+	if err := chain.AddBlock(sealed); err != nil {
+		stateDB.RevertToSnapshot(snapshot)
+		log.Warn().Err(err).Uint64("number", sealed.NumberU64()).Msg("Failed to import mined block")
+		return
+	}
+	log.Info().Uint64("number", sealed.NumberU64()).Str("hash", sealed.Hash().Hex()).Int("uncles", sealed.UncleCount()).Msg("Mined block")
+}
+
+// buildBlock 在当前头部之上构建包含叔块的待封装区块，区块和叔块奖励计入 stateDB
+func (m *Miner) buildBlock(chain Chain, stateDB state.StateDB) (*types.Block, error) {
+	parent := chain.CurrentHeader()
+	timestamp := uint64(time.Now().Unix())
+	if timestamp <= parent.Time {
+		timestamp = parent.Time + 1
+	}
+	header := &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Coinbase:   m.config.Coinbase,
+		Bloom:      make([]byte, 256),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+		Time:       timestamp,
+		Extra:      m.config.ExtraData,
+	}
+	if err := m.engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	return m.engine.Finalize(chain, header, stateDB, nil, m.SelectUncles(parent))
 }
 
 // Seal  sealing区块
 func (m *Miner) Seal(ctx context.Context, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
-	// 初始化NogoPow
-	headerBytes := m.serializeHeader(block.Header)
-	seed := m.calculateSeed(block.Header.Number)
-	m.engine.Initialize(seed)
+	// 由共识引擎搜索nonce，返回时停止引擎的封装
+	abort := make(chan struct{})
+	defer close(abort)
 
-	// 启动挖矿
 	found := make(chan *types.Block, 1)
-
-	go func() {
-		nonce, _, mixDigest, ok := m.engine.MineParallel(headerBytes, block.Header.Difficulty, 1000000)
-		if ok {
-			// 更新区块头
-			newHeader := *block.Header
-			newHeader.Nonce = nonce
-			newHeader.MixDigest = common.BytesToHash(mixDigest)
-
-			newBlock := &types.Block{
-				Header:       &newHeader,
-				Transactions: block.Transactions,
-				Uncles:       block.Uncles,
-			}
-
-			select {
-			case found <- newBlock:
-			case <-stop:
-			}
-		}
-	}()
+	if err := m.engine.Seal(nil, block, found, abort); err != nil {
+		return err
+	}
 
 	select {
 	case result := <-found:
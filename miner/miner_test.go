@@ -263,10 +263,27 @@ func TestValidateShare(t *testing.T) {
 		Nonce:       12345,
 	}
 
-	// 测试验证份额（当前实现返回true）
-	result := ValidateShare(header, 12345, common.HexToHash("0x05"), big.NewInt(1000000))
-	if !result {
-		t.Errorf("ValidateShare should return true (implementation returns true)")
+	// 无效的 nonce 和 mixDigest
+	if ValidateShare(header, 12345, common.HexToHash("0x05"), big.NewInt(1000000)) {
+		t.Errorf("ValidateShare should reject an invalid share")
+	}
+
+	// 搜索满足低份额难度的 nonce
+	shareDifficulty := big.NewInt(16)
+	target := nogopow.ToTarget(shareDifficulty)
+	sealed := *header
+	for sealed.Nonce = 0; ; sealed.Nonce++ {
+		hash, mixDigest := nogopow.ComputeWork(&sealed)
+		if new(big.Int).SetBytes(hash.Bytes()).Cmp(target) <= 0 {
+			sealed.MixDigest = mixDigest
+			break
+		}
+	}
+	if !ValidateShare(header, sealed.Nonce, sealed.MixDigest, shareDifficulty) {
+		t.Errorf("ValidateShare should accept a valid share")
+	}
+	if ValidateShare(header, sealed.Nonce, common.HexToHash("0x05"), shareDifficulty) {
+		t.Errorf("ValidateShare should reject a wrong mix digest")
 	}
 }
 
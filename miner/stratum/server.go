@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"nogochain/consensus/nogopow"
 	"nogochain/core/types"
 	"nogochain/metrics"
 )
@@ -43,6 +44,7 @@ type Client struct {
 type Job struct {
 	ID        string             `json:"id"`
 	Header    *types.BlockHeader `json:"header"`
+	SealHash  common.Hash        `json:"seal_hash"` // 矿工挖掘的区块头哈希，不包含 Nonce 和 MixDigest
 	Target    string             `json:"target"`
 	Seed      string             `json:"seed"`
 	Height    uint64             `json:"height"`
@@ -205,7 +207,9 @@ func (s *Server) broadcastJob() {
 	job := &Job{
 		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
 		Header:    block.Header,
+		SealHash:  nogopow.SealHash(block.Header),
 		Target:    block.Header.Difficulty.Text(16),
+		Seed:      common.BytesToHash(nogopow.SeedHash(block.Header.Number.Uint64())).Hex(),
 		Height:    block.Header.Number.Uint64(),
 		Timestamp: block.Header.Time,
 	}
@@ -219,11 +223,19 @@ func (s *Server) broadcastJob() {
 
 // handleShare 处理份额
 func (s *Server) handleShare(share *Share) {
-	if s.submitFn == nil {
+	if s.submitFn == nil || share.Header == nil {
 		return
 	}
 
-	err := s.submitFn(share.Header)
+	// 使用份额的 nonce 和 mixDigest 验证封装，先快速检查目标再验证 mixDigest
+	header := *share.Header
+	header.Nonce = share.Nonce
+	header.MixDigest = share.MixDigest
+	if err := nogopow.VerifyWork(&header, header.Difficulty); err != nil {
+		return
+	}
+
+	err := s.submitFn(&header)
 	if err != nil {
 		return
 	}
@@ -320,8 +332,8 @@ func (c *Client) handleSubmit(msg *Message, encoder *json.Encoder) {
 func (c *Client) sendJob(job *Job) {
 	params := []interface{}{
 		job.ID,
-		"", // 简化处理，实际需要序列化区块头
-		"", // 种子
+		job.SealHash.Hex(),
+		job.Seed,
 		job.Target,
 	}
 
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"math/big"
+
+	"nogochain/consensus"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// UncleSource 提供叔块候选的区块链接口
+type UncleSource interface {
+	consensus.ChainReader
+
+	// SideBlocks 获取不是当前头部祖先的最近区块，较新的区块在前
+	SideBlocks() []*types.Block
+}
+
+// SelectUncles 为 parent 之上的新区块选择最多 params.MaxUncles 个叔块
+// 区块链未实现 UncleSource 时不包含叔块
+func (m *Miner) SelectUncles(parent *types.BlockHeader) []*types.BlockHeader {
+	chain, ok := m.chain.(UncleSource)
+	if !ok {
+		return nil
+	}
+	return SelectUncles(chain, parent)
+}
+
+// SelectUncles 从侧链区块中选择可以被 parent 之上的新区块包含的叔块，较新的叔块优先
+func SelectUncles(chain UncleSource, parent *types.BlockHeader) []*types.BlockHeader {
+	header := &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+	}
+	var uncles []*types.BlockHeader
+	for _, block := range chain.SideBlocks() {
+		if len(uncles) == params.MaxUncles {
+			break
+		}
+		candidates := append(uncles[:len(uncles):len(uncles)], block.Header)
+		if _, err := consensus.VerifyUncleAncestry(chain, header, candidates); err != nil {
+			continue
+		}
+		uncles = candidates
+	}
+	return uncles
+}
@@ -0,0 +1,143 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"nogochain/consensus"
+	"nogochain/consensus/fake"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/blockchain"
+	"nogochain/core/state"
+)
+
+// 测试矿工选择侧链区块作为叔块，包含叔块的区块通过验证并发放叔块和侄块奖励
+func TestSelectUncles(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	bc.SetEngine(fake.NewFaker())
+	engine := bc.Engine()
+	stateDB := state.NewMemoryStateDB()
+	miner := NewMiner(&Config{Coinbase: common.Address{0x01}}, engine)
+
+	// 没有区块链时不选择叔块
+	if uncles := miner.SelectUncles(bc.CurrentHeader()); uncles != nil {
+		t.Errorf("selected %d uncles without chain", len(uncles))
+	}
+	miner.SetChain(bc)
+
+	// 主链到区块3，区块2和区块3各有一个侧链区块
+	for i := 1; i <= 3; i++ {
+		parent := bc.CurrentHeader()
+		block, err := fake.GenerateBlock(engine, bc, parent, common.Address{0x01}, stateDB, nil)
+		if err != nil {
+			t.Fatalf("GenerateBlock %d failed: %v", i, err)
+		}
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock %d failed: %v", i, err)
+		}
+		// 同高度的区块不替换头部
+		if i > 1 {
+			side, err := fake.GenerateBlock(engine, bc, parent, common.Address{byte(0x10 + i)}, state.NewMemoryStateDB(), nil)
+			if err != nil {
+				t.Fatalf("GenerateBlock side %d failed: %v", i, err)
+			}
+			if err := bc.AddBlock(side); err != nil {
+				t.Fatalf("AddBlock side %d failed: %v", i, err)
+			}
+		}
+	}
+	if side := bc.SideBlocks(); len(side) != 2 || side[0].NumberU64() != 3 || side[1].NumberU64() != 2 {
+		t.Fatalf("unexpected side blocks: %d", len(side))
+	}
+
+	// 区块2和区块3的兄弟区块都可以作为区块4的叔块，较新的在前
+	head := bc.CurrentHeader()
+	uncles := miner.SelectUncles(head)
+	if len(uncles) != 2 || uncles[0].Coinbase != (common.Address{0x13}) || uncles[1].Coinbase != (common.Address{0x12}) {
+		t.Fatalf("unexpected uncles: %d", len(uncles))
+	}
+	before := new(big.Int).Set(stateDB.GetBalance(common.Address{0x01}))
+	block, err := fake.GenerateBlockWithUncles(engine, bc, head, common.Address{0x01}, stateDB, nil, uncles)
+	if err != nil {
+		t.Fatalf("GenerateBlockWithUncles failed: %v", err)
+	}
+	if err := bc.AddBlock(block); err != nil {
+		t.Fatalf("AddBlock with uncles failed: %v", err)
+	}
+	if bc.CurrentHeader().Hash() != block.Hash() {
+		t.Fatalf("block with uncles is not the head")
+	}
+
+	number := block.NumberU64()
+	reward := nogopow.CalculateReward(number)
+	reward.Add(reward, nogopow.CalculateNephewReward(number))
+	reward.Add(reward, nogopow.CalculateNephewReward(number))
+	if got := new(big.Int).Sub(stateDB.GetBalance(common.Address{0x01}), before); got.Cmp(reward) != 0 {
+		t.Errorf("miner reward = %s, want %s", got, reward)
+	}
+	for _, uncle := range uncles {
+		want := nogopow.CalculateUncleReward(number, uncle.Number.Uint64())
+		if got := stateDB.GetBalance(uncle.Coinbase); got.Cmp(want) != 0 {
+			t.Errorf("uncle %d reward = %s, want %s", uncle.Number, got, want)
+		}
+	}
+
+	// 已包含的叔块不会再次被选择，也不能被再次包含
+	if again := miner.SelectUncles(bc.CurrentHeader()); len(again) != 0 {
+		t.Errorf("selected %d included uncles", len(again))
+	}
+	block, err = fake.GenerateBlockWithUncles(engine, bc, bc.CurrentHeader(), common.Address{0x01}, stateDB, nil, uncles[:1])
+	if err != nil {
+		t.Fatalf("GenerateBlockWithUncles failed: %v", err)
+	}
+	if err := bc.AddBlock(block); err != consensus.ErrDuplicateUncle {
+		t.Errorf("got %v, want %v", err, consensus.ErrDuplicateUncle)
+	}
+}
+
+// 测试矿工构建的区块包含侧链叔块，并在封装后成为新的头部
+func TestMinePendingIncludesUncles(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	bc.SetEngine(fake.NewFaker())
+	engine := bc.Engine()
+	stateDB := state.NewMemoryStateDB()
+
+	// 主链到区块2，区块2有一个侧链区块
+	for i := 1; i <= 2; i++ {
+		parent := bc.CurrentHeader()
+		block, err := fake.GenerateBlock(engine, bc, parent, common.Address{0x01}, stateDB, nil)
+		if err != nil {
+			t.Fatalf("GenerateBlock %d failed: %v", i, err)
+		}
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock %d failed: %v", i, err)
+		}
+		if i == 2 {
+			side, err := fake.GenerateBlock(engine, bc, parent, common.Address{0x12}, state.NewMemoryStateDB(), nil)
+			if err != nil {
+				t.Fatalf("GenerateBlock side failed: %v", err)
+			}
+			if err := bc.AddBlock(side); err != nil {
+				t.Fatalf("AddBlock side failed: %v", err)
+			}
+		}
+	}
+
+	miner := NewMiner(&Config{Coinbase: common.Address{0x02}, RecommitInterval: 5 * time.Second}, engine)
+	miner.SetChain(bc)
+	miner.minePending(make(chan struct{}))
+
+	head := bc.CurrentHead()
+	if head.NumberU64() != 3 || head.Coinbase() != (common.Address{0x02}) {
+		t.Fatalf("mined block is not the head: #%d", head.NumberU64())
+	}
+	if uncles := head.Body().Uncles; len(uncles) != 1 || uncles[0].Coinbase != (common.Address{0x12}) {
+		t.Errorf("mined block has %d uncles, want the side block", len(uncles))
+	}
+	if balance := bc.StateDB().GetBalance(common.Address{0x12}); balance.Sign() <= 0 {
+		t.Errorf("uncle reward not credited")
+	}
+}
@@ -1,78 +1,23 @@
 package miner
 
 import (
-	"encoding/binary"
 	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"nogochain/consensus/nogopow"
 	"nogochain/core/types"
 )
 
-// SerializeHeader 序列化区块头
+// SerializeHeader 序列化工作量证明覆盖的区块头字段，不包含 Nonce 和 MixDigest
+// 与 nogopow.EncodeSealHeader 相同，挖矿、矿池和验证使用同一序列化
 func SerializeHeader(header *types.BlockHeader) []byte {
-	// 简化实现，实际需要更详细的序列化
-	var data []byte
-
-	// ParentHash
-	data = append(data, header.ParentHash.Bytes()...)
-
-	// UncleHash
-	data = append(data, header.UncleHash.Bytes()...)
-
-	// Coinbase
-	data = append(data, header.Coinbase.Bytes()...)
-
-	// Root
-	data = append(data, header.Root.Bytes()...)
-
-	// TxHash
-	data = append(data, header.TxHash.Bytes()...)
-
-	// ReceiptHash
-	data = append(data, header.ReceiptHash.Bytes()...)
-
-	// Bloom
-	data = append(data, header.Bloom...)
-
-	// Difficulty
-	diffBytes := header.Difficulty.Bytes()
-	data = append(data, make([]byte, 32-len(diffBytes))...)
-	data = append(data, diffBytes...)
-
-	// Number
-	numBytes := header.Number.Bytes()
-	data = append(data, make([]byte, 32-len(numBytes))...)
-	data = append(data, numBytes...)
-
-	// GasLimit
-	gasLimitBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(gasLimitBytes, header.GasLimit)
-	data = append(data, gasLimitBytes...)
-
-	// GasUsed
-	gasUsedBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(gasUsedBytes, header.GasUsed)
-	data = append(data, gasUsedBytes...)
-
-	// Time
-	timeBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(timeBytes, header.Time)
-	data = append(data, timeBytes...)
-
-	// Extra
-	data = append(data, header.Extra...)
-
-	return data
+	return nogopow.EncodeSealHeader(header)
 }
 
-// CalculateSeed 计算种子
+// CalculateSeed 计算区块所在纪元的种子
 func CalculateSeed(number *big.Int) []byte {
-	// 简化实现，实际需要基于区块号计算种子
-	seed := make([]byte, 32)
-	numBytes := number.Bytes()
-	copy(seed[32-len(numBytes):], numBytes)
-	return seed
+	return nogopow.SeedHash(number.Uint64())
 }
 
 // CalculateTarget 计算目标值
@@ -89,10 +34,12 @@ func CalculateTarget(difficulty *big.Int) []byte {
 	return result
 }
 
-// ValidateShare 验证份额
+// ValidateShare 验证份额：nonce 和 mixDigest 必须满足份额难度，且 mixDigest 与 Hashimoto 的输出一致
 func ValidateShare(header *types.BlockHeader, nonce uint64, mixDigest common.Hash, difficulty *big.Int) bool {
-	// TODO: 实现份额验证
-	return true
+	sealed := *header
+	sealed.Nonce = nonce
+	sealed.MixDigest = mixDigest
+	return nogopow.VerifyWork(&sealed, difficulty) == nil
 }
 
 // EstimateHashRate 估算哈希率
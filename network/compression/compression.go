@@ -4,31 +4,60 @@ import (
 	"bytes"
 	"compress/flate"
 	"io"
+	"sync"
+
+	storagecompression "nogochain/core/storage/compression"
 )
 
-// Compress 压缩数据
-func Compress(data []byte) ([]byte, error) {
-	var buf bytes.Buffer
-	w, err := flate.NewWriter(&buf, flate.BestCompression)
-	if err != nil {
-		return nil, err
-	}
+// DefaultAlgorithm 默认的消息压缩器
+const DefaultAlgorithm = storagecompression.NameFlateBest
 
-	_, err = w.Write(data)
-	if err != nil {
-		return nil, err
-	}
+var (
+	compressor   *storagecompression.TaggedCompressor
+	compressorMu sync.RWMutex
+)
+
+func init() {
+	compressor, _ = storagecompression.Lookup(DefaultAlgorithm)
+}
 
-	err = w.Close()
+// SetAlgorithm 设置消息压缩器，名称见 core/storage/compression 注册表
+// 接收端按算法标签解压，因此节点之间的压缩配置不需要一致
+func SetAlgorithm(name string) error {
+	c, err := storagecompression.Lookup(name)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return buf.Bytes(), nil
+	compressorMu.Lock()
+	compressor = c
+	compressorMu.Unlock()
+	return nil
+}
+
+// Algorithm 获取当前的消息压缩器名称
+func Algorithm() string {
+	compressorMu.RLock()
+	defer compressorMu.RUnlock()
+
+	return compressor.Name()
+}
+
+// Compress 压缩数据，结果的第一个字节为算法标签
+func Compress(data []byte) ([]byte, error) {
+	compressorMu.RLock()
+	c := compressor
+	compressorMu.RUnlock()
+
+	return c.Compress(data)
 }
 
-// Decompress 解压数据
+// Decompress 解压数据，没有算法标签的数据按旧版本的 flate 格式解压
 func Decompress(data []byte) ([]byte, error) {
+	if storagecompression.IsTagged(data) {
+		return storagecompression.Decompress(data)
+	}
+
 	reader := flate.NewReader(bytes.NewReader(data))
 	decompressed, err := io.ReadAll(reader)
 	if err != nil {
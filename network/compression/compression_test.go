@@ -0,0 +1,49 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+)
+
+// 测试旧版本未加标签的 flate 消息仍然可以解压
+func TestDecompressLegacyFlate(t *testing.T) {
+	payload := bytes.Repeat([]byte("legacy network message "), 50)
+
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write(payload)
+	w.Close()
+
+	decompressed, err := Decompress(buf.Bytes())
+	if err != nil || !bytes.Equal(decompressed, payload) {
+		t.Fatalf("legacy flate message not readable: %v", err)
+	}
+}
+
+// 测试切换压缩器后接收端按标签解压
+func TestSetAlgorithm(t *testing.T) {
+	defer SetAlgorithm(DefaultAlgorithm)
+
+	payload := bytes.Repeat([]byte("tagged network message "), 50)
+	for _, name := range []string{"lz", "gzip-fast", DefaultAlgorithm} {
+		if err := SetAlgorithm(name); err != nil {
+			t.Fatalf("SetAlgorithm(%s) failed: %v", name, err)
+		}
+		compressed, err := Compress(payload)
+		if err != nil {
+			t.Fatalf("Compress failed: %v", err)
+		}
+		decompressed, err := Decompress(compressed)
+		if err != nil || !bytes.Equal(decompressed, payload) {
+			t.Errorf("%s: round trip failed: %v", name, err)
+		}
+	}
+
+	if err := SetAlgorithm("unknown"); err == nil {
+		t.Errorf("expected error for unknown algorithm")
+	}
+	if Algorithm() != DefaultAlgorithm {
+		t.Errorf("failed SetAlgorithm should keep current compressor")
+	}
+}
@@ -1,5 +1,17 @@
 package config
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"nogochain/params"
+)
+
 // Config 网络配置
 type Config struct {
 	// P2P配置
@@ -21,6 +33,9 @@ type Config struct {
 
 	// 监控配置
 	Metrics *MetricsConfig `json:"metrics"`
+
+	// 链数据配置
+	Chain *ChainConfig `json:"chain"`
 }
 
 // DiscoveryConfig 节点发现配置
@@ -41,10 +56,18 @@ type SyncConfig struct {
 
 // RPCConfig RPC配置
 type RPCConfig struct {
-	Enabled bool       `json:"enabled"`
-	Port    int        `json:"port"`
-	Host    string     `json:"host"`
-	JWT     *JWTConfig `json:"jwt"`
+	Enabled bool         `json:"enabled"`
+	Port    int          `json:"port"`
+	Host    string       `json:"host"`
+	JWT     *JWTConfig   `json:"jwt"`
+	Admin   *AdminConfig `json:"admin"`
+}
+
+// AdminConfig 管理接口配置，默认关闭
+// 启用后 admin 命名空间只在 /admin 路径上提供，每个请求都需要JWT认证
+type AdminConfig struct {
+	Enabled    bool   `json:"enabled"`
+	BackupRoot string `json:"backupRoot"` // 备份只能写入该目录之下
 }
 
 // JWTConfig JWT认证配置
@@ -70,6 +93,83 @@ type MetricsConfig struct {
 	Host    string `json:"host"`
 }
 
+// ChainConfig 链数据配置
+type ChainConfig struct {
+	DataDir          string `json:"dataDir"`
+	FreezerThreshold uint64 `json:"freezerThreshold"` // 区块移入冻结库的深度，0 表示不启用冻结库
+	BlockRetention   uint64 `json:"blockRetention"`   // 保留区块体和收据的最近区块数，0 表示不裁剪
+	StateRetention   uint64 `json:"stateRetention"`   // 保留状态的最近区块数，0 表示不裁剪
+	TxLookupLimit    uint64 `json:"txLookupLimit"`    // 索引交易的最近区块数，0 表示索引整条链
+	FinalityDepth    uint64 `json:"finalityDepth"`    // 软最终性深度，拒绝回滚超过该区块数的重组，0 表示不限制
+
+	// 配置的检查点，区块号到区块哈希，合并到硬编码的主网检查点之上
+	Checkpoints map[uint64]common.Hash `json:"checkpoints"`
+
+	// 共识引擎配置
+	Engine *EngineConfig `json:"engine"`
+
+	// 难度调整配置，选择分叉后的难度算法和分叉区块
+	Difficulty *params.DifficultyConfig `json:"difficulty"`
+}
+
+// Params 获取节点使用的链配置，在默认链配置上合并配置的检查点、软最终性深度和难度调整配置
+func (c *ChainConfig) Params() *params.ChainConfig {
+	chainConfig := params.DefaultChainConfig.WithCheckpoints(c.Checkpoints)
+	chainConfig.FinalityDepth = c.FinalityDepth
+	if c.Difficulty != nil {
+		difficulty := *c.Difficulty
+		chainConfig.Difficulty = &difficulty
+	}
+	return chainConfig
+}
+
+// CheckpointFlag 检查点命令行参数，格式为 区块号=区块哈希，可重复指定
+type CheckpointFlag map[uint64]common.Hash
+
+// String 按区块号顺序输出检查点
+func (f CheckpointFlag) String() string {
+	numbers := make([]uint64, 0, len(f))
+	for number := range f {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	parts := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		parts = append(parts, fmt.Sprintf("%d=%s", number, f[number].Hex()))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set 解析一个检查点
+func (f CheckpointFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid checkpoint %q, expected number=hash", value)
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint number %q: %v", parts[0], err)
+	}
+	hash, err := hexutil.Decode(parts[1])
+	if err != nil || len(hash) != common.HashLength {
+		return fmt.Errorf("invalid checkpoint hash %q", parts[1])
+	}
+	f[number] = common.BytesToHash(hash)
+	return nil
+}
+
+// DefaultChainConfig 默认链数据配置，归档节点不裁剪历史
+func DefaultChainConfig() *ChainConfig {
+	return &ChainConfig{
+		DataDir:          "data",
+		FreezerThreshold: 90000,
+		Checkpoints:      make(map[uint64]common.Hash),
+		Engine:           DefaultEngineConfig(),
+		Difficulty:       &params.DifficultyConfig{Algorithm: params.DifficultyLegacy},
+	}
+}
+
 // DefaultConfig 默认网络配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -97,6 +197,7 @@ func DefaultConfig() *Config {
 				Secret:    "test-secret-key-for-jwt-authentication", // 测试用密钥
 				TokenFile: "jwt-token.txt",
 			},
+			Admin: &AdminConfig{},
 		},
 		Log: &LogConfig{
 			Level:    "info",
@@ -110,6 +211,7 @@ func DefaultConfig() *Config {
 			Port:    9090,
 			Host:    "127.0.0.1",
 		},
+		Chain: DefaultChainConfig(),
 	}
 }
 
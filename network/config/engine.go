@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"nogochain/consensus"
+	"nogochain/consensus/clique"
+	"nogochain/consensus/fake"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// 共识引擎类型
+const (
+	EngineNogoPow = "nogopow" // 工作量证明，主网和测试网使用
+	EngineClique  = "clique"  // 权威证明，私有网络使用
+	EngineDev     = "dev"     // 开发引擎，本地开发链使用，有交易时立即出块
+)
+
+// EngineConfig 共识引擎配置
+type EngineConfig struct {
+	Type   string        `json:"type"`   // 共识引擎类型：nogopow、clique 或 dev，为空时使用 nogopow
+	Clique *CliqueConfig `json:"clique"` // 权威证明引擎配置，类型为 clique 时使用
+}
+
+// CliqueConfig 权威证明引擎配置
+type CliqueConfig struct {
+	Period    uint64           `json:"period"`    // 出块间隔秒数
+	Epoch     uint64           `json:"epoch"`     // 重置投票和检查点的纪元长度，0 使用默认值
+	Signers   []common.Address `json:"signers"`   // 写入创世区块的初始签名者
+	SignerKey string           `json:"signerKey"` // 本地签名私钥文件（十六进制），为空时只验证不签名
+}
+
+// DefaultEngineConfig 默认共识引擎配置
+func DefaultEngineConfig() *EngineConfig {
+	return &EngineConfig{
+		Type:   EngineNogoPow,
+		Clique: &CliqueConfig{},
+	}
+}
+
+// NewEngine 根据配置创建共识引擎，工作量证明和开发引擎使用 chainConfig 选择难度算法，
+// 配置了签名私钥时授权权威证明引擎签名
+func (c *EngineConfig) NewEngine(chainConfig *params.ChainConfig) (consensus.Engine, error) {
+	if err := checkDifficulty(chainConfig.Difficulty); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case "", EngineNogoPow:
+		return nogopow.NewNogoPowWithConfig(chainConfig), nil
+	case EngineClique:
+		cfg := c.cliqueConfig()
+		engine := clique.NewClique(&clique.Config{Period: cfg.Period, Epoch: cfg.Epoch})
+		if cfg.SignerKey != "" {
+			key, err := crypto.LoadECDSA(cfg.SignerKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load clique signer key: %v", err)
+			}
+			engine.Authorize(clique.KeySigner(key))
+		}
+		return engine, nil
+	case EngineDev:
+		engine := fake.NewDevEngine()
+		engine.SetChainConfig(chainConfig)
+		return engine, nil
+	default:
+		return nil, fmt.Errorf("unknown consensus engine %q", c.Type)
+	}
+}
+
+// Genesis 获取共识引擎要求的创世区块，nil 表示使用默认创世区块
+// 权威证明网络的创世区块额外数据列出初始签名者
+func (c *EngineConfig) Genesis() (*types.Block, error) {
+	if c.Type != EngineClique {
+		return nil, nil
+	}
+	cfg := c.cliqueConfig()
+	if len(cfg.Signers) == 0 {
+		return nil, errors.New("clique engine requires at least one genesis signer")
+	}
+	header := &types.BlockHeader{
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(0),
+		Bloom:      make([]byte, 256),
+		GasLimit:   10000000,
+		Time:       1700000000,
+		Extra:      clique.GenesisExtra(cfg.Signers),
+	}
+	return types.NewBlockWithHeader(header, &types.Body{}), nil
+}
+
+// checkDifficulty 检查难度算法名称，未知算法不能静默回退到原有算法
+func checkDifficulty(difficulty *params.DifficultyConfig) error {
+	if difficulty == nil {
+		return nil
+	}
+	switch difficulty.Algorithm {
+	case "", params.DifficultyLegacy, params.DifficultyLWMA, params.DifficultyASERT:
+		return nil
+	default:
+		return fmt.Errorf("unknown difficulty algorithm %q", difficulty.Algorithm)
+	}
+}
+
+// cliqueConfig 获取权威证明引擎配置，未配置时返回空配置
+func (c *EngineConfig) cliqueConfig() *CliqueConfig {
+	if c.Clique == nil {
+		return &CliqueConfig{}
+	}
+	return c.Clique
+}
+
+// SignersFlag 签名者命令行参数，逗号分隔的地址，可重复指定
+type SignersFlag struct {
+	Signers *[]common.Address
+}
+
+// String 输出逗号分隔的签名者地址
+func (f SignersFlag) String() string {
+	if f.Signers == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*f.Signers))
+	for _, signer := range *f.Signers {
+		parts = append(parts, signer.Hex())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set 解析并追加签名者地址
+func (f SignersFlag) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		if !common.IsHexAddress(part) {
+			return fmt.Errorf("invalid signer address %q", part)
+		}
+		*f.Signers = append(*f.Signers, common.HexToAddress(part))
+	}
+	return nil
+}
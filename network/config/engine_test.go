@@ -0,0 +1,137 @@
+package config
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"nogochain/consensus/clique"
+	"nogochain/consensus/fake"
+	"nogochain/consensus/nogopow"
+	"nogochain/core/blockchain"
+	"nogochain/core/state"
+	"nogochain/core/types"
+	"nogochain/params"
+)
+
+// 测试默认配置创建工作量证明引擎并使用默认创世区块
+func TestDefaultEngineConfig(t *testing.T) {
+	cfg := DefaultEngineConfig()
+	engine, err := cfg.NewEngine(params.DefaultChainConfig)
+	if err != nil {
+		t.Fatalf("NewEngine returned error: %v", err)
+	}
+	if _, ok := engine.(*nogopow.NogoPow); !ok {
+		t.Errorf("default engine is %T, want *nogopow.NogoPow", engine)
+	}
+	if genesis, err := cfg.Genesis(); err != nil || genesis != nil {
+		t.Errorf("default genesis: got %v, %v", genesis, err)
+	}
+
+	cfg.Type = EngineDev
+	if engine, err := cfg.NewEngine(params.DefaultChainConfig); err != nil {
+		t.Errorf("NewEngine dev returned error: %v", err)
+	} else if _, ok := engine.(*fake.DevEngine); !ok {
+		t.Errorf("dev engine is %T, want *fake.DevEngine", engine)
+	}
+
+	cfg.Type = "unknown"
+	if _, err := cfg.NewEngine(params.DefaultChainConfig); err == nil {
+		t.Errorf("unknown engine accepted")
+	}
+}
+
+// 测试节点配置的难度算法和分叉区块传递给工作量证明和开发引擎
+func TestEngineDifficultyConfig(t *testing.T) {
+	chain := DefaultChainConfig()
+	chain.Difficulty = &params.DifficultyConfig{Algorithm: params.DifficultyASERT, ForkBlock: 1000}
+
+	engine, err := chain.Engine.NewEngine(chain.Params())
+	if err != nil {
+		t.Fatalf("NewEngine returned error: %v", err)
+	}
+	config := engine.(*nogopow.NogoPow).ChainConfig()
+	if config.DifficultyAlgorithm(999) != params.DifficultyLegacy || config.DifficultyAlgorithm(1000) != params.DifficultyASERT {
+		t.Errorf("engine difficulty config = %+v", config.Difficulty)
+	}
+	if len(config.Checkpoints) != len(params.MainnetCheckpoints) {
+		t.Errorf("engine config lost the checkpoints")
+	}
+
+	// 开发引擎按配置的算法计算难度
+	chain.Engine.Type = EngineDev
+	chain.Difficulty = &params.DifficultyConfig{Algorithm: params.DifficultyASERT, ForkBlock: 1, MinDifficulty: 7}
+	dev, err := chain.Engine.NewEngine(chain.Params())
+	if err != nil {
+		t.Fatalf("NewEngine dev returned error: %v", err)
+	}
+	genesis := &types.BlockHeader{Number: big.NewInt(0), Difficulty: big.NewInt(1), Time: 1700000000}
+	if got := dev.CalcDifficulty(nil, genesis.Time+1, genesis); got == nil || got.Uint64() != 7 {
+		t.Errorf("dev engine difficulty = %v, want the configured minimum 7", got)
+	}
+
+	chain.Difficulty.Algorithm = "aserrt"
+	if _, err := chain.Engine.NewEngine(chain.Params()); err == nil {
+		t.Errorf("unknown difficulty algorithm accepted")
+	}
+}
+
+// 测试按配置创建的权威证明引擎使用签名私钥在配置的创世区块之上封装区块
+func TestCliqueEngineConfig(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	keyFile := filepath.Join(t.TempDir(), "signer.key")
+	if err := crypto.SaveECDSA(keyFile, key); err != nil {
+		t.Fatalf("SaveECDSA failed: %v", err)
+	}
+	cfg := &EngineConfig{Type: EngineClique}
+	if _, err := cfg.Genesis(); err == nil {
+		t.Fatalf("clique genesis without signers accepted")
+	}
+	cfg.Clique = &CliqueConfig{
+		Period:    1,
+		Signers:   []common.Address{crypto.PubkeyToAddress(key.PublicKey)},
+		SignerKey: keyFile,
+	}
+
+	engine, err := cfg.NewEngine(params.DefaultChainConfig)
+	if err != nil {
+		t.Fatalf("NewEngine returned error: %v", err)
+	}
+	if _, ok := engine.(*clique.Clique); !ok {
+		t.Fatalf("engine is %T, want *clique.Clique", engine)
+	}
+	genesis, err := cfg.Genesis()
+	if err != nil {
+		t.Fatalf("Genesis returned error: %v", err)
+	}
+	bc := blockchain.NewBlockchain(genesis)
+	bc.SetEngine(engine)
+
+	parent := bc.CurrentHeader()
+	header := &types.BlockHeader{
+		ParentHash: parent.Hash(),
+		Bloom:      make([]byte, 256),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+	}
+	if err := engine.Prepare(bc, header); err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	txs := []*types.Transaction{types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)}
+	block, err := engine.Finalize(bc, header, state.NewMemoryStateDB(), txs, nil)
+	if err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	results := make(chan *types.Block, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := engine.Seal(bc, block, results, stop); err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if err := bc.AddBlock(<-results); err != nil {
+		t.Fatalf("sealed block rejected: %v", err)
+	}
+}
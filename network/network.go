@@ -7,6 +7,7 @@ import (
 	"net"
 	"sync"
 
+	"nogochain/consensus/clique"
 	"nogochain/core/blockchain"
 	"nogochain/core/types"
 	"nogochain/interfaces"
@@ -43,6 +44,13 @@ func NewNetwork(cfg *config.Config, bc *blockchain.Blockchain) *Network {
 	// 初始化RPC服务器
 	if cfg.RPC.Enabled {
 		network.rpcServer = rpc.NewServer(cfg.RPC)
+		if bc != nil {
+			network.rpcServer.SetEthBackend(bc)
+			network.rpcServer.SetAdminBackend(bc)
+			if engine, ok := bc.Engine().(*clique.Clique); ok {
+				network.rpcServer.SetCliqueBackend(clique.NewAPI(bc, engine))
+			}
+		}
 	}
 
 	return network
@@ -0,0 +1,132 @@
+package params
+
+import "github.com/ethereum/go-ethereum/common"
+
+// 难度调整算法
+const (
+	// DifficultyLegacy - 原有算法，只比较父区块时间戳，难度不会上调
+	DifficultyLegacy string = "legacy"
+
+	// DifficultyLWMA - 线性加权移动平均，根据最近N个区块逐块调整
+	DifficultyLWMA string = "lwma"
+
+	// DifficultyASERT - 绝对调度指数调整，以锚定区块为基准逐块调整
+	DifficultyASERT string = "asert"
+)
+
+// 难度调整默认参数
+const (
+	// DefaultLWMAWindow - LWMA默认窗口，60个区块
+	DefaultLWMAWindow uint64 = 60
+
+	// DefaultASERTHalfLife - ASERT默认半衰期，1小时
+	// 出块时间偏离计划一个半衰期，难度变为一半或两倍
+	DefaultASERTHalfLife uint64 = 3600
+
+	// DefaultMinDifficulty - 默认最低难度
+	DefaultMinDifficulty uint64 = 1
+)
+
+// DifficultyConfig - 难度调整配置
+// ForkBlock 之前的区块使用原有算法，从 ForkBlock 开始使用 Algorithm 指定的算法
+type DifficultyConfig struct {
+	// Algorithm - 分叉后使用的算法：legacy、lwma 或 asert
+	Algorithm string `json:"algorithm"`
+
+	// ForkBlock - 第一个使用新算法的区块号
+	ForkBlock uint64 `json:"forkBlock"`
+
+	// TargetBlockTime - 目标出块时间（秒），为0时使用 TargetBlockTime
+	TargetBlockTime uint64 `json:"targetBlockTime,omitempty"`
+
+	// LWMAWindow - LWMA窗口区块数，为0时使用 DefaultLWMAWindow
+	LWMAWindow uint64 `json:"lwmaWindow,omitempty"`
+
+	// ASERTHalfLife - ASERT半衰期（秒），为0时使用 DefaultASERTHalfLife
+	ASERTHalfLife uint64 `json:"asertHalfLife,omitempty"`
+
+	// MinDifficulty - 最低难度，为0时使用 DefaultMinDifficulty
+	MinDifficulty uint64 `json:"minDifficulty,omitempty"`
+}
+
+// ChainConfig - 链配置
+type ChainConfig struct {
+	// ChainID - 链ID
+	ChainID uint64 `json:"chainId"`
+
+	// Difficulty - 难度调整配置，为 nil 时始终使用原有算法
+	Difficulty *DifficultyConfig `json:"difficulty,omitempty"`
+
+	// Checkpoints - 检查点，区块号到区块哈希，链不会重组越过检查点
+	Checkpoints map[uint64]common.Hash `json:"checkpoints,omitempty"`
+
+	// FinalityDepth - 软最终性深度，拒绝回滚超过该区块数的重组，为0时不限制
+	FinalityDepth uint64 `json:"finalityDepth,omitempty"`
+}
+
+// MainnetCheckpoints - 硬编码的主网检查点
+var MainnetCheckpoints = map[uint64]common.Hash{
+	0: common.HexToHash("0x65f8319fb8207ad805cd6c3a8dc8535e6aa1c41773f07c4a4b72b611bb8a4b32"),
+}
+
+// DefaultChainConfig - 默认链配置，在配置分叉区块之前保持原有难度算法
+var DefaultChainConfig = &ChainConfig{
+	ChainID: ChainID,
+	Difficulty: &DifficultyConfig{
+		Algorithm: DifficultyLegacy,
+	},
+	Checkpoints: MainnetCheckpoints,
+}
+
+// WithCheckpoints - 返回合并检查点后的链配置副本，同一区块号上的新检查点覆盖已有检查点
+func (c *ChainConfig) WithCheckpoints(checkpoints map[uint64]common.Hash) *ChainConfig {
+	config := *c
+	config.Checkpoints = make(map[uint64]common.Hash, len(c.Checkpoints)+len(checkpoints))
+	for number, hash := range c.Checkpoints {
+		config.Checkpoints[number] = hash
+	}
+	for number, hash := range checkpoints {
+		config.Checkpoints[number] = hash
+	}
+	return &config
+}
+
+// DifficultyAlgorithm - 获取指定区块使用的难度调整算法
+func (c *ChainConfig) DifficultyAlgorithm(number uint64) string {
+	if c == nil || c.Difficulty == nil || number < c.Difficulty.ForkBlock || c.Difficulty.Algorithm == "" {
+		return DifficultyLegacy
+	}
+	return c.Difficulty.Algorithm
+}
+
+// BlockTime - 获取目标出块时间（秒）
+func (c *DifficultyConfig) BlockTime() uint64 {
+	if c == nil || c.TargetBlockTime == 0 {
+		return TargetBlockTime
+	}
+	return c.TargetBlockTime
+}
+
+// Window - 获取LWMA窗口区块数
+func (c *DifficultyConfig) Window() uint64 {
+	if c == nil || c.LWMAWindow == 0 {
+		return DefaultLWMAWindow
+	}
+	return c.LWMAWindow
+}
+
+// HalfLife - 获取ASERT半衰期（秒）
+func (c *DifficultyConfig) HalfLife() uint64 {
+	if c == nil || c.ASERTHalfLife == 0 {
+		return DefaultASERTHalfLife
+	}
+	return c.ASERTHalfLife
+}
+
+// Minimum - 获取最低难度
+func (c *DifficultyConfig) Minimum() uint64 {
+	if c == nil || c.MinDifficulty == 0 {
+		return DefaultMinDifficulty
+	}
+	return c.MinDifficulty
+}
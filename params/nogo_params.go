@@ -29,6 +29,12 @@ const (
 
 	// RewardReductionRate - 每次减半的奖励减少率，20%
 	RewardReductionRate float64 = 0.2
+
+	// UncleRewardDivisor - 叔块奖励为区块奖励的 (叔块高度+8-区块高度)/8
+	UncleRewardDivisor uint64 = 8
+
+	// NephewRewardDivisor - 每包含一个叔块，区块矿工额外获得区块奖励的1/32
+	NephewRewardDivisor uint64 = 32
 )
 
 // Gas参数（兼容以太坊London硬分叉）
@@ -62,6 +68,18 @@ const (
 
 	// MaxDifficultyAdjustment - 最大难度调整幅度，50%
 	MaxDifficultyAdjustment float64 = 0.5
+
+	// MaxFutureBlockTime - 区块时间戳允许超前本地时间的最大秒数
+	MaxFutureBlockTime uint64 = 15
+
+	// MedianTimeSpan - 计算过去中位时间的区块数，新区块时间戳必须大于最近11个区块时间戳的中位数
+	MedianTimeSpan uint64 = 11
+
+	// MaxUncles - 区块中允许的最大叔块数量
+	MaxUncles int = 2
+
+	// MaxUncleDepth - 叔块的父区块必须是最近7代祖先区块之一
+	MaxUncleDepth uint64 = 7
 )
 
 // 网络参数
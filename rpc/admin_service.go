@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"nogochain/core/blockchain"
+)
+
+var (
+	// ErrAdminUnavailable is returned when no backend is attached to the admin service
+	ErrAdminUnavailable = errors.New("admin backend not available")
+
+	// ErrNoBackupRoot is returned when backups are requested without a configured backup root
+	ErrNoBackupRoot = errors.New("no backup root configured")
+
+	// ErrBackupOutsideRoot is returned when a backup target is not inside the backup root
+	ErrBackupOutsideRoot = errors.New("backup target outside the backup root")
+)
+
+// AdminBackend is the node backend used by the admin service
+type AdminBackend interface {
+	Backup(dir string) (*blockchain.BackupInfo, error)
+}
+
+// AdminService represents the Admin RPC service
+type AdminService struct {
+	backend    AdminBackend
+	backupRoot string
+	mutex      sync.RWMutex
+}
+
+// NewAdminService creates a new Admin service writing backups below backupRoot
+func NewAdminService(backupRoot string) *AdminService {
+	return &AdminService{backupRoot: backupRoot}
+}
+
+// SetBackend attaches the node backend
+func (s *AdminService) SetBackend(backend AdminBackend) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.backend = backend
+}
+
+// Backup writes a consistent copy of the chain data into dir on the node's host
+// A relative dir is taken relative to the backup root, the target must lie inside the root
+func (s *AdminService) Backup(dir string) (*blockchain.BackupInfo, error) {
+	s.mutex.RLock()
+	backend := s.backend
+	s.mutex.RUnlock()
+
+	if backend == nil {
+		return nil, ErrAdminUnavailable
+	}
+	target, err := s.backupPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Backup(target)
+}
+
+// backupPath resolves a backup target below the backup root
+// Symbolic links are resolved so a link inside the root cannot lead outside it
+func (s *AdminService) backupPath(dir string) (string, error) {
+	if s.backupRoot == "" {
+		return "", ErrNoBackupRoot
+	}
+	if err := os.MkdirAll(s.backupRoot, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup root: %v", err)
+	}
+	root, err := filepath.Abs(s.backupRoot)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
+	}
+
+	target := dir
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	target = filepath.Clean(target)
+
+	// Resolve the deepest existing ancestor, the rest of the path is created by the backup
+	existing, rest := target, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			target = filepath.Join(resolved, rest)
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%v: %s", ErrBackupOutsideRoot, dir)
+	}
+	return target, nil
+}
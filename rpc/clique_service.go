@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"nogochain/consensus/clique"
+)
+
+// ErrCliqueUnavailable is returned when the node does not run the proof-of-authority engine
+var ErrCliqueUnavailable = errors.New("proof-of-authority engine not available")
+
+// CliqueBackend is the signer voting backend used by the clique service
+type CliqueBackend interface {
+	GetSnapshot(number *uint64) (*clique.Snapshot, error)
+	GetSnapshotAtHash(hash common.Hash) (*clique.Snapshot, error)
+	GetSigners(number *uint64) ([]common.Address, error)
+	GetSignersAtHash(hash common.Hash) ([]common.Address, error)
+	Proposals() map[common.Address]bool
+	Propose(address common.Address, auth bool)
+	Discard(address common.Address)
+	Status() (*clique.Status, error)
+}
+
+// CliqueService represents the Clique RPC service of proof-of-authority networks
+type CliqueService struct {
+	backend CliqueBackend
+	mutex   sync.RWMutex
+}
+
+// NewCliqueService creates a new Clique service
+func NewCliqueService() *CliqueService {
+	return &CliqueService{}
+}
+
+// SetBackend attaches the signer voting backend
+func (s *CliqueService) SetBackend(backend CliqueBackend) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.backend = backend
+}
+
+// getBackend returns the attached backend, or ErrCliqueUnavailable
+func (s *CliqueService) getBackend() (CliqueBackend, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.backend == nil {
+		return nil, ErrCliqueUnavailable
+	}
+	return s.backend, nil
+}
+
+// GetSnapshot returns the voting snapshot at a block number, or at the head when omitted
+func (s *CliqueService) GetSnapshot(number *hexutil.Uint64) (*clique.Snapshot, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.GetSnapshot((*uint64)(number))
+}
+
+// GetSnapshotAtHash returns the voting snapshot at a block hash
+func (s *CliqueService) GetSnapshotAtHash(hash common.Hash) (*clique.Snapshot, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.GetSnapshotAtHash(hash)
+}
+
+// GetSigners returns the authorized signers at a block number, or at the head when omitted
+func (s *CliqueService) GetSigners(number *hexutil.Uint64) ([]common.Address, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.GetSigners((*uint64)(number))
+}
+
+// GetSignersAtHash returns the authorized signers at a block hash
+func (s *CliqueService) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.GetSignersAtHash(hash)
+}
+
+// Proposals returns the current proposals of the local signer
+func (s *CliqueService) Proposals() (map[common.Address]bool, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Proposals(), nil
+}
+
+// Propose adds a proposal to authorize (true) or remove (false) a signer
+func (s *CliqueService) Propose(address common.Address, auth bool) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return err
+	}
+	backend.Propose(address, auth)
+	return nil
+}
+
+// Discard drops a proposal of the local signer
+func (s *CliqueService) Discard(address common.Address) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return err
+	}
+	backend.Discard(address)
+	return nil
+}
+
+// Status returns the sealing activity of the signers over the recent blocks
+func (s *CliqueService) Status() (*clique.Status, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Status()
+}
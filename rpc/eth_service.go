@@ -1,18 +1,46 @@
 package rpc
 
 import (
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"nogochain/core/blockchain"
+	"nogochain/core/types"
 )
 
+// EthBackend is the chain backend used by the Ethereum service
+type EthBackend interface {
+	GetTransaction(hash common.Hash) *blockchain.TxLookup
+	GetReceipts(hash common.Hash) types.Receipts
+}
+
 // EthService represents the Ethereum RPC service
-type EthService struct{}
+type EthService struct {
+	backend EthBackend
+	mutex   sync.RWMutex
+}
 
 // NewEthService creates a new Ethereum service
 func NewEthService() *EthService {
 	return &EthService{}
 }
 
+// SetBackend attaches the chain backend
+func (s *EthService) SetBackend(backend EthBackend) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.backend = backend
+}
+
+// getBackend returns the attached chain backend, or nil
+func (s *EthService) getBackend() EthBackend {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.backend
+}
+
 // ProtocolVersion returns the current Ethereum protocol version
 func (s *EthService) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(67)
@@ -131,7 +159,15 @@ func (s *EthService) GetBlockByNumber(block string, full bool) map[string]interf
 
 // GetTransactionByHash returns a transaction by hash
 func (s *EthService) GetTransactionByHash(txhash common.Hash) map[string]interface{} {
-	return nil
+	backend := s.getBackend()
+	if backend == nil {
+		return nil
+	}
+	lookup := backend.GetTransaction(txhash)
+	if lookup == nil {
+		return nil
+	}
+	return marshalTransaction(lookup)
 }
 
 // GetTransactionByBlockHashAndIndex returns a transaction by block hash and index
@@ -146,7 +182,21 @@ func (s *EthService) GetTransactionByBlockNumberAndIndex(block string, index hex
 
 // GetTransactionReceipt returns a transaction receipt
 func (s *EthService) GetTransactionReceipt(txhash common.Hash) map[string]interface{} {
-	return nil
+	backend := s.getBackend()
+	if backend == nil {
+		return nil
+	}
+	lookup := backend.GetTransaction(txhash)
+	if lookup == nil {
+		return nil
+	}
+
+	// Receipts are only available for blocks whose receipts were stored and not pruned
+	receipts := backend.GetReceipts(lookup.BlockHash)
+	if lookup.Index >= uint64(len(receipts)) {
+		return nil
+	}
+	return marshalReceipt(lookup, receipts[lookup.Index])
 }
 
 // GetUncleByBlockHashAndIndex returns an uncle by block hash and index
@@ -233,3 +283,55 @@ func (s *EthService) SubmitHashrate(hashrate hexutil.Uint64, id string) bool {
 func (s *EthService) GetProof(address common.Address, keys []string, block string) map[string]interface{} {
 	return nil
 }
+
+// marshalTransaction converts a canonical transaction into its RPC representation
+func marshalTransaction(lookup *blockchain.TxLookup) map[string]interface{} {
+	tx := lookup.Tx
+	fields := map[string]interface{}{
+		"hash":             tx.Hash(),
+		"nonce":            hexutil.Uint64(tx.Nonce),
+		"blockHash":        lookup.BlockHash,
+		"blockNumber":      hexutil.Uint64(lookup.BlockNumber),
+		"transactionIndex": hexutil.Uint64(lookup.Index),
+		"to":               tx.To,
+		"value":            (*hexutil.Big)(tx.Value),
+		"gas":              hexutil.Uint64(tx.Gas),
+		"gasPrice":         (*hexutil.Big)(tx.GasPrice),
+		"input":            hexutil.Bytes(tx.Data),
+		"v":                (*hexutil.Big)(tx.V),
+		"r":                (*hexutil.Big)(tx.R),
+		"s":                (*hexutil.Big)(tx.S),
+	}
+	if from, err := tx.Sender(); err == nil {
+		fields["from"] = from
+	}
+	return fields
+}
+
+// marshalReceipt converts a receipt into its RPC representation
+func marshalReceipt(lookup *blockchain.TxLookup, receipt *types.Receipt) map[string]interface{} {
+	tx := lookup.Tx
+	fields := map[string]interface{}{
+		"transactionHash":   tx.Hash(),
+		"transactionIndex":  hexutil.Uint64(lookup.Index),
+		"blockHash":         lookup.BlockHash,
+		"blockNumber":       hexutil.Uint64(lookup.BlockNumber),
+		"to":                tx.To,
+		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
+		"contractAddress":   nil,
+		"logs":              receipt.Logs,
+		"logsBloom":         hexutil.Bytes(receipt.Bloom),
+		"status":            hexutil.Uint64(receipt.Status),
+	}
+	if receipt.Logs == nil {
+		fields["logs"] = []*types.Log{}
+	}
+	if tx.IsContractCreation() {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	if from, err := tx.Sender(); err == nil {
+		fields["from"] = from
+	}
+	return fields
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -14,15 +15,27 @@ import (
 	"nogochain/network/config"
 )
 
+// AdminPath is the HTTP path serving the admin namespace when it is enabled
+const AdminPath = "/admin"
+
+var (
+	// ErrAdminRequiresJWT is returned when the admin namespace is enabled without JWT authentication
+	ErrAdminRequiresJWT = errors.New("admin namespace requires JWT authentication")
+)
+
 // Server represents the RPC server
 type Server struct {
-	server     *http.Server
-	rpcServer  *rpc.Server
-	nonceStore map[string]uint64
-	nonceMutex sync.Mutex
-	ctx        context.Context
-	cancel     context.CancelFunc
-	config     *config.RPCConfig
+	server      *http.Server
+	rpcServer   *rpc.Server
+	adminServer *rpc.Server
+	eth         *EthService
+	admin       *AdminService
+	clique      *CliqueService
+	nonceStore  map[string]uint64
+	nonceMutex  sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	config      *config.RPCConfig
 }
 
 // NewServer creates a new RPC server
@@ -44,17 +57,48 @@ func NewServer(cfg *config.RPCConfig) *Server {
 	web3Service := NewWeb3Service()
 	debugService := NewDebugService()
 	nogoService := NewNogoService()
+	var backupRoot string
+	if cfg.Admin != nil {
+		backupRoot = cfg.Admin.BackupRoot
+	}
+	adminService := NewAdminService(backupRoot)
+	cliqueService := NewCliqueService()
 
 	rpcServer.RegisterName("eth", ethService)
 	rpcServer.RegisterName("net", nogService)
 	rpcServer.RegisterName("web3", web3Service)
 	rpcServer.RegisterName("debug", debugService)
 	rpcServer.RegisterName("nogo", nogoService)
+	rpcServer.RegisterName("clique", cliqueService)
+
+	// The admin namespace writes to the node's host, it is opt-in and kept off the public endpoint
+	if cfg.Admin != nil && cfg.Admin.Enabled {
+		server.adminServer = rpc.NewServer()
+		server.adminServer.RegisterName("admin", adminService)
+	}
 
 	server.rpcServer = rpcServer
+	server.eth = ethService
+	server.admin = adminService
+	server.clique = cliqueService
 	return server
 }
 
+// SetEthBackend attaches the chain backend used by the Ethereum service
+func (s *Server) SetEthBackend(backend EthBackend) {
+	s.eth.SetBackend(backend)
+}
+
+// SetAdminBackend attaches the node backend used by the admin service
+func (s *Server) SetAdminBackend(backend AdminBackend) {
+	s.admin.SetBackend(backend)
+}
+
+// SetCliqueBackend attaches the signer voting backend of a proof-of-authority chain
+func (s *Server) SetCliqueBackend(backend CliqueBackend) {
+	s.clique.SetBackend(backend)
+}
+
 // generateJWTToken 生成JWT令牌
 func (s *Server) generateJWTToken() (string, error) {
 	claims := jwt.MapClaims{
@@ -93,46 +137,88 @@ func (s *Server) jwtAuthMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
-
-		// 从请求头获取令牌
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
+		if s.authorize(w, r) {
+			next.ServeHTTP(w, r)
 		}
+	})
+}
 
-		// 提取令牌
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
+// requireJWT 要求每个请求都携带有效的JWT令牌，本地连接也不例外
+func (s *Server) requireJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.authorize(w, r) {
+			next.ServeHTTP(w, r)
 		}
+	})
+}
 
-		tokenString := parts[1]
-		if !s.validateJWTToken(tokenString) {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
-		}
+// authorize 校验请求头中的JWT令牌，校验失败时写入401响应
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	// 从请求头获取令牌
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		return false
+	}
+
+	// 提取令牌
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+		return false
+	}
+
+	tokenString := parts[1]
+	if !s.validateJWTToken(tokenString) {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
 
-		next.ServeHTTP(w, r)
+// jwtEnabled 是否配置了JWT认证
+func (s *Server) jwtEnabled() bool {
+	return s.config.JWT != nil && s.config.JWT.Enabled && s.config.JWT.Secret != ""
+}
+
+// handler 构建HTTP处理器，启用管理接口时在 AdminPath 上提供需要认证的 admin 命名空间
+func (s *Server) handler() (http.Handler, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.rpcServer.ServeHTTP(w, r)
 	})
+	if s.adminServer != nil {
+		if !s.jwtEnabled() {
+			return nil, ErrAdminRequiresJWT
+		}
+		if s.config.Admin.BackupRoot == "" {
+			return nil, fmt.Errorf("admin namespace enabled: %v", ErrNoBackupRoot)
+		}
+		mux.Handle(AdminPath, s.requireJWT(s.adminServer))
+	}
+
+	// 如果启用JWT认证，添加中间件
+	if s.jwtEnabled() {
+		return s.jwtAuthMiddleware(mux), nil
+	}
+	return mux, nil
 }
 
 // Start starts the RPC server
 func (s *Server) Start() error {
+	handler, err := s.handler()
+	if err != nil {
+		return err
+	}
+
 	// 生成JWT令牌（如果启用且密钥已设置）
-	if s.config.JWT.Enabled && s.config.JWT.Secret != "" {
+	if s.jwtEnabled() {
 		_, err := s.generateJWTToken()
 		if err != nil {
 			return fmt.Errorf("failed to generate JWT token: %v", err)
 		}
 	}
 
-	handler := http.NewServeMux()
-	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		s.rpcServer.ServeHTTP(w, r)
-	})
-
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 
 	// 创建HTTP服务器
@@ -141,11 +227,6 @@ func (s *Server) Start() error {
 		Handler: handler,
 	}
 
-	// 如果启用JWT认证，添加中间件
-	if s.config.JWT.Enabled && s.config.JWT.Secret != "" {
-		s.server.Handler = s.jwtAuthMiddleware(s.server.Handler)
-	}
-
 	fmt.Printf("RPC server started on %s\n", addr)
 	return s.server.ListenAndServe()
 }
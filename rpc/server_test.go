@@ -1,10 +1,20 @@
 package rpc
 
 import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"nogochain/consensus/clique"
+	"nogochain/core/blockchain"
+	"nogochain/core/types"
 	"nogochain/network/config"
 )
 
@@ -407,7 +417,92 @@ func TestEthService(t *testing.T) {
 	}
 }
 
+// 测试通过交易索引查询交易
+func TestEthServiceTransactionLookup(t *testing.T) {
+	bc := blockchain.NewBlockchain(nil)
+	genesis := bc.Genesis()
+	tx := types.NewTransaction(1, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	block := types.NewBlock(
+		genesis.Hash(),
+		common.Address{0x01},
+		common.Hash{},
+		common.Hash{},
+		common.Hash{},
+		big.NewInt(1000000),
+		big.NewInt(1),
+		10000000,
+		0,
+		genesis.Header.Time+10,
+		[]byte("Tx Block"),
+		common.Hash{},
+		0,
+		[]*types.Transaction{tx},
+		[]*types.BlockHeader{},
+	)
+	if err := bc.AddBlock(block); err != nil {
+		t.Fatalf("AddBlock returned error: %v", err)
+	}
+
+	ethService := NewEthService()
+	ethService.SetBackend(bc)
+
+	result := ethService.GetTransactionByHash(tx.Hash())
+	if result == nil {
+		t.Fatalf("GetTransactionByHash returned nil")
+	}
+	if result["blockHash"] != block.Hash() || result["blockNumber"] != hexutil.Uint64(1) || result["transactionIndex"] != hexutil.Uint64(0) {
+		t.Errorf("unexpected transaction position: %v", result)
+	}
+	if ethService.GetTransactionByHash(common.Hash{0x01}) != nil {
+		t.Errorf("GetTransactionByHash should return nil for unknown transactions")
+	}
+
+	// 内存区块链不保存收据
+	if receipt := ethService.GetTransactionReceipt(tx.Hash()); receipt != nil {
+		t.Errorf("GetTransactionReceipt should return nil without stored receipts, got %v", receipt)
+	}
+}
+
 // 测试NetService
+func TestCliqueService(t *testing.T) {
+	cliqueService := NewCliqueService()
+	if _, err := cliqueService.GetSigners(nil); err != ErrCliqueUnavailable {
+		t.Errorf("Expected ErrCliqueUnavailable without backend, got %v", err)
+	}
+
+	signer := common.Address{0x01}
+	genesis := types.NewBlockWithHeader(&types.BlockHeader{
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(0),
+		GasLimit:   10000000,
+		Extra:      clique.GenesisExtra([]common.Address{signer}),
+	}, nil)
+	bc := blockchain.NewBlockchain(genesis)
+	engine := clique.NewClique(&clique.Config{Period: 5})
+	bc.SetEngine(engine)
+	cliqueService.SetBackend(clique.NewAPI(bc, engine))
+
+	number := hexutil.Uint64(0)
+	signers, err := cliqueService.GetSigners(&number)
+	if err != nil || len(signers) != 1 || signers[0] != signer {
+		t.Errorf("Unexpected signers %v, %v", signers, err)
+	}
+	if signers, err := cliqueService.GetSignersAtHash(genesis.Hash()); err != nil || len(signers) != 1 {
+		t.Errorf("Unexpected signers at hash %v, %v", signers, err)
+	}
+
+	if err := cliqueService.Propose(common.Address{0x02}, true); err != nil {
+		t.Fatalf("Propose returned error: %v", err)
+	}
+	if proposals, _ := cliqueService.Proposals(); !proposals[common.Address{0x02}] {
+		t.Errorf("Expected proposal to authorize 0x02, got %v", proposals)
+	}
+	cliqueService.Discard(common.Address{0x02})
+	if proposals, _ := cliqueService.Proposals(); len(proposals) != 0 {
+		t.Errorf("Expected no proposals after discard, got %v", proposals)
+	}
+}
+
 func TestNetService(t *testing.T) {
 	netService := NewNetService()
 	if netService == nil {
@@ -621,3 +716,122 @@ func TestRPCIntegration(t *testing.T) {
 		t.Errorf("NewNogoService returned nil")
 	}
 }
+
+// backupRecorder records the directories backups are written to
+type backupRecorder struct {
+	dirs []string
+}
+
+func (b *backupRecorder) Backup(dir string) (*blockchain.BackupInfo, error) {
+	b.dirs = append(b.dirs, dir)
+	return &blockchain.BackupInfo{Head: 1}, nil
+}
+
+// 测试管理接口默认关闭，启用后只在 /admin 上提供，需要JWT认证，备份只能写入备份根目录
+func TestAdminNamespace(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "backups")
+	cfg := &config.RPCConfig{
+		Enabled: true,
+		Host:    "127.0.0.1",
+		JWT: &config.JWTConfig{
+			Enabled:   true,
+			Secret:    "test-secret",
+			TokenFile: filepath.Join(dir, "jwt-token.txt"),
+		},
+		Admin: &config.AdminConfig{},
+	}
+	backend := new(backupRecorder)
+
+	// 默认不注册 admin 命名空间
+	server := NewServer(cfg)
+	server.SetAdminBackend(backend)
+	handler, err := server.handler()
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	token, err := server.generateJWTToken()
+	if err != nil {
+		t.Fatalf("generateJWTToken returned error: %v", err)
+	}
+	public := httptest.NewServer(handler)
+	defer public.Close()
+	for _, path := range []string{"/", AdminPath} {
+		client, err := rpc.DialOptions(context.Background(), public.URL+path, rpc.WithHeader("Authorization", "Bearer "+token))
+		if err != nil {
+			t.Fatalf("dial %s: %v", path, err)
+		}
+		if err := client.Call(nil, "admin_backup", "copy"); err == nil {
+			t.Errorf("admin_backup served on %s while disabled", path)
+		}
+		client.Close()
+	}
+
+	// 启用时必须配置JWT和备份根目录
+	cfg.Admin.Enabled = true
+	cfg.JWT.Enabled = false
+	if _, err := NewServer(cfg).handler(); err != ErrAdminRequiresJWT {
+		t.Errorf("admin without JWT: got %v, want %v", err, ErrAdminRequiresJWT)
+	}
+	cfg.JWT.Enabled = true
+	if _, err := NewServer(cfg).handler(); err == nil {
+		t.Errorf("admin without backup root accepted")
+	}
+	cfg.Admin.BackupRoot = root
+
+	server = NewServer(cfg)
+	server.SetAdminBackend(backend)
+	if handler, err = server.handler(); err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	admin := httptest.NewServer(handler)
+	defer admin.Close()
+
+	// 公共端点上仍然没有 admin 命名空间
+	client, err := rpc.DialOptions(context.Background(), admin.URL+"/", rpc.WithHeader("Authorization", "Bearer "+token))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if err := client.Call(nil, "admin_backup", "copy"); err == nil {
+		t.Errorf("admin_backup served on the public endpoint")
+	}
+	client.Close()
+
+	// 没有令牌的请求被拒绝
+	client, err = rpc.Dial(admin.URL + AdminPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if err := client.Call(nil, "admin_backup", "copy"); err == nil {
+		t.Errorf("unauthenticated admin_backup accepted")
+	}
+	client.Close()
+
+	client, err = rpc.DialOptions(context.Background(), admin.URL+AdminPath, rpc.WithHeader("Authorization", "Bearer "+token))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	info := new(blockchain.BackupInfo)
+	if err := client.Call(info, "admin_backup", "nightly/copy"); err != nil {
+		t.Fatalf("admin_backup returned error: %v", err)
+	}
+	realRoot, _ := filepath.EvalSymlinks(root)
+	if len(backend.dirs) != 1 || backend.dirs[0] != filepath.Join(realRoot, "nightly", "copy") {
+		t.Errorf("backup written to %v, want below %s", backend.dirs, root)
+	}
+
+	// 备份根目录之外的目标被拒绝，包括经由根目录中符号链接的目标
+	if err := os.Symlink(dir, filepath.Join(root, "escape")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	for _, target := range []string{"../outside", filepath.Join(dir, "outside"), "/", ".", "escape/outside"} {
+		err := client.Call(nil, "admin_backup", target)
+		if err == nil || !strings.Contains(err.Error(), ErrBackupOutsideRoot.Error()) {
+			t.Errorf("backup to %q: got %v, want %v", target, err, ErrBackupOutsideRoot)
+		}
+	}
+	if len(backend.dirs) != 1 {
+		t.Errorf("backups outside the root reached the backend: %v", backend.dirs[1:])
+	}
+}